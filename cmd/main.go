@@ -1,14 +1,23 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"hbuf/pkg/build"
+	"hbuf/pkg/compat"
 	"hbuf/pkg/dart"
+	"hbuf/pkg/format"
 	"hbuf/pkg/golang"
 	"hbuf/pkg/java"
+	"hbuf/pkg/jsonschema"
+	"hbuf/pkg/openapi"
+	"hbuf/pkg/scanner"
 	ts "hbuf/pkg/typescript"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 var version = "0.0.1"
@@ -17,31 +26,83 @@ func main() {
 	build.AddBuildType("dart", dart.Build)
 	build.AddBuildType("go", golang.Build)
 	build.AddBuildType("java", java.Build)
+	build.AddBuildType("jsonschema", jsonschema.Build)
+	build.AddBuildType("openapi", openapi.Build)
 	build.AddBuildType("ts", ts.Build)
 
-	var out = flag.String("o", "", "out dir")
-	var in = flag.String("i", "", "input dir")
-	var typ = flag.String("t", "", "out type")
-	var pack = flag.String("p", "", "package path")
-	var showVersion = flag.Bool("v", false, "show version")
+	if 1 < len(os.Args) {
+		switch os.Args[1] {
+		case "gen":
+			runGen(os.Args[2:])
+			return
+		case "fmt":
+			runFormat(os.Args[2:])
+			return
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		case "diff":
+			runDiff(os.Args[2:])
+			return
+		}
+	}
+	runGen(os.Args[1:])
+}
+
+// includes 可以重复出现的 -I 参数，按出现顺序查找引入的文件
+type includes []string
+
+func (i *includes) String() string {
+	return strings.Join(*i, string(os.PathListSeparator))
+}
+
+func (i *includes) Set(value string) error {
+	*i = append(*i, value)
+	return nil
+}
 
-	flag.Parse()
+// runGen 执行 hbuf gen -i 输入 -o 输出 -t 语言 [-p 包路径] [-I 引入目录]，不带子命令时同样生成代码；
+// 使用 -c 配置文件，或不带 -i 时使用当前目录的 hbuf.yaml 一次生成配置中的全部语言
+func runGen(args []string) {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	var out = flags.String("o", "", "out dir")
+	var in = flags.String("i", "", "input dir")
+	var include includes
+	flags.Var(&include, "I", "import search dir, can be repeated")
+	var typ = flags.String("t", "", "out type")
+	var pack = flags.String("p", "", "package path")
+	var conf = flags.String("c", "", "config file, default hbuf.yaml")
+	var showVersion = flags.Bool("v", false, "show version")
+	_ = flags.Parse(args)
 
 	if *showVersion {
 		log.Println("Version:", version)
 		return
 	}
 
-	if nil == out || 0 == len(*out) {
-		log.Fatalln("Output directory not found")
+	if 0 == len(*conf) && 0 == len(*in) {
+		*conf = build.FindConfig(".")
 	}
-	if nil == in || 0 == len(*in) {
-		log.Fatalln("Input file not found")
+	if 0 < len(*conf) {
+		config, err := build.LoadConfig(*conf)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		err = build.BuildConfig(config)
+		if err != nil {
+			scanner.PrintError(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
 	}
-	if nil == pack || 0 == len(*pack) {
 
+	if 0 == len(*out) {
+		log.Fatalln("Output directory not found")
 	}
-	if nil == typ || 0 == len(*typ) {
+	if 0 == len(*in) {
+		log.Fatalln("Input file not found")
+	}
+	if 0 == len(*typ) {
 		log.Fatalln("Build type not found")
 	}
 	if !build.CheckType(*typ) {
@@ -49,9 +110,121 @@ func main() {
 		return
 	}
 
-	err := build.Build(*out, *in, *typ, *pack)
+	err := build.Build(*out, *in, *typ, *pack, include...)
 	if err != nil {
-		fmt.Println(fmt.Errorf("Build error: %s", err))
-		return
+		scanner.PrintError(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runCheck 执行 hbuf check -i 输入 [-I 引入目录]，只检查不生成代码，有错误时以 file:line:col 格式输出并返回非 0
+func runCheck(args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	var in = flags.String("i", "", "input dir")
+	var include includes
+	flags.Var(&include, "I", "import search dir, can be repeated")
+	_ = flags.Parse(args)
+
+	if nil == in || 0 == len(*in) {
+		log.Fatalln("Input file not found")
+	}
+
+	err := build.Check(*in, include...)
+	if err != nil {
+		scanner.PrintError(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// runDiff 执行 hbuf diff 旧目录 新目录，输出每处变更在 JSON 和二进制格式下是否兼容，有不兼容变更时返回非 0
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	_ = flags.Parse(args)
+	if 2 != flags.NArg() {
+		log.Fatalln("Usage: hbuf diff old/ new/")
+	}
+
+	changes, err := compat.Dir(flags.Arg(0), flags.Arg(1))
+	if err != nil {
+		scanner.PrintError(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := 0
+	for _, change := range changes {
+		fmt.Println(change)
+		if change.Breaking() {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
+
+// runFormat 执行 hbuf fmt [-l] [-w] [-d] 文件...
+func runFormat(args []string) {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	var list = flags.Bool("l", false, "list files whose formatting differs")
+	var write = flags.Bool("w", false, "write result to source file")
+	var diff = flags.Bool("d", false, "display diffs instead of rewriting files")
+	_ = flags.Parse(args)
+
+	code := 0
+	for _, pattern := range flags.Args() {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("Format error: %s", err))
+			code = 1
+			continue
+		}
+		// 与 gofmt 找不到文件时相同，没有匹配到文件也是错误，写错的路径不会被当作格式正确
+		if 0 == len(files) {
+			fmt.Fprintln(os.Stderr, fmt.Errorf("Format error: %s: %w", pattern, os.ErrNotExist))
+			code = 1
+			continue
+		}
+		for _, file := range files {
+			err = formatFile(file, *list, *write, *diff)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, fmt.Errorf("Format error: %s:%s", file, err))
+				code = 1
+			}
+		}
+	}
+	os.Exit(code)
+}
+
+func formatFile(file string, list bool, write bool, diff bool) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	res, err := format.Source(src)
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(src, res) {
+		if !list && !write && !diff {
+			_, err = os.Stdout.Write(res)
+		}
+		return err
+	}
+	if list {
+		fmt.Println(file)
+	}
+	if write {
+		err = os.WriteFile(file, res, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+	}
+	if diff {
+		_, err = os.Stdout.Write(format.Diff(file, src, res))
+	} else if !list && !write {
+		_, err = os.Stdout.Write(res)
 	}
+	return err
 }
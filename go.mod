@@ -5,6 +5,7 @@ go 1.21.0
 require (
 	github.com/shopspring/decimal v1.4.0
 	github.com/wskfjtheqian/hbuf_golang v1.0.12
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -27,5 +28,4 @@ require (
 	google.golang.org/genproto v0.0.0-20210602131652-f16073e35f0c // indirect
 	google.golang.org/grpc v1.38.0 // indirect
 	google.golang.org/protobuf v1.26.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
@@ -135,6 +135,7 @@ type Field struct {
 	Name    *Ident        // field/method/parameter names; or nil
 	Type    Type          // field/method/parameter type
 	Id      *BasicLit     // field tag; or nil
+	Default *BasicLit     // default value; or nil
 	Tag     *BasicLit     // field tag; or nil
 	Comment *CommentGroup // line comments; or nil
 }
@@ -150,6 +151,9 @@ func (f *Field) End() token.Pos {
 	if f.Tag != nil {
 		return f.Tag.End()
 	}
+	if f.Default != nil {
+		return f.Default.End()
+	}
 	return f.Type.End()
 }
 
@@ -208,6 +212,20 @@ type (
 		Obj     *Object   // denoted object; or nil
 	}
 
+	// SelectorExpr 带包名的类型，如 common.Page，X 为引入时 as 后的名称
+	SelectorExpr struct {
+		X   *Ident // package name
+		Sel *Ident // type name
+	}
+
+	// StreamType 方法的消息流类型，如 stream<Item>，只能作为方法的参数或返回类型
+	StreamType struct {
+		Stream token.Pos // position of "stream"
+		LSS    token.Pos // position of "<"
+		Item   *VarType  // 消息的数据类型
+		GTR    token.Pos // position of ">"
+	}
+
 	BasicLit struct {
 		ValuePos token.Pos   // literal position
 		Kind     token.Token // token.INT, token.FLOAT, token.IMAG, token.CHAR, or token.STRING
@@ -263,14 +281,14 @@ type (
 	}
 
 	ArrayType struct {
-		VType  *VarType
+		VType  Type      // 元素类型，可以是数组或 Map
 		Empty  bool      //是否可为空
 		Lbrack token.Pos // position of "["
 		Rbrack token.Pos // position of "]"
 	}
 
 	MapType struct {
-		VType *VarType
+		VType Type //值类型，可以是数组或 Map
 		Empty bool //是否可为空
 		Key   Expr
 		LSS   token.Pos // position of "<"
@@ -284,20 +302,50 @@ type (
 		Incomplete bool       // true if (source) fields are missing in the Fields list
 		Name       *Ident
 		Extends    []*Extends
+		Id         *BasicLit     // data Id; or nil
+		Reserved   []*Reserved   // reserved ids and names
+		OneOfs     []*OneOf      // oneof blocks
 		Doc        *CommentGroup // associated documentation; or nil
 		Comment    *CommentGroup // line comments; or nil
 	}
 
+	// OneOf 同时只能设置一个成员的字段组，成员的 Id 和数据的字段共用
+	OneOf struct {
+		Doc     *CommentGroup // associated documentation; or nil
+		OneOf   token.Pos     // position of "oneof" keyword
+		Name    *Ident
+		Opening token.Pos     // position of "{"
+		Fields  []*Field      // members
+		Closing token.Pos     // position of "}"
+		Comment *CommentGroup // line comments; or nil
+	}
+
 	Extends struct {
+		X    *Ident // package name; or nil
 		Name *Ident
 		Id   *BasicLit // extends Id; or nil
 	}
 
+	// Reserved 保留的 Id 和名称，如 reserved 3, 5 to 9, "old_name"
+	Reserved struct {
+		Reserved token.Pos        // position of "reserved" keyword
+		Ranges   []*ReservedRange // reserved ids
+		Names    []*BasicLit      // reserved names
+		Comment  *CommentGroup    // line comments; or nil
+	}
+
+	// ReservedRange 保留的 Id 范围，包含 From 和 To
+	ReservedRange struct {
+		From *BasicLit
+		To   *BasicLit // or nil
+	}
+
 	ServerType struct {
 		Tags    []*Tag
 		Server  token.Pos // position of "server" keyword
 		Name    *Ident
 		Extends []*Extends
+		Id      *BasicLit // server Id; or nil
 		Opening token.Pos // position of opening parenthesis/brace, if any
 		Methods []*FuncType
 		Closing token.Pos     // position of closing parenthesis/brace, if any
@@ -317,14 +365,15 @@ type (
 	}
 
 	EnumType struct {
-		Tags    []*Tag
-		Enum    token.Pos
-		Name    *Ident
-		Items   []*EnumItem
-		Opening token.Pos
-		Closing token.Pos
-		Doc     *CommentGroup // associated documentation; or nil
-		Comment *CommentGroup // line comments; or nil
+		Tags     []*Tag
+		Enum     token.Pos
+		Name     *Ident
+		Items    []*EnumItem
+		Reserved []*Reserved // reserved ids and names
+		Opening  token.Pos
+		Closing  token.Pos
+		Doc      *CommentGroup // associated documentation; or nil
+		Comment  *CommentGroup // line comments; or nil
 	}
 
 	EnumItem struct {
@@ -337,6 +386,9 @@ type (
 )
 
 func (e *Extends) Pos() token.Pos {
+	if nil != e.X {
+		return e.X.Pos()
+	}
 	return e.Name.Pos()
 }
 
@@ -344,6 +396,40 @@ func (e *Extends) End() token.Pos {
 	return e.Id.End()
 }
 
+func (r *Reserved) Pos() token.Pos {
+	return r.Reserved
+}
+
+func (r *Reserved) End() token.Pos {
+	var end token.Pos
+	if 0 < len(r.Ranges) {
+		end = r.Ranges[len(r.Ranges)-1].End()
+	}
+	if 0 < len(r.Names) && r.Names[len(r.Names)-1].End() > end {
+		end = r.Names[len(r.Names)-1].End()
+	}
+	return end
+}
+
+func (o *OneOf) Pos() token.Pos {
+	return o.OneOf
+}
+
+func (o *OneOf) End() token.Pos {
+	return o.Closing + 1
+}
+
+func (r *ReservedRange) Pos() token.Pos {
+	return r.From.Pos()
+}
+
+func (r *ReservedRange) End() token.Pos {
+	if nil != r.To {
+		return r.To.End()
+	}
+	return r.From.End()
+}
+
 func (x *BadExpr) Pos() token.Pos  { return x.From }
 func (x *Ident) Pos() token.Pos    { return x.NamePos }
 func (x *BasicLit) Pos() token.Pos { return x.ValuePos }
@@ -359,13 +445,15 @@ func (x *DataType) Pos() token.Pos  { return x.Data }
 func (x *FuncType) Pos() token.Pos {
 	return x.Result.Pos()
 }
-func (x *ServerType) Pos() token.Pos { return x.Server }
-func (x *MapType) Pos() token.Pos    { return x.VType.Pos() }
-func (x *EnumType) Pos() token.Pos   { return x.Enum }
-func (x *EnumItem) Pos() token.Pos   { return x.Name.Pos() }
-func (x *VarType) Pos() token.Pos    { return x.TypeExpr.Pos() }
-func (x *Tag) Pos() token.Pos        { return x.Opening }
-func (x *KeyValue) Pos() token.Pos   { return x.Name.Pos() }
+func (x *ServerType) Pos() token.Pos   { return x.Server }
+func (x *MapType) Pos() token.Pos      { return x.VType.Pos() }
+func (x *EnumType) Pos() token.Pos     { return x.Enum }
+func (x *EnumItem) Pos() token.Pos     { return x.Name.Pos() }
+func (x *VarType) Pos() token.Pos      { return x.TypeExpr.Pos() }
+func (x *SelectorExpr) Pos() token.Pos { return x.X.Pos() }
+func (x *StreamType) Pos() token.Pos   { return x.Stream }
+func (x *Tag) Pos() token.Pos          { return x.Opening }
+func (x *KeyValue) Pos() token.Pos     { return x.Name.Pos() }
 
 func (x *BadExpr) End() token.Pos      { return x.To }
 func (x *Ident) End() token.Pos        { return token.Pos(int(x.NamePos) + len(x.Name)) }
@@ -380,6 +468,8 @@ func (x *MapType) End() token.Pos      { return x.GTR }
 func (x *EnumType) End() token.Pos     { return x.Items[len(x.Items)-1].End() }
 func (x *EnumItem) End() token.Pos     { return x.Comment.End() }
 func (x *VarType) End() token.Pos      { return x.TypeExpr.End() }
+func (x *SelectorExpr) End() token.Pos { return x.Sel.End() }
+func (x *StreamType) End() token.Pos   { return x.GTR + 1 }
 func (x *Tag) End() token.Pos          { return x.Closing }
 func (x *KeyValue) End() token.Pos     { return x.Values[len(x.Values)-1].End() }
 
@@ -396,8 +486,16 @@ func (*MapType) exprNode()      {}
 func (*EnumType) exprNode()     {}
 func (*EnumItem) exprNode()     {}
 func (*VarType) exprNode()      {}
+func (*SelectorExpr) exprNode() {}
+func (*StreamType) exprNode()   {}
 
-func (x *VarType) Type() Expr   { return x.TypeExpr }
+// Type 返回类型名，带包名时返回包名后的类型名
+func (x *VarType) Type() Expr {
+	if sel, ok := x.TypeExpr.(*SelectorExpr); ok {
+		return sel.Sel
+	}
+	return x.TypeExpr
+}
 func (x *ArrayType) Type() Expr { return x.VType }
 func (x *MapType) Type() Expr   { return x.VType }
 
@@ -431,10 +529,22 @@ type (
 	ImportSpec struct {
 		Doc     *CommentGroup // associated documentation; or nil
 		Path    *BasicLit     // import path
+		Name    *Ident        // package name after "as"; or nil
+		File    string        // key of the imported file in Package.Files, set by parser.ParseDir
 		Comment *CommentGroup // line comments; or nil
 		EndPos  token.Pos     // end of spec (overrides Values.Pos if nonzero)
 	}
 
+	// ConstSpec 常量定义，如 const int32 MaxPage = 100
+	ConstSpec struct {
+		Doc     *CommentGroup // associated documentation; or nil
+		Const   token.Pos     // position of "const" keyword
+		Type    *Ident        // base type
+		Name    *Ident        // const name
+		Value   *BasicLit     // INT, FLOAT, STRING or IDENT (true/false)
+		Comment *CommentGroup // line comments; or nil
+	}
+
 	PackageSpec struct {
 		Doc     *CommentGroup // associated documentation; or nil
 		Name    *Ident        // local package name (including "."); or nil
@@ -466,6 +576,10 @@ func (s *ImportSpec) End() token.Pos {
 
 func (s *TypeSpec) End() token.Pos { return s.Type.End() }
 
+func (s *ConstSpec) Pos() token.Pos { return s.Const }
+
+func (s *ConstSpec) End() token.Pos { return s.Value.End() }
+
 func (s *BadSpec) Pos() token.Pos {
 	return s.From
 }
@@ -478,6 +592,8 @@ func (*ImportSpec) specNode() {}
 
 func (*TypeSpec) specNode() {}
 
+func (*ConstSpec) specNode() {}
+
 func (*BadSpec) specNode() {}
 
 type File struct {
@@ -489,6 +605,7 @@ type File struct {
 	Unresolved []*Ident        // unresolved identifiers in this file
 	Comments   []*CommentGroup // list of all comments in the source file
 	Path       string
+	Name       string // key in Package.Files, relative to the input or include directory, e.g. common/page.hbuf
 }
 
 func (f *File) Pos() token.Pos {
@@ -102,6 +102,7 @@ const (
 	Method // function or method
 	Var
 	Enum
+	Const
 )
 
 var objKindStrings = [...]string{
@@ -111,6 +112,8 @@ var objKindStrings = [...]string{
 	Server: "server",
 	Method: "method",
 	Var:    "var",
+	Enum:   "enum",
+	Const:  "const",
 }
 
 func (kind ObjKind) String() string { return objKindStrings[kind] }
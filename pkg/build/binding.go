@@ -19,7 +19,7 @@ func GetBinding(tags []*ast.Tag, file *ast.File, getType func(file *ast.File, na
 	if nil != val.KV {
 		for _, item := range val.KV {
 			if "value" == item.Name.Name {
-				value := item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				value := TagString(item.Values[0])
 
 				if 0 == len(value) {
 					return nil, NewError(item.Pos()+1, "Not set value")
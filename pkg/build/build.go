@@ -1,61 +1,89 @@
 package build
 
 import (
+	"errors"
 	"hbuf/pkg/ast"
 	"hbuf/pkg/parser"
 	"hbuf/pkg/scanner"
 	"hbuf/pkg/token"
+	"path"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 type BaseType string
 
 const (
-	Int8    BaseType = "int8"
-	Int16   BaseType = "int16"
-	Int32   BaseType = "int32"
-	Int64   BaseType = "int64"
-	Uint8   BaseType = "uint8"
-	Uint16  BaseType = "uint16"
-	Uint32  BaseType = "uint32"
-	Uint64  BaseType = "uint64"
-	Bool    BaseType = "bool"
-	Float   BaseType = "float"
-	Double  BaseType = "double"
-	Decimal BaseType = "decimal"
-	String  BaseType = "string"
-	Date    BaseType = "date"
-	Enum    BaseType = "enum"
-	Data    BaseType = "data"
-	Server  BaseType = "server"
-	Import  BaseType = "import"
-	Package BaseType = "package"
+	Int8     BaseType = "int8"
+	Int16    BaseType = "int16"
+	Int32    BaseType = "int32"
+	Int64    BaseType = "int64"
+	Uint8    BaseType = "uint8"
+	Uint16   BaseType = "uint16"
+	Uint32   BaseType = "uint32"
+	Uint64   BaseType = "uint64"
+	Bool     BaseType = "bool"
+	Float    BaseType = "float"
+	Double   BaseType = "double"
+	Decimal  BaseType = "decimal"
+	String   BaseType = "string"
+	Date     BaseType = "date"
+	Bytes    BaseType = "bytes"
+	Duration BaseType = "duration"
+	Uuid     BaseType = "uuid"
+	Json     BaseType = "json"
+	Int      BaseType = "int"  // int32 的别名
+	Uint     BaseType = "uint" // uint32 的别名
+	Enum     BaseType = "enum"
+	Data     BaseType = "data"
+	Server   BaseType = "server"
+	Import   BaseType = "import"
+	Package  BaseType = "package"
 )
 
 type void struct {
 }
 
 var _types = map[BaseType]struct{}{
-	Int8:    struct{}{},
-	Int16:   struct{}{},
-	Int32:   struct{}{},
-	Int64:   struct{}{},
-	Uint8:   struct{}{},
-	Uint16:  struct{}{},
-	Uint32:  struct{}{},
-	Uint64:  struct{}{},
-	Bool:    struct{}{},
-	Float:   struct{}{},
-	Double:  struct{}{},
-	String:  struct{}{},
-	Date:    struct{}{},
-	Decimal: struct{}{},
+	Int8:     struct{}{},
+	Int16:    struct{}{},
+	Int32:    struct{}{},
+	Int64:    struct{}{},
+	Uint8:    struct{}{},
+	Uint16:   struct{}{},
+	Uint32:   struct{}{},
+	Uint64:   struct{}{},
+	Bool:     struct{}{},
+	Float:    struct{}{},
+	Double:   struct{}{},
+	String:   struct{}{},
+	Date:     struct{}{},
+	Decimal:  struct{}{},
+	Bytes:    struct{}{},
+	Duration: struct{}{},
+	Uuid:     struct{}{},
+	Json:     struct{}{},
 }
 
 var _keys = map[BaseType]void{
 	Int8: {}, Int16: {}, Int32: {}, Int64: {}, Uint8: {}, Uint16: {}, Uint32: {}, Uint64: {}, Bool: {}, Float: {}, Double: {}, String: {}, Data: {}, Server: {}, Enum: {}, Import: {}, Package: {}, Date: {}, Decimal: {},
+	Bytes: {}, Duration: {}, Uuid: {}, Json: {}, Int: {}, Uint: {},
+}
+
+// _aliases 类型别名，检查时替换成实际的类型，生成代码时只会看到实际的类型
+var _aliases = map[BaseType]BaseType{
+	Int:  Int32,
+	Uint: Uint32,
+}
+
+// resolveAlias 把类型别名替换成实际的类型
+func resolveAlias(ident *ast.Ident) {
+	if typ, ok := _aliases[BaseType(ident.Name)]; ok {
+		ident.Name = string(typ)
+	}
 }
 
 type Function = func(file *ast.File, fset *token.FileSet, param *Param) error
@@ -71,20 +99,32 @@ func CheckType(typ string) bool {
 }
 
 type Param struct {
-	out   string
-	pack  string
-	build *Builder
-	pkg   *ast.Package
+	out      string
+	dir      string
+	pack     string
+	features map[string]bool
+	build    *Builder
+	pkg      *ast.Package
 }
 
 func (p *Param) GetOut() string {
 	return p.out
 }
 
+// GetOutDir 返回目标的输出目录，GetOut 为其中与输入文件相对路径相同的文件
+func (p *Param) GetOutDir() string {
+	return p.dir
+}
+
 func (p *Param) GetPack() string {
 	return p.pack
 }
 
+// Feature 返回是否生成 name 对应的代码，未配置的功能默认生成，关闭 data 时未配置的 server 等功能也不生成
+func (p *Param) Feature(name string) bool {
+	return featureEnabled(p.features, name)
+}
+
 func (p *Param) GetBuilder() *Builder {
 	return p.build
 }
@@ -94,10 +134,11 @@ func (p *Param) GetPkg() *ast.Package {
 }
 
 type Builder struct {
-	fset  *token.FileSet
-	pkg   *ast.Package
-	build Function
-	param *Param
+	fset   *token.FileSet
+	pkg    *ast.Package
+	build  Function
+	param  *Param
+	errors scanner.ErrorList // 检查时发现的全部错误
 }
 
 func NewBuilder(build Function, param *Param) *Builder {
@@ -109,35 +150,62 @@ func NewBuilder(build Function, param *Param) *Builder {
 	}
 }
 
-func Build(out string, in string, typ string, pack string) error {
-	in = filepath.Clean(in)
-	path := filepath.Dir(in)
-	name := in[len(path)+1:]
-	reg, err := regexp.Compile(strings.ReplaceAll(name, "*", "(.*)"))
-	if err != nil {
-		return err
-	}
-
-	build := NewBuilder(buildInits[typ], &Param{
-		out:  out,
-		pack: pack,
+// Build 解析并检查 in 匹配的文件，按 typ 生成代码到 out，includes 为查找引入文件的目录
+func Build(out string, in string, typ string, pack string, includes ...string) error {
+	return BuildConfig(&Config{
+		Inputs:   []string{in},
+		Includes: includes,
+		Targets: []*Target{{
+			Type:    typ,
+			Out:     out,
+			Package: pack,
+		}},
 	})
-	err = parser.ParseDir(build.fset, build.pkg, path, reg)
+}
+
+// BuildConfig 按配置只解析和检查一次输入文件，再依次生成每个目标的代码
+func BuildConfig(config *Config) error {
+	build := NewBuilder(nil, &Param{})
+	err := build.parse(config.Inputs, config.Includes)
 	if err != nil {
 		return err
 	}
-	err = build.checkFiles()
-	if err != nil {
-		return err
+
+	for _, target := range config.Targets {
+		build.build = buildInits[target.Type]
+		build.param = &Param{
+			out:      target.Out,
+			pack:     target.Package,
+			features: target.Features,
+		}
+		err = build.generate()
+		if err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	for path, file := range build.pkg.Files {
-		_, name := filepath.Split(path)
-		err := build.build(file, build.fset, &Param{
-			out:   filepath.Join(build.param.out, name),
-			pkg:   build.pkg,
-			pack:  build.param.pack,
-			build: build,
+// generate 按 b.build 为每个文件生成代码，输出的相对路径与文件在包中的名称相同
+func (b *Builder) generate() error {
+	paths := GetKeysByMap(b.pkg.Files)
+	sort.Strings(paths)
+	outs := map[string]string{}
+	for _, path := range paths {
+		name := OutName(b.pkg.Files[path])
+		if other, ok := outs[name]; ok {
+			return errors.New("Duplicate output: " + name + " (" + other + " and " + path + ")")
+		}
+		outs[name] = path
+	}
+	for _, path := range paths {
+		err := b.build(b.pkg.Files[path], b.fset, &Param{
+			out:      filepath.Join(b.param.out, filepath.FromSlash(OutName(b.pkg.Files[path]))),
+			dir:      b.param.out,
+			pkg:      b.pkg,
+			pack:     b.param.pack,
+			features: b.param.features,
+			build:    b,
 		})
 		if err != nil {
 			return err
@@ -146,53 +214,184 @@ func Build(out string, in string, typ string, pack string) error {
 	return nil
 }
 
+// OutName 返回 file 生成代码的相对路径，与文件在包中的名称相同，如 common/page.hbuf，
+// 以绝对路径引入或在输入目录之外的文件只使用文件名
+func OutName(file *ast.File) string {
+	name := file.Name
+	if 0 == len(name) {
+		name = filepath.ToSlash(file.Path)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(name)) {
+		return path.Base(name)
+	}
+	return path.Clean(name)
+}
+
+// ImportPath 返回 file 生成的代码引入输出目录中 to 文件时使用的相对路径，file 为空时直接返回 to
+func ImportPath(file *ast.File, to string) string {
+	if nil == file {
+		return to
+	}
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(OutName(file))), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// DuplicateOut 按包名放置代码的语言（如 go、java）只使用文件名，返回与 file 生成到同一目录且文件名相同的其它文件，
+// dir 返回文件生成代码的目录，不生成代码时返回空
+func DuplicateOut(pkg *ast.Package, file *ast.File, dir func(file *ast.File) string) *ast.File {
+	out := dir(file)
+	if 0 == len(out) {
+		return nil
+	}
+	paths := GetKeysByMap(pkg.Files)
+	sort.Strings(paths)
+	for _, key := range paths {
+		other := pkg.Files[key]
+		if other != file && path.Base(OutName(other)) == path.Base(OutName(file)) && dir(other) == out {
+			return other
+		}
+	}
+	return nil
+}
+
+// Check 解析并检查输入文件，不生成任何代码
+func Check(in string, includes ...string) error {
+	build := NewBuilder(nil, &Param{})
+	return ErrorToFileError(build.parse([]string{in}, includes), build.fset)
+}
+
+// parse 解析 inputs 匹配的文件并检查
+func (b *Builder) parse(inputs []string, includes []string) error {
+	for _, in := range inputs {
+		in = filepath.Clean(in)
+		path := filepath.Dir(in)
+		name := filepath.Base(in)
+		reg, err := regexp.Compile(strings.ReplaceAll(name, "*", "(.*)"))
+		if err != nil {
+			return err
+		}
+
+		err = parser.ParseDir(b.fset, b.pkg, path, reg, includes...)
+		if err != nil {
+			return err
+		}
+	}
+	return b.checkFiles()
+}
+
 func (b *Builder) checkFiles() error {
-	for path, file := range b.pkg.Files {
+	paths := GetKeysByMap(b.pkg.Files)
+	sort.Strings(paths)
+	for _, path := range paths {
 		imports := map[string]void{
 			path: {},
 		}
-		err := b.checkFile(file, imports)
-		if err != nil {
-			return err
+		b.checkFile(b.pkg.Files[path], imports)
+	}
+	b.checkTypeId()
+	b.checkHttp()
+
+	b.errors.Sort()
+	return b.errors.Err()
+}
+
+// error 记录一个检查错误，检查会继续进行以便一次报告全部错误，位置和内容都相同的错误只记录一次
+func (b *Builder) error(pos token.Pos, msg string) {
+	position := b.fset.Position(pos)
+	for _, e := range b.errors {
+		if e.Pos == position && e.Msg == msg {
+			return
 		}
 	}
+	b.errors.Add(position, msg)
+}
 
-	return nil
+// errorOf 记录带位置的 *Error
+func (b *Builder) errorOf(err error) {
+	var val *Error
+	if errors.As(err, &val) {
+		b.error(val.Pos, val.Msg)
+	}
 }
 
-func (b *Builder) checkFile(file *ast.File, imports map[string]void) error {
+// checkTypeId 检查整个包内 data 和 server 的 Id 是否重复
+func (b *Builder) checkTypeId() {
+	paths := GetKeysByMap(b.pkg.Files)
+	sort.Strings(paths)
+
+	dataIds := map[uint64]*ast.Ident{}
+	serverIds := map[uint64]*ast.Ident{}
+	for _, path := range paths {
+		for _, s := range b.pkg.Files[path].Specs {
+			spec, ok := s.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			var id *ast.BasicLit
+			var name *ast.Ident
+			var ids map[uint64]*ast.Ident
+			switch t := spec.Type.(type) {
+			case *ast.DataType:
+				id, name, ids = t.Id, t.Name, dataIds
+			case *ast.ServerType:
+				id, name, ids = t.Id, t.Name, serverIds
+			default:
+				continue
+			}
+			// 服务未设置 Id 时按 0 路由，同样参与重复检查
+			server, isServer := spec.Type.(*ast.ServerType)
+			if nil == id && !isServer {
+				continue
+			}
+
+			var value uint64
+			if isServer {
+				var err error
+				value, err = ParseServerId(server)
+				if err != nil {
+					b.errorOf(err)
+					continue
+				}
+			} else if value, ok = parseId(id, MaxFieldId); !ok {
+				b.error(id.Pos(), "Data id out of range (0 to "+strconv.FormatUint(MaxFieldId, 10)+"): "+id.Value)
+				continue
+			}
+			if other, ok := ids[value]; ok {
+				if nil == id {
+					b.error(name.Pos(), "Duplicate id: 0 (server id not set), already used by "+other.Name)
+				} else {
+					b.error(id.Pos(), "Duplicate id: "+id.Value+", already used by "+other.Name)
+				}
+				continue
+			}
+			ids[value] = name
+		}
+	}
+}
+
+func (b *Builder) checkFile(file *ast.File, imports map[string]void) {
 	for index, s := range file.Specs {
 		switch s.(type) {
 		case *ast.TypeSpec:
-			err := b.checkType(file, (s.(*ast.TypeSpec)).Type, index)
-			if err != nil {
-				return err
-			}
+			b.checkType(file, (s.(*ast.TypeSpec)).Type, index)
+		case *ast.ConstSpec:
+			b.checkConst(file, s.(*ast.ConstSpec), index)
 		}
 	}
-	return nil
 }
 
-func (b *Builder) checkType(file *ast.File, expr ast.Expr, index int) error {
+func (b *Builder) checkType(file *ast.File, expr ast.Expr, index int) {
 	switch expr.(type) {
 	case *ast.EnumType:
-		err := b.checkEnum(file, expr.(*ast.EnumType), index)
-		if err != nil {
-			return err
-		}
+		b.checkEnum(file, expr.(*ast.EnumType), index)
 	case *ast.DataType:
-		err := b.checkData(file, expr.(*ast.DataType), index)
-		if err != nil {
-			return err
-		}
+		b.checkData(file, expr.(*ast.DataType), index)
 	case *ast.ServerType:
-		err := b.checkServer(file, expr.(*ast.ServerType), index)
-		if err != nil {
-			return err
-		}
+		b.checkServer(file, expr.(*ast.ServerType), index)
 	}
-
-	return nil
 }
 
 func (b *Builder) checkDuplicateType(file *ast.File, index int, name string) bool {
@@ -218,11 +417,18 @@ func (b *Builder) checkDuplicateType(file *ast.File, index int, name string) boo
 					return true
 				}
 			}
+		case *ast.ConstSpec:
+			if (s.(*ast.ConstSpec)).Name.Name == name {
+				return true
+			}
 		}
 	}
 
 	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
+		if nil != spec.Name {
+			continue
+		}
+		if f, ok := b.pkg.Files[spec.File]; ok {
 			if obj := f.Scope.Lookup(name); nil != obj {
 				return true
 			}
@@ -252,67 +458,67 @@ func (b *Builder) registerServer(file *ast.File, enum *ast.ServerType) error {
 	return nil
 }
 
-func (b *Builder) checkDataMapKey(file *ast.File, varType *ast.VarType) error {
+func (b *Builder) checkDataMapKey(file *ast.File, varType *ast.VarType) {
 	if varType.Empty {
-		return scanner.Error{
-			Pos: b.fset.Position(varType.TypeExpr.End()),
-			Msg: "Type cannot be empty",
-		}
+		b.error(varType.TypeExpr.End(), "Type cannot be empty")
+		return
 	}
-	switch varType.TypeExpr.(type) {
-	case *ast.Ident:
-		if _, ok := _types[BaseType(varType.TypeExpr.(*ast.Ident).Name)]; ok {
-			return nil
+	if pkg, ident := typeName(varType); nil != ident {
+		if 0 == len(pkg) {
+			resolveAlias(ident)
 		}
-		obj := b.GetDataType(file, varType.TypeExpr.(*ast.Ident).Name)
-		if obj.Kind == ast.Enum {
-			varType.TypeExpr.(*ast.Ident).Obj = obj
-			return nil
+		typ := BaseType(ident.Name)
+		if _, ok := _types[typ]; ok && 0 == len(pkg) {
+			if Bytes != typ && Json != typ {
+				return
+			}
+		} else if obj, msg := b.lookup(file, pkg, ident.Name, ast.Data, ast.Enum); 0 < len(msg) {
+			b.error(ident.Pos(), msg)
+			return
+		} else if nil != obj && obj.Kind == ast.Enum {
+			ident.Obj = obj
+			return
 		}
 	}
-	return scanner.Error{
-		Pos: b.fset.Position(varType.TypeExpr.Pos()),
-		Msg: "Map keys can only be of type",
-	}
+	b.error(varType.TypeExpr.Pos(), "Map keys can only be of type")
 }
 
-func (b *Builder) checkKeyValue(kvs []*ast.KeyValue) error {
-	if nil == kvs {
-		return nil
-	}
-
-	for i := 0; i < len(kvs)-1; i++ {
-		for j := i + 1; j < len(kvs); j++ {
-			if kvs[i].Name.Name == kvs[j].Name.Name {
-				return scanner.Error{
-					Pos: b.fset.Position(kvs[j].Pos()),
-					Msg: "Repeated tag key",
-				}
+// checkReservedRange 检查保留的 Id 范围是否有效
+func (b *Builder) checkReservedRange(reserved []*ast.Reserved) {
+	for _, item := range reserved {
+		for _, r := range item.Ranges {
+			if nil == r.To {
+				continue
+			}
+			from, err1 := strconv.ParseInt(r.From.Value, 0, 64)
+			to, err2 := strconv.ParseInt(r.To.Value, 0, 64)
+			if nil == err1 && nil == err2 && from > to {
+				b.error(r.Pos(), "Invalid reserved range: "+r.From.Value+" to "+r.To.Value)
 			}
 		}
 	}
-	return nil
 }
-func (b *Builder) checkTags(tags []*ast.Tag) error {
-	if nil == tags {
-		return nil
-	}
 
-	for i := 0; i < len(tags)-1; i++ {
-		for j := i + 1; j < len(tags); j++ {
-			if tags[i].Name.Name == tags[j].Name.Name {
-				return scanner.Error{
-					Pos: b.fset.Position(tags[j].Pos()),
-					Msg: "Repeated tag key",
-				}
+// checkReserved 检查 Id 和名称是否已经被保留
+func (b *Builder) checkReserved(reserved []*ast.Reserved, id *ast.BasicLit, name *ast.Ident) {
+	value, err := strconv.ParseInt(id.Value, 0, 64)
+	for _, item := range reserved {
+		for _, r := range item.Ranges {
+			from, _ := strconv.ParseInt(r.From.Value, 0, 64)
+			to := from
+			if nil != r.To {
+				to, _ = strconv.ParseInt(r.To.Value, 0, 64)
+			}
+			if nil == err && from <= value && value <= to {
+				b.error(id.Pos(), "Reserved id: "+id.Value)
+			}
+		}
+		for _, n := range item.Names {
+			if v, _ := strconv.Unquote(n.Value); v == name.Name {
+				b.error(name.Pos(), "Reserved name: "+name.Name)
 			}
 		}
 	}
-
-	for _, tag := range tags {
-		return b.checkKeyValue(tag.KV)
-	}
-	return nil
 }
 
 func GetTag(tags []*ast.Tag, key string) (*ast.Tag, bool) {
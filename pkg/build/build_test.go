@@ -0,0 +1,895 @@
+package build
+
+import (
+	"errors"
+	"hbuf/pkg/ast"
+	"hbuf/pkg/scanner"
+	"hbuf/pkg/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCheckReportsAllErrors(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"package go = \"a\"\n" +
+		"\n" +
+		"data A = 1 {\n" +
+		"    Foo a = 0\n" +
+		"    int32<A> b = 1\n" +
+		"}\n" +
+		"\n" +
+		"[db:k=\"1\";k=\"2\"]\n" +
+		"data B = 1 {\n" +
+		"    int32 c = 0\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	if 6 != len(list) {
+		t.Fatalf("want 6 errors, got %d:\n%v", len(list), err)
+	}
+	// 同一行中不同的错误都要报告
+	got := map[string]bool{}
+	for _, e := range list {
+		if 8 == e.Pos.Line {
+			got[e.Msg] = true
+		}
+	}
+	if !got["Repeated tag key"] || !got["Unknown tag key: db.k"] {
+		t.Errorf("want both errors on line 8, got %v", err)
+	}
+}
+
+func TestCheckReserved(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data A {\n" +
+		"    reserved 1, 3 to 5, \"old\"\n" +
+		"    int32 a = 0\n" +
+		"    int32 b = 4\n" +
+		"    int32 old = 6\n" +
+		"    reserved 9 to 7\n" +
+		"}\n" +
+		"\n" +
+		"enum E {\n" +
+		"    X = 0\n" +
+		"    Y = 1\n" +
+		"    reserved 1\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{"Reserved id: 4", "Reserved name: old", "Invalid reserved range: 9 to 7", "Reserved id: 1"}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+// TestCheckIdValue Id 按数值比较是否重复，超出范围时报错
+func TestCheckIdValue(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data P = 1 {\n" +
+		"}\n" +
+		"\n" +
+		"data Q = 0x1 {\n" +
+		"}\n" +
+		"\n" +
+		"data A : P = 1 : Q = 0x1 {\n" +
+		"    int32 a = 1\n" +
+		"    int32 b = 0x1\n" +
+		"    int32 c = 5000000000\n" +
+		"}\n" +
+		"\n" +
+		"data B : P = 9999999999 {\n" +
+		"}\n" +
+		"\n" +
+		"enum E {\n" +
+		"    X = 1\n" +
+		"    Y = 0x01\n" +
+		"    Z = 2147483648\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Duplicate id: 0x1, already used by P",
+		"Duplicate item: 1",
+		"Duplicate item: 1",
+		"Field id out of range (0 to 4294967295): 5000000000",
+		"Extends id out of range (0 to 4294967295): 9999999999",
+		"Duplicate item: 1",
+		"Enum id out of range (0 to 2147483647): 2147483648",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckTags(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"enum Status {\n" +
+		"    [format:null=\"yes\"]\n" +
+		"    Enable = 0\n" +
+		"}\n" +
+		"\n" +
+		"[db:lmit=\"10\"]\n" +
+		"[cache:min=60;max=120]\n" +
+		"data Info {\n" +
+		"    [ui:width=200.5;digit=2;onlyRead=true;form=text]\n" +
+		"    [verify:format=Status.Enable]\n" +
+		"    int32 c = 2\n" +
+		"    [ui:digit=true]\n" +
+		"    int32 d = 3\n" +
+		"    [verify:format=\"Status.Gone\"]\n" +
+		"    int32 a = 0\n" +
+		"    [bind:value=\"S.get\"]\n" +
+		"    int32 b = 1\n" +
+		"}\n" +
+		"\n" +
+		"[dbx:name=\"a\"]\n" +
+		"server S {\n" +
+		"    [tag:auth=\"a\",\"b\"]\n" +
+		"    Info get(Info req) = 0\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Invalid bool value of format.null: \"yes\"",
+		"Unknown tag key: db.lmit",
+		"Invalid int value of ui.digit: true",
+		"Not find enum item of verify.format: \"Status.Gone\"",
+		"Tag bind cannot be used on field, only on method",
+		"Unknown tag: dbx",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckConst(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"const int32 MaxPage = 100\n" +
+		"const string Reg = \"^1\\\\d{10}$\"\n" +
+		"const bool Flag = 1\n" +
+		"const date Day = 1\n" +
+		"const int32 MaxPage = 2\n" +
+		"\n" +
+		"enum Status {\n" +
+		"    [format:reg=Reg;null=MaxPage]\n" +
+		"    Enable = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Info {\n" +
+		"    [ui:digit=MaxPage]\n" +
+		"    int32 a = 0\n" +
+		"    MaxPage b = 1\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Duplicate type: MaxPage",
+		"Invalid bool value: 1",
+		"Invalid const type: date",
+		"Invalid bool value of format.null: 100",
+		"Invalid name: MaxPage",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckDefault(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"const int32 PageSize = 20\n" +
+		"\n" +
+		"enum Status {\n" +
+		"    Enable = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Page {\n" +
+		"    int32 size = 0 = PageSize\n" +
+		"    Status status = 1 = Status.Enable\n" +
+		"    int8 small = 2 = 300\n" +
+		"    uint32 neg = 3 = -1\n" +
+		"    Status? gone = 4 = Gone\n" +
+		"    date day = 5 = 0\n" +
+		"    int32[] list = 6 = 1\n" +
+		"    double rate = 7 = -0x10\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Invalid int8 value: 300",
+		"Invalid uint32 value: -1",
+		"Not find enum item of Status: Gone",
+		"Invalid default type: date",
+		"Invalid default type: list",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckOneOf(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data Login {\n" +
+		"    string user = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Event {\n" +
+		"    int64 id = 0\n" +
+		"    oneof payload {\n" +
+		"        Login login = 1\n" +
+		"        string text = 0\n" +
+		"        int32? code = 2\n" +
+		"        int32[] list = 3\n" +
+		"        int32 page = 4 = 1\n" +
+		"    }\n" +
+		"    oneof id {\n" +
+		"    }\n" +
+		"}\n" +
+		"\n" +
+		"data Child : Event = 1 {\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Duplicate item: 0",
+		"Invalid oneof type: code",
+		"Invalid oneof type: list",
+		"Oneof member cannot have default value: page",
+		"Duplicate item: id",
+		"Oneof cannot be empty: id",
+		"Data with oneof cannot be extended: Event",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+// TestCheckContextualKeyword const、reserved 和 oneof 只在声明或成员开始处是关键字，仍可以作为名称使用
+func TestCheckContextualKeyword(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"const int32 reserved = 1\n" +
+		"\n" +
+		"enum Kind {\n" +
+		"    reserved 3\n" +
+		"    const = 0\n" +
+		"    reserved = 1\n" +
+		"    oneof = 2\n" +
+		"    old = 3\n" +
+		"}\n" +
+		"\n" +
+		"data oneof {\n" +
+		"    int32 const = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Item {\n" +
+		"    reserved 9, \"old\"\n" +
+		"    int32 reserved = 0\n" +
+		"    oneof oneof = 1\n" +
+		"    oneof? parent = 2\n" +
+		"    oneof[] list = 3\n" +
+		"    string text = 9\n" +
+		"    oneof value {\n" +
+		"        string text = 4\n" +
+		"    }\n" +
+		"}\n" +
+		"\n" +
+		"server ItemServer = 1 {\n" +
+		"    Item oneof(Item const) = 0\n" +
+		"    Item reserved(Item req) = 1\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	// 保留和 oneof 仍然生效
+	want := []string{"Reserved id: 3", "Duplicate item: text", "Reserved id: 9"}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckNestedType(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data Item {\n" +
+		"    string name = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Box {\n" +
+		"    string[][] grid = 0\n" +
+		"    Item?[]?<string>[] groups = 1\n" +
+		"    Item<string[]> bad = 2\n" +
+		"    Lost[]<int32> lost = 3\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Map keys can only be of type",
+		"Invalid name: Lost",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckScalarType(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data File {\n" +
+		"    bytes          body    = 0\n" +
+		"    duration?      timeout = 1\n" +
+		"    uuid<uuid>     refs    = 2\n" +
+		"    json[]         extras  = 3\n" +
+		"    string<bytes>  bad     = 4\n" +
+		"    duration       wait    = 5 = 1000\n" +
+		"    int<uint>      counts  = 6\n" +
+		"    int            size    = 7 = 2147483648\n" +
+		"}\n" +
+		"const uint Max = -1\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Map keys can only be of type",
+		"Invalid default type: duration",
+		"Invalid int32 value: 2147483648",
+		"Invalid uint32 value: -1",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckStreamType(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data Item {\n" +
+		"    string name = 0\n" +
+		"}\n" +
+		"\n" +
+		"server Chat {\n" +
+		"    stream<Item> Send(stream<Item> req) = 0\n" +
+		"    Item Sum(stream<Item> req) = 1\n" +
+		"    stream<int32> Count(Item req) = 2\n" +
+		"    void Push(stream<Lost> req) = 3\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Type can only be data: int32",
+		"Type can only be data: Lost",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckMethodId(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data Item {\n" +
+		"    string name = 0\n" +
+		"}\n" +
+		"\n" +
+		"server A = 1 {\n" +
+		"    Item Get(Item req) = 1\n" +
+		"    Item Set(Item req) = 0x1\n" +
+		"}\n" +
+		"\n" +
+		"server B : A = 2 {\n" +
+		"    Item Put(Item req) = 1\n" +
+		"    Item Del(Item req) = 4294967296\n" +
+		"}\n" +
+		"\n" +
+		"server C = 2097152 {\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Duplicate id: 0x1, already used by Get",
+		"Method id out of range (0 to 4294967295): 4294967296",
+		"Server id out of range (0 to 2097151): 2097152",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+// TestCheckServerIdDefault 未设置 Id 的服务按 0 参与重复检查
+func TestCheckServerIdDefault(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"server A {\n" +
+		"}\n" +
+		"\n" +
+		"server B : A = 1 {\n" +
+		"}\n" +
+		"\n" +
+		"server C = 0 {\n" +
+		"}\n" +
+		"\n" +
+		"server D : A = 1 = 2 {\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Duplicate id: 0 (server id not set), already used by A",
+		"Duplicate id: 0, already used by A",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckHttpTag(t *testing.T) {
+	dir := t.TempDir()
+	src := "" +
+		"data Base {\n" +
+		"    int64 id = 0\n" +
+		"}\n" +
+		"\n" +
+		"data User : Base = 1 {\n" +
+		"    string name = 0\n" +
+		"    Base parent = 1\n" +
+		"}\n" +
+		"\n" +
+		"server UserServer {\n" +
+		"    [http:method=\"GET\";path=\"/users/{id}\"]\n" +
+		"    User Get(User req) = 0\n" +
+		"    [http:method=\"PUT\";path=\"/users/{id}\";body=\"name\"]\n" +
+		"    User Set(User req) = 1\n" +
+		"    [http:method=\"HEAD\";path=\"/users\"]\n" +
+		"    User Head(User req) = 2\n" +
+		"    [http:path=\"users\"]\n" +
+		"    User Add(User req) = 3\n" +
+		"    [http:path=\"/users/{lost}\"]\n" +
+		"    User Lost(User req) = 4\n" +
+		"    [http:path=\"/users/{parent}\"]\n" +
+		"    User Parent(User req) = 5\n" +
+		"    [http:method=\"GET\";path=\"/users\";body=\"*\"]\n" +
+		"    User List(User req) = 6\n" +
+		"    [http:path=\"/users/{id}\";body=\"id\"]\n" +
+		"    User Body(User req) = 7\n" +
+		"    [http:path=\"/users\"]\n" +
+		"    stream<User> Stream(User req) = 8\n" +
+		"}\n" +
+		"\n" +
+		"server ItemServer = 1 {\n" +
+		"    [http:method=\"GET\";path=\"/items/{id}\"]\n" +
+		"    User Get(User req) = 0\n" +
+		"    [http:method=\"PUT\";path=\"/items/{id}\"]\n" +
+		"    User Set(User req) = 1\n" +
+		"    [http:method=\"GET\";path=\"/items/{name}\"]\n" +
+		"    User Find(User req) = 2\n" +
+		"    [http:path=\"/items\"]\n" +
+		"    User Lost(Lost req) = 3\n" +
+		"}\n" +
+		"\n" +
+		"server ShopServer = 4 {\n" +
+		"    [http:method=\"GET\";path=\"/users/{name}\"]\n" +
+		"    User Find(User req) = 0\n" +
+		"}\n" +
+		"\n" +
+		"server OrderServer : Missing = 2 = 3 {\n" +
+		"    [http:method=\"GET\";path=\"/orders/{id}\"]\n" +
+		"    User Get(User req) = 0\n" +
+		"}\n"
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Check(filepath.Join(dir, "*.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	// 其他错误和 http 注解的错误一次全部报告，类型没有找到的方法和服务不检查 http 注解
+	want := []string{
+		"Invalid value of http.method: \"HEAD\", must be one of GET, POST, PUT, DELETE, PATCH",
+		"Path must start with /: users",
+		"Not find path field: lost",
+		"Path field can only be a number, string, bool, date, duration, uuid or enum: parent",
+		"GET cannot have body",
+		"Body field cannot be a path field: id",
+		"Tag http cannot be used on stream method: Stream",
+		"Duplicate http route: GET /items/{name}, already used by Get",
+		"Type can only be data: Lost",
+		"Duplicate http route: GET /users/{name}, already used by UserServer.Get",
+		"Not find: Missing",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckQualifiedType(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.hbuf": "" +
+			"import \"b.hbuf\"\n" +
+			"import \"c.hbuf\"\n" +
+			"import \"d.hbuf\" as d\n" +
+			"\n" +
+			"data A {\n" +
+			"    Page    page  = 0\n" +
+			"    d.Page  other = 1\n" +
+			"    Deep    deep  = 2\n" +
+			"    x.Page  lost  = 3\n" +
+			"    d.Deep  none  = 4\n" +
+			"}\n" +
+			"\n" +
+			"data B : d.Page = 0 = 1 {\n" +
+			"}\n",
+		"b.hbuf": "import \"e.hbuf\"\n\ndata Page {\n    int32 size = 0\n}\n",
+		"c.hbuf": "data Page {\n    string token = 0\n}\n",
+		"d.hbuf": "data Page {\n    int64 id = 0\n}\n",
+		"e.hbuf": "data Deep {\n    int32 level = 0\n}\n",
+	}
+	for name, src := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Check(filepath.Join(dir, "a.hbuf"))
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("want scanner.ErrorList, got %v", err)
+	}
+	want := []string{
+		"Ambiguous type: Page",
+		"Not find import: x",
+		"Invalid name: Deep",
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d errors, got %d:\n%v", len(want), len(list), err)
+	}
+	for i, e := range list {
+		if want[i] != e.Msg {
+			t.Errorf("error %d: want %q, got %q", i, want[i], e.Msg)
+		}
+	}
+}
+
+func TestCheckIncludes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"svc/a.hbuf":              "import \"common/page.hbuf\" as common\nimport \"b.hbuf\"\n\ndata A {\n    common.Page page = 0\n    B b = 1\n}\n",
+		"svc/b.hbuf":              "import \"common/page.hbuf\"\n\ndata B {\n    Page page = 0\n}\n",
+		"shared/common/page.hbuf": "data Page {\n    int32 size = 0\n}\n",
+	}
+	for name, src := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := Check(filepath.Join(dir, "svc", "*.hbuf"))
+	var e scanner.Error
+	if !errors.As(err, &e) || "Not import: common/page.hbuf" != e.Msg {
+		t.Fatalf("want Not import error, got %v", err)
+	}
+	err = Check(filepath.Join(dir, "svc", "*.hbuf"), filepath.Join(dir, "none"), filepath.Join(dir, "shared"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "a.hbuf"), []byte("data A {\n    int32 a = 0\n}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := "" +
+		"inputs:\n" +
+		"  - \"*.hbuf\"\n" +
+		"targets:\n" +
+		"  - type: test\n" +
+		"    out: out/one\n" +
+		"  - type: test\n" +
+		"    out: out/two\n" +
+		"    package: demo\n" +
+		"    features:\n" +
+		"      ui: false\n"
+	err = os.WriteFile(filepath.Join(dir, "hbuf.yaml"), []byte(config), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	AddBuildType("test", func(file *ast.File, fset *token.FileSet, param *Param) error {
+		got = append(got, param.GetOut()+" "+param.GetPack()+" "+strconv.FormatBool(param.Feature(FeatureUi)))
+		return nil
+	})
+	c, err := LoadConfig(FindConfig(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = BuildConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "out", "one", "a.hbuf") + "  true",
+		filepath.Join(dir, "out", "two", "a.hbuf") + " demo false",
+	}
+	if len(want) != len(got) {
+		t.Fatalf("want %v, got %v", want, got)
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Errorf("target %d: want %q, got %q", i, want[i], got[i])
+		}
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "hbuf.yaml"), []byte(config+"      form: true\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadConfig(filepath.Join(dir, "hbuf.yaml"))
+	if nil == err || !strings.HasSuffix(err.Error(), "Unknown feature: form") {
+		t.Errorf("want unknown feature error, got %v", err)
+	}
+
+	// server 等功能依赖 data，关闭 data 时未配置的功能随之关闭，明确开启时报错
+	err = os.WriteFile(filepath.Join(dir, "hbuf.yaml"), []byte(config+"      data: false\n      server: true\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadConfig(filepath.Join(dir, "hbuf.yaml"))
+	if nil == err || !strings.HasSuffix(err.Error(), "Feature server requires data: test") {
+		t.Errorf("want feature requires error, got %v", err)
+	}
+	param := &Param{features: map[string]bool{FeatureData: false, FeatureJson: true}}
+	for _, name := range []string{FeatureServer, FeatureDatabase, FeatureVerify, FeatureUi, FeatureExport} {
+		if param.Feature(name) {
+			t.Errorf("feature %s enabled without data", name)
+		}
+	}
+	if !param.Feature(FeatureYaml) {
+		t.Errorf("feature yaml disabled")
+	}
+}
+
+// TestBuildImportPath 文件以相对输入目录或引入目录的路径为键，生成代码的路径与键相同，不同的文件不能得到相同的键或输出
+func TestBuildImportPath(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"svc/a.hbuf":           "import \"common/page.hbuf\"\nimport \"other/page.hbuf\" as other\n\ndata A {\n    Page page = 0\n    other.Page next = 1\n}\n",
+		"a/common/page.hbuf":   "data Page {\n    int32 size = 0\n}\n",
+		"b/other/page.hbuf":    "data Page {\n    string cursor = 0\n}\n",
+		"dup/a.hbuf":           "import \"common/page.hbuf\"\n",
+		"dup/common/page.hbuf": "data Page {\n    int32 size = 0\n}\n",
+		"dup/sub/b.hbuf":       "import \"common/page.hbuf\"\n",
+		"abs/page.hbuf":        "import " + strconv.Quote(filepath.Join(dir, "a", "common", "page.hbuf")) + "\n",
+	}
+	for name, src := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var outs, imports []string
+	AddBuildType("test", func(file *ast.File, fset *token.FileSet, param *Param) error {
+		out, err := filepath.Rel(param.GetOutDir(), param.GetOut())
+		if err != nil {
+			return err
+		}
+		outs = append(outs, filepath.ToSlash(out))
+		for _, spec := range file.Imports {
+			imports = append(imports, spec.File)
+		}
+		return nil
+	})
+	includes := []string{filepath.Join(dir, "a"), filepath.Join(dir, "b")}
+	err := Build(filepath.Join(dir, "out"), filepath.Join(dir, "svc", "*.hbuf"), "test", "", includes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a.hbuf common/page.hbuf other/page.hbuf"
+	if got := strings.Join(outs, " "); want != got {
+		t.Errorf("outputs: want %q, got %q", want, got)
+	}
+	want = "common/page.hbuf other/page.hbuf"
+	if got := strings.Join(imports, " "); want != got {
+		t.Errorf("imports: want %q, got %q", want, got)
+	}
+
+	err = Build(filepath.Join(dir, "out"), filepath.Join(dir, "dup", "*.hbuf"), "test", "", includes...)
+	if nil == err || !strings.Contains(err.Error(), "Duplicate import: common/page.hbuf") {
+		t.Errorf("want duplicate import error, got %v", err)
+	}
+	err = Build(filepath.Join(dir, "out"), filepath.Join(dir, "abs", "*.hbuf"), "test", "")
+	if nil == err || !strings.Contains(err.Error(), "Duplicate output: page.hbuf") {
+		t.Errorf("want duplicate output error, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package buildtest 生成器测试共用的函数，以及各语言生成的代码都必须遵守的编码常量
+package buildtest
+
+import (
+	"hbuf/pkg/build"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// FrameKinds stream 帧的类型，帧头为 1 字节类型和 4 字节小端长度，与 Go 生成的 StreamServer 相同
+var FrameKinds = map[string]int{
+	"End":   0,
+	"Data":  1,
+	"Null":  2,
+	"Error": 3,
+}
+
+// WireTypes 二进制编码中字段头部的类型
+var WireTypes = map[string]int{
+	"Int":    0,
+	"Uint":   1,
+	"Float":  2,
+	"Bytes":  3,
+	"List":   4,
+	"Map":    5,
+	"Data":   6,
+	"Extend": 7,
+}
+
+// Generate 把 files 写入临时目录，用 fn 按 typ 生成 in 文件的代码，返回生成代码的目录
+func Generate(t testing.TB, typ string, fn build.Function, in string, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	WriteFiles(t, dir, files)
+	build.AddBuildType(typ, fn)
+	out := filepath.Join(dir, "out")
+	err := build.Build(out, filepath.Join(dir, in), typ, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// WriteFiles 把 files 写入 dir，文件名可以包含子目录
+func WriteFiles(t testing.TB, dir string, files map[string]string) {
+	t.Helper()
+	for name, src := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		err := os.MkdirAll(filepath.Dir(file), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(file, []byte(src), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// ReadFile 读出生成代码的目录 dir 中的文件
+func ReadFile(t testing.TB, dir string, elem ...string) string {
+	t.Helper()
+	buf, err := os.ReadFile(filepath.Join(append([]string{dir}, elem...)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf)
+}
+
+// Contains 检查 code 中包含 wants 中的每一段代码
+func Contains(t testing.TB, code string, wants ...string) {
+	t.Helper()
+	for _, want := range wants {
+		if !strings.Contains(code, want) {
+			t.Errorf("want %q in:\n%s", want, code)
+		}
+	}
+}
+
+// Constants 检查 code 中以 prefix 开头的常量，每个 want 中的名称都要定义为相同的整数，
+// 常量写成 `prefixName = 1`，前面的关键字和后面的类型声明都会被忽略
+func Constants(t testing.TB, code string, prefix string, want map[string]int) {
+	t.Helper()
+	reg := regexp.MustCompile(`\b` + regexp.QuoteMeta(prefix) + `(\w+)\b[^=\n(]*=\s*(\d+)\b`)
+	got := map[string]int{}
+	for _, match := range reg.FindAllStringSubmatch(code, -1) {
+		if _, ok := got[match[1]]; ok {
+			t.Errorf("%s%s is defined more than once", prefix, match[1])
+			continue
+		}
+		got[match[1]], _ = strconv.Atoi(match[2])
+	}
+	for name, value := range want {
+		if v, ok := got[name]; !ok {
+			t.Errorf("%s%s is not defined", prefix, name)
+		} else if value != v {
+			t.Errorf("%s%s: want %d, got %d", prefix, name, value, v)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package build
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 可以在配置中关闭的功能，关闭后不输出对应的文件，枚举和常量总是输出
+const (
+	FeatureData     = "data"
+	FeatureServer   = "server"
+	FeatureDatabase = "database"
+	FeatureVerify   = "verify"
+	FeatureUi       = "ui"
+	FeatureExport   = "export"
+	FeatureJson     = "json" // openapi 生成 JSON 文档
+	FeatureYaml     = "yaml" // openapi 生成 YAML 文档
+)
+
+var _features = map[string]void{
+	FeatureData: {}, FeatureServer: {}, FeatureDatabase: {}, FeatureVerify: {}, FeatureUi: {}, FeatureExport: {},
+	FeatureJson: {}, FeatureYaml: {},
+}
+
+// _requires 功能依赖的其它功能，这些功能生成的代码会引用 data 生成的类型，
+// 依赖的功能关闭时未配置的功能也随之关闭，明确开启时检查报错
+var _requires = map[string]string{
+	FeatureServer: FeatureData, FeatureDatabase: FeatureData, FeatureVerify: FeatureData, FeatureUi: FeatureData,
+	FeatureExport: FeatureData,
+}
+
+// ConfigNames 未指定配置文件时在当前目录依次查找的文件名
+var ConfigNames = []string{"hbuf.yaml", "hbuf.yml", "hbuf.json"}
+
+// Config 项目配置，一次生成多种语言的代码
+type Config struct {
+	Inputs   []string  `yaml:"inputs"`   // 输入文件，如 "api/*.hbuf"
+	Includes []string  `yaml:"includes"` // 查找引入文件的目录
+	Targets  []*Target `yaml:"targets"`
+}
+
+// Target 一种语言的生成配置
+type Target struct {
+	Type     string          `yaml:"type"`     // 语言，同 -t
+	Out      string          `yaml:"out"`      // 输出目录，同 -o
+	Package  string          `yaml:"package"`  // 包路径，同 -p
+	Features map[string]bool `yaml:"features"` // 功能开关，未配置的功能默认生成
+}
+
+// LoadConfig 读取 YAML 或 JSON 格式的配置文件，相对路径以配置文件所在目录为准
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	err = yaml.Unmarshal(data, config)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+
+	dir := filepath.Dir(path)
+	for i, in := range config.Inputs {
+		config.Inputs[i] = joinPath(dir, in)
+	}
+	for i, include := range config.Includes {
+		config.Includes[i] = joinPath(dir, include)
+	}
+	for _, target := range config.Targets {
+		if nil != target {
+			target.Out = joinPath(dir, target.Out)
+		}
+	}
+
+	err = config.Check()
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return config, nil
+}
+
+// FindConfig 返回 dir 中第一个存在的配置文件，没有时返回空
+func FindConfig(dir string) string {
+	for _, name := range ConfigNames {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// Check 检查配置是否完整
+func (c *Config) Check() error {
+	if 0 == len(c.Inputs) {
+		return errors.New("Input file not found")
+	}
+	if 0 == len(c.Targets) {
+		return errors.New("Target not found")
+	}
+	for _, target := range c.Targets {
+		if nil == target || 0 == len(target.Type) {
+			return errors.New("Build type not found")
+		}
+		if !CheckType(target.Type) {
+			return errors.New("Type error : " + target.Type)
+		}
+		if 0 == len(target.Out) {
+			return errors.New("Output directory not found: " + target.Type)
+		}
+		names := GetKeysByMap(target.Features)
+		sort.Strings(names)
+		for _, name := range names {
+			if _, ok := _features[name]; !ok {
+				return errors.New("Unknown feature: " + name)
+			}
+			if require, ok := _requires[name]; ok && target.Features[name] && !featureEnabled(target.Features, require) {
+				return errors.New("Feature " + name + " requires " + require + ": " + target.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// featureEnabled 返回是否生成 name 对应的代码，未配置的功能默认生成，依赖的功能关闭时随之关闭
+func featureEnabled(features map[string]bool, name string) bool {
+	if enable, ok := features[name]; ok {
+		return enable
+	}
+	if require, ok := _requires[name]; ok {
+		return featureEnabled(features, require)
+	}
+	return true
+}
+
+func joinPath(dir string, path string) string {
+	if 0 == len(path) || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
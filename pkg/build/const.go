@@ -0,0 +1,109 @@
+package build
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/token"
+	"strconv"
+)
+
+// _constTypes 常量可以使用的类型
+var _constTypes = map[BaseType]struct{}{
+	Int8: {}, Int16: {}, Int32: {}, Int64: {}, Uint8: {}, Uint16: {}, Uint32: {}, Uint64: {},
+	Float: {}, Double: {}, Bool: {}, String: {},
+}
+
+// _intBits 整数类型的位数
+var _intBits = map[BaseType]int{
+	Int8: 8, Int16: 16, Int32: 32, Int64: 64, Uint8: 8, Uint16: 16, Uint32: 32, Uint64: 64,
+}
+
+func (b *Builder) checkConst(file *ast.File, spec *ast.ConstSpec, index int) {
+	name := spec.Name.Name
+	if _, ok := _keys[BaseType(name)]; ok {
+		b.error(spec.Name.Pos(), "Invalid name: "+name)
+	}
+	if b.checkDuplicateType(file, index, name) {
+		b.error(spec.Name.Pos(), "Duplicate type: "+name)
+	}
+
+	resolveAlias(spec.Type)
+	typ := BaseType(spec.Type.Name)
+	if _, ok := _constTypes[typ]; !ok {
+		b.error(spec.Type.Pos(), "Invalid const type: "+spec.Type.Name)
+		return
+	}
+	if !checkLiteral(typ, spec.Value) {
+		b.error(spec.Value.Pos(), "Invalid "+spec.Type.Name+" value: "+spec.Value.Value)
+	}
+}
+
+// checkLiteral 检查字面量是否是类型 typ 的合法值
+func checkLiteral(typ BaseType, lit *ast.BasicLit) bool {
+	switch typ {
+	case Int8, Int16, Int32, Int64:
+		_, err := strconv.ParseInt(lit.Value, 0, _intBits[typ])
+		return token.INT == lit.Kind && err == nil
+	case Uint8, Uint16, Uint32, Uint64:
+		_, err := strconv.ParseUint(lit.Value, 0, _intBits[typ])
+		return token.INT == lit.Kind && err == nil
+	case Float, Double:
+		if token.INT == lit.Kind {
+			_, err := strconv.ParseInt(lit.Value, 0, 64)
+			return err == nil
+		}
+		_, err := strconv.ParseFloat(lit.Value, 64)
+		return token.FLOAT == lit.Kind && err == nil
+	case Decimal:
+		_, err := strconv.ParseFloat(TagString(lit), 64)
+		return token.IDENT != lit.Kind && err == nil
+	case Bool:
+		return token.IDENT == lit.Kind && ("true" == lit.Value || "false" == lit.Value)
+	case String:
+		return token.STRING == lit.Kind
+	}
+	return false
+}
+
+// GetConst 在文件和引入的文件中查找常量
+func (b *Builder) GetConst(file *ast.File, name string) *ast.ConstSpec {
+	if obj, _ := b.lookup(file, "", name, ast.Const); nil != obj {
+		if spec, ok := obj.Decl.(*ast.ConstSpec); ok {
+			return spec
+		}
+	}
+	return nil
+}
+
+// ConstValue 返回常量值的通用写法，见 LiteralValue
+func ConstValue(spec *ast.ConstSpec) string {
+	return LiteralValue(BaseType(spec.Type.Name), spec.Value)
+}
+
+// LiteralValue 返回字面量的通用写法，整数转为十进制，字符串重新加引号，decimal 去掉引号保留原样，各语言的生成器在此基础上输出
+func LiteralValue(typ BaseType, lit *ast.BasicLit) string {
+	value := lit.Value
+	switch typ {
+	case Int8, Int16, Int32, Int64:
+		if v, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return strconv.FormatInt(v, 10)
+		}
+	case Uint8, Uint16, Uint32, Uint64:
+		if v, err := strconv.ParseUint(value, 0, 64); err == nil {
+			return strconv.FormatUint(v, 10)
+		}
+	case Float, Double:
+		if v, err := strconv.ParseInt(value, 0, 64); err == nil {
+			return strconv.FormatInt(v, 10)
+		}
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return strconv.FormatFloat(v, 'g', -1, 64)
+		}
+	case Decimal:
+		return TagString(lit)
+	case String:
+		if v, err := strconv.Unquote(value); err == nil {
+			return strconv.Quote(v)
+		}
+	}
+	return value
+}
@@ -2,203 +2,236 @@ package build
 
 import (
 	"hbuf/pkg/ast"
-	"hbuf/pkg/scanner"
+	"hbuf/pkg/token"
+	"strconv"
+	"strings"
 )
 
-func (b *Builder) checkData(file *ast.File, data *ast.DataType, index int) error {
-	err := b.checkTags(data.Tags)
-	if err != nil {
-		return err
-	}
+// MaxFieldId 字段和继承的 Id 的最大值，二进制中 Id 最多 4 个字节
+const MaxFieldId = 1<<32 - 1
+
+// parseId 解析字段、继承或枚举项的 Id，不是整数或大于 max 时返回 false
+func parseId(id *ast.BasicLit, max uint64) (uint64, bool) {
+	value, err := strconv.ParseUint(id.Value, 0, 64)
+	return value, err == nil && value <= max
+}
+
+func (b *Builder) checkData(file *ast.File, data *ast.DataType, index int) {
+	b.checkTags(file, data.Tags, TargetData)
 	name := data.Name.Name
 	if _, ok := _keys[BaseType(name)]; ok {
-		return scanner.Error{
-			Pos: b.fset.Position(data.Name.Pos()),
-			Msg: "Invalid name: " + name,
-		}
+		b.error(data.Name.Pos(), "Invalid name: "+name)
 	}
 
 	if b.checkDuplicateType(file, index, name) {
-		return scanner.Error{
-			Pos: b.fset.Position(data.Name.Pos()),
-			Msg: "Duplicate type: " + name,
-		}
-	}
-
-	err = b.checkDataExtends(file, data, index)
-	if err != nil {
-		return err
+		b.error(data.Name.Pos(), "Duplicate type: "+name)
 	}
 
-	err = b.checkDataItem(file, data)
-	if err != nil {
-		return err
-	}
+	b.checkDataExtends(file, data, index)
+	b.checkReservedRange(data.Reserved)
+	b.checkDataItem(file, data)
 
 	data.Name.Obj.Data = file
-	return nil
 }
 
-func (b *Builder) checkDataExtends(file *ast.File, data *ast.DataType, index int) error {
+func (b *Builder) checkDataExtends(file *ast.File, data *ast.DataType, index int) {
 	for i, item := range data.Extends {
 		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(data.Name.Pos()),
-				Msg: "Invalid name: " + item.Name.Name,
-			}
+			b.error(data.Name.Pos(), "Invalid name: "+item.Name.Name)
+			continue
 		}
 		if b.checkDataDuplicateExtends(data, i, item.Name.Name) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.NamePos),
-				Msg: "Duplicate item: " + item.Name.Name,
-			}
+			b.error(item.Name.NamePos, "Duplicate item: "+item.Name.Name)
+		}
+		if id, ok := parseId(item.Id, MaxFieldId); !ok {
+			b.error(item.Id.Pos(), "Extends id out of range (0 to "+strconv.FormatUint(MaxFieldId, 10)+"): "+item.Id.Value)
+		} else if b.checkDataDuplicateExtendsId(data, i, id) {
+			b.error(item.Id.Pos(), "Duplicate item: "+item.Id.Value)
 		}
 
-		obj := b.getDataExtends(file, index, item.Name.Name)
+		obj, msg := b.lookup(file, extendsName(item), item.Name.Name, ast.Data)
+		if 0 < len(msg) {
+			b.error(item.Name.NamePos, msg)
+			continue
+		}
 		if nil == obj {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.NamePos),
-				Msg: "Not find: " + item.Name.Name,
-			}
+			b.error(item.Name.NamePos, "Not find: "+item.Name.Name)
+			continue
+		}
+		if 0 < len(obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType).OneOfs) {
+			b.error(item.Name.NamePos, "Data with oneof cannot be extended: "+item.Name.Name)
 		}
 		item.Name.Obj = obj
 	}
-	return nil
 }
 
-func (b *Builder) getDataExtends(file *ast.File, index int, name string) *ast.Object {
-	if obj := file.Scope.Lookup(name); nil != obj {
-		switch obj.Decl.(type) {
-		case *ast.TypeSpec:
-			t := (obj.Decl.(*ast.TypeSpec)).Type
-			switch t.(type) {
-			case *ast.DataType:
-				return obj
-			}
-		}
+// GetDataType 在文件和引入的文件中查找数据或枚举
+func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
+	obj, _ := b.lookup(file, "", name, ast.Data, ast.Enum)
+	return obj
+}
 
+func (b *Builder) checkDataItemType(file *ast.File, typ ast.Type) {
+	pkg, ident := typeName(typ)
+	if nil == ident {
+		b.error(typ.Pos(), "Type Error")
+		return
 	}
-	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
-			if obj := f.Scope.Lookup(name); nil != obj {
-				switch obj.Decl.(type) {
-				case *ast.TypeSpec:
-					t := (obj.Decl.(*ast.TypeSpec)).Type
-					switch t.(type) {
-					case *ast.DataType:
-						return obj
-					}
-				}
-			}
-		}
+	if 0 == len(pkg) {
+		resolveAlias(ident)
 	}
-	return nil
+	if _, ok := _types[BaseType(ident.Name)]; ok && 0 == len(pkg) {
+		return
+	}
+	obj, msg := b.lookup(file, pkg, ident.Name, ast.Data, ast.Enum)
+	if nil != obj {
+		ident.Obj = obj
+		return
+	}
+	if 0 == len(msg) {
+		msg = "Invalid name: " + ident.Name
+	}
+	b.error(ident.Pos(), msg)
 }
 
-func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
-	if obj := file.Scope.Lookup(name); nil != obj {
-		switch obj.Decl.(type) {
-		case *ast.TypeSpec:
-			t := (obj.Decl.(*ast.TypeSpec)).Type
-			switch t.(type) {
-			case *ast.DataType:
-				return obj
-			case *ast.EnumType:
-				return obj
-			}
+// checkDataFieldType 检查字段类型，数组和 Map 逐层检查到最内层的类型
+func (b *Builder) checkDataFieldType(file *ast.File, typ ast.Type) {
+	switch t := typ.(type) {
+	case *ast.VarType:
+		b.checkDataItemType(file, t)
+	case *ast.ArrayType:
+		b.checkDataFieldType(file, t.VType)
+	case *ast.MapType:
+		if key, ok := t.Key.(*ast.VarType); ok {
+			b.checkDataMapKey(file, key)
+		} else {
+			b.error(t.Key.Pos(), "Map keys can only be of type")
 		}
+		b.checkDataFieldType(file, t.VType)
 	}
-	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
-			if obj := f.Scope.Lookup(name); nil != obj {
-				switch obj.Decl.(type) {
-				case *ast.TypeSpec:
-					t := (obj.Decl.(*ast.TypeSpec)).Type
-					switch t.(type) {
-					case *ast.DataType:
-						return obj
-					case *ast.EnumType:
-						return obj
-					}
-				}
-			}
+}
+
+func (b *Builder) checkDataItem(file *ast.File, data *ast.DataType) {
+	fields := DataFields(data)
+	for index, item := range fields {
+		b.checkTags(file, item.Tags, TargetField)
+		b.checkDataFieldType(file, item.Type)
+		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
+			b.error(item.Name.Pos(), "Invalid name: "+item.Name.Name)
+		}
+		if b.checkDataDuplicateItem(fields, index, item.Name.Name) {
+			b.error(item.Name.Pos(), "Duplicate item: "+item.Name.Name)
 		}
+		if id, ok := parseId(item.Id, MaxFieldId); !ok {
+			b.error(item.Id.Pos(), "Field id out of range (0 to "+strconv.FormatUint(MaxFieldId, 10)+"): "+item.Id.Value)
+		} else if b.checkDataDuplicateValue(fields, index, id) {
+			b.error(item.Id.Pos(), "Duplicate item: "+item.Id.Value)
+		}
+		b.checkReserved(data.Reserved, item.Id, item.Name)
+		b.checkDataDefault(file, item)
 	}
-	return nil
+	b.checkDataOneOf(data, fields)
 }
 
-func (b *Builder) checkDataItemType(file *ast.File, typ ast.Type) error {
-	switch typ.Type().(type) {
-	case *ast.Ident:
-		ident := typ.Type().(*ast.Ident)
-		if _, ok := _types[BaseType(ident.Name)]; ok {
-			return nil
+// checkDataOneOf 检查 oneof 的名称和成员类型，成员只能是不可为空的单个值
+func (b *Builder) checkDataOneOf(data *ast.DataType, fields []*ast.Field) {
+	for index, oneOf := range data.OneOfs {
+		name := oneOf.Name.Name
+		if _, ok := _keys[BaseType(name)]; ok {
+			b.error(oneOf.Name.Pos(), "Invalid name: "+name)
 		}
-		obj := b.GetDataType(file, ident.Name)
-		if nil != obj {
-			ident.Obj = obj
-			return nil
+		if b.checkDataDuplicateItem(fields, -1, name) || b.checkOneOfDuplicate(data.OneOfs, index, name) {
+			b.error(oneOf.Name.Pos(), "Duplicate item: "+name)
 		}
-		return scanner.Error{
-			Pos: b.fset.Position(ident.Pos()),
-			Msg: "Invalid name: " + ident.Name,
+		if 0 == len(oneOf.Fields) {
+			b.error(oneOf.Closing, "Oneof cannot be empty: "+name)
+		}
+		for _, field := range oneOf.Fields {
+			if field.Type.IsEmpty() || IsArray(field.Type) || IsMap(field.Type) {
+				b.error(field.Type.Pos(), "Invalid oneof type: "+field.Name.Name)
+			}
+			if nil != field.Default {
+				b.error(field.Default.Pos(), "Oneof member cannot have default value: "+field.Name.Name)
+			}
 		}
 	}
+}
 
-	return scanner.Error{
-		Pos: b.fset.Position(typ.Pos()),
-		Msg: "Type Error",
+func (b *Builder) checkOneOfDuplicate(oneOfs []*ast.OneOf, index int, name string) bool {
+	for i := index + 1; i < len(oneOfs); i++ {
+		if oneOfs[i].Name.Name == name {
+			return true
+		}
 	}
+	return false
 }
 
-func (b *Builder) checkDataItem(file *ast.File, data *ast.DataType) error {
-	for index, item := range data.Fields.List {
-		err := b.checkTags(item.Tags)
-		if err != nil {
-			return err
-		}
-		switch item.Type.(type) {
-		case *ast.VarType:
-			err := b.checkDataItemType(file, item.Type)
-			if err != nil {
-				return err
-			}
-		case *ast.ArrayType:
-			err := b.checkDataItemType(file, item.Type.(*ast.ArrayType).Type().(*ast.VarType))
-			if err != nil {
-				return err
-			}
-		case *ast.MapType:
-			ma := item.Type.(*ast.MapType)
-			err := b.checkDataMapKey(file, ma.Key.(*ast.VarType))
-			if err != nil {
-				return err
-			}
-			err = b.checkDataItemType(file, ma.Type().(*ast.VarType))
-			if err != nil {
-				return err
-			}
+// DataFields 返回数据的字段和所有 oneof 的成员
+func DataFields(data *ast.DataType) []*ast.Field {
+	fields := data.Fields.List
+	if 0 < len(data.OneOfs) {
+		fields = append([]*ast.Field{}, fields...)
+		for _, oneOf := range data.OneOfs {
+			fields = append(fields, oneOf.Fields...)
 		}
-		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.Pos()),
-				Msg: "Invalid name: " + item.Name.Name,
-			}
+	}
+	return fields
+}
+
+// checkDataDefault 检查字段的默认值，引用的常量替换为常量的值，枚举项统一写成枚举项名称
+func (b *Builder) checkDataDefault(file *ast.File, field *ast.Field) {
+	def := field.Default
+	if nil == def {
+		return
+	}
+	if IsArray(field.Type) || IsMap(field.Type) {
+		b.error(def.Pos(), "Invalid default type: "+field.Name.Name)
+		return
+	}
+	ident, ok := field.Type.Type().(*ast.Ident)
+	if !ok {
+		return
+	}
+	if nil != ident.Obj {
+		spec, _ := ident.Obj.Decl.(*ast.TypeSpec)
+		if nil == spec {
+			return
 		}
-		if b.checkDataDuplicateItem(data, index, item.Name.Name) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.Pos()),
-				Msg: "Duplicate item: " + item.Name.Name,
-			}
+		enum, ok := spec.Type.(*ast.EnumType)
+		if !ok {
+			b.error(def.Pos(), "Invalid default type: "+ident.Name)
+			return
 		}
-		if b.checkDataDuplicateValue(data, index, item.Id.Value) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Id.Pos()),
-				Msg: "Duplicate item: " + item.Id.Value,
+		if token.IDENT == def.Kind {
+			if item := getEnumItem(enum, strings.TrimPrefix(def.Value, ident.Name+".")); nil != item {
+				def.Value = item.Name.Name
+				return
 			}
 		}
+		b.error(def.Pos(), "Not find enum item of "+ident.Name+": "+def.Value)
+		return
+	}
+
+	typ := BaseType(ident.Name)
+	if _, ok := _types[typ]; !ok {
+		return
+	}
+	switch typ {
+	case Date, Bytes, Duration, Uuid, Json:
+		b.error(def.Pos(), "Invalid default type: "+ident.Name)
+		return
+	}
+	if token.IDENT == def.Kind {
+		if spec := b.GetConst(file, def.Value); nil != spec {
+			def.Kind = spec.Value.Kind
+			def.Value = ConstValue(spec)
+		}
 	}
-	return nil
+	if !checkLiteral(typ, def) {
+		b.error(def.Pos(), "Invalid "+ident.Name+" value: "+def.Value)
+		return
+	}
+	def.Value = LiteralValue(typ, def)
 }
 
 func (b *Builder) checkDataDuplicateExtends(data *ast.DataType, index int, name string) bool {
@@ -211,9 +244,9 @@ func (b *Builder) checkDataDuplicateExtends(data *ast.DataType, index int, name
 	return false
 }
 
-func (b *Builder) checkDataDuplicateItem(data *ast.DataType, index int, name string) bool {
-	for i := index + 1; i < len(data.Fields.List); i++ {
-		s := data.Fields.List[i]
+func (b *Builder) checkDataDuplicateItem(fields []*ast.Field, index int, name string) bool {
+	for i := index + 1; i < len(fields); i++ {
+		s := fields[i]
 		if s.Name.Name == name {
 			return true
 		}
@@ -221,10 +254,20 @@ func (b *Builder) checkDataDuplicateItem(data *ast.DataType, index int, name str
 	return false
 }
 
-func (b *Builder) checkDataDuplicateValue(data *ast.DataType, index int, id string) bool {
-	for i := index + 1; i < len(data.Fields.List); i++ {
-		s := data.Fields.List[i]
-		if s.Id.Value == id {
+// checkDataDuplicateExtendsId 按数值比较继承的 Id，0x1 和 1 视为重复
+func (b *Builder) checkDataDuplicateExtendsId(data *ast.DataType, index int, id uint64) bool {
+	for i := index + 1; i < len(data.Extends); i++ {
+		if value, ok := parseId(data.Extends[i].Id, MaxFieldId); ok && value == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDataDuplicateValue 按数值比较字段的 Id，0x1 和 1 视为重复
+func (b *Builder) checkDataDuplicateValue(fields []*ast.Field, index int, id uint64) bool {
+	for i := index + 1; i < len(fields); i++ {
+		if value, ok := parseId(fields[i].Id, MaxFieldId); ok && value == id {
 			return true
 		}
 	}
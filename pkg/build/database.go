@@ -60,55 +60,55 @@ func GetDB(n string, tag []*ast.Tag) []*DB {
 			if nil != val.KV {
 				for _, item := range val.KV {
 					if "name" == item.Name.Name {
-						db.Name = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Name = TagString(item.Values[0])
 					} else if "schema" == item.Name.Name {
-						db.Schema = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Schema = TagString(item.Values[0])
 					} else if "converter" == item.Name.Name {
-						db.Converter = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Converter = TagString(item.Values[0])
 					} else if "order" == item.Name.Name {
-						db.Order = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Order = TagString(item.Values[0])
 					} else if "key" == item.Name.Name {
-						db.Key = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+						db.Key, _ = TagBool(item.Values[0])
 					} else if "typ" == item.Name.Name {
-						db.typ = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.typ = TagString(item.Values[0])
 					} else if "where" == item.Name.Name {
 						where := make([]string, len(item.Values))
 						for i, val := range item.Values {
-							where[i] = val.Value[1 : len(item.Values[0].Value)-1]
+							where[i] = TagString(val)
 						}
 						db.Where = where
 					} else if "offset" == item.Name.Name {
-						db.Offset = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Offset = TagString(item.Values[0])
 					} else if "limit" == item.Name.Name {
-						db.Limit = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Limit = TagString(item.Values[0])
 					} else if "insert" == item.Name.Name {
-						db.Insert = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Insert = TagString(item.Values[0])
 					} else if "inserts" == item.Name.Name {
-						db.Inserts = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Inserts = TagString(item.Values[0])
 					} else if "update" == item.Name.Name {
-						db.Update = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Update = TagString(item.Values[0])
 					} else if "group" == item.Name.Name {
-						db.Group = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Group = TagString(item.Values[0])
 					} else if "del" == item.Name.Name {
-						db.Del = "true" == strings.ToLower(item.Values[0].Value[1:len(item.Values[0].Value)-1])
+						db.Del, _ = TagBool(item.Values[0])
 					} else if "get" == item.Name.Name {
-						db.Get = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Get = TagString(item.Values[0])
 					} else if "list" == item.Name.Name {
-						db.List = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.List = TagString(item.Values[0])
 					} else if "map" == item.Name.Name {
-						db.Map = strings.ToLower(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+						db.Map = strings.ToLower(TagString(item.Values[0]))
 					} else if "table" == item.Name.Name {
-						db.Table = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Table = TagString(item.Values[0])
 					} else if "set" == item.Name.Name {
-						db.Set = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+						db.Set = TagString(item.Values[0])
 					} else if "count" == item.Name.Name {
-						db.Count = "true" == strings.ToLower(item.Values[0].Value[1:len(item.Values[0].Value)-1])
+						db.Count, _ = TagBool(item.Values[0])
 					} else if "force" == item.Name.Name {
-						db.Force = "true" == strings.ToLower(item.Values[0].Value[1:len(item.Values[0].Value)-1])
+						db.Force, _ = TagBool(item.Values[0])
 					} else if "rm" == item.Name.Name {
-						db.Remove = "true" == strings.ToLower(item.Values[0].Value[1:len(item.Values[0].Value)-1])
+						db.Remove, _ = TagBool(item.Values[0])
 					} else if "fake" == item.Name.Name {
-						db.Fake = "true" == strings.ToLower(item.Values[0].Value[1:len(item.Values[0].Value)-1])
+						db.Fake, _ = TagBool(item.Values[0])
 					}
 				}
 			}
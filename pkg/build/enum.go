@@ -2,60 +2,45 @@ package build
 
 import (
 	"hbuf/pkg/ast"
-	"hbuf/pkg/scanner"
+	"strconv"
 )
 
-func (b *Builder) checkEnum(file *ast.File, enum *ast.EnumType, index int) error {
+// MaxEnumId 枚举项 Id 的最大值，Go 的枚举类型为 int，在 32 位平台上也要能表示
+const MaxEnumId = 1<<31 - 1
+
+func (b *Builder) checkEnum(file *ast.File, enum *ast.EnumType, index int) {
+	b.checkTags(file, enum.Tags, TargetEnum)
 	name := enum.Name.Name
 	if _, ok := _keys[BaseType(name)]; ok {
-		return scanner.Error{
-			Pos: b.fset.Position(enum.Name.Pos()),
-			Msg: "Invalid name: " + name,
-		}
+		b.error(enum.Name.Pos(), "Invalid name: "+name)
 	}
 
 	if b.checkDuplicateType(file, index, name) {
-		return scanner.Error{
-			Pos: b.fset.Position(enum.Name.Pos()),
-			Msg: "Duplicate type: " + name,
-		}
+		b.error(enum.Name.Pos(), "Duplicate type: "+name)
 	}
 
-	err := b.checkEnumItem(file, enum)
-	if err != nil {
-		return err
-	}
+	b.checkReservedRange(enum.Reserved)
+	b.checkEnumItem(file, enum)
 
 	enum.Name.Obj.Data = file
-	return nil
 }
 
-func (b *Builder) checkEnumItem(file *ast.File, enum *ast.EnumType) error {
+func (b *Builder) checkEnumItem(file *ast.File, enum *ast.EnumType) {
 	for index, item := range enum.Items {
-		err := b.checkTags(item.Tags)
-		if err != nil {
-			return err
-		}
+		b.checkTags(file, item.Tags, TargetEnumItem)
 		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(enum.Name.Pos()),
-				Msg: "Invalid name: " + item.Name.Name,
-			}
+			b.error(item.Name.Pos(), "Invalid name: "+item.Name.Name)
 		}
 		if b.checkEnumDuplicateItem(enum, index, item.Name.Name) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.Pos()),
-				Msg: "Duplicate item: " + item.Name.Name,
-			}
+			b.error(item.Name.Pos(), "Duplicate item: "+item.Name.Name)
 		}
-		if b.checkEnumDuplicateValue(enum, index, item.Id.Value) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Id.Pos()),
-				Msg: "Duplicate item: " + item.Id.Value,
-			}
+		if id, ok := parseId(item.Id, MaxEnumId); !ok {
+			b.error(item.Id.Pos(), "Enum id out of range (0 to "+strconv.FormatUint(MaxEnumId, 10)+"): "+item.Id.Value)
+		} else if b.checkEnumDuplicateValue(enum, index, id) {
+			b.error(item.Id.Pos(), "Duplicate item: "+item.Id.Value)
 		}
+		b.checkReserved(enum.Reserved, item.Id, item.Name)
 	}
-	return nil
 }
 
 func (b *Builder) checkEnumDuplicateItem(enum *ast.EnumType, index int, name string) bool {
@@ -68,10 +53,10 @@ func (b *Builder) checkEnumDuplicateItem(enum *ast.EnumType, index int, name str
 	return false
 }
 
-func (b *Builder) checkEnumDuplicateValue(enum *ast.EnumType, index int, id string) bool {
+// checkEnumDuplicateValue 按数值比较枚举项的 Id，0x1 和 1 视为重复
+func (b *Builder) checkEnumDuplicateValue(enum *ast.EnumType, index int, id uint64) bool {
 	for i := index + 1; i < len(enum.Items); i++ {
-		s := enum.Items[i]
-		if s.Id.Value == id {
+		if value, ok := parseId(enum.Items[i].Id, MaxEnumId); ok && value == id {
 			return true
 		}
 	}
@@ -21,7 +21,7 @@ func GetExport(tags []*ast.Tag) (*Export, error) {
 	for _, item := range val.KV {
 		if "filter" == item.Name.Name {
 			for _, i := range item.Values {
-				filter := i.Value[1 : len(i.Value)-1]
+				filter := TagString(i)
 				if 0 == len(filter) {
 					return nil, NewError(i.Pos()+1, "Not set filter")
 				}
@@ -22,13 +22,13 @@ func GetFormat(tags []*ast.Tag) *Format {
 	if nil != val.KV {
 		for _, item := range val.KV {
 			if "null" == item.Name.Name {
-				f.Null = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				f.Null, _ = TagBool(item.Values[0])
 			} else if "reg" == item.Name.Name {
-				f.Reg = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				f.Reg = TagString(item.Values[0])
 			} else if "min" == item.Name.Name {
-				f.Min = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				f.Min = TagString(item.Values[0])
 			} else if "max" == item.Name.Name {
-				f.Max = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				f.Max = TagString(item.Values[0])
 			}
 		}
 	}
@@ -0,0 +1,241 @@
+package build
+
+import (
+	"hbuf/pkg/ast"
+	"sort"
+	"strings"
+)
+
+// Http 方法的 http 注解，如 [http:method="GET";path="/users/{id}";body="*"]，
+// Path 中的参数和 Body 已经换成 JSON 中的字段名称
+type Http struct {
+	Method string        // 请求方法，默认为 POST
+	Path   string        // 请求路径，{字段} 为路径参数
+	Body   string        // "*" 为整个请求数据，字段名称为该字段，为空时没有请求体，其余字段放在查询参数中
+	Data   *ast.DataType // 请求数据的类型
+}
+
+var httpMethods = []string{"GET", "POST", "PUT", "DELETE", "PATCH"}
+
+// GetHttp 获得方法的 http 注解，没有注解时返回 nil
+func GetHttp(method *ast.FuncType) (*Http, error) {
+	val, ok := GetTag(method.Tags, "http")
+	if !ok {
+		return nil, nil
+	}
+	if nil != GetStreamItem(method.Param) || nil != GetStreamItem(method.Result) || "stream" == GetBaseType(method.Param) || "stream" == GetBaseType(method.Result) {
+		return nil, NewError(val.Name.Pos(), "Tag http cannot be used on stream method: "+method.Name.Name)
+	}
+	_, ident := typeName(method.Param)
+	if nil == ident || !isDataResolved(ident.Obj, map[*ast.Object]bool{}) {
+		return nil, NewError(method.Param.Pos(), "Type can only be data")
+	}
+	data := ident.Obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType)
+	fields := map[string]*ast.Field{}
+	_ = EnumField(data, func(field *ast.Field, data *ast.DataType) error {
+		fields[field.Name.Name] = field
+		return nil
+	})
+
+	ret := &Http{Method: "POST", Data: data}
+	if kv, ok := GetKeyValue(val.KV, "method"); ok {
+		ret.Method = TagString(kv.Values[0])
+	}
+	if "GET" == ret.Method || "DELETE" == ret.Method {
+		ret.Body = ""
+	} else {
+		ret.Body = "*"
+	}
+
+	kv, ok := GetKeyValue(val.KV, "path")
+	if !ok || 0 == len(TagString(kv.Values[0])) {
+		return nil, NewError(val.Name.Pos(), "Not set path")
+	}
+	path := TagString(kv.Values[0])
+	if !strings.HasPrefix(path, "/") {
+		return nil, NewError(kv.Values[0].Pos(), "Path must start with /: "+path)
+	}
+	params := map[string]struct{}{}
+	var err error
+	ret.Path = HttpPath(path, func(name string) string {
+		field, ok := fields[name]
+		_, repeated := params[name]
+		if nil == err && !ok {
+			err = NewError(kv.Values[0].Pos(), "Not find path field: "+name)
+		} else if nil == err && repeated {
+			err = NewError(kv.Values[0].Pos(), "Duplicate path field: "+name)
+		} else if nil == err && !isHttpParam(field.Type) {
+			err = NewError(kv.Values[0].Pos(), "Path field can only be a number, string, bool, date, duration, uuid or enum: "+name)
+		}
+		params[name] = struct{}{}
+		return "{" + StringToUnderlineName(name) + "}"
+	})
+	if nil != err {
+		return nil, err
+	}
+
+	if kv, ok := GetKeyValue(val.KV, "body"); ok {
+		body := TagString(kv.Values[0])
+		if 0 < len(body) && "*" != body {
+			if _, ok := fields[body]; !ok {
+				return nil, NewError(kv.Values[0].Pos(), "Not find body field: "+body)
+			}
+			if _, ok := params[body]; ok {
+				return nil, NewError(kv.Values[0].Pos(), "Body field cannot be a path field: "+body)
+			}
+			body = StringToUnderlineName(body)
+		}
+		if 0 < len(body) && "GET" == ret.Method {
+			return nil, NewError(kv.Values[0].Pos(), "GET cannot have body")
+		}
+		ret.Body = body
+	}
+	return ret, nil
+}
+
+// HttpPath 把路径中的每个 {参数} 替换为 call 的返回值
+func HttpPath(path string, call func(name string) string) string {
+	list := strings.Split(path, "/")
+	for i, item := range list {
+		if strings.HasPrefix(item, "{") && strings.HasSuffix(item, "}") {
+			list[i] = call(item[1 : len(item)-1])
+		}
+	}
+	return strings.Join(list, "/")
+}
+
+// isHttpParam 返回字段能否作为路径参数
+func isHttpParam(typ ast.Expr) bool {
+	if IsArray(typ) || IsMap(typ) {
+		return false
+	}
+	if IsEnum(typ) || IsNumber(typ) {
+		return true
+	}
+	switch GetBaseType(typ) {
+	case String, Bool, Date, Duration, Uuid:
+		return true
+	}
+	return false
+}
+
+// isDataResolved 对象是数据，并且继承的数据都已找到时返回 true，继承有环时返回 false
+func isDataResolved(obj *ast.Object, path map[*ast.Object]bool) bool {
+	if nil == obj || ast.Data != obj.Kind || path[obj] {
+		return false
+	}
+	spec, ok := obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return false
+	}
+	data, ok := spec.Type.(*ast.DataType)
+	if !ok {
+		return false
+	}
+	path[obj] = true
+	defer delete(path, obj)
+	for _, extend := range data.Extends {
+		if !isDataResolved(extend.Name.Obj, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// isServerResolved 服务继承的服务都已找到时返回 true，继承有环时返回 false
+func isServerResolved(server *ast.ServerType, path map[*ast.ServerType]bool) bool {
+	if path[server] {
+		return false
+	}
+	path[server] = true
+	defer delete(path, server)
+	for _, extend := range server.Extends {
+		obj := extend.Name.Obj
+		if nil == obj || ast.Server != obj.Kind {
+			return false
+		}
+		spec, ok := obj.Decl.(*ast.TypeSpec)
+		if !ok {
+			return false
+		}
+		parent, ok := spec.Type.(*ast.ServerType)
+		if !ok || !isServerResolved(parent, path) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHttpResolved 方法的请求数据已找到，或者是 stream 方法时返回 true，
+// 请求数据没有找到时错误已经在类型检查中报告，不再检查 http 注解
+func isHttpResolved(method *ast.FuncType) bool {
+	if nil != GetStreamItem(method.Param) || nil != GetStreamItem(method.Result) || "stream" == GetBaseType(method.Param) || "stream" == GetBaseType(method.Result) {
+		return true
+	}
+	_, ident := typeName(method.Param)
+	return nil != ident && isDataResolved(ident.Obj, map[*ast.Object]bool{})
+}
+
+// checkHttp 检查方法的 http 注解，其他检查有错误时也会进行，只跳过类型没有找到的服务和方法
+func (b *Builder) checkHttp() {
+	paths := GetKeysByMap(b.pkg.Files)
+	sort.Strings(paths)
+	routes := map[string]*httpRouteOwner{}
+	for _, path := range paths {
+		for _, s := range b.pkg.Files[path].Specs {
+			spec, ok := s.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			server, ok := spec.Type.(*ast.ServerType)
+			if !ok {
+				continue
+			}
+			for _, method := range server.Methods {
+				if !isHttpResolved(method) {
+					continue
+				}
+				if _, err := GetHttp(method); nil != err {
+					b.errorOf(err)
+				}
+			}
+			if isServerResolved(server, map[*ast.ServerType]bool{}) {
+				b.checkHttpRoute(server, routes)
+			}
+		}
+	}
+}
+
+// checkHttpRoute 检查服务（包括继承的方法）中是否有与 routes 中相同请求方法和路径的路由，路径参数名称不同也视为相同，
+// routes 在整个包内共用，不同服务注册到同一个 HttpHandler 时路由也不能重复，继承得到的同一个方法不算重复
+func (b *Builder) checkHttpRoute(server *ast.ServerType, routes map[string]*httpRouteOwner) {
+	_ = EnumMethod(server, func(method *ast.FuncType, s *ast.ServerType) error {
+		route, err := GetHttp(method)
+		if nil != err || nil == route {
+			return nil
+		}
+		key := route.Method + " " + HttpPath(route.Path, func(name string) string {
+			return "{}"
+		})
+		if other, ok := routes[key]; ok {
+			if other.method == method {
+				return nil
+			}
+			name := other.method.Name.Name
+			if other.server != server {
+				name = other.server.Name.Name + "." + name
+			}
+			val, _ := GetTag(method.Tags, "http")
+			b.error(val.Name.Pos(), "Duplicate http route: "+route.Method+" "+route.Path+", already used by "+name)
+			return nil
+		}
+		routes[key] = &httpRouteOwner{server: server, method: method}
+		return nil
+	})
+}
+
+// httpRouteOwner 定义路由的服务和方法
+type httpRouteOwner struct {
+	server *ast.ServerType
+	method *ast.FuncType
+}
@@ -0,0 +1,86 @@
+package build
+
+import (
+	"hbuf/pkg/ast"
+)
+
+// lookup 查找名称为 name 且类型为 kinds 之一的对象，pkg 不为空时只在 import ... as pkg 引入的文件中查找。
+// 先查找当前文件，再按引入的层级逐层查找没有 as 的引入，找到的层中有多个文件定义了该名称时返回 Ambiguous 错误
+func (b *Builder) lookup(file *ast.File, pkg string, name string, kinds ...ast.ObjKind) (*ast.Object, string) {
+	if 0 < len(pkg) {
+		imp := b.getImport(file, pkg)
+		if nil == imp {
+			return nil, "Not find import: " + pkg
+		}
+		return b.lookup(imp, "", name, kinds...)
+	}
+
+	visited := map[*ast.File]bool{file: true}
+	level := []*ast.File{file}
+	for 0 < len(level) {
+		var found *ast.Object
+		var next []*ast.File
+		for _, f := range level {
+			if obj := f.Scope.Lookup(name); nil != obj && isKind(obj, kinds) {
+				if nil != found {
+					return nil, "Ambiguous type: " + name
+				}
+				found = obj
+			}
+			for _, spec := range f.Imports {
+				if nil != spec.Name {
+					continue
+				}
+				if imp, ok := b.pkg.Files[spec.File]; ok && !visited[imp] {
+					visited[imp] = true
+					next = append(next, imp)
+				}
+			}
+		}
+		if nil != found {
+			return found, ""
+		}
+		level = next
+	}
+	return nil, ""
+}
+
+// getImport 返回 file 中 import ... as name 引入的文件
+func (b *Builder) getImport(file *ast.File, name string) *ast.File {
+	for _, spec := range file.Imports {
+		if nil != spec.Name && spec.Name.Name == name {
+			return b.pkg.Files[spec.File]
+		}
+	}
+	return nil
+}
+
+func isKind(obj *ast.Object, kinds []ast.ObjKind) bool {
+	for _, kind := range kinds {
+		if obj.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName 返回类型引用的包名和类型名，不带包名时包名为空
+func typeName(expr ast.Expr) (string, *ast.Ident) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return "", t
+	case *ast.SelectorExpr:
+		return t.X.Name, t.Sel
+	case *ast.VarType:
+		return typeName(t.TypeExpr)
+	}
+	return "", nil
+}
+
+// extendsName 返回继承的包名
+func extendsName(item *ast.Extends) string {
+	if nil == item.X {
+		return ""
+	}
+	return item.X.Name
+}
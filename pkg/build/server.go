@@ -2,143 +2,87 @@ package build
 
 import (
 	"hbuf/pkg/ast"
-	"hbuf/pkg/scanner"
+	"strconv"
 )
 
-func (b *Builder) checkServer(file *ast.File, server *ast.ServerType, index int) error {
-	err := b.checkTags(server.Tags)
-	if err != nil {
-		return err
-	}
+func (b *Builder) checkServer(file *ast.File, server *ast.ServerType, index int) {
+	b.checkTags(file, server.Tags, TargetServer)
 	name := server.Name.Name
 	if _, ok := _keys[BaseType(name)]; ok {
-		return scanner.Error{
-			Pos: b.fset.Position(server.Name.Pos()),
-			Msg: "Invalid name: " + name,
-		}
+		b.error(server.Name.Pos(), "Invalid name: "+name)
 	}
 
 	if b.checkDuplicateType(file, index, name) {
-		return scanner.Error{
-			Pos: b.fset.Position(server.Name.Pos()),
-			Msg: "Duplicate type: " + name,
-		}
+		b.error(server.Name.Pos(), "Duplicate type: "+name)
 	}
 
-	err = b.checkServerExtends(file, server, index)
-	if err != nil {
-		return err
-	}
-
-	err = b.checkServerItem(file, server)
-	if err != nil {
-		return err
-	}
+	b.checkServerExtends(file, server, index)
+	b.checkServerItem(file, server)
 
 	server.Name.Obj.Data = file
-	return nil
 }
 
-func (b *Builder) checkServerItem(file *ast.File, server *ast.ServerType) error {
+func (b *Builder) checkServerItem(file *ast.File, server *ast.ServerType) {
+	ids := map[uint64]*ast.Ident{}
 	for index, item := range server.Methods {
-		err := b.checkTags(item.Tags)
-		if err != nil {
-			return err
-		}
+		b.checkTags(file, item.Tags, TargetMethod)
 
-		ident := item.Result.TypeExpr.(*ast.Ident)
-		if "void" != ident.Name && "stream" != ident.Name {
-			err = b.checkServerItemType(file, item.Result)
-			if err != nil {
-				return err
-			}
+		if stream := GetStreamItem(item.Result); nil != stream {
+			b.checkServerItemType(file, stream)
+		} else if ident, ok := item.Result.TypeExpr.(*ast.Ident); !ok || ("void" != ident.Name && "stream" != ident.Name) {
+			b.checkServerItemType(file, item.Result)
 		}
 
 		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.Pos()),
-				Msg: "Invalid name: " + item.Name.Name,
-			}
+			b.error(item.Name.Pos(), "Invalid name: "+item.Name.Name)
 		}
 
-		ident = item.Param.TypeExpr.(*ast.Ident)
-		if "stream" != ident.Name {
-			err = b.checkServerItemType(file, item.Param)
-			if err != nil {
-				return err
-			}
+		if stream := GetStreamItem(item.Param); nil != stream {
+			b.checkServerItemType(file, stream)
+		} else if ident, ok := item.Param.TypeExpr.(*ast.Ident); !ok || "stream" != ident.Name {
+			b.checkServerItemType(file, item.Param)
 		}
 
 		if b.checkServerDuplicateItem(server, index, item.Name.Name) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.Pos()),
-				Msg: "Duplicate item: " + item.Name.Name,
-			}
+			b.error(item.Name.Pos(), "Duplicate item: "+item.Name.Name)
 		}
 
 		if _, ok := _keys[BaseType(item.ParamName.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(item.ParamName.Pos()),
-				Msg: "Invalid name: " + item.ParamName.Name,
-			}
+			b.error(item.ParamName.Pos(), "Invalid name: "+item.ParamName.Name)
 		}
 
-		//if b.checkDataDuplicateValue(server, index, item.Id.Values) {
-		//	return scanner.Error{
-		//		Pos: b.fset.Position(item.Id.Pos()),
-		//		Msg: "Duplicate item: " + item.Id.Values,
-		//	}
-		//}
+		// 方法 Id 是调用 Id 的低 32 位，同一个服务中不能重复
+		if id, err := ParseMethodId(item); err != nil {
+			b.errorOf(err)
+		} else if other, ok := ids[id]; ok {
+			b.error(item.Id.Pos(), "Duplicate id: "+item.Id.Value+", already used by "+other.Name)
+		} else {
+			ids[id] = item.Name
+		}
 	}
-	return nil
 }
 
-func (b *Builder) checkServerItemType(file *ast.File, result *ast.VarType) error {
+func (b *Builder) checkServerItemType(file *ast.File, result *ast.VarType) {
 	if result.IsEmpty() {
-		return scanner.Error{
-			Pos: b.fset.Position(result.TypeExpr.End()),
-			Msg: "Map key cannot be empty",
-		}
+		b.error(result.TypeExpr.End(), "Map key cannot be empty")
+		return
 	}
 
-	ident := result.TypeExpr.(*ast.Ident)
-	if obj := file.Scope.Lookup(ident.Name); nil != obj {
-		switch obj.Decl.(*ast.TypeSpec).Type.(type) {
-		case *ast.DataType:
-			if _, ok := _types[BaseType(ident.Name)]; ok {
-				return nil
-			}
-			obj := b.GetDataType(file, ident.Name)
-			if nil != obj {
-				ident.Obj = obj
-				return nil
-			}
-			return nil
-		}
+	pkg, ident := typeName(result)
+	if nil == ident {
+		b.error(result.Pos(), "Type Error")
+		return
 	}
-
-	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
-			if obj := f.Scope.Lookup(ident.Name); nil != obj {
-				switch obj.Decl.(*ast.TypeSpec).Type.(type) {
-				case *ast.DataType:
-					if _, ok := _types[BaseType(ident.Name)]; ok {
-						return nil
-					}
-					obj := b.GetDataType(file, ident.Name)
-					if nil != obj {
-						ident.Obj = obj
-						return nil
-					}
-					return nil
-				}
-			}
-		}
+	obj, msg := b.lookup(file, pkg, ident.Name, ast.Data)
+	if 0 < len(msg) {
+		b.error(ident.Pos(), msg)
+		return
 	}
-	return scanner.Error{
-		Pos: b.fset.Position(result.TypeExpr.End()),
-		Msg: "Type can only be data: " + result.TypeExpr.(*ast.Ident).Name,
+	if nil == obj {
+		b.error(result.TypeExpr.End(), "Type can only be data: "+ident.Name)
+		return
 	}
+	ident.Obj = obj
 }
 
 func (b *Builder) checkServerDuplicateItem(server *ast.ServerType, index int, name string) bool {
@@ -151,60 +95,27 @@ func (b *Builder) checkServerDuplicateItem(server *ast.ServerType, index int, na
 	return false
 }
 
-func (b *Builder) checkServerExtends(file *ast.File, server *ast.ServerType, index int) error {
+func (b *Builder) checkServerExtends(file *ast.File, server *ast.ServerType, index int) {
 	for i, item := range server.Extends {
 		if _, ok := _keys[BaseType(item.Name.Name)]; ok {
-			return scanner.Error{
-				Pos: b.fset.Position(server.Name.Pos()),
-				Msg: "Invalid name: " + item.Name.Name,
-			}
+			b.error(server.Name.Pos(), "Invalid name: "+item.Name.Name)
+			continue
 		}
 		if b.checkServerDuplicateExtends(server, i, item.Name.Name) {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.NamePos),
-				Msg: "Duplicate item: " + item.Name.Name,
-			}
+			b.error(item.Name.NamePos, "Duplicate item: "+item.Name.Name)
 		}
 
-		obj := b.getServerExtends(file, index, item.Name.Name)
+		obj, msg := b.lookup(file, extendsName(item), item.Name.Name, ast.Server)
+		if 0 < len(msg) {
+			b.error(item.Name.NamePos, msg)
+			continue
+		}
 		if nil == obj {
-			return scanner.Error{
-				Pos: b.fset.Position(item.Name.NamePos),
-				Msg: "Not find: " + item.Name.Name,
-			}
+			b.error(item.Name.NamePos, "Not find: "+item.Name.Name)
+			continue
 		}
 		item.Name.Obj = obj
 	}
-	return nil
-}
-
-func (b *Builder) getServerExtends(file *ast.File, index int, name string) *ast.Object {
-	if obj := file.Scope.Lookup(name); nil != obj {
-		switch obj.Decl.(type) {
-		case *ast.TypeSpec:
-			t := (obj.Decl.(*ast.TypeSpec)).Type
-			switch t.(type) {
-			case *ast.ServerType:
-				return obj
-			}
-		}
-
-	}
-	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
-			if obj := f.Scope.Lookup(name); nil != obj {
-				switch obj.Decl.(type) {
-				case *ast.TypeSpec:
-					t := (obj.Decl.(*ast.TypeSpec)).Type
-					switch t.(type) {
-					case *ast.ServerType:
-						return obj
-					}
-				}
-			}
-		}
-	}
-	return nil
 }
 
 func (b *Builder) checkServerDuplicateExtends(server *ast.ServerType, index int, name string) bool {
@@ -216,3 +127,60 @@ func (b *Builder) checkServerDuplicateExtends(server *ast.ServerType, index int,
 	}
 	return false
 }
+
+// GetStreamItem 获得 stream<Item> 中消息的类型，不是消息流时返回 nil
+func GetStreamItem(typ *ast.VarType) *ast.VarType {
+	if stream, ok := typ.TypeExpr.(*ast.StreamType); ok {
+		return stream.Item
+	}
+	return nil
+}
+
+// MaxServerId 服务 Id 的最大值，调用 Id 需要小于 2^53 才能在 Dart 和 TypeScript 中精确表示
+const MaxServerId = 1<<21 - 1
+
+// MaxMethodId 方法 Id 的最大值，方法 Id 为调用 Id 的低 32 位
+const MaxMethodId = 1<<32 - 1
+
+// ParseServerId 解析服务的 Id，未设置时为 0
+func ParseServerId(server *ast.ServerType) (uint64, error) {
+	if nil == server.Id {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(server.Id.Value, 0, 64)
+	if err != nil || MaxServerId < id {
+		return 0, NewError(server.Id.Pos(), "Server id out of range (0 to "+strconv.FormatUint(MaxServerId, 10)+"): "+server.Id.Value)
+	}
+	return id, nil
+}
+
+// ParseMethodId 解析方法的 Id
+func ParseMethodId(method *ast.FuncType) (uint64, error) {
+	id, err := strconv.ParseUint(method.Id.Value, 0, 64)
+	if err != nil || MaxMethodId < id {
+		return 0, NewError(method.Id.Pos(), "Method id out of range (0 to "+strconv.FormatUint(MaxMethodId, 10)+"): "+method.Id.Value)
+	}
+	return id, nil
+}
+
+// GetServerId 获得服务的 Id，未设置时为 0
+func GetServerId(server *ast.ServerType) (string, error) {
+	id, err := ParseServerId(server)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(id, 10), nil
+}
+
+// GetInvokeId 获得方法的调用 Id，高 32 位为服务 Id，低 32 位为方法 Id
+func GetInvokeId(server *ast.ServerType, method *ast.FuncType) (string, error) {
+	serverId, err := ParseServerId(server)
+	if err != nil {
+		return "", err
+	}
+	methodId, err := ParseMethodId(method)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(serverId<<32|methodId, 10), nil
+}
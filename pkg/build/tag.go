@@ -0,0 +1,377 @@
+package build
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/token"
+	"strconv"
+	"strings"
+)
+
+// ValueKind 标签值的类型
+type ValueKind int
+
+const (
+	StringValue ValueKind = iota // 任意字符串
+	BoolValue                    // true 或 false
+	IntValue                     // 整数
+	FloatValue                   // 数字
+	EnumValue                    // 限定的字符串，见 TagKey.Enum
+	TypeValue                    // 类型引用，见 TagKey.Ref
+)
+
+// TagTarget 标签可以使用的位置
+type TagTarget int
+
+const (
+	TargetData TagTarget = 1 << iota
+	TargetField
+	TargetEnum
+	TargetEnumItem
+	TargetServer
+	TargetMethod
+)
+
+var targetNames = map[TagTarget]string{
+	TargetData:     "data",
+	TargetField:    "field",
+	TargetEnum:     "enum",
+	TargetEnumItem: "enum item",
+	TargetServer:   "server",
+	TargetMethod:   "method",
+}
+
+func (t TagTarget) String() string {
+	list := make([]string, 0, len(targetNames))
+	for i := TargetData; i <= TargetMethod; i <<= 1 {
+		if 0 != t&i {
+			list = append(list, targetNames[i])
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// TagKey 标签中一个键的定义
+type TagKey struct {
+	Kind  ValueKind
+	Enum  []string    // Kind 为 EnumValue 时允许的值
+	Ref   ast.ObjKind // Kind 为 TypeValue 时引用的类型，ast.Enum 和 ast.Server 需要写成 "类型.成员"
+	Multi bool        // 是否允许多个值
+}
+
+// TagSpec 标签的定义
+type TagSpec struct {
+	Name    string
+	Suffix  bool               // 名称后可以带数字，如 db1
+	Targets TagTarget          // 可以使用的位置
+	Keys    map[string]*TagKey // 允许的键
+	Any     *TagKey            // 不为 nil 时允许任意键，如 lang 的语言代码
+}
+
+var tagSpecs = map[string]*TagSpec{}
+
+// AddTag 注册标签，检查时未注册的标签会报错
+func AddTag(spec *TagSpec) {
+	tagSpecs[spec.Name] = spec
+}
+
+// GetTagSpec 按标签名称获取定义，带数字后缀的名称如 db1 返回 db 的定义
+func GetTagSpec(name string) (*TagSpec, bool) {
+	if spec, ok := tagSpecs[name]; ok {
+		return spec, true
+	}
+	base := strings.TrimRight(name, "0123456789")
+	if spec, ok := tagSpecs[base]; ok && spec.Suffix && base != name {
+		return spec, true
+	}
+	return nil, false
+}
+
+func init() {
+	str := &TagKey{Kind: StringValue}
+	boolean := &TagKey{Kind: BoolValue}
+	integer := &TagKey{Kind: IntValue}
+	float := &TagKey{Kind: FloatValue}
+
+	AddTag(&TagSpec{
+		Name:    "db",
+		Suffix:  true,
+		Targets: TargetData | TargetField,
+		Keys: map[string]*TagKey{
+			"name":      str,
+			"schema":    str,
+			"converter": str,
+			"order":     str,
+			"key":       boolean,
+			"typ":       str,
+			"where":     {Kind: StringValue, Multi: true},
+			"offset":    str,
+			"limit":     str,
+			"insert":    str,
+			"inserts":   str,
+			"update":    str,
+			"group":     str,
+			"del":       boolean,
+			"get":       str,
+			"list":      str,
+			"map":       str,
+			"table":     {Kind: TypeValue, Ref: ast.Data},
+			"set":       str,
+			"count":     boolean,
+			"force":     boolean,
+			"rm":        boolean,
+			"fake":      boolean,
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "ui",
+		Targets: TargetData | TargetField | TargetEnum,
+		Keys: map[string]*TagKey{
+			"onlyRead": boolean,
+			"form": {Kind: EnumValue, Enum: []string{
+				"true", "text", "pass", "click", "file", "image", "menu", "switch", "radio", "radioButton",
+				"date", "dates", "datetime", "year", "month",
+			}},
+			"table":      str,
+			"digit":      integer,
+			"index":      integer,
+			"format":     str,
+			"width":      float,
+			"height":     float,
+			"maxLine":    integer,
+			"maxCount":   integer,
+			"clip":       boolean,
+			"toNull":     boolean,
+			"unlink":     boolean,
+			"extensions": {Kind: StringValue, Multi: true},
+			"min":        float,
+			"max":        float,
+			"step":       float,
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "verify",
+		Targets: TargetData | TargetField,
+		Keys: map[string]*TagKey{
+			"format": {Kind: TypeValue, Ref: ast.Enum, Multi: true},
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "format",
+		Targets: TargetEnumItem,
+		Keys: map[string]*TagKey{
+			"null": boolean,
+			"reg":  str,
+			"min":  str,
+			"max":  str,
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "export",
+		Targets: TargetField,
+		Keys: map[string]*TagKey{
+			"filter": {Kind: StringValue, Multi: true},
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "cache",
+		Targets: TargetData,
+		Keys: map[string]*TagKey{
+			"min": integer,
+			"max": integer,
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "mq",
+		Targets: TargetData,
+		Keys:    map[string]*TagKey{},
+	})
+	AddTag(&TagSpec{
+		Name:    "bind",
+		Targets: TargetMethod,
+		Keys: map[string]*TagKey{
+			"value": {Kind: TypeValue, Ref: ast.Server},
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "http",
+		Targets: TargetMethod,
+		Keys: map[string]*TagKey{
+			"method": {Kind: EnumValue, Enum: httpMethods},
+			"path":   str,
+			"body":   str,
+		},
+	})
+	AddTag(&TagSpec{
+		Name:    "lang",
+		Targets: TargetField | TargetEnumItem,
+		Any:     str,
+	})
+	AddTag(&TagSpec{
+		Name:    "tag",
+		Targets: TargetMethod,
+		Any:     &TagKey{Kind: StringValue, Multi: true},
+	})
+}
+
+func (b *Builder) checkTags(file *ast.File, tags []*ast.Tag, target TagTarget) {
+	for i := 0; i < len(tags)-1; i++ {
+		for j := i + 1; j < len(tags); j++ {
+			if tags[i].Name.Name == tags[j].Name.Name {
+				b.error(tags[j].Pos(), "Repeated tag key")
+			}
+		}
+	}
+
+	for _, tag := range tags {
+		b.checkKeyValue(tag.KV)
+
+		spec, ok := GetTagSpec(tag.Name.Name)
+		if !ok {
+			b.error(tag.Name.Pos(), "Unknown tag: "+tag.Name.Name)
+			continue
+		}
+		if 0 == spec.Targets&target {
+			b.error(tag.Name.Pos(), "Tag "+tag.Name.Name+" cannot be used on "+target.String()+", only on "+spec.Targets.String())
+			continue
+		}
+		for _, kv := range tag.KV {
+			if nil == kv {
+				continue
+			}
+			key, ok := spec.Keys[kv.Name.Name]
+			if !ok {
+				key = spec.Any
+			}
+			if nil == key {
+				b.error(kv.Name.Pos(), "Unknown tag key: "+tag.Name.Name+"."+kv.Name.Name)
+				continue
+			}
+			b.checkTagValue(file, tag.Name.Name+"."+kv.Name.Name, kv, key)
+		}
+	}
+}
+
+func (b *Builder) checkKeyValue(kvs []*ast.KeyValue) {
+	for i := 0; i < len(kvs)-1; i++ {
+		for j := i + 1; j < len(kvs); j++ {
+			if nil != kvs[i] && nil != kvs[j] && kvs[i].Name.Name == kvs[j].Name.Name {
+				b.error(kvs[j].Pos(), "Repeated tag key")
+			}
+		}
+	}
+}
+
+// checkTagValue 按键的定义检查值的数量和类型
+func (b *Builder) checkTagValue(file *ast.File, name string, kv *ast.KeyValue, key *TagKey) {
+	if !key.Multi && 1 < len(kv.Values) {
+		b.error(kv.Values[1].Pos(), "Tag key "+name+" can only have one value")
+	}
+	for _, lit := range kv.Values {
+		if token.IDENT == lit.Kind && TypeValue != key.Kind {
+			// 引用常量时替换为常量的值
+			if spec := b.GetConst(file, lit.Value); nil != spec {
+				lit.Kind = spec.Value.Kind
+				lit.Value = ConstValue(spec)
+			}
+		}
+		value := TagString(lit)
+		switch key.Kind {
+		case BoolValue:
+			if _, err := TagBool(lit); err != nil {
+				b.error(lit.Pos(), "Invalid bool value of "+name+": "+lit.Value)
+			}
+		case IntValue:
+			if _, err := TagInt(lit); err != nil {
+				b.error(lit.Pos(), "Invalid int value of "+name+": "+lit.Value)
+			}
+		case FloatValue:
+			if _, err := TagFloat(lit); err != nil {
+				b.error(lit.Pos(), "Invalid float value of "+name+": "+lit.Value)
+			}
+		case EnumValue:
+			if !containsString(key.Enum, value) {
+				b.error(lit.Pos(), "Invalid value of "+name+": "+lit.Value+", must be one of "+strings.Join(key.Enum, ", "))
+			}
+		case TypeValue:
+			if msg := b.checkTagType(file, value, key.Ref); 0 < len(msg) {
+				b.error(lit.Pos(), msg+" of "+name+": "+lit.Value)
+			}
+		}
+	}
+}
+
+// checkTagType 检查类型引用，Data 为数据名称，Enum 为 "枚举.枚举项"，Server 为 "服务.方法"
+func (b *Builder) checkTagType(file *ast.File, value string, ref ast.ObjKind) string {
+	name, member, _ := strings.Cut(value, ".")
+	spec := b.getTypeSpec(file, name)
+	if nil == spec {
+		return "Not find type"
+	}
+	switch typ := spec.Type.(type) {
+	case *ast.DataType:
+		if ast.Data == ref && 0 == len(member) {
+			return ""
+		}
+	case *ast.EnumType:
+		if ast.Enum == ref {
+			for _, item := range typ.Items {
+				if item.Name.Name == member {
+					return ""
+				}
+			}
+			return "Not find enum item"
+		}
+	case *ast.ServerType:
+		if ast.Server == ref {
+			for _, method := range typ.Methods {
+				if method.Name.Name == member {
+					return ""
+				}
+			}
+			return "Not find server method"
+		}
+	}
+	return "Invalid type"
+}
+
+// getTypeSpec 在文件和引入的文件中查找类型定义
+func (b *Builder) getTypeSpec(file *ast.File, name string) *ast.TypeSpec {
+	if obj, _ := b.lookup(file, "", name, ast.Data, ast.Enum, ast.Server); nil != obj {
+		if spec, ok := obj.Decl.(*ast.TypeSpec); ok {
+			return spec
+		}
+	}
+	return nil
+}
+
+// TagString 返回标签值的内容，字符串去掉两端的引号，其他字面量原样返回
+func TagString(lit *ast.BasicLit) string {
+	if token.STRING == lit.Kind && 2 <= len(lit.Value) {
+		return lit.Value[1 : len(lit.Value)-1]
+	}
+	return lit.Value
+}
+
+// TagBool 返回标签值的布尔值，可以写成 true 或 "true"
+func TagBool(lit *ast.BasicLit) (bool, error) {
+	return strconv.ParseBool(TagString(lit))
+}
+
+// TagInt 返回标签值的整数值，可以写成 2 或 "2"
+func TagInt(lit *ast.BasicLit) (int, error) {
+	return strconv.Atoi(TagString(lit))
+}
+
+// TagFloat 返回标签值的数字值，可以写成 1.5 或 "1.5"
+func TagFloat(lit *ast.BasicLit) (float64, error) {
+	return strconv.ParseFloat(TagString(lit), 64)
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
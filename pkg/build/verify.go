@@ -31,7 +31,7 @@ func GetVerify(tags []*ast.Tag, file *ast.File, getType func(file *ast.File, nam
 	for _, item := range val.KV {
 		if "format" == item.Name.Name {
 			for _, i := range item.Values {
-				format := i.Value[1 : len(i.Value)-1]
+				format := TagString(i)
 				if 0 == len(format) {
 					return nil, NewError(i.Pos()+1, "Not set format")
 				}
@@ -0,0 +1,445 @@
+package compat
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/parser"
+	"hbuf/pkg/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Level 变更的兼容级别
+type Level int
+
+const (
+	Compatible Level = iota // 兼容
+	Breaking                // 不兼容
+	Semantic                // 编码兼容，但含义可能改变，需要人工确认
+)
+
+func (l Level) String() string {
+	switch l {
+	case Breaking:
+		return "breaking"
+	case Semantic:
+		return "semantic"
+	}
+	return "compatible"
+}
+
+// Change 两个版本之间的一处变更，分别给出 JSON 和二进制格式下的兼容级别
+type Change struct {
+	Pos    token.Position
+	Msg    string
+	JSON   Level
+	Binary Level
+}
+
+// Breaking 任一格式不兼容时返回 true
+func (c *Change) Breaking() bool {
+	return Breaking == c.JSON || Breaking == c.Binary
+}
+
+func (c *Change) String() string {
+	return c.Pos.String() + ": " + c.Msg + " (json: " + c.JSON.String() + ", binary: " + c.Binary.String() + ")"
+}
+
+// Dir 解析 old 和 new 目录下的全部 .hbuf 文件并比较
+func Dir(old string, new string) ([]*Change, error) {
+	fset := token.NewFileSet()
+	reg := regexp.MustCompile(`\.hbuf$`)
+	oldPkg := ast.NewPackage()
+	err := parser.ParseDir(fset, oldPkg, old, reg)
+	if err != nil {
+		return nil, err
+	}
+	newPkg := ast.NewPackage()
+	err = parser.ParseDir(fset, newPkg, new, reg)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(fset, oldPkg, newPkg), nil
+}
+
+// Compare 按文件和类型名称比较两个版本的包，数据字段按 ID 对应，枚举项和接口方法按名称对应，
+// 文件按在包中的名称对应，即相对输入目录的路径，不同文件中的同名类型分别比较
+func Compare(fset *token.FileSet, old *ast.Package, new *ast.Package) []*Change {
+	c := &comparer{fset: fset}
+	oldTypes := types(old)
+	newTypes := types(new)
+	for _, key := range sortedKeys(oldTypes) {
+		o := oldTypes[key]
+		name := o.Name.Name
+		n, ok := newTypes[key]
+		if !ok {
+			c.add(o.Name.Pos(), "type removed: "+name, Breaking, Breaking)
+			continue
+		}
+		switch ot := o.Type.(type) {
+		case *ast.DataType:
+			if nt, ok := n.Type.(*ast.DataType); ok {
+				c.compareData(ot, nt)
+				continue
+			}
+		case *ast.EnumType:
+			if nt, ok := n.Type.(*ast.EnumType); ok {
+				c.compareEnum(ot, nt)
+				continue
+			}
+		case *ast.ServerType:
+			if nt, ok := n.Type.(*ast.ServerType); ok {
+				c.compareServer(ot, nt)
+				continue
+			}
+		}
+		c.add(n.Name.Pos(), "type kind changed: "+name, Breaking, Breaking)
+	}
+	for _, key := range sortedKeys(newTypes) {
+		if _, ok := oldTypes[key]; !ok {
+			c.add(newTypes[key].Name.Pos(), "type added: "+newTypes[key].Name.Name, Compatible, Compatible)
+		}
+	}
+
+	sort.SliceStable(c.changes, func(i, j int) bool {
+		a, b := c.changes[i].Pos, c.changes[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.changes
+}
+
+type comparer struct {
+	fset    *token.FileSet
+	changes []*Change
+}
+
+func (c *comparer) add(pos token.Pos, msg string, json Level, binary Level) {
+	c.changes = append(c.changes, &Change{
+		Pos:    c.fset.Position(pos),
+		Msg:    msg,
+		JSON:   json,
+		Binary: binary,
+	})
+}
+
+func (c *comparer) compareData(old *ast.DataType, new *ast.DataType) {
+	name := new.Name.Name
+	if idKey(old.Id) != idKey(new.Id) {
+		// 数据作为字段时按字段 ID 编码，继承时按 extends 的 ID 编码，数据 ID 不会写入
+		c.add(new.Name.Pos(), "data "+name+" id changed: "+idString(old.Id)+" -> "+idString(new.Id), Compatible, Compatible)
+	}
+	c.compareExtends(name, old.Extends, new.Extends, new.Name.Pos())
+
+	oldIds := map[string]*ast.Field{}
+	oldNames := map[string]*ast.Field{}
+	for _, field := range fields(old) {
+		oldIds[idKey(field.Id)] = field
+		oldNames[field.Name.Name] = field
+	}
+	newIds := map[string]*ast.Field{}
+	newNames := map[string]*ast.Field{}
+	for _, field := range fields(new) {
+		newIds[idKey(field.Id)] = field
+		newNames[field.Name.Name] = field
+	}
+
+	for _, o := range fields(old) {
+		field := name + "." + o.Name.Name
+		n, ok := newIds[idKey(o.Id)]
+		if !ok {
+			if n, ok := newNames[o.Name.Name]; ok {
+				c.add(n.Id.Pos(), "field "+field+" id changed: "+o.Id.Value+" -> "+n.Id.Value, Compatible, Breaking)
+				c.compareType(n.Type.Pos(), "field "+field, o.Type, n.Type)
+			} else {
+				c.add(new.Name.Pos(), "field removed: "+field+" = "+o.Id.Value, Compatible, Compatible)
+			}
+			continue
+		}
+		if o.Name.Name != n.Name.Name {
+			// 类型相同时无法区分是改名还是用旧 ID 表示新的含义
+			if typeString(o.Type) != typeString(n.Type) {
+				c.add(n.Id.Pos(), "field id "+o.Id.Value+" reused: "+field+" -> "+name+"."+n.Name.Name, Breaking, Breaking)
+			} else {
+				c.add(n.Id.Pos(), "field id "+o.Id.Value+" reused with the same type: "+field+" -> "+name+"."+n.Name.Name, Breaking, Semantic)
+			}
+			continue
+		}
+		c.compareType(n.Type.Pos(), "field "+field, o.Type, n.Type)
+		if idString(o.Default) != idString(n.Default) {
+			c.add(n.Name.Pos(), "field "+field+" default changed: "+idString(o.Default)+" -> "+idString(n.Default), Compatible, Compatible)
+		}
+		if oneOfName(old, o) != oneOfName(new, n) {
+			c.add(n.Name.Pos(), "field "+field+" oneof changed: "+oneOfName(old, o)+" -> "+oneOfName(new, n), Breaking, Compatible)
+		}
+	}
+	for _, n := range fields(new) {
+		_, id := oldIds[idKey(n.Id)]
+		_, named := oldNames[n.Name.Name]
+		if !id && !named {
+			c.add(n.Name.Pos(), "field added: "+name+"."+n.Name.Name+" = "+n.Id.Value, Compatible, Compatible)
+		}
+	}
+}
+
+func (c *comparer) compareExtends(name string, old []*ast.Extends, new []*ast.Extends, pos token.Pos) {
+	newExtends := map[string]*ast.Extends{}
+	for _, extend := range new {
+		newExtends[extend.Name.Name] = extend
+	}
+	oldExtends := map[string]*ast.Extends{}
+	for _, o := range old {
+		oldExtends[o.Name.Name] = o
+		n, ok := newExtends[o.Name.Name]
+		if !ok {
+			c.add(pos, name+" extends removed: "+o.Name.Name, Breaking, Breaking)
+			continue
+		}
+		if idKey(o.Id) != idKey(n.Id) {
+			c.add(n.Name.Pos(), name+" extends "+o.Name.Name+" id changed: "+idString(o.Id)+" -> "+idString(n.Id), Compatible, Breaking)
+		}
+	}
+	for _, n := range new {
+		if _, ok := oldExtends[n.Name.Name]; !ok {
+			c.add(n.Name.Pos(), name+" extends added: "+n.Name.Name, Compatible, Compatible)
+		}
+	}
+}
+
+// compareType 比较类型，可空性改变时两个方向都不兼容：变为必填时旧数据可能缺少该字段，
+// 变为可空时旧版本读取到 null 或缺少的字段会失败或得到零值
+func (c *comparer) compareType(pos token.Pos, name string, old ast.Expr, new ast.Expr) {
+	o := typeString(old)
+	n := typeString(new)
+	if o == n {
+		return
+	}
+	if bits, ok := widened(old, new); ok {
+		// Dart 和 TypeScript 的 JSON 中 64 位整数为字符串
+		json := Compatible
+		if 64 == bits {
+			json = Breaking
+		}
+		c.add(pos, name+" widened: "+o+" -> "+n, json, Compatible)
+		return
+	}
+	if strings.TrimSuffix(o, "?") == strings.TrimSuffix(n, "?") {
+		if strings.HasSuffix(o, "?") {
+			c.add(pos, name+" becomes required: "+o+" -> "+n, Breaking, Breaking)
+		} else {
+			c.add(pos, name+" becomes nullable: "+o+" -> "+n, Breaking, Breaking)
+		}
+		return
+	}
+	c.add(pos, name+" type changed: "+o+" -> "+n, Breaking, Breaking)
+}
+
+// _intBits 整数类型的位数，有符号和无符号整数在二进制中分别按同一种类型编码，位数变大时能读取旧数据
+var _intBits = map[string]int{"int8": 8, "int16": 16, "int32": 32, "int64": 64}
+
+var _uintBits = map[string]int{"uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64}
+
+// widened 可空性不变，同为有符号或无符号的整数位数变大时返回 true 和新的位数
+func widened(old ast.Expr, new ast.Expr) (int, bool) {
+	o, ok := old.(*ast.VarType)
+	if !ok {
+		return 0, false
+	}
+	n, ok := new.(*ast.VarType)
+	if !ok || o.Empty != n.Empty {
+		return 0, false
+	}
+	for _, bits := range []map[string]int{_intBits, _uintBits} {
+		ob, ok := bits[typeString(o.TypeExpr)]
+		if !ok {
+			continue
+		}
+		if nb, ok := bits[typeString(n.TypeExpr)]; ok && ob < nb {
+			return nb, true
+		}
+	}
+	return 0, false
+}
+
+// compareEnum 枚举在 JSON 和二进制中都以数值传输，重新编号对两种格式都不兼容
+func (c *comparer) compareEnum(old *ast.EnumType, new *ast.EnumType) {
+	name := new.Name.Name
+	newItems := map[string]*ast.EnumItem{}
+	newIds := map[string]*ast.EnumItem{}
+	for _, item := range new.Items {
+		newItems[item.Name.Name] = item
+		newIds[idKey(item.Id)] = item
+	}
+	oldItems := map[string]*ast.EnumItem{}
+	oldIds := map[string]*ast.EnumItem{}
+	for _, o := range old.Items {
+		oldItems[o.Name.Name] = o
+		oldIds[idKey(o.Id)] = o
+		n, ok := newItems[o.Name.Name]
+		if !ok {
+			if n, ok := newIds[idKey(o.Id)]; ok {
+				c.add(n.Name.Pos(), "enum item renamed: "+name+"."+o.Name.Name+" -> "+name+"."+n.Name.Name, Compatible, Compatible)
+			} else {
+				c.add(new.Name.Pos(), "enum item removed: "+name+"."+o.Name.Name, Breaking, Breaking)
+			}
+			continue
+		}
+		if idKey(o.Id) != idKey(n.Id) {
+			c.add(n.Id.Pos(), "enum item "+name+"."+o.Name.Name+" renumbered: "+o.Id.Value+" -> "+n.Id.Value, Breaking, Breaking)
+		}
+	}
+	for _, n := range new.Items {
+		_, named := oldItems[n.Name.Name]
+		_, id := oldIds[idKey(n.Id)]
+		if !named && !id {
+			c.add(n.Name.Pos(), "enum item added: "+name+"."+n.Name.Name+" = "+n.Id.Value, Compatible, Compatible)
+		}
+	}
+}
+
+// compareServer 方法按名称对应，JSON 按名称路由，二进制按 ID 路由
+func (c *comparer) compareServer(old *ast.ServerType, new *ast.ServerType) {
+	name := new.Name.Name
+	if serverIdKey(old.Id) != serverIdKey(new.Id) {
+		c.add(new.Name.Pos(), "server "+name+" id changed: "+idString(old.Id)+" -> "+idString(new.Id), Compatible, Breaking)
+	}
+	c.compareExtends(name, old.Extends, new.Extends, new.Name.Pos())
+
+	newMethods := map[string]*ast.FuncType{}
+	for _, method := range new.Methods {
+		newMethods[method.Name.Name] = method
+	}
+	oldMethods := map[string]*ast.FuncType{}
+	oldIds := map[string]*ast.FuncType{}
+	for _, o := range old.Methods {
+		oldMethods[o.Name.Name] = o
+		oldIds[idKey(o.Id)] = o
+		method := name + "." + o.Name.Name
+		n, ok := newMethods[o.Name.Name]
+		if !ok {
+			c.add(new.Name.Pos(), "method removed: "+method, Breaking, Breaking)
+			continue
+		}
+		if idKey(o.Id) != idKey(n.Id) {
+			c.add(n.Id.Pos(), "method "+method+" id changed: "+o.Id.Value+" -> "+n.Id.Value, Compatible, Breaking)
+		}
+		if typeString(o.Param) != typeString(n.Param) {
+			c.add(n.Param.Pos(), "method "+method+" param changed: "+typeString(o.Param)+" -> "+typeString(n.Param), Breaking, Breaking)
+		}
+		if typeString(o.Result) != typeString(n.Result) {
+			c.add(n.Result.Pos(), "method "+method+" result changed: "+typeString(o.Result)+" -> "+typeString(n.Result), Breaking, Breaking)
+		}
+	}
+	for _, n := range new.Methods {
+		if _, ok := oldMethods[n.Name.Name]; ok {
+			continue
+		}
+		if o, ok := oldIds[idKey(n.Id)]; ok {
+			if _, ok := newMethods[o.Name.Name]; !ok {
+				c.add(n.Id.Pos(), "method id "+n.Id.Value+" reused: "+name+"."+o.Name.Name+" -> "+name+"."+n.Name.Name, Breaking, Breaking)
+				continue
+			}
+		}
+		c.add(n.Name.Pos(), "method added: "+name+"."+n.Name.Name, Compatible, Compatible)
+	}
+}
+
+// types 收集包内全部类型定义，按文件在包中的名称加类型名称索引，如 common/page.hbuf:Page
+func types(pkg *ast.Package) map[string]*ast.TypeSpec {
+	ret := map[string]*ast.TypeSpec{}
+	for path, file := range pkg.Files {
+		for _, s := range file.Specs {
+			if spec, ok := s.(*ast.TypeSpec); ok {
+				ret[path+":"+spec.Name.Name] = spec
+			}
+		}
+	}
+	return ret
+}
+
+// fields 返回数据的字段和所有 oneof 的成员
+func fields(data *ast.DataType) []*ast.Field {
+	list := append([]*ast.Field{}, data.Fields.List...)
+	for _, oneOf := range data.OneOfs {
+		list = append(list, oneOf.Fields...)
+	}
+	return list
+}
+
+// oneOfName 返回字段所在 oneof 的名称，不在 oneof 中时为 none
+func oneOfName(data *ast.DataType, field *ast.Field) string {
+	for _, oneOf := range data.OneOfs {
+		for _, item := range oneOf.Fields {
+			if item == field {
+				return oneOf.Name.Name
+			}
+		}
+	}
+	return "none"
+}
+
+func sortedKeys(m map[string]*ast.TypeSpec) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// serverIdKey 返回服务 Id 的数值，未设置时为 0
+func serverIdKey(id *ast.BasicLit) string {
+	if nil == id {
+		return "0"
+	}
+	return idKey(id)
+}
+
+// idKey 返回 Id 的数值，用于比较，1 和 0x1 视为相同
+func idKey(id *ast.BasicLit) string {
+	if nil == id {
+		return "none"
+	}
+	if value, err := strconv.ParseUint(id.Value, 0, 64); err == nil {
+		return strconv.FormatUint(value, 10)
+	}
+	return id.Value
+}
+
+func idString(id *ast.BasicLit) string {
+	if nil == id {
+		return "none"
+	}
+	return id.Value
+}
+
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.X.Name + "." + t.Sel.Name
+	case *ast.StreamType:
+		return "stream<" + typeString(t.Item) + ">"
+	case *ast.VarType:
+		return typeString(t.TypeExpr) + nullString(t.Empty)
+	case *ast.ArrayType:
+		return typeString(t.VType) + "[]" + nullString(t.Empty)
+	case *ast.MapType:
+		return typeString(t.VType) + "<" + typeString(t.Key) + ">" + nullString(t.Empty)
+	}
+	return ""
+}
+
+func nullString(empty bool) string {
+	if empty {
+		return "?"
+	}
+	return ""
+}
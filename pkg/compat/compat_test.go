@@ -0,0 +1,170 @@
+package compat
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/parser"
+	"hbuf/pkg/token"
+	"testing"
+)
+
+// parse 解析 version 版本中名为 a.hbuf 的文件
+func parse(t *testing.T, fset *token.FileSet, version string, src string) *ast.Package {
+	return parseFiles(t, fset, version, map[string]string{"a.hbuf": src})
+}
+
+// parseFiles 解析 version 版本的全部文件，文件在包中的名称不带版本，两个版本的同名文件互相对应
+func parseFiles(t *testing.T, fset *token.FileSet, version string, files map[string]string) *ast.Package {
+	pkg := ast.NewPackage()
+	for name, src := range files {
+		file, err := parser.ParseFile(fset, version+"/"+name, src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg.Files[name] = file
+	}
+	return pkg
+}
+
+func TestCompare(t *testing.T) {
+	fset := token.NewFileSet()
+	old := parse(t, fset, "old", ""+
+		"data Info = 1 {\n"+
+		"    int32   id   = 0\n"+
+		"    string? name = 1\n"+
+		"    string  note = 2\n"+
+		"    int32   code = 4\n"+
+		"}\n"+
+		"server InfoServer = 1 {\n"+
+		"    Info getInfo(Info req) = 0\n"+
+		"}\n")
+	new := parse(t, fset, "new", ""+
+		"data Info = 1 {\n"+
+		"    int32  id     = 0 = 1\n"+
+		"    string name   = 1\n"+
+		"    string remark = 2\n"+
+		"    int32  age    = 3\n"+
+		"    oneof result {\n"+
+		"        int32 code = 4\n"+
+		"    }\n"+
+		"}\n"+
+		"server InfoServer = 1 {\n"+
+		"    Info getInfo(Info req) = 1\n"+
+		"}\n")
+
+	want := []struct {
+		msg    string
+		json   Level
+		binary Level
+	}{
+		{"field Info.id default changed: none -> 1", Compatible, Compatible},
+		{"field Info.name becomes required: string? -> string", Breaking, Breaking},
+		{"field id 2 reused with the same type: Info.note -> Info.remark", Breaking, Semantic},
+		{"field added: Info.age = 3", Compatible, Compatible},
+		{"field Info.code oneof changed: none -> result", Breaking, Compatible},
+		{"method InfoServer.getInfo id changed: 0 -> 1", Compatible, Breaking},
+	}
+	changes := Compare(fset, old, new)
+	if len(changes) != len(want) {
+		t.Fatalf("want %d changes, got %d: %v", len(want), len(changes), changes)
+	}
+	for i, change := range changes {
+		if change.Msg != want[i].msg || change.JSON != want[i].json || change.Binary != want[i].binary {
+			t.Errorf("change %d: got %s", i, change)
+		}
+	}
+}
+
+// TestCompareEncoding 按二进制编码判断兼容性
+func TestCompareEncoding(t *testing.T) {
+	tests := []struct {
+		old    string
+		new    string
+		msg    string
+		json   Level
+		binary Level
+	}{
+		{
+			"data Info = 1 {\n    int32 count = 0\n}\n",
+			"data Info = 1 {\n    int64 count = 0\n}\n",
+			"field Info.count widened: int32 -> int64", Breaking, Compatible,
+		},
+		{
+			"data Info = 1 {\n    string name = 0\n}\n",
+			"data Info = 1 {\n    string title = 0\n}\n",
+			"field id 0 reused with the same type: Info.name -> Info.title", Breaking, Semantic,
+		},
+		{
+			"data Info = 1 {\n    string name = 0\n}\n",
+			"data Info = 2 {\n    string name = 0\n}\n",
+			"data Info id changed: 1 -> 2", Compatible, Compatible,
+		},
+		{
+			"data Info = 1 {\n    string name = 0\n}\n",
+			"data Info = 1 {\n    string? name = 0\n}\n",
+			"field Info.name becomes nullable: string -> string?", Breaking, Breaking,
+		},
+	}
+	for _, test := range tests {
+		fset := token.NewFileSet()
+		changes := Compare(fset, parse(t, fset, "old", test.old), parse(t, fset, "new", test.new))
+		if 1 != len(changes) {
+			t.Errorf("want 1 change, got %d: %v", len(changes), changes)
+			continue
+		}
+		change := changes[0]
+		if change.Msg != test.msg || change.JSON != test.json || change.Binary != test.binary {
+			t.Errorf("want %s (json: %s, binary: %s), got %s", test.msg, test.json, test.binary, change)
+		}
+	}
+}
+
+// TestCompareIdValue Id 按数值比较，改写成十六进制不是变更
+func TestCompareIdValue(t *testing.T) {
+	fset := token.NewFileSet()
+	old := parse(t, fset, "old", ""+
+		"data Info = 1 {\n"+
+		"    int32 id = 1\n"+
+		"}\n"+
+		"enum Status {\n"+
+		"    Enable = 2\n"+
+		"}\n"+
+		"server InfoServer {\n"+
+		"    Info getInfo(Info req) = 3\n"+
+		"}\n")
+	new := parse(t, fset, "new", ""+
+		"data Info = 0x1 {\n"+
+		"    int32 id = 0x1\n"+
+		"}\n"+
+		"enum Status {\n"+
+		"    Enable = 0x2\n"+
+		"}\n"+
+		"server InfoServer = 0 {\n"+
+		"    Info getInfo(Info req) = 0x3\n"+
+		"}\n")
+	if changes := Compare(fset, old, new); 0 != len(changes) {
+		t.Errorf("want no changes, got %v", changes)
+	}
+}
+
+// TestCompareSameName 不同文件中的同名类型按文件分别对应
+func TestCompareSameName(t *testing.T) {
+	fset := token.NewFileSet()
+	old := parseFiles(t, fset, "old", map[string]string{
+		"a.hbuf":        "data Page = 1 {\n    int32 size = 0\n}\n",
+		"common/b.hbuf": "data Page = 2 {\n    string cursor = 0\n}\n",
+	})
+	new := parseFiles(t, fset, "new", map[string]string{
+		"a.hbuf":        "data Page = 1 {\n    int32 size = 0\n}\n",
+		"common/b.hbuf": "data Page = 2 {\n    string token = 0\n}\n",
+	})
+	for i := 0; i < 10; i++ {
+		changes := Compare(fset, old, new)
+		if 1 != len(changes) {
+			t.Fatalf("want 1 change, got %d: %v", len(changes), changes)
+		}
+		want := "new/common/b.hbuf:2:20: field id 0 reused with the same type: Page.cursor -> Page.token (json: breaking, binary: semantic)"
+		if got := changes[0].String(); want != got {
+			t.Fatalf("want %s, got %s", want, got)
+		}
+	}
+}
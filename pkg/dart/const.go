@@ -0,0 +1,41 @@
+package dart
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+	"strconv"
+	"strings"
+)
+
+var _constTypes = map[build.BaseType]string{
+	build.Int8: "int", build.Int16: "int", build.Int32: "int", build.Int64: "int", build.Uint8: "int",
+	build.Uint16: "int", build.Uint32: "int", build.Uint64: "int", build.Bool: "bool", build.Float: "double",
+	build.Double: "double", build.String: "String",
+}
+
+func (b *Builder) printConstCode(dst *build.Writer, spec *ast.ConstSpec) {
+	if nil != spec.Doc && 0 < len(spec.Doc.Text()) {
+		dst.Code("///" + spec.Doc.Text())
+	}
+	typ := build.BaseType(spec.Type.Name)
+	value := literalValue(typ, build.ConstValue(spec))
+	dst.Code("const " + _constTypes[typ] + " " + build.StringToFirstLower(build.StringToHumpName(spec.Name.Name)) + " = " + value + ";\n\n")
+}
+
+// literalValue 将 build.LiteralValue 的结果转为 Dart 的字面量
+func literalValue(typ build.BaseType, value string) string {
+	switch typ {
+	case build.Uint64:
+		// Dart 的 int 为 64 位有符号整数，按相同的二进制位输出
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			value = strconv.FormatInt(int64(v), 10)
+		}
+	case build.String:
+		value = strings.ReplaceAll(value, "$", "\\$")
+	case build.Float, build.Double:
+		if !strings.ContainsAny(value, ".eEn") {
+			value += ".0"
+		}
+	}
+	return value
+}
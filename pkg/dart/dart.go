@@ -14,6 +14,7 @@ var _types = map[build.BaseType]string{
 	build.Int8: "int", build.Int16: "int", build.Int32: "int", build.Int64: "Int64", build.Uint8: "int",
 	build.Uint16: "int", build.Uint32: "int", build.Uint64: "Int64", build.Bool: "bool", build.Float: "double",
 	build.Double: "double", build.String: "String", build.Date: "DateTime", build.Decimal: "Decimal",
+	build.Bytes: "Uint8List", build.Duration: "Duration", build.Uuid: "String", build.Json: "dynamic",
 }
 
 type DartWriter struct {
@@ -72,7 +73,26 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 		return err
 	}
 
-	if 0 < dst.data.GetCode().Len() {
+	// 二进制编码和 REST 请求的公共代码，服务端和客户端也会用到
+	isData := param.Feature(build.FeatureData) && 0 < dst.data.GetCode().Len()
+	if isData || param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
+		codec := build.NewWriter()
+		printCodecCode(codec)
+		err = writerFile(codec, filepath.Join(param.GetOutDir(), "hbuf_encoder.dart"))
+		if err != nil {
+			return err
+		}
+	}
+	// stream 方法的公共代码，只有服务含有 stream 方法时生成
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() && isFileStream(file) {
+		stream := build.NewWriter()
+		printStreamCode(stream)
+		err = writerFile(stream, filepath.Join(param.GetOutDir(), "hbuf_stream.dart"))
+		if err != nil {
+			return err
+		}
+	}
+	if isData {
 		err := writerFile(dst.data, filepath.Join(dir, name+".data.dart"))
 		if err != nil {
 			return err
@@ -84,7 +104,7 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 			return err
 		}
 	}
-	if 0 < dst.server.GetCode().Len() {
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
 		err = writerFile(dst.server, filepath.Join(dir, name+".server.dart"))
 		if err != nil {
 			return err
@@ -92,13 +112,13 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 	}
 
 	printLanguge(dst.ui)
-	if 0 < dst.ui.GetCode().Len() {
+	if param.Feature(build.FeatureUi) && 0 < dst.ui.GetCode().Len() {
 		err = writerFile(dst.ui, filepath.Join(dir, name+".ui.dart"))
 		if err != nil {
 			return err
 		}
 	}
-	if 0 < dst.verify.GetCode().Len() {
+	if param.Feature(build.FeatureVerify) && 0 < dst.verify.GetCode().Len() {
 		err = writerFile(dst.verify, filepath.Join(dir, name+".verify.dart"))
 		if err != nil {
 			return err
@@ -121,7 +141,7 @@ func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
 		}
 	}
 	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
+		if f, ok := b.pkg.Files[spec.File]; ok {
 			if obj := f.Scope.Lookup(name); nil != obj {
 				switch obj.Decl.(type) {
 				case *ast.TypeSpec:
@@ -161,7 +181,11 @@ func writerFile(data *build.Writer, out string) error {
 		}
 		sort.Strings(imps)
 		for _, val := range imps {
-			_, _ = fc.WriteString("import '" + val + "';\n")
+			_, _ = fc.WriteString("import '" + val + "'")
+			if key := data.GetImports()[val]; 0 < len(key) {
+				_, _ = fc.WriteString(" as " + key)
+			}
+			_, _ = fc.WriteString(";\n")
 		}
 	}
 	_, _ = fc.WriteString("\n")
@@ -187,26 +211,32 @@ func (b *Builder) Node(dst *DartWriter, fset *token.FileSet, node interface{}) e
 	for _, s := range file.Specs {
 		switch s.(type) {
 		case *ast.ImportSpec:
+		case *ast.ConstSpec:
+			b.printConstCode(dst.enum, s.(*ast.ConstSpec))
 		case *ast.TypeSpec:
-			b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
+			err := b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
+			if err != nil {
+				return build.ErrorToFileError(err, fset)
+			}
 		}
 	}
 	return nil
 }
 
-func (b *Builder) printTypeSpec(dst *DartWriter, expr ast.Expr) {
+func (b *Builder) printTypeSpec(dst *DartWriter, expr ast.Expr) error {
 	switch expr.(type) {
 	case *ast.DataType:
 		b.printDataCode(dst.data, expr.(*ast.DataType))
 		b.printFormCode(dst.ui, expr)
 		b.printVerifyCode(dst.verify, expr.(*ast.DataType))
 	case *ast.ServerType:
-		b.printServerCode(dst.server, expr.(*ast.ServerType))
+		return b.printServerCode(dst.server, expr.(*ast.ServerType))
 
 	case *ast.EnumType:
 		b.printEnumCode(dst.enum, expr.(*ast.EnumType))
 		b.printFormCode(dst.ui, expr)
 	}
+	return nil
 }
 
 func (b *Builder) printType(dst *build.Writer, expr ast.Expr, notEmpty bool) {
@@ -214,13 +244,15 @@ func (b *Builder) printType(dst *build.Writer, expr ast.Expr, notEmpty bool) {
 	case *ast.Ident:
 		t := expr.(*ast.Ident)
 		if nil != t.Obj {
-			b.getPackage(dst, expr, "")
+			dst.Code(b.getPackage(dst, expr, ""))
 			dst.Code(expr.(*ast.Ident).Name)
 		} else {
 			if build.Decimal == build.BaseType((expr.(*ast.Ident).Name)) {
 				dst.Import("package:decimal/decimal.dart", "")
 			} else if build.Int64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Uint64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Uint32 == build.BaseType((expr.(*ast.Ident).Name)) {
 				dst.Import("package:fixnum/fixnum.dart", "")
+			} else if build.Bytes == build.BaseType((expr.(*ast.Ident).Name)) {
+				dst.Import("dart:typed_data", "")
 			}
 			dst.Code(_types[build.BaseType((expr.(*ast.Ident).Name))])
 		}
@@ -260,7 +292,7 @@ func (b *Builder) getPackage(dst *build.Writer, expr ast.Expr, s string) string
 		return ""
 	}
 
-	_, name := filepath.Split(file.(*ast.File).Path)
+	name := build.OutName(file.(*ast.File))
 	name = name[:len(name)-len(".hbuf")]
 	if 0 < len(s) {
 		name = name + "." + s + ".dart"
@@ -275,6 +307,26 @@ func (b *Builder) getPackage(dst *build.Writer, expr ast.Expr, s string) string
 		}
 	}
 
-	dst.Import(name, "")
+	name = build.ImportPath(dst.File, name)
+	// 用 import ... as 引入的文件加上前缀，避免不同文件中的同名类型冲突
+	alias := b.importAlias(dst.File, file.(*ast.File))
+	if 0 == len(alias) {
+		dst.Import(name, "")
+		return ""
+	}
+	dst.Import(name, alias)
+	return alias + "."
+}
+
+// importAlias 返回 file 中引入 target 时 as 后的名称，加上 $ 避免与生成代码中的变量重名
+func (b *Builder) importAlias(file *ast.File, target *ast.File) string {
+	if nil == file {
+		return ""
+	}
+	for _, spec := range file.Imports {
+		if nil != spec.Name && target == b.pkg.Files[spec.File] {
+			return "$" + spec.Name.Name
+		}
+	}
 	return ""
 }
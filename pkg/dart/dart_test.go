@@ -0,0 +1,66 @@
+package dart
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestImportAlias 两个用 as 引入的文件中有同名的类型时，按引入的名称加上前缀
+func TestImportAlias(t *testing.T) {
+	files := map[string]string{
+		"x.hbuf": "" +
+			"data Page = 1 {\n" +
+			"    int32 page = 0\n" +
+			"}\n",
+		"y.hbuf": "" +
+			"data Page = 2 {\n" +
+			"    string cursor = 0\n" +
+			"}\n",
+		"a.hbuf": "" +
+			"import \"x.hbuf\" as x\n" +
+			"import \"y.hbuf\" as y\n" +
+			"\n" +
+			"data Result : x.Page = 3 {\n" +
+			"    y.Page next = 0\n" +
+			"}\n",
+	}
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", files)
+	code := buildtest.ReadFile(t, out, "a.data.dart")
+	buildtest.Contains(t, code,
+		"import 'x.data.dart' as $x;\n",
+		"import 'y.data.dart' as $y;\n",
+		"abstract class Result implements Data, $x.Page{\n",
+		"$y.Page get next;\n",
+		"$y.Page.fromMap(temp)",
+		"hbufReadData($y.Page.fromData)",
+	)
+}
+
+// TestImportPath 子目录中的文件生成到相同的子目录，引入时使用相对路径，公共代码只生成在输出目录下
+func TestImportPath(t *testing.T) {
+	files := map[string]string{
+		"common/page.hbuf": "" +
+			"data Page = 1 {\n" +
+			"    int32 page = 0\n" +
+			"}\n",
+		"a.hbuf": "" +
+			"import \"common/page.hbuf\"\n" +
+			"\n" +
+			"data Result = 2 {\n" +
+			"    Page page = 0\n" +
+			"}\n",
+	}
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", files)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "a.data.dart"),
+		"import 'common/page.data.dart';\n",
+		"import 'hbuf_encoder.dart';\n",
+	)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "common", "page.data.dart"),
+		"import '../hbuf_encoder.dart';\n",
+	)
+	if _, err := os.Stat(filepath.Join(out, "common", "hbuf_encoder.dart")); err == nil {
+		t.Errorf("hbuf_encoder.dart generated in sub directory")
+	}
+}
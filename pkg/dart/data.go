@@ -3,15 +3,17 @@ package dart
 import (
 	"hbuf/pkg/ast"
 	"hbuf/pkg/build"
+	"strconv"
 )
 
 func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 	dst.Import("dart:typed_data", "")
 	dst.Import("package:hbuf_dart/hbuf_dart.dart", "")
+	dst.Import(build.ImportPath(dst.File, "hbuf_encoder.dart"), "")
 
 	b.printData(dst, typ)
 	b.printDataEntity(dst, typ)
-
+	b.printOneOfCode(dst, typ)
 }
 func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 	if nil != typ.Doc && 0 < len(typ.Doc.Text()) {
@@ -46,6 +48,17 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 		b.printType(dst, field.Type, false)
 		dst.Code(" value);\n\n")
 	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Tab(1).Code("/// Get " + oneOf.Doc.Text())
+		}
+		dst.Tab(1).Code(oneOfName(typ, oneOf) + "? get " + name + ";\n\n")
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Tab(1).Code("/// Set " + oneOf.Doc.Text())
+		}
+		dst.Tab(1).Code("set " + name + "(" + oneOfName(typ, oneOf) + "? value);\n\n")
+	}
 	isParam := false
 	dst.Tab(1).Code("factory " + build.StringToHumpName(typ.Name.Name) + "(")
 	err := build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
@@ -54,10 +67,22 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 			isParam = true
 		}
 		dst.Tab(2).Code("")
-		if !field.Type.IsEmpty() {
-			dst.Code("required ")
+		if nil != field.Default {
+			b.printType(dst, field.Type, true)
+			dst.Code("?")
+			// 可空字段传入 null 时保持为 null，只有常量能作为参数的默认值
+			if field.Type.IsEmpty() && isConstDefault(field) {
+				dst.Code(" " + build.StringToFirstLower(field.Name.Name) + " = ")
+				b.printFieldDefault(dst, field)
+				dst.Code(",\n")
+				return nil
+			}
+		} else {
+			if !field.Type.IsEmpty() {
+				dst.Code("required ")
+			}
+			b.printType(dst, field.Type, false)
 		}
-		b.printType(dst, field.Type, false)
 		dst.Code(" " + build.StringToFirstLower(field.Name.Name))
 		dst.Code(",\n")
 		return nil
@@ -65,6 +90,13 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if !isParam {
+			dst.Code("{\n")
+			isParam = true
+		}
+		dst.Tab(2).Code(oneOfName(typ, oneOf) + "? " + build.StringToFirstLower(oneOf.Name.Name) + ",\n")
+	}
 	if isParam {
 		dst.Tab(1).Code("}")
 	} else {
@@ -77,12 +109,20 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 		dst.Code(build.StringToFirstLower(field.Name.Name))
 		dst.Code(": ")
 		dst.Code(build.StringToFirstLower(field.Name.Name))
+		if nil != field.Default && !field.Type.IsEmpty() {
+			dst.Code(" ?? ")
+			b.printFieldDefault(dst, field)
+		}
 		dst.Code(",\n")
 		return nil
 	})
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(3).Code(name + ": " + name + ",\n")
+	}
 	dst.Tab(2).Code(");\n")
 	dst.Tab(1).Code("}\n\n")
 
@@ -110,6 +150,13 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if !isParam {
+			dst.Code("{\n")
+			isParam = true
+		}
+		dst.Tab(2).Code(oneOfName(typ, oneOf) + "? " + build.StringToFirstLower(oneOf.Name.Name) + ",\n")
+	}
 	if isParam {
 		dst.Tab(1).Code("}")
 	} else {
@@ -138,6 +185,10 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(1).Code("@override\n")
+		dst.Tab(1).Code(oneOfName(typ, oneOf) + "? " + build.StringToFirstLower(oneOf.Name.Name) + ";\n\n")
+	}
 
 	dst.Tab(1).Code("_" + build.StringToHumpName(typ.Name.Name) + "(")
 	isParam := false
@@ -157,6 +208,13 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if !isParam {
+			dst.Code("{\n")
+			isParam = true
+		}
+		dst.Tab(2).Code("this." + build.StringToFirstLower(oneOf.Name.Name) + ",\n")
+	}
 	dst.Tab(1).Code("")
 	if isParam {
 		dst.Code("}")
@@ -170,7 +228,14 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
 		dst.Tab(3).Code("" + build.StringToFirstLower(field.Name.Name) + ": ")
 		jsonName := build.StringToUnderlineName(field.Name.Name)
-		b.printFormMap(dst, "(temp = map[\""+jsonName+"\"])", "temp", field.Type, data, false)
+		if nil != field.Default {
+			dst.Code("map.containsKey(\"" + jsonName + "\") ? (")
+			b.printFormMap(dst, "(temp = map[\""+jsonName+"\"])", "temp", field.Type, data, false)
+			dst.Code(") : ")
+			b.printFieldDefault(dst, field)
+		} else {
+			b.printFormMap(dst, "(temp = map[\""+jsonName+"\"])", "temp", field.Type, data, false)
+		}
 		dst.Code(",\n")
 		return nil
 	})
@@ -178,6 +243,10 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 		return
 	}
 
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(3).Code(name + ": " + oneOfName(typ, oneOf) + ".fromMap(map[\"" + build.StringToUnderlineName(oneOf.Name.Name) + "\"]),\n")
+	}
 	dst.Tab(2).Code(");\n")
 	dst.Tab(1).Code("}\n")
 
@@ -195,38 +264,60 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(3).Code("\"" + build.StringToUnderlineName(oneOf.Name.Name) + "\":" + build.StringToFirstLower(oneOf.Name.Name) + "?.toMap(),\n")
+	}
 	dst.Tab(2).Code("};\n")
 	dst.Tab(1).Code("}\n\n")
 
 	dst.Tab(1).Code("static _" + build.StringToHumpName(typ.Name.Name) + " fromData(ByteData data){\n")
-	dst.Tab(2).Code("return _" + build.StringToHumpName(typ.Name.Name) + ".fromMap({});\n")
-
-	//err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
-	//	dst.Code("      " + build.StringToFirstLower(field.Name.Name))
-	//	dst.Code(": map[\"" + getJsonName(field) + "\"]")
-	//	dst.Code(",\n")
-	//	return nil
-	//})
-	//if err != nil {
-	//	return
-	//}
-
-	//dst.Code("    );\n")
+	err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
+		dst.Tab(2).Code("")
+		b.printType(dst, field.Type, true)
+		dst.Code("? v" + build.StringToHumpName(field.Name.Name) + ";\n")
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(2).Code(oneOfName(typ, oneOf) + "? v" + build.StringToHumpName(oneOf.Name.Name) + ";\n")
+	}
+	dst.Tab(2).Code("hbufWalk(data, (typ, id, val) {\n")
+	b.printFromData(dst, typ, 3)
+	dst.Tab(2).Code("});\n")
+	dst.Tab(2).Code("return _" + build.StringToHumpName(typ.Name.Name) + "(\n")
+	err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
+		dst.Tab(3).Code(build.StringToFirstLower(field.Name.Name) + ": v" + build.StringToHumpName(field.Name.Name))
+		if nil != field.Default {
+			// 二进制中可空字段为 null 时不写入，不能使用默认值
+			if !field.Type.IsEmpty() {
+				dst.Code(" ?? ")
+				b.printFieldDefault(dst, field)
+			}
+		} else if !field.Type.IsEmpty() && build.Json != build.GetBaseType(field.Type.Type()) {
+			dst.Code(" ?? ")
+			b.printDataDefault(dst, field.Type)
+		}
+		dst.Code(",\n")
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(3).Code(name + ": v" + build.StringToHumpName(oneOf.Name.Name) + ",\n")
+	}
+	dst.Tab(2).Code(");\n")
 	dst.Tab(1).Code("}\n")
 
 	dst.Code("\n")
 	dst.Tab(1).Code("@override\n")
 	dst.Tab(1).Code("ByteData toData() {\n")
-	dst.Tab(2).Code("return ByteData.view(Uint8List(12).buffer);\n")
-	//err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
-	//	dst.Code("      \"" + getJsonName(field))
-	//	dst.Code("\": " + build.StringToFirstLower(field.Name.Name) + ",\n")
-	//	return nil
-	//})
-	//if err != nil {
-	//	return
-	//}
-	//dst.Code("    };\n")
+	dst.Tab(2).Code("final writer = HbufWriter();\n")
+	b.printToData(dst, typ, 2)
+	dst.Tab(2).Code("return writer.toData();\n")
 	dst.Tab(1).Code("}\n\n")
 
 	isParam = false
@@ -246,6 +337,13 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if !isParam {
+			dst.Code("{\n")
+			isParam = true
+		}
+		dst.Tab(2).Code(oneOfName(typ, oneOf) + "? " + build.StringToFirstLower(oneOf.Name.Name) + ",\n")
+	}
 	if isParam {
 		dst.Tab(1).Code("}")
 	} else {
@@ -266,6 +364,10 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(3).Code(name + ": " + name + " ?? this." + name + ",\n")
+	}
 	dst.Tab(2).Code(");\n")
 	dst.Tab(1).Code("}\n\n")
 
@@ -284,6 +386,10 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Code("&& \n\t\t\t\t\t" + name + " == other." + name)
+	}
 	dst.Code(";\n\n")
 
 	dst.Tab(1).Code("@override\n")
@@ -297,6 +403,9 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Code(" ^ " + build.StringToFirstLower(oneOf.Name.Name) + ".hashCode")
+	}
 	dst.Code(";\n\n")
 
 	dst.Tab(1).Code("@override\n")
@@ -313,6 +422,10 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(3).Code(name + ": " + name + "?.copy(),\n")
+	}
 	dst.Tab(2).Code(");\n")
 	dst.Tab(1).Code("}\n")
 
@@ -333,6 +446,14 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if !isFast {
+			dst.Code("\n\t\t\t")
+		}
+		isFast = false
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Code(name + ": $" + name)
+	}
 	dst.Tab(2).Code("''';\n")
 	dst.Tab(1).Code("}\n")
 
@@ -363,6 +484,12 @@ func (b *Builder) printCopy(dst *build.Writer, name string, expr ast.Expr, data
 				} else {
 					dst.Code("Decimal.fromJson(" + name + ".toJson())")
 				}
+			case build.Bytes:
+				if empty {
+					dst.Code("null == " + name + " ? null : Uint8List.fromList(" + name + "!)")
+				} else {
+					dst.Code("Uint8List.fromList(" + name + ")")
+				}
 			default:
 				dst.Code(name)
 			}
@@ -386,13 +513,13 @@ func (b *Builder) printCopy(dst *build.Writer, name string, expr ast.Expr, data
 			dst.Code(name + "?.map((key,value) => MapEntry(")
 			b.printCopy(dst, "key", t.Key, data, empty)
 			dst.Code(",")
-			b.printCopy(dst, "value", t.Key, data, empty)
-			dst.Code(")")
+			b.printCopy(dst, "value", t.VType, data, empty)
+			dst.Code("))")
 		} else {
 			dst.Code(name + ".map((key,value) => MapEntry(")
 			b.printCopy(dst, "key", t.Key, data, empty)
 			dst.Code(",")
-			b.printCopy(dst, "value", t.Key, data, empty)
+			b.printCopy(dst, "value", t.VType, data, empty)
 			dst.Code("))")
 		}
 	case *ast.VarType:
@@ -406,17 +533,18 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 	case *ast.Ident:
 		t := expr.(*ast.Ident)
 		if nil != t.Obj {
+			tName := b.getPackage(dst, t, "") + t.Name
 			if ast.Enum == t.Obj.Kind {
 				if empty {
-					dst.Code("null == " + name + " ? null :" + v + " is num ? " + t.Name + ".valueOf(" + v + ".toInt()) : null == num.tryParse(" + v + ".toString()) ? null : " + t.Name + ".valueOf(num.tryParse(" + v + ".toString())!.toInt())")
+					dst.Code("null == " + name + " ? null :" + v + " is num ? " + tName + ".valueOf(" + v + ".toInt()) : null == num.tryParse(" + v + ".toString()) ? null : " + tName + ".valueOf(num.tryParse(" + v + ".toString())!.toInt())")
 				} else {
-					dst.Code("null == " + name + " ? " + t.Name + ".valueOf(0) : " + t.Name + ".valueOf(" + v + " is num ? " + v + ".toInt() : num.tryParse(" + v + ".toString())?.toInt() ?? 0)")
+					dst.Code("null == " + name + " ? " + tName + ".valueOf(0) : " + tName + ".valueOf(" + v + " is num ? " + v + ".toInt() : num.tryParse(" + v + ".toString())?.toInt() ?? 0)")
 				}
 			} else if ast.Data == t.Obj.Kind {
 				if empty {
-					dst.Code("null == " + name + " ? null : " + t.Name + ".fromMap(" + v + ")")
+					dst.Code("null == " + name + " ? null : " + tName + ".fromMap(" + v + ")")
 				} else {
-					dst.Code("null == " + name + " ? " + t.Name + ".fromMap({}) : " + t.Name + ".fromMap(" + v + ")")
+					dst.Code("null == " + name + " ? " + tName + ".fromMap({}) : " + tName + ".fromMap(" + v + ")")
 				}
 			} else {
 				dst.Code("map[\"" + name + "\"]")
@@ -465,6 +593,27 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 				} else {
 					dst.Code("null == " + name + " ? Decimal.zero : (Decimal.tryParse(" + v + ".toString()) ?? Decimal.zero)")
 				}
+			case build.Uuid:
+				if empty {
+					dst.Code("null == " + name + " ? null : " + v + ".toString()")
+				} else {
+					dst.Code("null == " + name + " ? \"\" : " + v + ".toString()")
+				}
+			case build.Bytes:
+				dst.Import("dart:convert", "")
+				if empty {
+					dst.Code("null == " + name + " ? null : base64Decode(" + v + ".toString())")
+				} else {
+					dst.Code("null == " + name + " ? Uint8List(0) : base64Decode(" + v + ".toString())")
+				}
+			case build.Duration:
+				if empty {
+					dst.Code("null == " + name + " ? null : Duration(microseconds: (" + v + " is num ? " + v + ".toInt() : int.tryParse(" + v + ".toString()) ?? 0) ~/ 1000)")
+				} else {
+					dst.Code("null == " + name + " ? Duration.zero : Duration(microseconds: (" + v + " is num ? " + v + ".toInt() : int.tryParse(" + v + ".toString()) ?? 0) ~/ 1000)")
+				}
+			case build.Json:
+				dst.Code(name)
 			default:
 				dst.Code("map[\"" + name + "\"]")
 			}
@@ -473,15 +622,15 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 		t := expr.(*ast.ArrayType)
 		empty = t.IsEmpty()
 		if empty {
-			dst.Code("null == " + name + " ? null : (" + v + "! is! List ? null : (temp as List).map((temp) => ")
-			b.printFormMap(dst, "temp", "temp", t.VType, data, empty)
+			dst.Code("null == " + name + " ? null : (" + v + " is! List ? null : (" + v + " as List).map((item) => ")
+			b.printFormMap(dst, "item", "item", t.VType, data, empty)
 			dst.Code(").toList())")
 		} else {
 			dst.Code("null == " + name + " ? <")
 			b.printType(dst, t.VType, false)
-			dst.Code(">[] : (temp! is! List ? <")
+			dst.Code(">[] : (" + v + " is! List ? <")
 			b.printType(dst, t.VType, false)
-			dst.Code(">[] : (temp as List).map((item) => ")
+			dst.Code(">[] : (" + v + " as List).map((item) => ")
 			b.printFormMap(dst, "item", "item", t.VType, data, empty)
 			dst.Code(").toList())")
 		}
@@ -489,7 +638,7 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 		t := expr.(*ast.MapType)
 		empty = t.IsEmpty()
 		if empty {
-			dst.Code("null == " + name + " ? null : (" + v + "! is! Map ? null : (temp as Map).map((key,value) => MapEntry(")
+			dst.Code("null == " + name + " ? null : (" + v + " is! Map ? null : (" + v + " as Map).map((key,value) => MapEntry(")
 			b.printFormMap(dst, "key", "key", t.Key, data, empty)
 			dst.Code(",")
 			b.printFormMap(dst, "value", "value", t.VType, data, empty)
@@ -499,11 +648,11 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 			b.printType(dst, t.Key, false)
 			dst.Code(",")
 			b.printType(dst, t.VType, false)
-			dst.Code(">{}: (temp! is! Map ?<")
+			dst.Code(">{}: (" + v + " is! Map ?<")
 			b.printType(dst, t.Key, false)
 			dst.Code(",")
 			b.printType(dst, t.VType, false)
-			dst.Code(">{} : (temp as Map).map((key,value) => MapEntry(")
+			dst.Code(">{} : (" + v + " as Map).map((key,value) => MapEntry(")
 			b.printFormMap(dst, "key", "key", t.Key, data, empty)
 			dst.Code(",")
 			b.printFormMap(dst, "value", "value", t.VType, data, empty)
@@ -558,6 +707,19 @@ func (b *Builder) printToMap(dst *build.Writer, name string, expr ast.Expr, data
 				} else {
 					dst.Code(name + ".toString()")
 				}
+			case build.Bytes:
+				dst.Import("dart:convert", "")
+				if empty {
+					dst.Code("null == " + name + " ? null : base64Encode(" + name + "!)")
+				} else {
+					dst.Code("base64Encode(" + name + ")")
+				}
+			case build.Duration:
+				if empty {
+					dst.Code("null == " + name + " ? null : " + name + "!.inMicroseconds * 1000")
+				} else {
+					dst.Code(name + ".inMicroseconds * 1000")
+				}
 			default:
 				dst.Code(name)
 			}
@@ -581,13 +743,13 @@ func (b *Builder) printToMap(dst *build.Writer, name string, expr ast.Expr, data
 			dst.Code(name + "?.map((key,value) => MapEntry(")
 			b.printToMap(dst, "key", t.Key, data, empty)
 			dst.Code(",")
-			b.printToMap(dst, "value", t.Key, data, empty)
-			dst.Code(")")
+			b.printToMap(dst, "value", t.VType, data, empty)
+			dst.Code("))")
 		} else {
 			dst.Code(name + ".map((key,value) => MapEntry(")
 			b.printToMap(dst, "key", t.Key, data, empty)
 			dst.Code(",")
-			b.printToMap(dst, "value", t.Key, data, empty)
+			b.printToMap(dst, "value", t.VType, data, empty)
 			dst.Code("))")
 		}
 	case *ast.VarType:
@@ -601,8 +763,47 @@ func (b *Builder) printExtend(dst *build.Writer, extends []*ast.Extends, start b
 		if 0 != i || start {
 			dst.Code(", ")
 		}
-		b.getPackage(dst, v.Name, "")
+		dst.Code(b.getPackage(dst, v.Name, ""))
 		dst.Code(build.StringToHumpName(v.Name.Name))
 
 	}
 }
+
+// printFieldDefault 输出字段的默认值，默认值已在检查时统一写法
+func (b *Builder) printFieldDefault(dst *build.Writer, field *ast.Field) {
+	ident := field.Type.Type().(*ast.Ident)
+	if nil != ident.Obj {
+		b.printType(dst, ident, true)
+		dst.Code("." + build.StringToAllUpper(field.Default.Value))
+		return
+	}
+	typ := build.BaseType(ident.Name)
+	switch typ {
+	case build.Int64, build.Uint64:
+		// 超出 2^53 的整数在 Web 中会丢失精度，按字符串解析
+		value := literalValue(build.Uint64, field.Default.Value)
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil && -1<<53 <= v && v <= 1<<53 {
+			dst.Code("Int64(" + value + ")")
+		} else {
+			dst.Code("Int64.parseInt(\"" + value + "\")")
+		}
+	case build.Decimal:
+		dst.Import("package:decimal/decimal.dart", "")
+		dst.Code("Decimal.parse(\"" + field.Default.Value + "\")")
+	default:
+		dst.Code(literalValue(typ, field.Default.Value))
+	}
+}
+
+// isConstDefault 返回字段的默认值能否写成常量，Int64、Decimal 和枚举项不是常量
+func isConstDefault(field *ast.Field) bool {
+	ident := field.Type.Type().(*ast.Ident)
+	if nil != ident.Obj {
+		return false
+	}
+	switch build.BaseType(ident.Name) {
+	case build.Int64, build.Uint64, build.Decimal:
+		return false
+	}
+	return true
+}
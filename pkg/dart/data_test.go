@@ -0,0 +1,28 @@
+package dart
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestDataDefault 显式的 null 保持为 null，只有缺少字段时使用默认值
+func TestDataDefault(t *testing.T) {
+	src := "" +
+		"data Option = 1 {\n" +
+		"    bool   enable = 0 = true\n" +
+		"    int32  size   = 1 = -1\n" +
+		"    int32? limit  = 2 = 10\n" +
+		"    int64  big    = 3 = 9007199254740993\n" +
+		"}\n"
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	code := buildtest.ReadFile(t, out, "a.data.dart")
+	buildtest.Contains(t, code,
+		"int? limit = 10,\n",
+		"\t\t\tlimit: limit,\n",
+		"enable: map.containsKey(\"enable\") ? (null == (temp = map[\"enable\"]) ? false",
+		"size: map.containsKey(\"size\") ? (null == (temp = map[\"size\"]) ? 0",
+		"limit: map.containsKey(\"limit\") ? (null == (temp = map[\"limit\"]) ? null",
+		"limit: vLimit,\n",
+		"big: vBig ?? Int64.parseInt(\"9007199254740993\"),\n",
+	)
+}
@@ -0,0 +1,712 @@
+package dart
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// printToData 生成二进制编码，父类的字段写在对应 Extends id 下
+func (b *Builder) printToData(dst *build.Writer, typ *ast.DataType, tab int) {
+	for _, extend := range typ.Extends {
+		data := extend.Name.Obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType)
+		dst.Tab(tab).Code("writer.writeExtend(" + extend.Id.Value + ", (writer) {\n")
+		b.printToData(dst, data, tab+1)
+		dst.Tab(tab).Code("});\n")
+	}
+	for _, field := range typ.Fields.List {
+		dst.Tab(tab).Code("")
+		b.printWriter(dst, field.Type)
+		dst.Code("(writer, " + field.Id.Value + ", " + build.StringToFirstLower(field.Name.Name) + ");\n")
+	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(tab).Code(build.StringToFirstLower(oneOf.Name.Name) + "?.toData(writer);\n")
+	}
+}
+
+// printFromData 生成二进制解码，读取到的值保存在 v 开头的局部变量中
+func (b *Builder) printFromData(dst *build.Writer, typ *ast.DataType, tab int) {
+	if 0 < len(typ.Extends) {
+		dst.Tab(tab).Code("if (hbufTypeExtend == typ) {\n")
+		dst.Tab(tab + 1).Code("switch (id) {\n")
+		for _, extend := range typ.Extends {
+			data := extend.Name.Obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType)
+			dst.Tab(tab + 2).Code("case " + extend.Id.Value + ":\n")
+			dst.Tab(tab + 3).Code("hbufWalk(val, (typ, id, val) {\n")
+			b.printFromData(dst, data, tab+4)
+			dst.Tab(tab + 3).Code("});\n")
+			dst.Tab(tab + 3).Code("break;\n")
+		}
+		dst.Tab(tab + 1).Code("}\n")
+		dst.Tab(tab + 1).Code("return;\n")
+		dst.Tab(tab).Code("}\n")
+	}
+	dst.Tab(tab).Code("switch (id) {\n")
+	for _, field := range typ.Fields.List {
+		dst.Tab(tab + 1).Code("case " + field.Id.Value + ":\n")
+		dst.Tab(tab + 2).Code("v" + build.StringToHumpName(field.Name.Name) + " = ")
+		b.printReader(dst, field.Type)
+		dst.Code("(typ, val);\n")
+		dst.Tab(tab + 2).Code("break;\n")
+	}
+	for _, oneOf := range typ.OneOfs {
+		for _, field := range oneOf.Fields {
+			dst.Tab(tab + 1).Code("case " + field.Id.Value + ":\n")
+			dst.Tab(tab + 2).Code("v" + build.StringToHumpName(oneOf.Name.Name) + " = " + oneOfName(typ, oneOf) + build.StringToHumpName(field.Name.Name) + "(")
+			b.printReader(dst, field.Type)
+			dst.Code("(typ, val));\n")
+			dst.Tab(tab + 2).Code("break;\n")
+		}
+	}
+	dst.Tab(tab).Code("}\n")
+}
+
+// printDataDefault 输出非空字段缺失时的默认值
+func (b *Builder) printDataDefault(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			b.printType(dst, t, true)
+			if ast.Enum == t.Obj.Kind {
+				dst.Code(".valueOf(0)")
+			} else {
+				dst.Code(".fromData(ByteData(0))")
+			}
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int64, build.Uint64:
+			dst.Code("Int64.ZERO")
+		case build.Float, build.Double:
+			dst.Code("0.0")
+		case build.Bool:
+			dst.Code("false")
+		case build.String:
+			dst.Code("\"\"")
+		case build.Date:
+			dst.Code("DateTime.fromMillisecondsSinceEpoch(0)")
+		case build.Decimal:
+			dst.Code("Decimal.zero")
+		case build.Uuid:
+			dst.Code("\"\"")
+		case build.Bytes:
+			dst.Code("Uint8List(0)")
+		case build.Duration:
+			dst.Code("Duration.zero")
+		case build.Json:
+			dst.Code("null")
+		default:
+			dst.Code("0")
+		}
+	case *ast.ArrayType:
+		dst.Code("[]")
+	case *ast.MapType:
+		dst.Code("{}")
+	case *ast.VarType:
+		b.printDataDefault(dst, expr.(*ast.VarType).Type())
+	}
+}
+
+// printWriter 输出字段类型对应的写入函数
+func (b *Builder) printWriter(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if ast.Enum == t.Obj.Kind {
+				dst.Code("((HbufWriter writer, int id, ")
+				b.printType(dst, t, true)
+				dst.Code(" value) => hbufWriteInt(writer, id, value.value))")
+			} else {
+				dst.Code("hbufWriteData")
+			}
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32:
+			dst.Code("hbufWriteInt")
+		case build.Uint8, build.Uint16, build.Uint32:
+			dst.Code("hbufWriteUint")
+		case build.Int64:
+			dst.Code("hbufWriteInt64")
+		case build.Uint64:
+			dst.Code("hbufWriteUint64")
+		case build.Bool:
+			dst.Code("hbufWriteBool")
+		case build.Float:
+			dst.Code("hbufWriteFloat")
+		case build.Double:
+			dst.Code("hbufWriteDouble")
+		case build.String:
+			dst.Code("hbufWriteString")
+		case build.Date:
+			dst.Code("hbufWriteDate")
+		case build.Decimal:
+			dst.Code("hbufWriteDecimal")
+		case build.Bytes:
+			dst.Code("hbufWriteBytes")
+		case build.Duration:
+			dst.Code("hbufWriteDuration")
+		case build.Uuid:
+			dst.Code("hbufWriteUuid")
+		case build.Json:
+			dst.Code("hbufWriteJson")
+		}
+	case *ast.ArrayType:
+		dst.Code("hbufWriteList(")
+		b.printWriter(dst, expr.(*ast.ArrayType).VType)
+		dst.Code(")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("hbufWriteMap(")
+		b.printWriter(dst, ma.Key)
+		dst.Code(", ")
+		b.printWriter(dst, ma.VType)
+		dst.Code(")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty {
+			dst.Code("hbufWriteNullable(")
+			b.printWriter(dst, t.Type())
+			dst.Code(")")
+		} else {
+			b.printWriter(dst, t.Type())
+		}
+	}
+}
+
+// printReader 输出字段类型对应的读取函数
+func (b *Builder) printReader(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if ast.Enum == t.Obj.Kind {
+				dst.Code("((int typ, ByteData val) => ")
+				b.printType(dst, t, true)
+				dst.Code(".valueOf(hbufReadInt(typ, val)))")
+			} else {
+				dst.Code("hbufReadData(")
+				b.printType(dst, t, true)
+				dst.Code(".fromData)")
+			}
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32:
+			dst.Code("hbufReadInt")
+		case build.Uint8, build.Uint16, build.Uint32:
+			dst.Code("hbufReadUint")
+		case build.Int64:
+			dst.Code("hbufReadInt64")
+		case build.Uint64:
+			dst.Code("hbufReadUint64")
+		case build.Bool:
+			dst.Code("hbufReadBool")
+		case build.Float, build.Double:
+			dst.Code("hbufReadDouble")
+		case build.String:
+			dst.Code("hbufReadString")
+		case build.Date:
+			dst.Code("hbufReadDate")
+		case build.Decimal:
+			dst.Code("hbufReadDecimal")
+		case build.Bytes:
+			dst.Code("hbufReadBytes")
+		case build.Duration:
+			dst.Code("hbufReadDuration")
+		case build.Uuid:
+			dst.Code("hbufReadUuid")
+		case build.Json:
+			dst.Code("hbufReadJson")
+		}
+	case *ast.ArrayType:
+		dst.Code("hbufReadList(")
+		b.printReader(dst, expr.(*ast.ArrayType).VType)
+		dst.Code(")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("hbufReadMap(")
+		b.printReader(dst, ma.Key)
+		dst.Code(", ")
+		b.printReader(dst, ma.VType)
+		dst.Code(")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty {
+			dst.Code("hbufReadNullable(")
+			b.printReader(dst, t.Type())
+			dst.Code(")")
+		} else {
+			b.printReader(dst, t.Type())
+		}
+	}
+}
+
+// printCodecCode 生成同一个目录内所有编码方法共用的函数
+func printCodecCode(dst *build.Writer) {
+	dst.Import("dart:convert", "")
+	dst.Import("dart:typed_data", "")
+	dst.Import("package:decimal/decimal.dart", "")
+	dst.Import("package:fixnum/fixnum.dart", "")
+	dst.Import("package:hbuf_dart/hbuf_dart.dart", "")
+	dst.Code(_codecCode)
+}
+
+const _codecCode = `const int hbufTypeInt = 0;
+const int hbufTypeUint = 1;
+const int hbufTypeFloat = 2;
+const int hbufTypeBytes = 3;
+const int hbufTypeList = 4;
+const int hbufTypeMap = 5;
+const int hbufTypeData = 6;
+const int hbufTypeExtend = 7;
+
+const int _hbufMaxList = 1 << 24;
+
+class HbufWriter {
+  final BytesBuilder _buf = BytesBuilder(copy: false);
+
+  void _write(int typ, int id, List<int> val, [List<int>? payload]) {
+    final ids = _hbufUintBytes(Int64(id));
+    _buf.addByte((ids.length - 1) << 6 | typ << 3 | (val.length - 1));
+    _buf.add(ids);
+    _buf.add(val);
+    if (null != payload && payload.isNotEmpty) {
+      _buf.add(payload);
+    }
+  }
+
+  void _sub(int typ, int id, void Function(HbufWriter writer) call) {
+    final sub = HbufWriter();
+    call(sub);
+    final bytes = sub._buf.takeBytes();
+    _write(typ, id, _hbufUintBytes(Int64(bytes.length)), bytes);
+  }
+
+  void writeExtend(int id, void Function(HbufWriter writer) call) {
+    _sub(hbufTypeExtend, id, call);
+  }
+
+  ByteData toData() {
+    final bytes = _buf.takeBytes();
+    return ByteData.view(bytes.buffer, bytes.offsetInBytes, bytes.length);
+  }
+}
+
+List<int> _hbufIntBytes(Int64 v) {
+  final ret = <int>[];
+  while (true) {
+    ret.add((v & 0xFF).toInt());
+    if (v >= -128 && v < 128) {
+      return ret;
+    }
+    v = v >> 8;
+  }
+}
+
+List<int> _hbufUintBytes(Int64 v) {
+  final ret = <int>[];
+  while (true) {
+    ret.add((v & 0xFF).toInt());
+    v = v.shiftRightUnsigned(8);
+    if (v.isZero) {
+      return ret;
+    }
+  }
+}
+
+Int64 _hbufToInt(ByteData val) {
+  final len = val.lengthInBytes;
+  final bytes = List<int>.filled(8, 0 < len && 0 != val.getUint8(len - 1) & 0x80 ? 0xFF : 0);
+  for (var i = 0; i < len && i < 8; i++) {
+    bytes[i] = val.getUint8(i);
+  }
+  return Int64.fromBytes(bytes);
+}
+
+Int64 _hbufToUint(ByteData val) {
+  final bytes = List<int>.filled(8, 0);
+  for (var i = 0; i < val.lengthInBytes && i < 8; i++) {
+    bytes[i] = val.getUint8(i);
+  }
+  return Int64.fromBytes(bytes);
+}
+
+ByteData _hbufView(ByteData data, int offset, int length) {
+  return ByteData.view(data.buffer, data.offsetInBytes + offset, length);
+}
+
+FormatException _hbufTypeError(int typ) {
+  return FormatException("hbuf: unexpected wire type $typ");
+}
+
+void hbufWriteInt(HbufWriter writer, int id, int v) {
+  writer._write(hbufTypeInt, id, _hbufIntBytes(Int64(v)));
+}
+
+void hbufWriteUint(HbufWriter writer, int id, int v) {
+  writer._write(hbufTypeUint, id, _hbufUintBytes(Int64(v)));
+}
+
+void hbufWriteInt64(HbufWriter writer, int id, Int64 v) {
+  writer._write(hbufTypeInt, id, _hbufIntBytes(v));
+}
+
+void hbufWriteUint64(HbufWriter writer, int id, Int64 v) {
+  writer._write(hbufTypeUint, id, _hbufUintBytes(v));
+}
+
+void hbufWriteBool(HbufWriter writer, int id, bool v) {
+  writer._write(hbufTypeUint, id, [v ? 1 : 0]);
+}
+
+void hbufWriteFloat(HbufWriter writer, int id, double v) {
+  final val = ByteData(4)..setFloat32(0, v, Endian.little);
+  writer._write(hbufTypeFloat, id, val.buffer.asUint8List());
+}
+
+void hbufWriteDouble(HbufWriter writer, int id, double v) {
+  final val = ByteData(8)..setFloat64(0, v, Endian.little);
+  writer._write(hbufTypeFloat, id, val.buffer.asUint8List());
+}
+
+void hbufWriteString(HbufWriter writer, int id, String v) {
+  final bytes = utf8.encode(v);
+  writer._write(hbufTypeBytes, id, _hbufUintBytes(Int64(bytes.length)), bytes);
+}
+
+void hbufWriteDate(HbufWriter writer, int id, DateTime v) {
+  writer._write(hbufTypeInt, id, _hbufIntBytes(Int64(v.millisecondsSinceEpoch)));
+}
+
+void hbufWriteDecimal(HbufWriter writer, int id, Decimal v) {
+  hbufWriteString(writer, id, v.toString());
+}
+
+void hbufWriteBytes(HbufWriter writer, int id, Uint8List v) {
+  writer._write(hbufTypeBytes, id, _hbufUintBytes(Int64(v.length)), v);
+}
+
+void hbufWriteDuration(HbufWriter writer, int id, Duration v) {
+  writer._write(hbufTypeInt, id, _hbufIntBytes(Int64(v.inMicroseconds) * 1000));
+}
+
+void hbufWriteUuid(HbufWriter writer, int id, String v) {
+  if (v.isEmpty) {
+    writer._write(hbufTypeBytes, id, _hbufUintBytes(Int64.ZERO));
+    return;
+  }
+  final hex = v.replaceAll("-", "");
+  if (36 != v.length || 32 != hex.length) {
+    throw FormatException("hbuf: invalid uuid $v");
+  }
+  final bytes = Uint8List(16);
+  for (var i = 0; i < 16; i++) {
+    bytes[i] = int.parse(hex.substring(i * 2, i * 2 + 2), radix: 16);
+  }
+  writer._write(hbufTypeBytes, id, _hbufUintBytes(Int64(bytes.length)), bytes);
+}
+
+void hbufWriteJson(HbufWriter writer, int id, dynamic v) {
+  hbufWriteString(writer, id, null == v ? "" : json.encode(v));
+}
+
+void hbufWriteData(HbufWriter writer, int id, Data v) {
+  final val = v.toData();
+  final bytes = val.buffer.asUint8List(val.offsetInBytes, val.lengthInBytes);
+  writer._write(hbufTypeData, id, _hbufUintBytes(Int64(bytes.length)), bytes);
+}
+
+void Function(HbufWriter, int, T?) hbufWriteNullable<T>(void Function(HbufWriter, int, T) write) {
+  return (HbufWriter writer, int id, T? v) {
+    if (null != v) {
+      write(writer, id, v);
+    }
+  };
+}
+
+void Function(HbufWriter, int, List<T>?) hbufWriteList<T>(void Function(HbufWriter, int, T) write) {
+  return (HbufWriter writer, int id, List<T>? v) {
+    if (null == v) {
+      return;
+    }
+    // 读取时长度不能超过 _hbufMaxList，超过的在写入时就报错
+    if (_hbufMaxList < v.length) {
+      throw FormatException("hbuf: list is too long: ${v.length}");
+    }
+    writer._sub(hbufTypeList, id, (writer) {
+      for (var i = 0; i < v.length; i++) {
+        write(writer, i, v[i]);
+      }
+      // 末尾为空的元素没有写入，写入长度以便读取时补空
+      if (v.isNotEmpty && null == v.last) {
+        hbufWriteUint(writer, _hbufMaxList, v.length);
+      }
+    });
+  };
+}
+
+void Function(HbufWriter, int, Map<K, V>?) hbufWriteMap<K, V>(void Function(HbufWriter, int, K) key, void Function(HbufWriter, int, V) value) {
+  return (HbufWriter writer, int id, Map<K, V>? v) {
+    if (null == v) {
+      return;
+    }
+    writer._sub(hbufTypeMap, id, (writer) {
+      // 按编码后的键排序，与 Go 编码得到相同的字节
+      final list = <MapEntry<List<int>, V>>[];
+      v.forEach((k, item) {
+        final sub = HbufWriter();
+        key(sub, 0, k);
+        list.add(MapEntry(sub._buf.takeBytes(), item));
+      });
+      list.sort((a, b) => _hbufCompare(a.key, b.key));
+      for (final entry in list) {
+        writer._buf.add(entry.key);
+        value(writer, 1, entry.value);
+      }
+    });
+  };
+}
+
+int _hbufCompare(List<int> a, List<int> b) {
+  for (var i = 0; i < a.length && i < b.length; i++) {
+    if (a[i] != b[i]) {
+      return a[i] - b[i];
+    }
+  }
+  return a.length - b.length;
+}
+
+void hbufWalk(ByteData data, void Function(int typ, int id, ByteData val) call) {
+  var offset = 0;
+  while (offset < data.lengthInBytes) {
+    final head = data.getUint8(offset);
+    final idLen = (head >> 6) + 1;
+    final typ = head >> 3 & 7;
+    final valLen = (head & 7) + 1;
+    if (data.lengthInBytes < offset + 1 + idLen + valLen) {
+      throw FormatException("hbuf: unexpected end of data");
+    }
+    final id = _hbufToUint(_hbufView(data, offset + 1, idLen)).toInt();
+    var val = _hbufView(data, offset + 1 + idLen, valLen);
+    offset += 1 + idLen + valLen;
+    if (hbufTypeBytes <= typ) {
+      final size = _hbufToUint(val);
+      if (Int64(data.lengthInBytes - offset) < size) {
+        throw FormatException("hbuf: unexpected end of data");
+      }
+      val = _hbufView(data, offset, size.toInt());
+      offset += size.toInt();
+    }
+    call(typ, id, val);
+  }
+}
+
+Int64 hbufReadInt64(int typ, ByteData val) {
+  switch (typ) {
+    case hbufTypeInt:
+      return _hbufToInt(val);
+    case hbufTypeUint:
+      return _hbufToUint(val);
+  }
+  throw _hbufTypeError(typ);
+}
+
+Int64 hbufReadUint64(int typ, ByteData val) {
+  return hbufReadInt64(typ, val);
+}
+
+int hbufReadInt(int typ, ByteData val) {
+  return hbufReadInt64(typ, val).toInt();
+}
+
+int hbufReadUint(int typ, ByteData val) {
+  return hbufReadInt64(typ, val).toInt();
+}
+
+bool hbufReadBool(int typ, ByteData val) {
+  return !hbufReadInt64(typ, val).isZero;
+}
+
+double hbufReadDouble(int typ, ByteData val) {
+  if (hbufTypeFloat == typ) {
+    switch (val.lengthInBytes) {
+      case 4:
+        return val.getFloat32(0, Endian.little);
+      case 8:
+        return val.getFloat64(0, Endian.little);
+    }
+  }
+  throw _hbufTypeError(typ);
+}
+
+String hbufReadString(int typ, ByteData val) {
+  if (hbufTypeBytes != typ) {
+    throw _hbufTypeError(typ);
+  }
+  return utf8.decode(val.buffer.asUint8List(val.offsetInBytes, val.lengthInBytes));
+}
+
+DateTime hbufReadDate(int typ, ByteData val) {
+  return DateTime.fromMillisecondsSinceEpoch(hbufReadInt64(typ, val).toInt());
+}
+
+Decimal hbufReadDecimal(int typ, ByteData val) {
+  return Decimal.parse(hbufReadString(typ, val));
+}
+
+Uint8List hbufReadBytes(int typ, ByteData val) {
+  if (hbufTypeBytes != typ) {
+    throw _hbufTypeError(typ);
+  }
+  return Uint8List.fromList(val.buffer.asUint8List(val.offsetInBytes, val.lengthInBytes));
+}
+
+Duration hbufReadDuration(int typ, ByteData val) {
+  return Duration(microseconds: (hbufReadInt64(typ, val) ~/ 1000).toInt());
+}
+
+String hbufReadUuid(int typ, ByteData val) {
+  final bytes = hbufReadBytes(typ, val);
+  if (bytes.isEmpty) {
+    return "";
+  }
+  if (16 != bytes.length) {
+    throw FormatException("hbuf: invalid uuid length ${bytes.length}");
+  }
+  final hex = bytes.map((e) => e.toRadixString(16).padLeft(2, "0")).join();
+  return "${hex.substring(0, 8)}-${hex.substring(8, 12)}-${hex.substring(12, 16)}-${hex.substring(16, 20)}-${hex.substring(20)}";
+}
+
+dynamic hbufReadJson(int typ, ByteData val) {
+  final text = hbufReadString(typ, val);
+  return text.isEmpty ? null : json.decode(text);
+}
+
+T Function(int, ByteData) hbufReadData<T>(T Function(ByteData data) fromData) {
+  return (int typ, ByteData val) {
+    if (hbufTypeData != typ) {
+      throw _hbufTypeError(typ);
+    }
+    return fromData(val);
+  };
+}
+
+T? Function(int, ByteData) hbufReadNullable<T>(T Function(int, ByteData) read) {
+  return (int typ, ByteData val) => read(typ, val);
+}
+
+List<T> Function(int, ByteData) hbufReadList<T>(T Function(int, ByteData) read) {
+  return (int typ, ByteData val) {
+    if (hbufTypeList != typ) {
+      throw _hbufTypeError(typ);
+    }
+    final list = <T>[];
+    hbufWalk(val, (typ, id, val) {
+      if (_hbufMaxList == id) {
+        final size = hbufReadUint(typ, val);
+        if (size < list.length || _hbufMaxList < size || (size > list.length && null is! T)) {
+          throw FormatException("hbuf: invalid list length $size");
+        }
+        while (list.length < size) {
+          list.add(null as T);
+        }
+        return;
+      }
+      if (id < list.length || _hbufMaxList <= id || (id > list.length && null is! T)) {
+        throw FormatException("hbuf: invalid list index $id");
+      }
+      final item = read(typ, val);
+      while (list.length < id) {
+        list.add(null as T);
+      }
+      list.add(item);
+    });
+    return list;
+  };
+}
+
+Map<K, V> Function(int, ByteData) hbufReadMap<K, V>(K Function(int, ByteData) key, V Function(int, ByteData) value) {
+  return (int typ, ByteData val) {
+    if (hbufTypeMap != typ) {
+      throw _hbufTypeError(typ);
+    }
+    final map = <K, V>{};
+    K? k;
+    var hasKey = false;
+    hbufWalk(val, (typ, id, val) {
+      if (0 == id) {
+        if (hasKey) {
+          map[k as K] = null as V;
+        }
+        k = key(typ, val);
+        hasKey = true;
+        return;
+      }
+      if (!hasKey) {
+        throw FormatException("hbuf: map value without key");
+      }
+      map[k as K] = value(typ, val);
+      hasKey = false;
+    });
+    if (hasKey) {
+      map[k as K] = null as V;
+    }
+    return map;
+  };
+}
+
+/// 由方法的 http 注解生成的 REST 请求，路径参数已替换为请求数据中的值
+class HbufHttpRequest {
+  final String method;
+  final String path;
+  final Map<String, String> query;
+  final Object? body;
+
+  HbufHttpRequest(this.method, this.path, this.query, this.body);
+
+  /// 从 toMap 的结果中依次取出路径参数和请求体，其余不为 null 的字段放在查询参数中，body 为 "*" 时请求体为整个数据
+  factory HbufHttpRequest.fromMap(String method, String path, Map<String, dynamic> map, String body) {
+    final values = Map<String, dynamic>.of(map);
+    final segments = path.split("/").map((e) {
+      if (e.startsWith("{") && e.endsWith("}")) {
+        return Uri.encodeComponent(hbufHttpValue(values.remove(e.substring(1, e.length - 1))));
+      }
+      return e;
+    }).toList();
+
+    Object? data;
+    if ("*" == body) {
+      data = map;
+      values.clear();
+    } else if (body.isNotEmpty) {
+      data = values.remove(body);
+    }
+    final query = <String, String>{};
+    values.forEach((key, value) {
+      if (null != value) {
+        query[key] = hbufHttpValue(value);
+      }
+    });
+    return HbufHttpRequest(method, segments.join("/"), query, data);
+  }
+
+  /// 请求的地址，base 为服务的地址
+  Uri uri(String base) {
+    final uri = Uri.parse(base + path);
+    return query.isEmpty ? uri : uri.replace(queryParameters: query);
+  }
+}
+
+/// 路径和查询参数中的值，字符串原样使用，其他值为 JSON
+String hbufHttpValue(dynamic value) {
+  if (null == value) {
+    return "";
+  }
+  if (value is String) {
+    return value;
+  }
+  return jsonEncode(value);
+}
+`
@@ -0,0 +1,38 @@
+package dart
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestEncoderWire 二进制编码与 Go 逐字节相同，Go 编码的样例在 test/go/parser/testdata/encoder.json 中
+func TestEncoderWire(t *testing.T) {
+	src := "" +
+		"data Item = 1 {\n" +
+		"    int64 id = 0\n" +
+		"    Item?[] list = 1\n" +
+		"    int32<string> map = 2\n" +
+		"}\n"
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	code := buildtest.ReadFile(t, out, "hbuf_encoder.dart")
+	buildtest.Constants(t, code, "hbufType", buildtest.WireTypes)
+	buildtest.Contains(t, code,
+		// 头部：第 7-6 位 ID 长度 - 1，第 5-3 位类型，第 2-0 位值长度 - 1，之后是 ID 和值
+		"_buf.addByte((ids.length - 1) << 6 | typ << 3 | (val.length - 1));\n    _buf.add(ids);\n    _buf.add(val);\n",
+		// 整数按小端写入最少的字节，有符号数到能表示符号的字节为止
+		"ret.add((v & 0xFF).toInt());\n    if (v >= -128 && v < 128) {\n      return ret;\n    }\n    v = v >> 8;\n",
+		"ret.add((v & 0xFF).toInt());\n    v = v.shiftRightUnsigned(8);\n    if (v.isZero) {\n",
+		// 列表末尾为空时以 ID 1 << 24 写入长度
+		"const int _hbufMaxList = 1 << 24;\n",
+		"hbufWriteUint(writer, _hbufMaxList, v.length);\n",
+		// Map 的键 ID 为 0，值 ID 为 1，按编码后的键排序
+		"key(sub, 0, k);\n",
+		"list.sort((a, b) => _hbufCompare(a.key, b.key));\n",
+		"writer._buf.add(entry.key);\n        value(writer, 1, entry.value);\n",
+	)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "a.data.dart"),
+		"hbufWriteInt64(writer, 0, id);\n",
+		"hbufWriteList(hbufWriteNullable(hbufWriteData))(writer, 1, list);\n",
+		"hbufWriteMap(hbufWriteString, hbufWriteInt)(writer, 2, map);\n",
+	)
+}
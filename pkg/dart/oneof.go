@@ -0,0 +1,90 @@
+package dart
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// oneOfName 返回 oneof 类型的名称，如 EventPayload
+func oneOfName(typ *ast.DataType, oneOf *ast.OneOf) string {
+	return build.StringToHumpName(typ.Name.Name) + build.StringToHumpName(oneOf.Name.Name)
+}
+
+// printOneOfCode 生成 oneof 的抽象类和每个成员的子类，JSON 写成 {"成员名": 值}
+func (b *Builder) printOneOfCode(dst *build.Writer, typ *ast.DataType) {
+	for _, oneOf := range typ.OneOfs {
+		name := oneOfName(typ, oneOf)
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Code("///" + oneOf.Doc.Text())
+		}
+		dst.Code("abstract class " + name + " {\n")
+		dst.Tab(1).Code("const " + name + "._();\n\n")
+
+		dst.Tab(1).Code("static " + name + "? fromMap(dynamic map) {\n")
+		dst.Tab(2).Code("if (map is! Map) {\n")
+		dst.Tab(3).Code("return null;\n")
+		dst.Tab(2).Code("}\n")
+		dst.Tab(2).Code("dynamic temp;\n")
+		for _, field := range oneOf.Fields {
+			jsonName := build.StringToUnderlineName(field.Name.Name)
+			dst.Tab(2).Code("if (map.containsKey(\"" + jsonName + "\")) {\n")
+			dst.Tab(3).Code("return " + name + build.StringToHumpName(field.Name.Name) + "(")
+			b.printFormMap(dst, "(temp = map[\""+jsonName+"\"])", "temp", field.Type, typ, false)
+			dst.Code(");\n")
+			dst.Tab(2).Code("}\n")
+		}
+		dst.Tab(2).Code("return null;\n")
+		dst.Tab(1).Code("}\n\n")
+
+		dst.Tab(1).Code("Map<String, dynamic> toMap();\n\n")
+		dst.Tab(1).Code("void toData(HbufWriter writer);\n\n")
+		dst.Tab(1).Code(name + " copy();\n")
+		dst.Code("}\n\n")
+
+		for _, field := range oneOf.Fields {
+			member := name + build.StringToHumpName(field.Name.Name)
+			if nil != field.Doc && 0 < len(field.Doc.Text()) {
+				dst.Code("///" + field.Doc.Text())
+			}
+			dst.Code("class " + member + " extends " + name + " {\n")
+			dst.Tab(1).Code("final ")
+			b.printType(dst, field.Type, false)
+			dst.Code(" value;\n\n")
+			dst.Tab(1).Code("const " + member + "(this.value) : super._();\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code("Map<String, dynamic> toMap() {\n")
+			dst.Tab(2).Code("return {\"" + build.StringToUnderlineName(field.Name.Name) + "\": ")
+			b.printToMap(dst, "value", field.Type, typ, false)
+			dst.Code("};\n")
+			dst.Tab(1).Code("}\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code("void toData(HbufWriter writer) {\n")
+			dst.Tab(2).Code("")
+			b.printWriter(dst, field.Type)
+			dst.Code("(writer, " + field.Id.Value + ", value);\n")
+			dst.Tab(1).Code("}\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code(member + " copy() {\n")
+			dst.Tab(2).Code("return " + member + "(")
+			b.printCopy(dst, "value", field.Type, typ, false)
+			dst.Code(");\n")
+			dst.Tab(1).Code("}\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code("bool operator ==(Object other) =>\n")
+			dst.Tab(3).Code("identical(this, other) || other is " + member + " && value == other.value;\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code("int get hashCode => value.hashCode;\n\n")
+
+			dst.Tab(1).Code("@override\n")
+			dst.Tab(1).Code("String toString() {\n")
+			dst.Tab(2).Code("return '" + build.StringToFirstLower(field.Name.Name) + ": $value';\n")
+			dst.Tab(1).Code("}\n")
+			dst.Code("}\n\n")
+		}
+	}
+}
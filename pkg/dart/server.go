@@ -5,15 +5,17 @@ import (
 	"hbuf/pkg/build"
 )
 
-func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) error {
 	dst.Import("dart:convert", "")
 	dst.Import("dart:typed_data", "")
 	dst.Import("package:hbuf_dart/hbuf_dart.dart", "")
 
 	b.printServer(dst, typ)
-	b.printServerImp(dst, typ)
-	b.printServerRouter(dst, typ)
-
+	err := b.printServerImp(dst, typ)
+	if err != nil {
+		return err
+	}
+	return b.printServerRouter(dst, typ)
 }
 
 func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
@@ -33,47 +35,66 @@ func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
 		if build.CheckSuperMethod(method.Name.Name, typ) {
 			dst.Code("  @override\n")
 		}
-		dst.Code("  Future<")
-		b.printType(dst, method.Result.Type(), false)
-		dst.Code("> " + build.StringToFirstLower(method.Name.Name))
+		dst.Code("  ")
+		b.printMethodResult(dst, method)
+		dst.Code(" " + build.StringToFirstLower(method.Name.Name))
 		dst.Code("(")
-		b.printType(dst, method.Param, false)
+		b.printMethodParam(dst, method)
 		dst.Code(" " + build.StringToFirstLower(method.ParamName.Name))
 		dst.Code(", [Context? ctx]);\n\n")
 	}
 	dst.Code("}\n\n")
 }
 
-func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) error {
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
 	dst.Code("class " + build.StringToHumpName(typ.Name.Name) + "Client extends ServerClient implements " + build.StringToHumpName(typ.Name.Name))
 
 	dst.Code("{\n")
 
-	dst.Code("  " + build.StringToHumpName(typ.Name.Name) + "Client(Client client):super(client);\n\n")
+	if hasServerStream(typ) {
+		dst.Import(build.ImportPath(dst.File, "hbuf_stream.dart"), "")
+		dst.Code("  final StreamClient _stream;\n\n")
+		dst.Code("  " + build.StringToHumpName(typ.Name.Name) + "Client(StreamClient client):_stream = client, super(client);\n\n")
+	} else {
+		dst.Code("  " + build.StringToHumpName(typ.Name.Name) + "Client(Client client):super(client);\n\n")
+	}
 
 	dst.Code("  @override\n")
 	dst.Code("  String get name => \"" + build.StringToUnderlineName(typ.Name.Name) + "\";\n\n")
 	dst.Code("  @override\n")
-	//TODO dst.Code("  int get id => " + typ.Id.Value + ";\n\n")
-	dst.Code("  int get id => " + "0" + ";\n\n")
+	dst.Code("  int get id => " + serverId + ";\n\n")
 
-	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
 		dst.Code("  @override\n")
-		dst.Code("  Future<")
-		b.printType(dst, method.Result.Type(), false)
-		dst.Code("> " + build.StringToFirstLower(method.Name.Name))
+		dst.Code("  ")
+		b.printMethodResult(dst, method)
+		dst.Code(" " + build.StringToFirstLower(method.Name.Name))
 		dst.Code("(")
-		b.printType(dst, method.Param, false)
+		b.printMethodParam(dst, method)
 		dst.Code(" " + build.StringToFirstLower(method.ParamName.Name))
-		dst.Code(", [Context? ctx]){\n")
+		dst.Code(", [Context? ctx])")
 
+		if hasStream(method) {
+			dst.Code("{\n")
+			b.printClientStream(dst, invokeId, method, build.StringToUnderlineName(typ.Name.Name)+"/"+build.StringToUnderlineName(server.Name.Name)+"/"+build.StringToUnderlineName(method.Name.Name))
+			dst.Code("  }\n\n")
+			return nil
+		}
+		dst.Code("{\n")
 		dst.Code("    return invoke<")
 		b.printType(dst, method.Result.Type(), false)
 		dst.Code(">(\"")
 		dst.Code(build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name))
 		dst.Code("\", ")
-		//TODO dst.Code(server.Id.Value + " << 32 | " + method.Id.Value)
-		dst.Code("0 << 32 | " + method.Id.Value)
+		dst.Code(invokeId)
 		dst.Code(", ")
 		dst.Code(build.StringToFirstLower(method.ParamName.Name))
 		dst.Code(", ")
@@ -83,13 +104,79 @@ func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
 		dst.Code(".fromData);\n")
 
 		dst.Code("  }\n\n")
+		b.printClientHttp(dst, method)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 	dst.Code("}\n\n")
+	return nil
 }
 
-func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
+// printClientHttp 输出带 http 注解的方法对应的 REST 请求，如 getUserHttp(req)，由调用方发送
+func (b *Builder) printClientHttp(dst *build.Writer, method *ast.FuncType) {
+	route, err := build.GetHttp(method)
+	if nil != err || nil == route {
+		return
+	}
+	dst.Import(build.ImportPath(dst.File, "hbuf_encoder.dart"), "")
+	dst.Code("  /// " + route.Method + " " + route.Path + "\n")
+	dst.Code("  HbufHttpRequest " + build.StringToFirstLower(method.Name.Name) + "Http(")
+	b.printType(dst, method.Param, false)
+	dst.Code(" " + build.StringToFirstLower(method.ParamName.Name) + ") {\n")
+	dst.Code("    return HbufHttpRequest.fromMap(\"" + route.Method + "\", \"" + route.Path + "\", ")
+	dst.Code(build.StringToFirstLower(method.ParamName.Name) + ".toMap(), \"" + route.Body + "\");\n")
+	dst.Code("  }\n\n")
+}
+
+// printMethodResult 输出方法的返回类型，stream 为 Stream<List<int>>，stream<Item> 为 Stream<Item>
+func (b *Builder) printMethodResult(dst *build.Writer, method *ast.FuncType) {
+	if isStream(method.Result) {
+		dst.Code("Stream<List<int>>")
+		return
+	}
+	if isVoid(method.Result) {
+		dst.Code("Future<void>")
+		return
+	}
+	if item := build.GetStreamItem(method.Result); nil != item {
+		dst.Code("Stream<")
+		b.printType(dst, item, false)
+		dst.Code(">")
+		return
+	}
+	dst.Code("Future<")
+	b.printType(dst, method.Result.Type(), false)
+	dst.Code(">")
+}
+
+// printMethodParam 输出方法的参数类型，stream 为 Stream<List<int>>，stream<Item> 为 Stream<Item>
+func (b *Builder) printMethodParam(dst *build.Writer, method *ast.FuncType) {
+	if isStream(method.Param) {
+		dst.Code("Stream<List<int>>")
+		return
+	}
+	if item := build.GetStreamItem(method.Param); nil != item {
+		dst.Code("Stream<")
+		b.printType(dst, item, false)
+		dst.Code(">")
+		return
+	}
+	b.printType(dst, method.Param, false)
+}
+
+func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) error {
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
+	isStreams := hasServerStream(typ)
 	dst.Code("class " + build.StringToHumpName(typ.Name.Name) + "Router extends ServerRouter")
+	if isStreams {
+		dst.Import(build.ImportPath(dst.File, "hbuf_stream.dart"), "")
+		dst.Code(" implements StreamRouter")
+	}
 
 	dst.Code("{\n")
 	dst.Code("  final " + build.StringToHumpName(typ.Name.Name) + " server;\n\n")
@@ -98,8 +185,7 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Code("  String get name => \"" + build.StringToUnderlineName(typ.Name.Name) + "\";\n\n")
 
 	dst.Code("  @override\n")
-	//TODO dst.Code("  int get id => " + typ.Id.Value + ";\n\n")
-	dst.Code("  int get id => 0;\n\n")
+	dst.Code("  int get id => " + serverId + ";\n\n")
 
 	dst.Code("  Map<String, ServerInvoke> _invokeNames = {};\n\n")
 
@@ -111,9 +197,24 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Code("  @override\n")
 	dst.Code("  Map<int, ServerInvoke> get invokeIds => _invokeIds;\n\n")
 
+	if isStreams {
+		dst.Code("  Map<String, StreamInvoke> _streams = {};\n\n")
+
+		dst.Code("  Map<int, StreamInvoke> _streamIds = {};\n\n")
+
+		dst.Code("  @override\n")
+		dst.Code("  Map<String, StreamInvoke> get streams => _streams;\n\n")
+
+		dst.Code("  @override\n")
+		dst.Code("  Map<int, StreamInvoke> get streamIds => _streamIds;\n\n")
+	}
+
 	dst.Code("  " + build.StringToHumpName(typ.Name.Name) + "Router(this.server){\n")
 	dst.Code("    _invokeNames = {\n")
 	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		if hasStream(method) {
+			return nil
+		}
 		dst.Code("      \"" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": ServerInvoke(\n")
 		dst.Code("        toData: (List<int> buf) async {\n")
 		dst.Code("          return ")
@@ -134,9 +235,15 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Code("    };\n\n")
 
 	dst.Code("    _invokeIds = {\n")
-	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
-		//dst.Code("        " + server.Id.Value + " << 32 | " + method.Id.Value + ": ServerInvoke(\n")
-		dst.Code("        0 << 32 | " + method.Id.Value + ": ServerInvoke(\n")
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		if hasStream(method) {
+			return nil
+		}
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
+		dst.Code("        " + invokeId + ": ServerInvoke(\n")
 		dst.Code("        toData: (List<int> buf) async {\n")
 		dst.Code("          return ")
 		b.printType(dst, method.Param.Type(), false)
@@ -153,8 +260,42 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 		dst.Code("      ),\n")
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 	dst.Code("    };\n\n")
 
+	if isStreams {
+		dst.Code("    _streams = {\n")
+		_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			dst.Code("      \"" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": ")
+			b.printRouterStream(dst, method)
+			return nil
+		})
+		dst.Code("    };\n\n")
+
+		dst.Code("    _streamIds = {\n")
+		err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			invokeId, err := build.GetInvokeId(server, method)
+			if err != nil {
+				return err
+			}
+			dst.Code("      " + invokeId + ": ")
+			b.printRouterStream(dst, method)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		dst.Code("    };\n\n")
+	}
+
 	dst.Code("  }\n\n")
 
 	//
@@ -181,4 +322,5 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	//dst.Code("  }\n\n")
 
 	dst.Code("}\n\n")
+	return nil
 }
@@ -0,0 +1,67 @@
+package dart
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"strings"
+	"testing"
+)
+
+const _streamSrc = "" +
+	"data Req = 1 {\n" +
+	"    string name = 0\n" +
+	"}\n" +
+	"server File = 1 {\n" +
+	"    Req Get(Req req) = 0\n" +
+	"    Req Upload(stream req) = 1\n" +
+	"    stream Download(Req req) = 2\n" +
+	"    Req Sum(stream<Req> req) = 3\n" +
+	"    stream<Req> List(Req req) = 4\n" +
+	"    stream<Req> Chat(stream<Req> req) = 5\n" +
+	"}\n"
+
+// TestServerStream stream 和 stream<Item> 方法不进入普通调用的路由，客户端和路由按帧读写
+func TestServerStream(t *testing.T) {
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", map[string]string{"a.hbuf": _streamSrc})
+	code := buildtest.ReadFile(t, out, "a.server.dart")
+	buildtest.Contains(t, code,
+		"Future<Req> upload(Stream<List<int>> req, [Context? ctx]);\n",
+		"Stream<List<int>> download(Req req, [Context? ctx]);\n",
+		"FileClient(StreamClient client):_stream = client, super(client);\n",
+		"hbufCall(_stream, \"file/file/upload\", 4294967297, (binary) => hbufSendStream(req), ctx);\n",
+		"return hbufRecvStream(call.frames);\n",
+		"class FileRouter extends ServerRouter implements StreamRouter{\n",
+		"\"file/upload\": StreamInvoke(",
+		"4294967298: StreamInvoke(",
+		"return hbufReplyStream(() async => server.download(await hbufRecv(request, binary, Req.fromMap, Req.fromData), ctx));\n",
+		"return hbufRecvMessages(call.frames, call.binary, Req.fromMap, Req.fromData);\n",
+		"(binary) => hbufSendMessages(binary, req), ctx);\n",
+		"return hbufReplyMessages(binary, () async => server.list(await hbufRecv(request, binary, Req.fromMap, Req.fromData), ctx));\n",
+		"return hbufReply(binary, () async => server.sum(hbufRecvMessages(request, binary, Req.fromMap, Req.fromData), ctx));\n",
+		"return hbufReplyMessages(binary, () async => server.chat(hbufRecvMessages(request, binary, Req.fromMap, Req.fromData), ctx));\n",
+	)
+	invoke := code[strings.Index(code, "_invokeNames = {\n"):strings.Index(code, "_streams = {\n")]
+	if !strings.Contains(invoke, "\"file/get\"") || strings.Contains(invoke, "\"file/upload\"") {
+		t.Errorf("stream methods in invoke:\n%s", invoke)
+	}
+}
+
+// TestStreamFrame 帧的类型和帧头与 Go 生成的 StreamServer 相同
+func TestStreamFrame(t *testing.T) {
+	out := buildtest.Generate(t, "dart", Build, "a.hbuf", map[string]string{"a.hbuf": _streamSrc})
+	code := buildtest.ReadFile(t, out, "hbuf_stream.dart")
+	buildtest.Constants(t, code, "hbufFrame", buildtest.FrameKinds)
+	buildtest.Contains(t, code,
+		// 写入：1 字节类型，之后是 4 字节小端长度
+		"final ret = Uint8List(5 + data.length);\n  ret[0] = kind;\n  ByteData.sublistView(ret).setUint32(1, data.length, Endian.little);\n  ret.setRange(5, ret.length, data);\n",
+		// 读取：从第 2 个字节读出小端长度，帧的内容从第 6 个字节开始
+		"final size = ByteData.sublistView(buf, offset + 1, offset + 5).getUint32(0, Endian.little);\n",
+		"yield HbufFrame(buf[offset], Uint8List.sublistView(buf, offset + 5, offset + need));\n",
+		// 空消息发送空消息帧，读取消息流时跳过
+		"if (null == v) {\n    return hbufFrame(hbufFrameNull);\n  }\n",
+		"} else if (hbufFrameNull != frame.kind) {\n",
+		// 错误帧的内容为 {"code", "msg"}
+		"hbufFrame(hbufFrameError, utf8.encode(json.encode({\"code\": res.code, \"msg\": res.msg})))",
+		// stream 结束和 void 结果都发送结束帧
+		"yield hbufFrame(hbufFrameEnd);\n",
+	)
+}
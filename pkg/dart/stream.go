@@ -0,0 +1,434 @@
+package dart
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// isStream 返回参数或结果是否为 stream
+func isStream(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "stream" == ident.Name
+}
+
+// isVoid 返回结果是否为 void
+func isVoid(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "void" == ident.Name
+}
+
+// hasStream 返回方法的参数或结果是否为 stream 或 stream<Item>
+func hasStream(method *ast.FuncType) bool {
+	return isStream(method.Param) || isStream(method.Result) || nil != build.GetStreamItem(method.Param) || nil != build.GetStreamItem(method.Result)
+}
+
+// hasServerStream 返回服务中是否有 stream 方法，包括继承的方法
+func hasServerStream(typ *ast.ServerType) bool {
+	ret := false
+	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		ret = ret || hasStream(method)
+		return nil
+	})
+	return ret
+}
+
+// isFileStream 返回文件中是否有服务含有 stream 方法
+func isFileStream(file *ast.File) bool {
+	for _, s := range file.Specs {
+		if spec, ok := s.(*ast.TypeSpec); ok {
+			if server, ok := spec.Type.(*ast.ServerType); ok && hasServerStream(server) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printClientStream 输出 stream 方法的调用，请求边读边发，结果边收边读
+func (b *Builder) printClientStream(dst *build.Writer, invokeId string, method *ast.FuncType, name string) {
+	dst.Import(build.ImportPath(dst.File, "hbuf_stream.dart"), "")
+	param := build.StringToFirstLower(method.ParamName.Name)
+	dst.Code("    final call = hbufCall(_stream, \"" + name + "\", " + invokeId + ", (binary) => ")
+	if isStream(method.Param) {
+		dst.Code("hbufSendStream(" + param + ")")
+	} else if nil != build.GetStreamItem(method.Param) {
+		dst.Code("hbufSendMessages(binary, " + param + ")")
+	} else {
+		dst.Code("hbufSend(binary, " + param + ")")
+	}
+	dst.Code(", ctx);\n")
+
+	if isVoid(method.Result) {
+		dst.Code("    return hbufCallEnd(call);\n")
+	} else if isStream(method.Result) {
+		dst.Code("    return hbufRecvStream(call.frames);\n")
+	} else if item := build.GetStreamItem(method.Result); nil != item {
+		dst.Code("    return hbufRecvMessages(call.frames, call.binary, ")
+		b.printType(dst, item.Type(), false)
+		dst.Code(".fromMap, ")
+		b.printType(dst, item.Type(), false)
+		dst.Code(".fromData);\n")
+	} else {
+		dst.Code("    return hbufCallReply(call, ")
+		b.printType(dst, method.Result.Type(), false)
+		dst.Code(".fromMap, ")
+		b.printType(dst, method.Result.Type(), false)
+		dst.Code(".fromData);\n")
+	}
+}
+
+// printRouterStream 输出路由中 stream 方法的调用，请求和结果都按帧边读边写
+func (b *Builder) printRouterStream(dst *build.Writer, method *ast.FuncType) {
+	dst.Code("StreamInvoke((Context ctx, bool binary, Stream<HbufFrame> request) {\n")
+	if isVoid(method.Result) {
+		dst.Code("        return hbufReplyEnd(() async => ")
+	} else if isStream(method.Result) {
+		dst.Code("        return hbufReplyStream(() async => ")
+	} else if nil != build.GetStreamItem(method.Result) {
+		dst.Code("        return hbufReplyMessages(binary, () async => ")
+	} else {
+		dst.Code("        return hbufReply(binary, () async => ")
+	}
+	dst.Code("server." + build.StringToFirstLower(method.Name.Name) + "(")
+	if isStream(method.Param) {
+		dst.Code("hbufRecvStream(request)")
+	} else if item := build.GetStreamItem(method.Param); nil != item {
+		dst.Code("hbufRecvMessages(request, binary, ")
+		b.printType(dst, item.Type(), false)
+		dst.Code(".fromMap, ")
+		b.printType(dst, item.Type(), false)
+		dst.Code(".fromData)")
+	} else {
+		dst.Code("await hbufRecv(request, binary, ")
+		b.printType(dst, method.Param.Type(), false)
+		dst.Code(".fromMap, ")
+		b.printType(dst, method.Param.Type(), false)
+		dst.Code(".fromData)")
+	}
+	dst.Code(", ctx));\n")
+	dst.Code("      }),\n")
+}
+
+// printStreamCode 生成 stream 方法共用的帧读写、客户端调用和路由分发代码
+func printStreamCode(dst *build.Writer) {
+	dst.Import("dart:convert", "")
+	dst.Import("dart:typed_data", "")
+	dst.Import("package:hbuf_dart/hbuf_dart.dart", "")
+	dst.Code(_streamCode)
+}
+
+const _streamCode = `/// stream 方法的请求和结果都是一串帧，帧头为 1 字节类型和 4 字节小端长度，之后是帧的内容，与 Go 生成的代码相同
+const int hbufFrameEnd = 0; // 流结束
+const int hbufFrameData = 1; // 一条消息，或 stream 中的一段字节
+const int hbufFrameNull = 2; // 为 null 的消息
+const int hbufFrameError = 3; // 服务返回的错误，内容为 {"code": ..., "msg": ...} 的 JSON
+
+/// stream 每帧最多发送的字节数
+const int hbufStreamChunk = 32 << 10;
+
+/// 一帧最大的长度
+const int hbufMaxFrame = 64 << 20;
+
+/// 一帧的类型和内容
+class HbufFrame {
+  final int kind;
+  final Uint8List data;
+
+  HbufFrame(this.kind, this.data);
+}
+
+/// 错误帧中服务返回的错误
+class HbufStreamError implements Exception {
+  final int code;
+  final String msg;
+
+  HbufStreamError(this.code, this.msg);
+
+  @override
+  String toString() => "HbufStreamError($code, $msg)";
+}
+
+Uint8List hbufFrame(int kind, [List<int> data = const []]) {
+  final ret = Uint8List(5 + data.length);
+  ret[0] = kind;
+  ByteData.sublistView(ret).setUint32(1, data.length, Endian.little);
+  ret.setRange(5, ret.length, data);
+  return ret;
+}
+
+/// 把收到的字节拆成帧，一帧可以分在多段字节中，字节结束时还有没读完的帧则抛出错误
+Stream<HbufFrame> hbufReadFrames(Stream<List<int>> input) async* {
+  final pending = BytesBuilder();
+  var need = 5;
+  await for (final chunk in input) {
+    pending.add(chunk);
+    if (pending.length < need) {
+      continue;
+    }
+    final buf = pending.takeBytes();
+    var offset = 0;
+    while (true) {
+      need = 5;
+      if (buf.length - offset < need) {
+        break;
+      }
+      final size = ByteData.sublistView(buf, offset + 1, offset + 5).getUint32(0, Endian.little);
+      if (hbufMaxFrame < size) {
+        throw const FormatException("hbuf: frame too large");
+      }
+      need = 5 + size;
+      if (buf.length - offset < need) {
+        break;
+      }
+      yield HbufFrame(buf[offset], Uint8List.sublistView(buf, offset + 5, offset + need));
+      offset += need;
+    }
+    pending.add(Uint8List.sublistView(buf, offset));
+  }
+  if (pending.isNotEmpty) {
+    throw const FormatException("hbuf: unexpected end of stream");
+  }
+}
+
+/// 数据帧以外的帧，错误帧返回其中的错误
+Exception _hbufFrameException(HbufFrame frame) {
+  if (hbufFrameError == frame.kind) {
+    final map = json.decode(utf8.decode(frame.data));
+    return HbufStreamError(map["code"] ?? 0, map["msg"] ?? "");
+  }
+  return FormatException("hbuf: unexpected frame ${frame.kind}");
+}
+
+/// 错误帧，不是 HbufStreamError 的错误只返回状态码 500
+Uint8List hbufErrorFrame(Object error) {
+  final res = error is HbufStreamError ? error : HbufStreamError(500, "Internal Server Error");
+  return hbufFrame(hbufFrameError, utf8.encode(json.encode({"code": res.code, "msg": res.msg})));
+}
+
+/// 把 data 中的字节分段作为数据帧
+Stream<List<int>> _hbufChunks(Stream<List<int>> data) async* {
+  await for (final chunk in data) {
+    for (var i = 0; i < chunk.length; i += hbufStreamChunk) {
+      final end = i + hbufStreamChunk < chunk.length ? i + hbufStreamChunk : chunk.length;
+      yield hbufFrame(hbufFrameData, chunk.sublist(i, end));
+    }
+  }
+}
+
+/// 分段发送 data 中的全部字节，之后发送结束帧
+Stream<List<int>> hbufSendStream(Stream<List<int>> data) async* {
+  yield* _hbufChunks(data);
+  yield hbufFrame(hbufFrameEnd);
+}
+
+/// 依次读出数据帧中的字节，读到结束帧时结束，读到错误帧时抛出其中的错误
+Stream<List<int>> hbufRecvStream(Stream<HbufFrame> frames) async* {
+  await for (final frame in frames) {
+    if (hbufFrameData == frame.kind) {
+      yield frame.data;
+    } else if (hbufFrameEnd == frame.kind) {
+      return;
+    } else {
+      throw _hbufFrameException(frame);
+    }
+  }
+  throw const FormatException("hbuf: unexpected end of stream");
+}
+
+/// 编码一条消息，binary 为 true 时使用二进制编码，否则使用 JSON，null 编码为空消息帧
+Uint8List hbufEncode(bool binary, Data? v) {
+  if (null == v) {
+    return hbufFrame(hbufFrameNull);
+  }
+  if (binary) {
+    return hbufFrame(hbufFrameData, Uint8List.sublistView(v.toData()));
+  }
+  return hbufFrame(hbufFrameData, utf8.encode(json.encode(v.toMap())));
+}
+
+T hbufDecode<T>(bool binary, Uint8List buf, T Function(Map<String, dynamic>) fromMap, T Function(ByteData) fromData) {
+  if (binary) {
+    return fromData(ByteData.sublistView(buf));
+  }
+  return fromMap(json.decode(utf8.decode(buf)));
+}
+
+/// 发送一条消息
+Stream<List<int>> hbufSend(bool binary, Data? v) {
+  return Stream.value(hbufEncode(binary, v));
+}
+
+/// 读出一条消息，读到错误帧时抛出其中的错误
+Future<T> hbufRecv<T>(Stream<HbufFrame> frames, bool binary, T Function(Map<String, dynamic>) fromMap, T Function(ByteData) fromData) async {
+  await for (final frame in frames) {
+    if (hbufFrameData == frame.kind) {
+      return hbufDecode(binary, frame.data, fromMap, fromData);
+    }
+    if (hbufFrameNull == frame.kind) {
+      throw const FormatException("hbuf: unexpected null message");
+    }
+    throw _hbufFrameException(frame);
+  }
+  throw const FormatException("hbuf: unexpected end of stream");
+}
+
+/// 依次发送 items 中的全部消息，之后发送结束帧
+Stream<List<int>> hbufSendMessages(bool binary, Stream<Data> items) async* {
+  await for (final item in items) {
+    yield hbufEncode(binary, item);
+  }
+  yield hbufFrame(hbufFrameEnd);
+}
+
+/// 逐帧读出 stream<T> 中的消息，读到结束帧时结束，读到错误帧时抛出其中的错误，为 null 的消息会被忽略
+Stream<T> hbufRecvMessages<T>(Stream<HbufFrame> frames, bool binary, T Function(Map<String, dynamic>) fromMap, T Function(ByteData) fromData) async* {
+  await for (final frame in frames) {
+    if (hbufFrameData == frame.kind) {
+      yield hbufDecode(binary, frame.data, fromMap, fromData);
+    } else if (hbufFrameEnd == frame.kind) {
+      return;
+    } else if (hbufFrameNull != frame.kind) {
+      throw _hbufFrameException(frame);
+    }
+  }
+  throw const FormatException("hbuf: unexpected end of stream");
+}
+
+/// 传输 stream 方法的客户端，普通方法仍由 Client 调用，含有 stream 方法的服务的客户端需要传入它
+abstract class StreamClient implements Client {
+  /// 为 true 时按 id 调用并使用二进制编码，否则按 name 调用并使用 JSON 编码
+  bool get binary;
+
+  /// 打开一次调用，边发送 request 中的请求帧边返回收到的结果帧，取消订阅时结束调用
+  Stream<List<int>> stream(String name, int id, Stream<List<int>> request, [Context? ctx]);
+}
+
+/// 客户端的一次 stream 调用，frames 为收到的结果帧
+class HbufStreamCall {
+  final bool binary;
+  final Stream<HbufFrame> frames;
+
+  HbufStreamCall(this.binary, this.frames);
+}
+
+HbufStreamCall hbufCall(StreamClient client, String name, int id, Stream<List<int>> Function(bool binary) send, [Context? ctx]) {
+  final binary = client.binary;
+  return HbufStreamCall(binary, hbufReadFrames(client.stream(name, id, send(binary), ctx)));
+}
+
+/// 读出调用的结果
+Future<T> hbufCallReply<T>(HbufStreamCall call, T Function(Map<String, dynamic>) fromMap, T Function(ByteData) fromData) {
+  return hbufRecv(call.frames, call.binary, fromMap, fromData);
+}
+
+/// 等到没有结果的调用结束
+Future<void> hbufCallEnd(HbufStreamCall call) async {
+  await for (final frame in call.frames) {
+    if (hbufFrameEnd == frame.kind) {
+      return;
+    }
+    throw _hbufFrameException(frame);
+  }
+  throw const FormatException("hbuf: unexpected end of stream");
+}
+
+/// 发送路由中服务返回的结果或错误
+Stream<List<int>> hbufReply(bool binary, Future<Data?> Function() call) async* {
+  Uint8List ret;
+  try {
+    ret = hbufEncode(binary, await call());
+  } catch (e) {
+    ret = hbufErrorFrame(e);
+  }
+  yield ret;
+}
+
+/// 结束路由中没有结果的调用，成功时发送结束帧，否则发送错误帧
+Stream<List<int>> hbufReplyEnd(Future<void> Function() call) async* {
+  Uint8List ret;
+  try {
+    await call();
+    ret = hbufFrame(hbufFrameEnd);
+  } catch (e) {
+    ret = hbufErrorFrame(e);
+  }
+  yield ret;
+}
+
+/// 分段发送路由中服务返回的字节，之后发送结束帧，出错时发送错误帧
+Stream<List<int>> hbufReplyStream(Future<Stream<List<int>>> Function() call) async* {
+  try {
+    await for (final frame in _hbufChunks(await call())) {
+      yield frame;
+    }
+  } catch (e) {
+    yield hbufErrorFrame(e);
+    return;
+  }
+  yield hbufFrame(hbufFrameEnd);
+}
+
+/// 逐条发送路由中服务返回的消息，之后发送结束帧，出错时发送错误帧
+Stream<List<int>> hbufReplyMessages(bool binary, Future<Stream<Data>> Function() call) async* {
+  try {
+    await for (final item in await call()) {
+      yield hbufEncode(binary, item);
+    }
+  } catch (e) {
+    yield hbufErrorFrame(e);
+    return;
+  }
+  yield hbufFrame(hbufFrameEnd);
+}
+
+/// 路由中 stream 方法的调用，从 request 读出请求帧，返回结果帧
+class StreamInvoke {
+  final Stream<List<int>> Function(Context ctx, bool binary, Stream<HbufFrame> request) invoke;
+
+  StreamInvoke(this.invoke);
+}
+
+/// 含有 stream 方法的服务路由
+abstract class StreamRouter {
+  String get name;
+
+  Map<String, StreamInvoke> get streams;
+
+  Map<int, StreamInvoke> get streamIds;
+}
+
+/// 按名称或 Id 分发 stream 方法的调用，名称与 Go 的 StreamServer 相同，为 "/服务名/" 加上路由中的名称
+class StreamServer {
+  final Map<String, StreamInvoke> _names = {};
+  final Map<int, StreamInvoke> _ids = {};
+
+  StreamServer([List<StreamRouter> routers = const []]) {
+    routers.forEach(add);
+  }
+
+  void add(StreamRouter router) {
+    router.streams.forEach((key, value) {
+      _names["/" + router.name + "/" + key] = value;
+    });
+    _ids.addAll(router.streamIds);
+  }
+
+  /// 按名称调用并使用 JSON 编码
+  Stream<List<int>> invoke(Context ctx, String name, Stream<List<int>> request) {
+    final value = _names[name];
+    if (null == value) {
+      return Stream.value(hbufErrorFrame(HbufStreamError(404, "not found")));
+    }
+    return value.invoke(ctx, false, hbufReadFrames(request));
+  }
+
+  /// 按 服务Id<<32 | 方法Id 调用并使用二进制编码
+  Stream<List<int>> invokeId(Context ctx, int id, Stream<List<int>> request) {
+    final value = _ids[id];
+    if (null == value) {
+      return Stream.value(hbufErrorFrame(HbufStreamError(404, "not found")));
+    }
+    return value.invoke(ctx, true, hbufReadFrames(request));
+  }
+}
+`
@@ -71,61 +71,57 @@ func (b *Builder) getUI(tags []*ast.Tag) *ui {
 	if nil != val.KV {
 		for _, item := range val.KV {
 			if "onlyRead" == item.Name.Name {
-				form.onlyRead = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.onlyRead, _ = build.TagBool(item.Values[0])
 			} else if "form" == item.Name.Name {
-				form.form = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.form = build.TagString(item.Values[0])
 			} else if "table" == item.Name.Name {
-				form.table = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.table = build.TagString(item.Values[0])
 			} else if "digit" == item.Name.Name {
-				atoi, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.digit = atoi
 			} else if "index" == item.Name.Name {
-				atoi, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.index = &atoi
 			} else if "format" == item.Name.Name {
-				form.format = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.format = build.TagString(item.Values[0])
 			} else if "width" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.width = atoi
 			} else if "height" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.height = atoi
 			} else if "maxLine" == item.Name.Name {
-				atoi, err := strconv.ParseInt(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10, 64)
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
 					println(err.Error())
 					return nil
 				}
-				form.maxLine = int(atoi)
+				form.maxLine = atoi
 			} else if "maxCount" == item.Name.Name {
-				atoi, err := strconv.ParseInt(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10, 64)
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
 					println(err.Error())
 					return nil
 				}
-				form.maxCount = int(atoi)
+				form.maxCount = atoi
 			} else if "clip" == item.Name.Name {
-				form.clip = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.clip, _ = build.TagBool(item.Values[0])
 
 			} else if "extensions" == item.Name.Name {
 				for _, value := range item.Values {
-					form.extensions = append(form.extensions, value.Value[1:len(value.Value)-1])
+					form.extensions = append(form.extensions, build.TagString(value))
 				}
 			}
 		}
@@ -406,7 +402,7 @@ func (b *Builder) printFormString(dst *build.Writer, name string, expr ast.Expr,
 	case *ast.Ident:
 		t := expr.(*ast.Ident)
 		if nil != t.Obj {
-			b.getPackage(dst, expr, "")
+			dst.Code(b.getPackage(dst, expr, ""))
 			b.printFormString(dst, name, t.Obj.Decl.(*ast.TypeSpec).Type, empty, digit, format)
 		} else {
 			switch build.BaseType(t.Name) {
@@ -693,7 +689,7 @@ func (b *Builder) printForm(dst *build.Writer, typ *ast.DataType, u *ui) {
 				setValue.Tab(2).Code("" + fieldName + ".validator = (val) => verify" + name + "_" + build.StringToHumpName(fieldName) + "(context, val?.toString());\n")
 			}
 			setValue.Tab(2).Code("" + fieldName + ".items = [\n")
-			b.printMenuItem(setValue, field.Type, false)
+			b.printMenuItem(setValue, field.Type, false, "")
 			setValue.Tab(2).Code("];\n\n")
 
 			fields.Tab(3).Code("" + fieldName + ".build(context),\n")
@@ -764,11 +760,11 @@ func (b *Builder) printForm(dst *build.Writer, typ *ast.DataType, u *ui) {
 	dst.ImportByWriter(setValue)
 }
 
-func (b *Builder) printMenuItem(dst *build.Writer, expr ast.Expr, empty bool) {
+func (b *Builder) printMenuItem(dst *build.Writer, expr ast.Expr, empty bool, pkg string) {
 	switch expr.(type) {
 	case *ast.EnumType:
 		t := expr.(*ast.EnumType)
-		name := build.StringToHumpName(t.Name.Name)
+		name := pkg + build.StringToHumpName(t.Name.Name)
 		dst.Tab(3).Code("for (var item in " + name + ".values)\n")
 		dst.Tab(4).Code("DropdownMenuItem<" + name + ">(\n")
 		dst.Tab(5).Code("value: item,\n")
@@ -777,8 +773,7 @@ func (b *Builder) printMenuItem(dst *build.Writer, expr ast.Expr, empty bool) {
 	case *ast.Ident:
 		t := expr.(*ast.Ident)
 		if nil != t.Obj {
-			b.getPackage(dst, expr, "")
-			b.printMenuItem(dst, t.Obj.Decl.(*ast.TypeSpec).Type, empty)
+			b.printMenuItem(dst, t.Obj.Decl.(*ast.TypeSpec).Type, empty, b.getPackage(dst, expr, ""))
 		}
 	case *ast.ArrayType:
 		//ar := expr.(*ast.ArrayType)
@@ -800,6 +795,6 @@ func (b *Builder) printMenuItem(dst *build.Writer, expr ast.Expr, empty bool) {
 		//}
 	case *ast.VarType:
 		t := expr.(*ast.VarType)
-		b.printMenuItem(dst, t.Type(), t.Empty)
+		b.printMenuItem(dst, t.Type(), t.Empty, pkg)
 	}
 }
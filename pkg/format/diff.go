@@ -0,0 +1,129 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const diffContext = 3
+
+// edit 逐行比较的一个操作，kind 为 ' '、'-' 或 '+'
+type edit struct {
+	kind byte
+	text string
+}
+
+// Diff 生成 old 到 new 的统一格式差异，内容相同时返回 nil
+func Diff(name string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+	edits := diffLines(splitLines(old), splitLines(new))
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s.orig\n+++ %s\n", name, name)
+	for i := 0; i < len(edits); {
+		if ' ' == edits[i].kind {
+			i++
+			continue
+		}
+
+		// 向前包含上下文，向后合并间隔不超过两倍上下文的修改
+		start := i
+		for start > 0 && i-start < diffContext && ' ' == edits[start-1].kind {
+			start--
+		}
+		end := i
+		for end < len(edits) {
+			if ' ' != edits[end].kind {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && ' ' == edits[next].kind {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				end += min(diffContext, next-end)
+				break
+			}
+			end = next
+		}
+
+		oldLine, newLine := 1, 1
+		for _, e := range edits[:start] {
+			if '+' != e.kind {
+				oldLine++
+			}
+			if '-' != e.kind {
+				newLine++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, e := range edits[start:end] {
+			if '+' != e.kind {
+				oldCount++
+			}
+			if '-' != e.kind {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, e := range edits[start:end] {
+			buf.WriteByte(e.kind)
+			buf.WriteString(e.text)
+			buf.WriteByte('\n')
+		}
+		i = end
+	}
+	return buf.Bytes()
+}
+
+func splitLines(src []byte) []string {
+	text := strings.TrimSuffix(string(src), "\n")
+	if 0 == len(text) {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffLines 按最长公共子序列生成逐行的修改
+func diffLines(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if a[i] == b[j] {
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		} else if lcs[i+1][j] >= lcs[i][j+1] {
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		} else {
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+	return edits
+}
@@ -1,52 +1,125 @@
 package format
 
 import (
+	"bytes"
 	"fmt"
-	"hbuf/pkg/ast"
-	"hbuf/pkg/parser"
-	"hbuf/pkg/token"
-	"os"
+	"testing"
 )
 
-func ExampleFormat_format() {
-	fset := token.NewFileSet() // positions are relative to fset
+func ExampleSource() {
 	src := []byte("" +
-		"package \"parser\" \n" +
-		"//引用parser.go \n" +
-		"import \"/home/yttx_heqian/develop/go/hbuf/pkg/parser/parser.go\" \n" +
-		"//引用11.go \n" +
-		"import \"/home/yttx_heqian/develop/go/hbuf/pkg/parser/11.go\" \n" +
-		"data NAME : Na,Nb { \n" +
-		"  String Type = 16 `json\"name\"` //姓名\n" +
-		"  String[] Info = 0 \n" +
-		"  String<int> other = 0 `json\"map\"` //姓名\n" +
-		"} \n" +
-		"\n " +
-		"data Type : Type{ \n" +
-		" int age = 15 `pr:id,json\"age\"` \n" +
-		"} \n" +
+		"package go=\"parser\" //包名\n" +
+		"import \"./base.hbuf\"\n" +
+		"enum Status{\n" +
+		"  Enable=0 //启用\n" +
+		"  Disable = 1\n" +
+		"}\n" +
+		"//信息\n" +
+		"[db:table=\"info\";key=\"id\",\"name\"]\n" +
+		"data Info:Base=1 {\n" +
+		"  int8 min = 0 \"json\" //最小\n" +
+		"  Status<string>?   states=12\n" +
 		"\n" +
-		"server GetName{ \n" +
-		"   String name(int Id) \n" +
-		"} \n" +
 		"\n" +
-		"server GetAge : GetName{ \n" +
-		"   Int age(int Id = 1,String key = 2) \n" +
-		"} \n" +
-		"enum Type{ \n" +
-		"   New" +
-		"   Old" +
-		"} \n" +
-		"")
-
-	// Parse src but stop after processing the imports.
-	f, err := parser.ParseFile(fset, "", src, parser.AllErrors)
+		"  Base?[]? items=11\n" +
+		"} = 2\n" +
+		"server InfoServer = 1 {\n" +
+		"  Info getInfo(Base req) = 0\n" +
+		"}\n",
+	)
+
+	res, err := Source(src)
 	if err != nil {
 		fmt.Println(err)
 		return
 	}
+	fmt.Print(string(res))
+
+	// Output:
+	// package go = "parser" //包名
+	//
+	// import "./base.hbuf"
+	//
+	// enum Status {
+	//     Enable  = 0 //启用
+	//     Disable = 1
+	// }
+	//
+	// //信息
+	// [db:table="info";key="id","name"]
+	// data Info : Base = 1 = 2 {
+	//     int8            min    = 0  "json" //最小
+	//     Status<string>? states = 12
+	//
+	//     Base?[]? items = 11
+	// }
+	//
+	// server InfoServer = 1 {
+	//     Info getInfo(Base req) = 0
+	// }
+}
+
+func TestSourceIdempotent(t *testing.T) {
+	src := []byte("" +
+		"// 文件说明\n" +
+		"package go = \"parser\"\n" +
+		"package java=\"com.parser\"\n" +
+		"import \"./common.hbuf\"  as  common\n" +
+		"// 最大页数\n" +
+		"const int32 MaxPage=100\n" +
+		"const string PhoneReg = \"^1\\\\d{10}$\" // 手机号\n" +
+		"\n" +
+		"\n" +
+		"data Base:common.Page=0 { /* 基础 */\n" +
+		"  // 编号\n" +
+		"  [ui:width=200.5;onlyRead=true;digit=2,3]\n" +
+		"  [verify:format=Status.Enable]\n" +
+		"  int64 id=0\n" +
+		"  int32 page=5= -1 \"json\"\n" +
+		"  string?[]<int32>?[] groups=8\n" +
+		"  common.Status<common.Status>? states=9\n" +
+		"  reserved 1,2 to 4 ,\"name\" // 已删除\n" +
+		"  // 内容\n" +
+		"  oneof payload{\n" +
+		"  Base base=6 // 基础\n" +
+		"  string text=7}\n" +
+		"\n" +
+		"  // 结束\n" +
+		"}\n" +
+		"server Chat {\n" +
+		"  stream<common.Page>  List(Base req)=0\n" +
+		"  stream<Base> Send( stream<Base> req )=1\n" +
+		"}\n" +
+		"// 结尾\n",
+	)
+
+	first, err := Source(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Source(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Errorf("format is not idempotent:\n%s\n---\n%s", first, second)
+	}
+	if !bytes.Contains(first, []byte("Send(stream<Base> req)")) {
+		t.Errorf("stream type not formatted:\n%s", first)
+	}
+}
+
+func ExampleDiff() {
+	old := []byte("data A {\n  int32 a=0\n}\n")
+	res, _ := Source(old)
+	fmt.Print(string(Diff("a.hbuf", old, res)))
 
-	ast.SortImports(fset, f)
-	Node(os.Stdout, f)
-	// output:
+	// Output:
+	// --- a.hbuf.orig
+	// +++ a.hbuf
+	// @@ -1,3 +1,3 @@
+	//  data A {
+	// -  int32 a=0
+	// +    int32 a = 0
+	//  }
 }
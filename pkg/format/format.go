@@ -1,136 +1,412 @@
 package format
 
 import (
-	"go/printer"
+	"bytes"
 	"hbuf/pkg/ast"
+	"hbuf/pkg/parser"
+	"hbuf/pkg/token"
 	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
 )
 
-func Node(dst io.Writer, node interface{}) error {
-	var file *ast.File
-	switch n := node.(type) {
-	case *ast.File:
-		file = n
-	case *printer.CommentedNode:
-		if f, ok := n.Node.(*ast.File); ok {
-			file = f
-			//cnode = n
-		}
+// Source 格式化 hbuf 源码，返回格式化后的内容
+func Source(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = Node(&buf, fset, file)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Node 按规范格式输出文件，file 需要使用 parser.ParseComments 解析才能保留注释
+func Node(dst io.Writer, fset *token.FileSet, file *ast.File) error {
+	p := &printer{
+		fset:     fset,
+		comments: file.Comments,
 	}
+	p.printFile(file)
+	_, err := dst.Write(p.render())
+	return err
+}
+
+const indent = "    "
+
+// line 输出的一行，cells 不为空时与相邻的行按列对齐
+type line struct {
+	indent int
+	text   string
+	cells  []string
+	blank  bool
+}
 
-	dst.Write([]byte("package " + file.Package.Value.Value + "\n\n"))
-	for _, s := range file.Imports {
-		printImport(dst, s)
+type printer struct {
+	fset     *token.FileSet
+	comments []*ast.CommentGroup
+	index    int // 下一个未输出的注释
+
+	lines   []*line
+	indent  int
+	last    int  // 最后输出内容在源码中的行号
+	sep     bool // 下一行之前强制空一行
+	noBlank bool // 下一行之前不允许空行
+}
+
+func (p *printer) lineOf(pos token.Pos) int {
+	return p.fset.Position(pos).Line
+}
+
+func (p *printer) printFile(file *ast.File) {
+	packages := make([]*ast.PackageSpec, 0, len(file.Packages))
+	for _, spec := range file.Packages {
+		packages = append(packages, spec)
 	}
-	dst.Write([]byte("\n"))
-	for _, s := range file.Specs {
-		switch s.(type) {
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Value.Pos() < packages[j].Value.Pos()
+	})
+	for _, spec := range packages {
+		p.lead(spec.Value.Pos())
+		p.text("package "+spec.Name.Name+" = "+spec.Value.Value, spec.Value.Pos(), spec.Value.Pos())
+	}
+
+	p.sep = true
+	for i, s := range file.Specs {
+		switch spec := s.(type) {
 		case *ast.ImportSpec:
+			p.lead(spec.Path.Pos())
+			if nil != spec.Name {
+				p.text("import "+spec.Path.Value+" as "+spec.Name.Name, spec.Path.Pos(), spec.Name.Pos())
+			} else {
+				p.text("import "+spec.Path.Value, spec.Path.Pos(), spec.Path.Pos())
+			}
+		case *ast.ConstSpec:
+			// 连续的常量作为一组对齐输出
+			if 0 == i {
+				p.sep = true
+			} else if _, ok := file.Specs[i-1].(*ast.ConstSpec); !ok {
+				p.sep = true
+			}
+			p.lead(spec.Pos())
+			p.row([]string{"const " + spec.Type.Name, spec.Name.Name, "= " + spec.Value.Value}, spec.Pos(), spec.Value.Pos())
 		case *ast.TypeSpec:
-			printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
+			p.sep = true
+			switch typ := spec.Type.(type) {
+			case *ast.DataType:
+				p.printData(typ)
+			case *ast.ServerType:
+				p.printServer(typ)
+			case *ast.EnumType:
+				p.printEnum(typ)
+			}
 		}
 	}
-	return nil
+
+	p.lead(token.NoPos)
+}
+
+func (p *printer) printData(typ *ast.DataType) {
+	p.printTags(typ.Tags)
+	p.lead(typ.Data)
+	p.text("data "+typ.Name.Name+extendsString(typ.Extends)+idString(typ.Id)+" {", typ.Data, typ.Fields.Opening)
+
+	p.open()
+	for _, node := range byPos(byPos(typ.Fields.List, typ.Reserved), typ.OneOfs) {
+		switch item := node.(type) {
+		case *ast.Field:
+			p.printField(item)
+		case *ast.Reserved:
+			p.printReserved(item)
+		case *ast.OneOf:
+			p.printOneOf(item)
+		}
+	}
+	p.close(typ.Fields.Closing)
+}
+
+func (p *printer) printField(field *ast.Field) {
+	p.printTags(field.Tags)
+	end := field.Id.Pos()
+	cells := []string{typeString(field.Type), field.Name.Name, "= " + field.Id.Value, "", ""}
+	if nil != field.Default {
+		end = field.Default.Pos()
+		cells[3] = "= " + field.Default.Value
+	}
+	if nil != field.Tag {
+		end = field.Tag.Pos()
+		cells[4] = field.Tag.Value
+	}
+	p.lead(field.Type.Pos())
+	p.row(cells, field.Type.Pos(), end)
 }
 
-func printTypeSpec(dst io.Writer, expr ast.Expr) {
-	switch expr.(type) {
-	case *ast.DataType:
-		printData(dst, expr.(*ast.DataType))
-	case *ast.ServerType:
-		printServer(dst, expr.(*ast.ServerType))
-	case *ast.EnumType:
-		printEnum(dst, expr.(*ast.EnumType))
+func (p *printer) printOneOf(oneOf *ast.OneOf) {
+	p.lead(oneOf.OneOf)
+	p.text("oneof "+oneOf.Name.Name+" {", oneOf.OneOf, oneOf.Opening)
+	p.open()
+	for _, field := range oneOf.Fields {
+		p.printField(field)
+	}
+	p.close(oneOf.Closing)
+}
+
+func (p *printer) printServer(typ *ast.ServerType) {
+	p.printTags(typ.Tags)
+	p.lead(typ.Server)
+	p.text("server "+typ.Name.Name+extendsString(typ.Extends)+idString(typ.Id)+" {", typ.Server, typ.Opening)
 
+	p.open()
+	for _, method := range typ.Methods {
+		p.printTags(method.Tags)
+		p.lead(method.Result.Pos())
+		call := method.Name.Name + "(" + typeString(method.Param) + " " + method.ParamName.Name + ")"
+		p.row([]string{typeString(method.Result), call, "= " + method.Id.Value}, method.Result.Pos(), method.Id.Pos())
 	}
+	p.close(typ.Closing)
 }
 
-func printEnum(dst io.Writer, typ *ast.EnumType) {
-	dst.Write([]byte("enum " + typ.Name.Name))
-	dst.Write([]byte("{\n"))
-	for _, item := range typ.Items {
-		dst.Write([]byte("    " + item.Name + "\n"))
+func (p *printer) printEnum(typ *ast.EnumType) {
+	p.printTags(typ.Tags)
+	p.lead(typ.Name.Pos())
+	p.text("enum "+typ.Name.Name+" {", typ.Name.Pos(), typ.Opening)
+
+	p.open()
+	for _, node := range byPos(typ.Items, typ.Reserved) {
+		item, ok := node.(*ast.EnumItem)
+		if !ok {
+			p.printReserved(node.(*ast.Reserved))
+			continue
+		}
+		p.printTags(item.Tags)
+		p.lead(item.Name.Pos())
+		p.row([]string{item.Name.Name, "= " + item.Id.Value}, item.Name.Pos(), item.Id.Pos())
 	}
-	dst.Write([]byte("}\n\n"))
+	p.close(typ.Closing)
 }
 
-func printServer(dst io.Writer, typ *ast.ServerType) {
-	dst.Write([]byte("server " + typ.Name.Name))
-	if nil != typ.Extends {
-		printExtend(dst, typ.Extends)
+func (p *printer) printTags(tags []*ast.Tag) {
+	for _, tag := range tags {
+		p.lead(tag.Opening)
+		p.text(tagString(tag), tag.Opening, tag.Closing)
 	}
-	dst.Write([]byte("{\n"))
-	for _, field := range typ.Methods.List {
-		dst.Write([]byte("    "))
-		var fun = field.Type.(*ast.FuncType)
-		printType(dst, *fun.Result)
-		dst.Write([]byte(" " + field.Name.Name))
-		dst.Write([]byte("("))
-		for i, field := range fun.Param.List {
-			if 0 != i {
-				dst.Write([]byte(", "))
+}
+
+func (p *printer) printReserved(reserved *ast.Reserved) {
+	list := make([]string, 0, len(reserved.Ranges)+len(reserved.Names))
+	for _, node := range byPos(reserved.Ranges, reserved.Names) {
+		switch item := node.(type) {
+		case *ast.ReservedRange:
+			if nil == item.To {
+				list = append(list, item.From.Value)
+			} else {
+				list = append(list, item.From.Value+" to "+item.To.Value)
 			}
-			printType(dst, field.Type)
-			dst.Write([]byte(" " + field.Name.Name))
-			if nil != field.Id {
-				dst.Write([]byte(" = " + field.Id.Value))
+		case *ast.BasicLit:
+			list = append(list, item.Value)
+		}
+	}
+	p.lead(reserved.Pos())
+	p.text("reserved "+strings.Join(list, ", "), reserved.Pos(), reserved.End())
+}
+
+// byPos 将两类节点按源码位置合并
+func byPos[A ast.Node, B ast.Node](a []A, b []B) []ast.Node {
+	list := make([]ast.Node, 0, len(a)+len(b))
+	for _, item := range a {
+		list = append(list, item)
+	}
+	for _, item := range b {
+		list = append(list, item)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Pos() < list[j].Pos()
+	})
+	return list
+}
+
+// open 进入 "{" 之后的代码块
+func (p *printer) open() {
+	p.indent++
+	p.noBlank = true
+}
+
+// close 输出代码块内剩余的注释和 "}"
+func (p *printer) close(closing token.Pos) {
+	p.lead(closing)
+	p.indent--
+	p.noBlank = true
+	p.text("}", closing, closing)
+}
+
+// lead 输出位于 pos 之前的所有注释，pos 无效时输出剩余的全部注释
+func (p *printer) lead(pos token.Pos) {
+	for p.index < len(p.comments) {
+		group := p.comments[p.index]
+		if pos.IsValid() && group.End() > pos {
+			return
+		}
+		p.index++
+		for _, comment := range group.List {
+			p.text(comment.Text, comment.Pos(), comment.End())
+		}
+	}
+}
+
+// trailing 取出与 pos 同一行的行尾注释
+func (p *printer) trailing(pos token.Pos) string {
+	if p.index >= len(p.comments) {
+		return ""
+	}
+	group := p.comments[p.index]
+	if p.lineOf(group.Pos()) != p.lineOf(pos) {
+		return ""
+	}
+	p.index++
+	list := make([]string, len(group.List))
+	for i, comment := range group.List {
+		list[i] = comment.Text
+	}
+	p.last = p.lineOf(group.End())
+	return strings.Join(list, " ")
+}
+
+// add 追加一行，并根据源码中的空行决定是否先输出一个空行
+func (p *printer) add(l *line, pos token.Pos) {
+	if 0 < len(p.lines) && !p.noBlank && (p.sep || p.lineOf(pos) > p.last+1) {
+		p.lines = append(p.lines, &line{blank: true})
+	}
+	p.sep = false
+	p.noBlank = false
+	p.lines = append(p.lines, l)
+}
+
+func (p *printer) text(text string, pos token.Pos, end token.Pos) {
+	p.add(&line{indent: p.indent, text: text}, pos)
+	p.last = p.lineOf(end)
+	if comment := p.trailing(end); 0 < len(comment) {
+		p.lines[len(p.lines)-1].text += " " + comment
+	}
+}
+
+func (p *printer) row(cells []string, pos token.Pos, end token.Pos) {
+	p.add(&line{indent: p.indent, cells: cells}, pos)
+	p.last = p.lineOf(end)
+	cells = append(cells, p.trailing(end))
+	p.lines[len(p.lines)-1].cells = cells
+}
+
+// render 对齐相邻行的各列并生成最终文本
+func (p *printer) render() []byte {
+	var buf bytes.Buffer
+	for i := 0; i < len(p.lines); {
+		l := p.lines[i]
+		if nil == l.cells {
+			if !l.blank {
+				buf.WriteString(strings.Repeat(indent, l.indent))
+				buf.WriteString(l.text)
 			}
-			if nil != field.Tag {
-				dst.Write([]byte(" " + field.Tag.Value))
+			buf.WriteByte('\n')
+			i++
+			continue
+		}
+
+		j := i + 1
+		for j < len(p.lines) && nil != p.lines[j].cells && len(p.lines[j].cells) == len(l.cells) {
+			j++
+		}
+		widths := make([]int, len(l.cells))
+		for _, item := range p.lines[i:j] {
+			for k, cell := range item.cells {
+				if n := utf8.RuneCountInString(cell); n > widths[k] {
+					widths[k] = n
+				}
 			}
 		}
-		dst.Write([]byte(")"))
-		dst.Write([]byte("\n"))
+		for _, item := range p.lines[i:j] {
+			var text strings.Builder
+			for k, cell := range item.cells {
+				if 0 == widths[k] {
+					continue
+				}
+				if 0 < text.Len() {
+					text.WriteByte(' ')
+				}
+				text.WriteString(cell)
+				text.WriteString(strings.Repeat(" ", widths[k]-utf8.RuneCountInString(cell)))
+			}
+			buf.WriteString(strings.Repeat(indent, item.indent))
+			buf.WriteString(strings.TrimRight(text.String(), " "))
+			buf.WriteByte('\n')
+		}
+		i = j
 	}
-	dst.Write([]byte("}\n\n"))
+	return buf.Bytes()
 }
 
-func printData(dst io.Writer, typ *ast.DataType) {
-	dst.Write([]byte("data " + typ.Name.Name))
-	if nil != typ.Extends {
-		printExtend(dst, typ.Extends)
+func extendsString(extends []*ast.Extends) string {
+	if 0 == len(extends) {
+		return ""
 	}
-	dst.Write([]byte("{\n"))
-	for _, field := range typ.Fields.List {
-		dst.Write([]byte("    "))
-		printType(dst, field.Type)
-		dst.Write([]byte(" " + field.Name.Name))
-		if nil != field.Id {
-			dst.Write([]byte(" = " + field.Id.Value))
-		}
-		if nil != field.Tag {
-			dst.Write([]byte(" " + field.Tag.Value))
+	list := make([]string, len(extends))
+	for i, extend := range extends {
+		list[i] = extend.Name.Name + idString(extend.Id)
+		if nil != extend.X {
+			list[i] = extend.X.Name + "." + list[i]
 		}
-		dst.Write([]byte("\n"))
 	}
-	dst.Write([]byte("}\n\n"))
+	return " : " + strings.Join(list, ", ")
 }
 
-func printExtend(dst io.Writer, extends []*ast.Ident) {
-	dst.Write([]byte(": "))
-	for i, v := range extends {
-		if 0 != i {
-			dst.Write([]byte(", "))
+func idString(id *ast.BasicLit) string {
+	if nil == id {
+		return ""
+	}
+	return " = " + id.Value
+}
+
+func tagString(tag *ast.Tag) string {
+	list := make([]string, 0, len(tag.KV))
+	for _, kv := range tag.KV {
+		if nil == kv {
+			continue
+		}
+		values := make([]string, len(kv.Values))
+		for i, value := range kv.Values {
+			values[i] = value.Value
 		}
-		dst.Write([]byte(v.Name))
+		list = append(list, kv.Name.Name+"="+strings.Join(values, ","))
 	}
+	return "[" + tag.Name.Name + ":" + strings.Join(list, ";") + "]"
 }
 
-func printType(dst io.Writer, expr ast.Expr) {
-	switch expr.(type) {
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
 	case *ast.Ident:
-		dst.Write([]byte((expr.(*ast.Ident)).Name))
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.X.Name + "." + t.Sel.Name
+	case *ast.StreamType:
+		return "stream<" + typeString(t.Item) + ">"
+	case *ast.VarType:
+		return typeString(t.TypeExpr) + nullString(t.Empty)
 	case *ast.ArrayType:
-		dst.Write([]byte(((expr.(*ast.ArrayType)).Elt.(*ast.Ident)).Name + "[]"))
+		return typeString(t.VType) + "[]" + nullString(t.Empty)
 	case *ast.MapType:
-		ma := expr.(*ast.MapType)
-		dst.Write([]byte((ma.Value.(*ast.Ident)).Name))
-		if nil != ma.Key {
-			dst.Write([]byte("<" + (ma.Key.(*ast.Ident)).Name + ">"))
-		}
+		return typeString(t.VType) + "<" + typeString(t.Key) + ">" + nullString(t.Empty)
 	}
+	return ""
 }
 
-func printImport(dst io.Writer, spec *ast.ImportSpec) {
-	dst.Write([]byte("import " + spec.Path.Value + "\n"))
+func nullString(empty bool) string {
+	if empty {
+		return "?"
+	}
+	return ""
 }
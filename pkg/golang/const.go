@@ -0,0 +1,20 @@
+package golang
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+var _constTypes = map[build.BaseType]string{
+	build.Int8: "int8", build.Int16: "int16", build.Int32: "int32", build.Int64: "int64", build.Uint8: "uint8",
+	build.Uint16: "uint16", build.Uint32: "uint32", build.Uint64: "uint64", build.Bool: "bool", build.Float: "float32",
+	build.Double: "float64", build.String: "string",
+}
+
+func printConstCode(dst *build.Writer, spec *ast.ConstSpec) {
+	name := build.StringToHumpName(spec.Name.Name)
+	if nil != spec.Doc && 0 < len(spec.Doc.Text()) {
+		dst.Code("// " + name + " " + spec.Doc.Text())
+	}
+	dst.Code("const " + name + " " + _constTypes[build.BaseType(spec.Type.Name)] + " = " + build.ConstValue(spec) + "\n\n")
+}
@@ -33,10 +33,15 @@ func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 		b.printType(temp, field.Type, true)
 		dst.AddImports(temp.GetImports())
 
+		// 有默认值的字段总是写入，否则对方会把零值和 null 读成默认值
+		omitempty := ",omitempty"
+		if nil != field.Default {
+			omitempty = ""
+		}
 		fields[i] = dataField{
 			name: build.StringToHumpName(field.Name.Name),
 			typ:  temp.String(),
-			tag:  "`json:\"" + build.StringToUnderlineName(field.Name.Name) + ",omitempty\"`",
+			tag:  "`json:\"" + build.StringToUnderlineName(field.Name.Name) + omitempty + "\"`",
 		}
 
 		if nil != field.Doc && 0 < len(field.Doc.Text()) {
@@ -57,6 +62,27 @@ func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 		}
 	}
 
+	for _, oneOf := range typ.OneOfs {
+		field := dataField{
+			name: build.StringToHumpName(oneOf.Name.Name),
+			typ:  oneOfName(typ, oneOf),
+			tag:  "`json:\"" + build.StringToUnderlineName(oneOf.Name.Name) + ",omitempty\"`",
+		}
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			field.comment = oneOf.Doc.Text()
+		}
+		if len(field.name) > nameLen {
+			nameLen = len(field.name)
+		}
+		if len(field.typ) > typLen {
+			typLen = len(field.typ)
+		}
+		if len(field.tag) > tagLen {
+			tagLen = len(field.tag)
+		}
+		fields = append(fields, field)
+	}
+
 	isFast := true
 	b.printDataExtend(dst, typ.Extends, &isFast)
 	for _, field := range fields {
@@ -84,13 +110,26 @@ func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 		b.printType(dst, field.Type, false)
 		dst.Code(" {\n")
 		if field.Type.IsEmpty() && !build.IsArray(field.Type) && !build.IsMap(field.Type) {
-			dst.Tab(1).Code("if nil == g." + build.StringToHumpName(field.Name.Name) + " {\n")
-			dst.Tab(2).Code("return ")
-			b.printDefault(dst, field.Type)
+			if nil != field.Default {
+				dst.Tab(1).Code("if nil == g || nil == g." + build.StringToHumpName(field.Name.Name) + " {\n")
+				dst.Tab(2).Code("return ")
+				b.printFieldDefault(dst, field)
+			} else {
+				dst.Tab(1).Code("if nil == g." + build.StringToHumpName(field.Name.Name) + " {\n")
+				dst.Tab(2).Code("return ")
+				b.printDefault(dst, field.Type)
+			}
 			dst.Code("\n")
 			dst.Tab(1).Code("}\n")
 			dst.Tab(1).Code("return *g." + build.StringToHumpName(field.Name.Name) + "\n")
 		} else {
+			if nil != field.Default {
+				dst.Tab(1).Code("if nil == g {\n")
+				dst.Tab(2).Code("return ")
+				b.printFieldDefault(dst, field)
+				dst.Code("\n")
+				dst.Tab(1).Code("}\n")
+			}
 			dst.Tab(1).Code("return g." + build.StringToHumpName(field.Name.Name) + "\n")
 		}
 		dst.Code("}\n\n")
@@ -109,6 +148,17 @@ func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 		}
 		dst.Code("}\n\n")
 	}
+
+	for _, oneOf := range typ.OneOfs {
+		dst.Code("func (g *" + build.StringToHumpName(typ.Name.Name) + ") Get" + build.StringToHumpName(oneOf.Name.Name) + "() " + oneOfName(typ, oneOf) + " {\n")
+		dst.Tab(1).Code("return g." + build.StringToHumpName(oneOf.Name.Name) + "\n")
+		dst.Code("}\n\n")
+
+		dst.Code("func (g *" + build.StringToHumpName(typ.Name.Name) + ") Set" + build.StringToHumpName(oneOf.Name.Name) + "(val " + oneOfName(typ, oneOf) + ") {\n")
+		dst.Tab(1).Code("g." + build.StringToHumpName(oneOf.Name.Name) + " = val\n")
+		dst.Code("}\n\n")
+	}
+	b.printOneOfCode(dst, typ)
 }
 
 func (b *Builder) printDefault(dst *build.Writer, expr ast.Expr) {
@@ -129,6 +179,10 @@ func (b *Builder) printDefault(dst *build.Writer, expr ast.Expr) {
 				dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
 			} else if build.Decimal == t {
 				dst.Import("github.com/shopspring/decimal", "")
+			} else if build.Duration == t {
+				dst.Import("time", "")
+			} else if build.Json == t {
+				dst.Import("encoding/json", "")
 			}
 			if val, ok := _typesDefaultValue[t]; ok {
 				dst.Code(val)
@@ -154,6 +208,21 @@ func (b *Builder) printDefault(dst *build.Writer, expr ast.Expr) {
 	}
 }
 
+// printFieldDefault 输出字段的默认值，默认值已在检查时统一写法
+func (b *Builder) printFieldDefault(dst *build.Writer, field *ast.Field) {
+	ident := field.Type.Type().(*ast.Ident)
+	if nil != ident.Obj {
+		dst.Code(b.getPackage(dst, ident) + build.StringToHumpName(ident.Name) + build.StringToHumpName(field.Default.Value))
+		return
+	}
+	if build.Decimal == build.BaseType(ident.Name) {
+		dst.Import("github.com/shopspring/decimal", "")
+		dst.Code("decimal.RequireFromString(\"" + field.Default.Value + "\")")
+		return
+	}
+	dst.Code(field.Default.Value)
+}
+
 func (b *Builder) printDataExtend(dst *build.Writer, extends []*ast.Extends, isFast *bool) {
 	for _, v := range extends {
 		if !*isFast {
@@ -27,16 +27,14 @@ func getCache(name string, tags []*ast.Tag) *cache {
 	if nil != val.KV {
 		for _, item := range val.KV {
 			if "min" == item.Name.Name {
-				val, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				val, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				c.min = val
 			} else if "max" == item.Name.Name {
-				val, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				val, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				c.max = val
@@ -303,7 +301,7 @@ func (b *Builder) getParamWhere(dst *build.Writer, fields []*build.DBField, page
 				}
 				where.Tab(1).Code(strconv.Itoa(i) + " < len(g." + fieldName + ") ")
 				array := field.Field.Type.(*ast.ArrayType)
-				if array.VType.Empty {
+				if build.IsNil(array.VType) {
 					where.Code("&& nil != g." + fieldName + "[" + strconv.Itoa(i) + "] ")
 				}
 				where.Code("{\n")
@@ -464,7 +462,7 @@ func (b *Builder) printListData(dst *build.Writer, typ *ast.DataType, key string
 
 	item, scan, _ := b.getItemAndValue(fields, key)
 	dst.Code("func (g " + fName + ") DbList(ctx context.Context) ([]" + dName + ", error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("s := db.NewSql()\n")
 	dst.Tab(1).Code("s.T(\"SELECT " + item.String() + " FROM \").T(tableName)")
 	if db.Fake {
@@ -525,7 +523,7 @@ func (b *Builder) printListDataAsync(dst *build.Writer, typ *ast.DataType, key s
 
 	item, scan, _ := b.getItemAndValue(fields, key)
 	dst.Code("func (g " + fName + ") DbListAsync(ctx context.Context, call func(ctx context.Context, val *" + dName + ") error) (error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("s := db.NewSql()\n")
 	dst.Tab(1).Code("s.T(\"SELECT " + item.String() + " FROM \").T(tableName)")
 	if db.Fake {
@@ -575,7 +573,7 @@ func (b *Builder) printMapData(dst *build.Writer, key string, typ *ast.DataType,
 
 	item, scan, _ := b.getItemAndValue(fields, key)
 	dst.Code("func (g " + fName + ") DbMap(ctx context.Context) (map[" + kType.String() + "]" + dName + ", error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("s := db.NewSql()\n")
 	dst.Tab(1).Code("s.T(\"SELECT " + item.String() + " FROM \").T(tableName)")
 	if db.Fake {
@@ -630,7 +628,7 @@ func (b *Builder) printCountData(dst *build.Writer, typ *ast.DataType, db *build
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbCount(ctx context.Context) (int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("s := db.NewSql()\n")
 	dst.Tab(1).Code("s.T(\"SELECT COUNT(1) FROM \").T(tableName)")
 	if db.Fake {
@@ -679,7 +677,7 @@ func (b *Builder) printDeleteData(dst *build.Writer, db *build.DB, wFields []*bu
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbDel(ctx context.Context) (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	if isCache {
 		dst.Tab(1).Code("err := cache.DbDel(ctx, tableName)\n")
 		dst.Tab(1).Code("if err != nil {\n")
@@ -707,7 +705,7 @@ func (b *Builder) printRemoveData(dst *build.Writer, db *build.DB, wFields []*bu
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbRemove(ctx context.Context) (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	if isCache {
 		dst.Tab(1).Code("err := cache.DbDel(ctx, tableName)\n")
 		dst.Tab(1).Code("if err != nil {\n")
@@ -733,7 +731,7 @@ func (b *Builder) printInsertData(dst *build.Writer, typ *ast.DataType, val stri
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbInsert(ctx context.Context) (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	if nil != c {
 		dst.Tab(1).Code("err := cache.DbDel(ctx, tableName)\n")
 		dst.Tab(1).Code("if err != nil {\n")
@@ -755,7 +753,7 @@ func (b *Builder) printInsertData(dst *build.Writer, typ *ast.DataType, val stri
 func (b *Builder) printInsertListData(dst *build.Writer, typ *ast.DataType, db *build.DB, fields []*build.DBField, key *build.DBField, isCache bool) {
 	name := build.StringToHumpName(typ.Name.Name)
 	dst.Code("func (g " + name + ") DbInsertList(ctx context.Context, values []*" + name + ") (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("if nil == values || 0 == len(values) {\n")
 	dst.Tab(2).Code("return 0, 0, nil\n")
 	dst.Tab(1).Code("}\n")
@@ -806,7 +804,7 @@ func (b *Builder) printUpdateData(dst *build.Writer, typ *ast.DataType, key stri
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbUpdate(ctx context.Context) (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	if nil != c {
 		dst.Tab(1).Code("err := cache.DbDel(ctx, tableName)\n")
 		dst.Tab(1).Code("if err != nil {\n")
@@ -838,7 +836,7 @@ func (b *Builder) printSetData(dst *build.Writer, typ *ast.DataType, key string,
 	dst.AddImports(w.GetImports())
 
 	dst.Code("func (g " + fName + ") DbSet(ctx context.Context) (int64, int64, error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	if nil != c {
 		dst.Tab(1).Code("err := cache.DbDel(ctx, tableName)\n")
 		dst.Tab(1).Code("if err != nil {\n")
@@ -908,7 +906,7 @@ func (b *Builder) printGetData(dst *build.Writer, typ *ast.DataType, key string,
 	item, scan, _ := b.getItemAndValue(fields, key)
 
 	dst.Code("func (g " + fName + ") DbGet(ctx context.Context) (*" + dName + ", error) {\n")
-	dst.Tab(1).Code("tableName := db.GET(ctx).Table(\"").Code(b.GetTableName(db)).Code("\")\n")
+	dst.Tab(1).Code("tableName := hbufDbTable(ctx, \"").Code(b.GetTableName(db)).Code("\")\n")
 	dst.Tab(1).Code("s := db.NewSql()\n")
 	dst.Tab(1).Code("s.T(\"SELECT " + item.String() + " FROM \").T(tableName)")
 	if db.Fake {
@@ -951,3 +949,66 @@ func (b *Builder) printGetData(dst *build.Writer, typ *ast.DataType, key string,
 	dst.Code("}\n\n")
 
 }
+
+// printDatabaseCommon 生成同一个包内数据库读写共用的代码
+func printDatabaseCommon(dst *build.Writer) {
+	dst.Import("context", "")
+	dst.Import("database/sql/driver", "")
+	dst.Import("encoding/json", "")
+	dst.Import("fmt", "")
+	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/db", "")
+	dst.Code(_databaseCode + "\n")
+}
+
+const _databaseCode = `// hbufDbTable 返回实际的表名，数据库连接提供 Table 方法（如添加表名前缀）时由它决定
+func hbufDbTable(ctx context.Context, name string) string {
+	if t, ok := db.GET(ctx).(interface{ Table(name string) string }); ok {
+		return t.Table(name)
+	}
+	return name
+}
+
+// hbufDbJson 读写 json 类型的字段，数据库的 NULL 对应 nil，驱动返回字符串或 []byte 时都原样保存
+type hbufDbJson struct {
+	data any
+}
+
+func (j hbufDbJson) Scan(value any) error {
+	var raw json.RawMessage
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		raw = append(json.RawMessage{}, v...)
+	case string:
+		raw = json.RawMessage(v)
+	default:
+		return fmt.Errorf("hbuf: cannot scan %T into json", value)
+	}
+	switch d := j.data.(type) {
+	case *json.RawMessage:
+		*d = raw
+	case **json.RawMessage:
+		*d = nil
+		if nil != raw {
+			*d = &raw
+		}
+	}
+	return nil
+}
+
+func (j hbufDbJson) Value() (driver.Value, error) {
+	var raw json.RawMessage
+	switch d := j.data.(type) {
+	case *json.RawMessage:
+		raw = *d
+	case **json.RawMessage:
+		if nil != *d {
+			raw = **d
+		}
+	}
+	if 0 == len(raw) {
+		return nil, nil
+	}
+	return string(raw), nil
+}
+`
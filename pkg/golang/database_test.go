@@ -0,0 +1,36 @@
+package golang
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestDatabaseScalarType bytes、duration、uuid 直接读写，json 通过 hbufDbJson 读写，int 按 int32 生成
+func TestDatabaseScalarType(t *testing.T) {
+	src := "" +
+		"package go = \"parser\"\n" +
+		"\n" +
+		"[db:name=\"attachment\"]\n" +
+		"data Attachment = 1 {\n" +
+		"    [db:name=\"body\"]\n" +
+		"    bytes body = 0\n" +
+		"    [db:name=\"timeout\"]\n" +
+		"    duration? timeout = 1\n" +
+		"    [db:name=\"ref\"]\n" +
+		"    uuid ref = 2\n" +
+		"    [db:name=\"extra\"]\n" +
+		"    json extra = 3\n" +
+		"    [db:name=\"meta\"]\n" +
+		"    json? meta = 4\n" +
+		"    [db:name=\"size\"]\n" +
+		"    int size = 5\n" +
+		"}\n"
+	out := buildtest.Generate(t, "go", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "parser", "a.database.go"),
+		"[]any{&val.Body, &val.Timeout, &val.Ref, hbufDbJson{&val.Extra}, hbufDbJson{&val.Meta}, &val.Size}")
+	// int 按 int32 生成
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "parser", "a.data.go"),
+		"func (g *Attachment) GetSize() int32 {")
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "parser", "hbuf_database.go"),
+		"func (j hbufDbJson) Scan(value any) error")
+}
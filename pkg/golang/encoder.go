@@ -0,0 +1,700 @@
+package golang
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// printEncoderCode 生成数据的二进制编码和解码方法
+func (b *Builder) printEncoderCode(dst *build.Writer, typ *ast.DataType) {
+	dst.Import("io", "")
+
+	name := build.StringToHumpName(typ.Name.Name)
+	dst.Code("func (g *" + name + ") Encoder(w io.Writer) error {\n")
+	dst.Code("\te := &hbufEncoder{w: w}\n")
+	for _, extend := range typ.Extends {
+		dst.Code("\thbufWriteExtend(e, " + extend.Id.Value + ", g." + build.StringToHumpName(extend.Name.Name) + ".Encoder)\n")
+	}
+	for _, field := range typ.Fields.List {
+		dst.Code("\t")
+		b.printWriter(dst, field.Type)
+		dst.Code("(e, " + field.Id.Value + ", g." + build.StringToHumpName(field.Name.Name) + ")\n")
+	}
+	b.printOneOfWriter(dst, typ)
+	dst.Code("\treturn e.err\n")
+	dst.Code("}\n\n")
+
+	dst.Code("func (g *" + name + ") Decoder(r io.Reader) error {\n")
+	dst.Code("\treturn hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {\n")
+	if 0 < len(typ.Extends) {
+		dst.Import("bytes", "")
+		dst.Code("\t\tif hbufTypeExtend == typ {\n")
+		dst.Code("\t\t\tswitch id {\n")
+		for _, extend := range typ.Extends {
+			dst.Code("\t\t\tcase " + extend.Id.Value + ":\n")
+			dst.Code("\t\t\t\terr = g." + build.StringToHumpName(extend.Name.Name) + ".Decoder(bytes.NewReader(val))\n")
+		}
+		dst.Code("\t\t\t}\n")
+		dst.Code("\t\t\treturn\n")
+		dst.Code("\t\t}\n")
+	}
+	dst.Code("\t\tswitch id {\n")
+	for _, field := range typ.Fields.List {
+		dst.Code("\t\tcase " + field.Id.Value + ":\n")
+		dst.Code("\t\t\tg." + build.StringToHumpName(field.Name.Name) + ", err = ")
+		b.printReader(dst, field.Type)
+		dst.Code("(typ, val)\n")
+	}
+	b.printOneOfReader(dst, typ)
+	dst.Code("\t\t}\n")
+	dst.Code("\t\treturn\n")
+	dst.Code("\t})\n")
+	dst.Code("}\n\n")
+}
+
+// printWriter 输出字段类型对应的写入函数
+func (b *Builder) printWriter(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if ast.Enum == t.Obj.Kind {
+				dst.Code("hbufWriteInt[")
+			} else {
+				dst.Code("hbufWriteData[")
+			}
+			b.printType(dst, t, true)
+			dst.Code("]")
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32, build.Int64, build.Duration:
+			dst.Code("hbufWriteInt[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		case build.Uint8, build.Uint16, build.Uint32, build.Uint64:
+			dst.Code("hbufWriteUint[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		case build.Bool:
+			dst.Code("hbufWriteBool")
+		case build.Float:
+			dst.Code("hbufWriteFloat")
+		case build.Double:
+			dst.Code("hbufWriteDouble")
+		case build.String:
+			dst.Code("hbufWriteString")
+		case build.Date:
+			dst.Code("hbufWriteTime")
+		case build.Bytes:
+			dst.Code("hbufWriteBytes")
+		case build.Uuid:
+			dst.Code("hbufWriteUuid")
+		case build.Json:
+			dst.Code("hbufWriteJson")
+		case build.Decimal:
+			dst.Code("hbufWriteText[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		}
+	case *ast.ArrayType:
+		ar := expr.(*ast.ArrayType)
+		dst.Code("hbufWriteList(")
+		b.printWriter(dst, ar.VType)
+		dst.Code(", " + boolString(ar.Empty) + ")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("hbufWriteMap(")
+		b.printWriter(dst, ma.Key)
+		dst.Code(", ")
+		b.printWriter(dst, ma.VType)
+		dst.Code(", " + boolString(ma.Empty) + ")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty {
+			dst.Code("hbufWriteNullable(")
+			b.printWriter(dst, t.Type())
+			dst.Code(")")
+		} else {
+			b.printWriter(dst, t.Type())
+		}
+	}
+}
+
+// printReader 输出字段类型对应的读取函数
+func (b *Builder) printReader(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if ast.Enum == t.Obj.Kind {
+				dst.Code("hbufReadInt[")
+			} else {
+				dst.Code("hbufReadData[")
+			}
+			b.printType(dst, t, true)
+			dst.Code("]")
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32, build.Int64, build.Duration:
+			dst.Code("hbufReadInt[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		case build.Uint8, build.Uint16, build.Uint32, build.Uint64:
+			dst.Code("hbufReadUint[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		case build.Bool:
+			dst.Code("hbufReadBool")
+		case build.Float:
+			dst.Code("hbufReadFloat")
+		case build.Double:
+			dst.Code("hbufReadDouble")
+		case build.String:
+			dst.Code("hbufReadString")
+		case build.Date:
+			dst.Code("hbufReadTime")
+		case build.Bytes:
+			dst.Code("hbufReadBytes")
+		case build.Uuid:
+			dst.Code("hbufReadUuid")
+		case build.Json:
+			dst.Code("hbufReadJson")
+		case build.Decimal:
+			dst.Code("hbufReadText[")
+			b.printType(dst, t, true)
+			dst.Code("]")
+		}
+	case *ast.ArrayType:
+		ar := expr.(*ast.ArrayType)
+		dst.Code("hbufReadList(")
+		b.printReader(dst, ar.VType)
+		dst.Code(")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("hbufReadMap(")
+		b.printReader(dst, ma.Key)
+		dst.Code(", ")
+		b.printReader(dst, ma.VType)
+		dst.Code(")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty {
+			dst.Code("hbufReadNullable(")
+			b.printReader(dst, t.Type())
+			dst.Code(")")
+		} else {
+			b.printReader(dst, t.Type())
+		}
+	}
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+// printCodecCode 生成同一个包内所有编码方法共用的函数
+func printCodecCode(dst *build.Writer) {
+	dst.Import("bytes", "")
+	dst.Import("encoding", "")
+	dst.Import("encoding/binary", "")
+	dst.Import("encoding/hex", "")
+	dst.Import("encoding/json", "")
+	dst.Import("errors", "")
+	dst.Import("fmt", "")
+	dst.Import("io", "")
+	dst.Import("math", "")
+	dst.Import("sort", "")
+	dst.Import("time", "")
+	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
+	dst.Code(_codecCode + "\n")
+}
+
+const _codecCode = `const (
+	hbufTypeInt    byte = 0
+	hbufTypeUint   byte = 1
+	hbufTypeFloat  byte = 2
+	hbufTypeBytes  byte = 3
+	hbufTypeList   byte = 4
+	hbufTypeMap    byte = 5
+	hbufTypeData   byte = 6
+	hbufTypeExtend byte = 7
+)
+
+const hbufMaxList = 1 << 24
+
+var errHbufShort = errors.New("hbuf: unexpected end of data")
+
+var errHbufIndex = errors.New("hbuf: invalid list index")
+
+var errHbufList = errors.New("hbuf: list is too long")
+
+type hbufEncoder struct {
+	w    io.Writer
+	err  error
+	size int
+}
+
+func (e *hbufEncoder) write(typ byte, id uint32, val []byte, payload []byte) {
+	if nil != e.err {
+		return
+	}
+	ids := hbufUintBytes(uint64(id))
+	head := make([]byte, 0, 1+len(ids)+len(val))
+	head = append(head, byte(len(ids)-1)<<6|typ<<3|byte(len(val)-1))
+	head = append(head, ids...)
+	head = append(head, val...)
+	e.size += len(head) + len(payload)
+	if _, e.err = e.w.Write(head); nil == e.err && 0 < len(payload) {
+		_, e.err = e.w.Write(payload)
+	}
+}
+
+func (e *hbufEncoder) raw(buf []byte) {
+	if nil != e.err {
+		return
+	}
+	e.size += len(buf)
+	_, e.err = e.w.Write(buf)
+}
+
+func (e *hbufEncoder) sub(typ byte, id uint32, call func(e *hbufEncoder)) {
+	if nil != e.err {
+		return
+	}
+	buf := &bytes.Buffer{}
+	sub := &hbufEncoder{w: buf}
+	call(sub)
+	if nil != sub.err {
+		e.err = sub.err
+		return
+	}
+	e.write(typ, id, hbufUintBytes(uint64(buf.Len())), buf.Bytes())
+}
+
+func hbufIntBytes(v int64) []byte {
+	ret := make([]byte, 0, 8)
+	for {
+		ret = append(ret, byte(v))
+		if -128 <= v && v < 128 {
+			return ret
+		}
+		v >>= 8
+	}
+}
+
+func hbufUintBytes(v uint64) []byte {
+	ret := make([]byte, 0, 8)
+	for {
+		ret = append(ret, byte(v))
+		v >>= 8
+		if 0 == v {
+			return ret
+		}
+	}
+}
+
+func hbufToInt(val []byte) int64 {
+	shift := 64 - 8*uint(len(val))
+	return int64(hbufToUint(val)<<shift) >> shift
+}
+
+func hbufToUint(val []byte) uint64 {
+	var v uint64
+	for i := len(val) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(val[i])
+	}
+	return v
+}
+
+func hbufTypeError(typ byte) error {
+	return fmt.Errorf("hbuf: unexpected wire type %d", typ)
+}
+
+func hbufWriteInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](e *hbufEncoder, id uint32, v T) {
+	e.write(hbufTypeInt, id, hbufIntBytes(int64(v)), nil)
+}
+
+func hbufWriteUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](e *hbufEncoder, id uint32, v T) {
+	e.write(hbufTypeUint, id, hbufUintBytes(uint64(v)), nil)
+}
+
+func hbufWriteBool(e *hbufEncoder, id uint32, v bool) {
+	if v {
+		e.write(hbufTypeUint, id, []byte{1}, nil)
+	} else {
+		e.write(hbufTypeUint, id, []byte{0}, nil)
+	}
+}
+
+func hbufWriteFloat(e *hbufEncoder, id uint32, v float32) {
+	e.write(hbufTypeFloat, id, binary.LittleEndian.AppendUint32(nil, math.Float32bits(v)), nil)
+}
+
+func hbufWriteDouble(e *hbufEncoder, id uint32, v float64) {
+	e.write(hbufTypeFloat, id, binary.LittleEndian.AppendUint64(nil, math.Float64bits(v)), nil)
+}
+
+func hbufWriteString(e *hbufEncoder, id uint32, v string) {
+	e.write(hbufTypeBytes, id, hbufUintBytes(uint64(len(v))), []byte(v))
+}
+
+func hbufWriteTime(e *hbufEncoder, id uint32, v hbuf.Time) {
+	hbufWriteInt(e, id, time.Time(v).UnixMilli())
+}
+
+func hbufWriteBytes(e *hbufEncoder, id uint32, v []byte) {
+	e.write(hbufTypeBytes, id, hbufUintBytes(uint64(len(v))), v)
+}
+
+// hbufWriteUuid 将 uuid 写成 16 字节，空字符串写成空数据
+func hbufWriteUuid(e *hbufEncoder, id uint32, v string) {
+	var buf []byte
+	if 0 < len(v) {
+		var err error
+		if buf, err = hbufUuidBytes(v); nil != err {
+			if nil == e.err {
+				e.err = err
+			}
+			return
+		}
+	}
+	hbufWriteBytes(e, id, buf)
+}
+
+func hbufUuidBytes(v string) ([]byte, error) {
+	if 36 != len(v) || '-' != v[8] || '-' != v[13] || '-' != v[18] || '-' != v[23] {
+		return nil, fmt.Errorf("hbuf: invalid uuid %q", v)
+	}
+	buf, err := hex.DecodeString(v[0:8] + v[9:13] + v[14:18] + v[19:23] + v[24:])
+	if nil != err {
+		return nil, fmt.Errorf("hbuf: invalid uuid %q", v)
+	}
+	return buf, nil
+}
+
+func hbufWriteJson(e *hbufEncoder, id uint32, v json.RawMessage) {
+	hbufWriteBytes(e, id, v)
+}
+
+func hbufWriteText[T encoding.TextMarshaler](e *hbufEncoder, id uint32, v T) {
+	text, err := v.MarshalText()
+	if nil != err {
+		if nil == e.err {
+			e.err = err
+		}
+		return
+	}
+	e.write(hbufTypeBytes, id, hbufUintBytes(uint64(len(text))), text)
+}
+
+func hbufWriteData[T any, P interface {
+	*T
+	Encoder(w io.Writer) error
+}](e *hbufEncoder, id uint32, v T) {
+	e.sub(hbufTypeData, id, func(e *hbufEncoder) {
+		e.err = P(&v).Encoder(e.w)
+	})
+}
+
+func hbufWriteExtend(e *hbufEncoder, id uint32, encoder func(w io.Writer) error) {
+	e.sub(hbufTypeExtend, id, func(e *hbufEncoder) {
+		e.err = encoder(e.w)
+	})
+}
+
+func hbufWriteNullable[T any](write func(*hbufEncoder, uint32, T)) func(*hbufEncoder, uint32, *T) {
+	return func(e *hbufEncoder, id uint32, v *T) {
+		if nil != v {
+			write(e, id, *v)
+		}
+	}
+}
+
+func hbufWriteList[T any](write func(*hbufEncoder, uint32, T), null bool) func(*hbufEncoder, uint32, []T) {
+	return func(e *hbufEncoder, id uint32, v []T) {
+		if null && nil == v {
+			return
+		}
+		// 读取时长度不能超过 hbufMaxList，超过的在写入时就报错
+		if hbufMaxList < len(v) {
+			if nil == e.err {
+				e.err = errHbufList
+			}
+			return
+		}
+		e.sub(hbufTypeList, id, func(e *hbufEncoder) {
+			last := 0
+			for i, item := range v {
+				size := e.size
+				write(e, uint32(i), item)
+				if size != e.size {
+					last = i + 1
+				}
+			}
+			// 末尾为空的元素没有写入，写入长度以便读取时补空
+			if last < len(v) {
+				hbufWriteUint(e, hbufMaxList, uint(len(v)))
+			}
+		})
+	}
+}
+
+func hbufWriteMap[K comparable, V any](key func(*hbufEncoder, uint32, K), value func(*hbufEncoder, uint32, V), null bool) func(*hbufEncoder, uint32, map[K]V) {
+	return func(e *hbufEncoder, id uint32, v map[K]V) {
+		if null && nil == v {
+			return
+		}
+		e.sub(hbufTypeMap, id, func(e *hbufEncoder) {
+			// 按编码后的键排序，同一个值每次编码得到相同的字节
+			type entry struct {
+				key  []byte
+				item V
+			}
+			list := make([]entry, 0, len(v))
+			for k, item := range v {
+				buf := &bytes.Buffer{}
+				sub := &hbufEncoder{w: buf}
+				key(sub, 0, k)
+				if nil != sub.err {
+					e.err = sub.err
+					return
+				}
+				list = append(list, entry{key: buf.Bytes(), item: item})
+			}
+			sort.Slice(list, func(i, j int) bool {
+				return bytes.Compare(list[i].key, list[j].key) < 0
+			})
+			for _, item := range list {
+				e.raw(item.key)
+				value(e, 1, item.item)
+			}
+		})
+	}
+}
+
+func hbufDecode(r io.Reader, call func(typ byte, id uint32, val []byte) error) error {
+	buf, err := io.ReadAll(r)
+	if nil != err {
+		return err
+	}
+	return hbufWalk(buf, call)
+}
+
+func hbufWalk(buf []byte, call func(typ byte, id uint32, val []byte) error) error {
+	for 0 < len(buf) {
+		idLen := int(buf[0]>>6) + 1
+		typ := buf[0] >> 3 & 7
+		valLen := int(buf[0]&7) + 1
+		if len(buf) < 1+idLen+valLen {
+			return errHbufShort
+		}
+		id := uint32(hbufToUint(buf[1 : 1+idLen]))
+		val := buf[1+idLen : 1+idLen+valLen]
+		buf = buf[1+idLen+valLen:]
+		if hbufTypeBytes <= typ {
+			size := hbufToUint(val)
+			if uint64(len(buf)) < size {
+				return errHbufShort
+			}
+			val, buf = buf[:size], buf[size:]
+		}
+		err := call(typ, id, val)
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+func hbufReadInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](typ byte, val []byte) (T, error) {
+	switch typ {
+	case hbufTypeInt:
+		return T(hbufToInt(val)), nil
+	case hbufTypeUint:
+		return T(hbufToUint(val)), nil
+	}
+	return 0, hbufTypeError(typ)
+}
+
+func hbufReadUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64](typ byte, val []byte) (T, error) {
+	switch typ {
+	case hbufTypeInt:
+		return T(hbufToInt(val)), nil
+	case hbufTypeUint:
+		return T(hbufToUint(val)), nil
+	}
+	return 0, hbufTypeError(typ)
+}
+
+func hbufReadBool(typ byte, val []byte) (bool, error) {
+	v, err := hbufReadUint[uint64](typ, val)
+	return 0 != v, err
+}
+
+func hbufReadFloat(typ byte, val []byte) (float32, error) {
+	v, err := hbufReadDouble(typ, val)
+	return float32(v), err
+}
+
+func hbufReadDouble(typ byte, val []byte) (float64, error) {
+	if hbufTypeFloat == typ {
+		switch len(val) {
+		case 4:
+			return float64(math.Float32frombits(binary.LittleEndian.Uint32(val))), nil
+		case 8:
+			return math.Float64frombits(binary.LittleEndian.Uint64(val)), nil
+		}
+	}
+	return 0, hbufTypeError(typ)
+}
+
+func hbufReadString(typ byte, val []byte) (string, error) {
+	if hbufTypeBytes != typ {
+		return "", hbufTypeError(typ)
+	}
+	return string(val), nil
+}
+
+func hbufReadTime(typ byte, val []byte) (hbuf.Time, error) {
+	v, err := hbufReadInt[int64](typ, val)
+	return hbuf.Time(time.UnixMilli(v)), err
+}
+
+func hbufReadBytes(typ byte, val []byte) ([]byte, error) {
+	if hbufTypeBytes != typ {
+		return nil, hbufTypeError(typ)
+	}
+	return append([]byte{}, val...), nil
+}
+
+func hbufReadUuid(typ byte, val []byte) (string, error) {
+	if hbufTypeBytes != typ {
+		return "", hbufTypeError(typ)
+	}
+	if 0 == len(val) {
+		return "", nil
+	}
+	if 16 != len(val) {
+		return "", fmt.Errorf("hbuf: invalid uuid length %d", len(val))
+	}
+	v := hex.EncodeToString(val)
+	return v[0:8] + "-" + v[8:12] + "-" + v[12:16] + "-" + v[16:20] + "-" + v[20:], nil
+}
+
+func hbufReadJson(typ byte, val []byte) (json.RawMessage, error) {
+	if hbufTypeBytes != typ {
+		return nil, hbufTypeError(typ)
+	}
+	if 0 == len(val) {
+		return nil, nil
+	}
+	return append(json.RawMessage{}, val...), nil
+}
+
+func hbufReadText[T any, P interface {
+	*T
+	encoding.TextUnmarshaler
+}](typ byte, val []byte) (T, error) {
+	var ret T
+	if hbufTypeBytes != typ {
+		return ret, hbufTypeError(typ)
+	}
+	err := P(&ret).UnmarshalText(val)
+	return ret, err
+}
+
+func hbufReadData[T any, P interface {
+	*T
+	Decoder(r io.Reader) error
+}](typ byte, val []byte) (T, error) {
+	var ret T
+	if hbufTypeData != typ {
+		return ret, hbufTypeError(typ)
+	}
+	err := P(&ret).Decoder(bytes.NewReader(val))
+	return ret, err
+}
+
+func hbufReadNullable[T any](read func(byte, []byte) (T, error)) func(byte, []byte) (*T, error) {
+	return func(typ byte, val []byte) (*T, error) {
+		v, err := read(typ, val)
+		if nil != err {
+			return nil, err
+		}
+		return &v, nil
+	}
+}
+
+func hbufReadList[T any](read func(byte, []byte) (T, error)) func(byte, []byte) ([]T, error) {
+	return func(typ byte, val []byte) ([]T, error) {
+		if hbufTypeList != typ {
+			return nil, hbufTypeError(typ)
+		}
+		list := make([]T, 0)
+		err := hbufWalk(val, func(typ byte, id uint32, val []byte) error {
+			if hbufMaxList == id {
+				size, err := hbufReadUint[uint32](typ, val)
+				if nil != err {
+					return err
+				}
+				if size < uint32(len(list)) || hbufMaxList < size {
+					return errHbufIndex
+				}
+				for uint32(len(list)) < size {
+					list = append(list, *new(T))
+				}
+				return nil
+			}
+			if id < uint32(len(list)) || hbufMaxList <= id {
+				return errHbufIndex
+			}
+			item, err := read(typ, val)
+			if nil != err {
+				return err
+			}
+			for uint32(len(list)) < id {
+				list = append(list, *new(T))
+			}
+			list = append(list, item)
+			return nil
+		})
+		return list, err
+	}
+}
+
+func hbufReadMap[K comparable, V any](key func(byte, []byte) (K, error), value func(byte, []byte) (V, error)) func(byte, []byte) (map[K]V, error) {
+	return func(typ byte, val []byte) (map[K]V, error) {
+		if hbufTypeMap != typ {
+			return nil, hbufTypeError(typ)
+		}
+		ret := map[K]V{}
+		var k K
+		hasKey := false
+		err := hbufWalk(val, func(typ byte, id uint32, val []byte) (err error) {
+			if 0 == id {
+				if hasKey {
+					ret[k] = *new(V)
+				}
+				k, err = key(typ, val)
+				hasKey = nil == err
+				return
+			}
+			if !hasKey {
+				return errHbufIndex
+			}
+			ret[k], err = value(typ, val)
+			hasKey = false
+			return
+		})
+		if hasKey {
+			ret[k] = *new(V)
+		}
+		return ret, err
+	}
+}
+`
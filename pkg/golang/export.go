@@ -137,6 +137,10 @@ func (b *Builder) printExportDataItemCode(dst *build.Writer, i int, item *ast.Fi
 	} else if build.GetBaseType(item.Type.Type()) == build.Date {
 		dst.Import("time", "")
 		dst.Tab(tab).Code("list[").Code(strconv.Itoa(i)).Code("] = time.Time(g.Get").Code(name).Code("()).In(loc)\n")
+	} else if build.GetBaseType(item.Type.Type()) == build.Duration {
+		dst.Tab(tab).Code("list[").Code(strconv.Itoa(i)).Code("] = g.Get").Code(name).Code("().String()\n")
+	} else if build.GetBaseType(item.Type.Type()) == build.Json {
+		dst.Tab(tab).Code("list[").Code(strconv.Itoa(i)).Code("] = string(g.Get").Code(name).Code("())\n")
 	} else {
 		dst.Tab(tab).Code("list[").Code(strconv.Itoa(i)).Code("] = g.Get").Code(name).Code("()\n")
 	}
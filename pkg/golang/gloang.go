@@ -1,6 +1,9 @@
 package golang
 
 import (
+	"bytes"
+	"errors"
+	"go/format"
 	"go/printer"
 	ast "hbuf/pkg/ast"
 	"hbuf/pkg/build"
@@ -15,22 +18,27 @@ var _types = map[build.BaseType]string{
 	build.Int8: "int8", build.Int16: "int16", build.Int32: "int32", build.Int64: "hbuf.Int64", build.Uint8: "uint8",
 	build.Uint16: "uint16", build.Uint32: "uint32", build.Uint64: "hbuf.Uint64", build.Bool: "bool", build.Float: "float32",
 	build.Double: "float64", build.String: "string", build.Date: "hbuf.Time", build.Decimal: "decimal.Decimal",
+	build.Bytes: "[]byte", build.Duration: "time.Duration", build.Uuid: "string", build.Json: "json.RawMessage",
 }
 var _typesDefaultValue = map[build.BaseType]string{
-	build.Int8:    "int8(0)",
-	build.Int16:   "int16(0)",
-	build.Int32:   "int32(0)",
-	build.Int64:   "hbuf.Int64(0)",
-	build.Uint8:   "uint8(0)",
-	build.Uint16:  "uint16(0)",
-	build.Uint32:  "uint32(0)",
-	build.Uint64:  "hbuf.Uint64(0)",
-	build.Bool:    "false",
-	build.Float:   "float32(0)",
-	build.Double:  "float64(0)",
-	build.String:  "\"\"",
-	build.Date:    "hbuf.Time{}",
-	build.Decimal: "decimal.Zero",
+	build.Int8:     "int8(0)",
+	build.Int16:    "int16(0)",
+	build.Int32:    "int32(0)",
+	build.Int64:    "hbuf.Int64(0)",
+	build.Uint8:    "uint8(0)",
+	build.Uint16:   "uint16(0)",
+	build.Uint32:   "uint32(0)",
+	build.Uint64:   "hbuf.Uint64(0)",
+	build.Bool:     "false",
+	build.Float:    "float32(0)",
+	build.Double:   "float64(0)",
+	build.String:   "\"\"",
+	build.Date:     "hbuf.Time{}",
+	build.Decimal:  "decimal.Zero",
+	build.Bytes:    "[]byte{}",
+	build.Duration: "time.Duration(0)",
+	build.Uuid:     "\"\"",
+	build.Json:     "json.RawMessage(nil)",
 }
 
 type GoWriter struct {
@@ -41,6 +49,7 @@ type GoWriter struct {
 	verify   *build.Writer
 	export   *build.Writer
 	mq       *build.Writer
+	encoder  *build.Writer
 
 	packages string
 }
@@ -53,6 +62,7 @@ func (w *GoWriter) SetPackages(s string) {
 	w.verify.Packages = s
 	w.export.Packages = s
 	w.mq.Packages = s
+	w.encoder.Packages = s
 	w.packages = s
 }
 
@@ -64,6 +74,7 @@ func (w *GoWriter) SetPath(file *ast.File) {
 	w.verify.File = file
 	w.export.File = file
 	w.mq.File = file
+	w.encoder.File = file
 }
 
 func NewGoWriter() *GoWriter {
@@ -75,6 +86,7 @@ func NewGoWriter() *GoWriter {
 		verify:   build.NewWriter(),
 		export:   build.NewWriter(),
 		mq:       build.NewWriter(),
+		encoder:  build.NewWriter(),
 	}
 }
 
@@ -101,7 +113,11 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 		return nil
 	}
 
-	dir, name := filepath.Split(param.GetOut())
+	// 代码按 go 包名放置，不同目录下的同名文件生成到同一个包时会互相覆盖
+	if other := build.DuplicateOut(b.pkg, file, goPackage); nil != other {
+		return errors.New("Duplicate output: " + file.Name + " and " + other.Name + " generate the same go file")
+	}
+	dir, name := param.GetOutDir(), filepath.Base(param.GetOut())
 	name = name[:len(name)-len(".hbuf")]
 	packs := strings.Split(dst.packages, ".")
 	for _, pack := range packs {
@@ -113,7 +129,7 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 		return err
 	}
 
-	if 0 < dst.data.GetCode().Len() {
+	if param.Feature(build.FeatureData) && 0 < dst.data.GetCode().Len() {
 		err := b.writerFile(dst.data, dst.data.Packages, filepath.Join(dir, name+".data.go"), 1)
 		if err != nil {
 			return err
@@ -125,25 +141,25 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 			return err
 		}
 	}
-	if 0 < dst.server.GetCode().Len() {
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
 		err = b.writerFile(dst.server, dst.server.Packages, filepath.Join(dir, name+".server.go"), 0)
 		if err != nil {
 			return err
 		}
 	}
-	if 0 < dst.database.GetCode().Len() {
+	if param.Feature(build.FeatureDatabase) && 0 < dst.database.GetCode().Len() {
 		err = b.writerFile(dst.database, dst.database.Packages, filepath.Join(dir, name+".database.go"), 0)
 		if err != nil {
 			return err
 		}
 	}
-	if 0 < dst.verify.GetCode().Len() {
+	if param.Feature(build.FeatureVerify) && 0 < dst.verify.GetCode().Len() {
 		err = b.writerFile(dst.verify, dst.verify.Packages, filepath.Join(dir, name+".verify.go"), 0)
 		if err != nil {
 			return err
 		}
 	}
-	if 0 < dst.export.GetCode().Len() {
+	if param.Feature(build.FeatureExport) && 0 < dst.export.GetCode().Len() {
 		err = b.writerFile(dst.export, dst.export.Packages, filepath.Join(dir, name+".export.go"), 0)
 		if err != nil {
 			return err
@@ -155,26 +171,60 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 			return err
 		}
 	}
+	if param.Feature(build.FeatureData) && 0 < dst.encoder.GetCode().Len() {
+		err = b.writerFile(dst.encoder, dst.encoder.Packages, filepath.Join(dir, name+".encoder.go"), 0)
+		if err != nil {
+			return err
+		}
+	}
+	// 二进制编码和 stream 的公共代码，服务端和客户端也会用到
+	isEncoder := param.Feature(build.FeatureData) && 0 < dst.encoder.GetCode().Len()
+	if isEncoder || param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
+		codec := build.NewWriter()
+		printCodecCode(codec)
+		err = b.writerFile(codec, dst.packages, filepath.Join(dir, "hbuf_encoder.go"), 0)
+		if err != nil {
+			return err
+		}
+	}
+	// 数据库读写的公共代码
+	if param.Feature(build.FeatureDatabase) && 0 < dst.database.GetCode().Len() {
+		database := build.NewWriter()
+		printDatabaseCommon(database)
+		err = b.writerFile(database, dst.packages, filepath.Join(dir, "hbuf_database.go"), 0)
+		if err != nil {
+			return err
+		}
+	}
+	// REST 路由的公共代码，只有服务带 http 注解时生成
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() && isFileHttp(file) {
+		http := build.NewWriter()
+		printHttpCode(http)
+		err = b.writerFile(http, dst.packages, filepath.Join(dir, "hbuf_http.go"), 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	// stream 方法的公共代码，只有服务含有 stream 方法时生成
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() && isFileStream(file) {
+		stream := build.NewWriter()
+		printStreamCode(stream)
+		err = b.writerFile(stream, dst.packages, filepath.Join(dir, "hbuf_stream.go"), 0)
+		if err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
 func (b *Builder) writerFile(data *build.Writer, packages string, out string, i int) error {
-	fc, err := os.Create(out)
-	if err != nil {
-		return err
-	}
-	defer func(fc *os.File) {
-		err := fc.Close()
-		if err != nil {
-			print(err)
-		}
-	}(fc)
-
-	_, _ = fc.WriteString("package " + packages + "\n\n")
+	buf := &bytes.Buffer{}
+	buf.WriteString("package " + packages + "\n\n")
 
 	if 0 < len(data.GetImports()) {
-		_, _ = fc.WriteString("import (\n")
+		buf.WriteString("import (\n")
 		imps := make([]string, len(data.GetImports()))
 
 		i := 0
@@ -185,19 +235,40 @@ func (b *Builder) writerFile(data *build.Writer, packages string, out string, i
 		sort.Strings(imps)
 		for _, val := range imps {
 			key := data.GetImports()[val]
-			_, _ = fc.WriteString("\t")
+			buf.WriteString("\t")
 			if 0 < len(key) {
-				_, _ = fc.WriteString(key + " ")
+				buf.WriteString(key + " ")
 			}
-			_, _ = fc.WriteString("\"" + val + "\"\n")
+			buf.WriteString("\"" + val + "\"\n")
 		}
-		_, _ = fc.WriteString(")\n\n")
+		buf.WriteString(")\n\n")
 	}
 	code := data.GetCode().String()
-	_, _ = fc.WriteString(code[:len(code)-1])
+	buf.WriteString(code[:len(code)-1])
+
+	// 生成的代码统一用 gofmt 格式化，模板中不需要手工对齐；格式化失败时仍写入原样的代码以便查看
+	src, fmtErr := format.Source(buf.Bytes())
+	if fmtErr != nil {
+		src = buf.Bytes()
+	}
+	err := os.WriteFile(out, src, 0666)
+	if err != nil {
+		return err
+	}
+	if fmtErr != nil {
+		return errors.New(out + ": " + fmtErr.Error())
+	}
 	return nil
 }
 
+// goPackage 返回文件的 go 包名，没有时返回空
+func goPackage(file *ast.File) string {
+	if val, ok := file.Packages["go"]; ok {
+		return val.Value.Value
+	}
+	return ""
+}
+
 func (b *Builder) Node(dst *GoWriter, fset *token.FileSet, node interface{}) error {
 	var file *ast.File
 	switch n := node.(type) {
@@ -221,6 +292,8 @@ func (b *Builder) Node(dst *GoWriter, fset *token.FileSet, node interface{}) err
 	for _, s := range file.Specs {
 		switch s.(type) {
 		case *ast.ImportSpec:
+		case *ast.ConstSpec:
+			printConstCode(dst.enum, s.(*ast.ConstSpec))
 		case *ast.TypeSpec:
 			err := b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
 			if err != nil {
@@ -235,6 +308,7 @@ func (b *Builder) printTypeSpec(dst *GoWriter, expr ast.Expr) error {
 	switch expr.(type) {
 	case *ast.DataType:
 		b.printDataCode(dst.data, expr.(*ast.DataType))
+		b.printEncoderCode(dst.encoder, expr.(*ast.DataType))
 		err := b.printDatabaseCode(dst.database, expr.(*ast.DataType))
 		if err != nil {
 			return err
@@ -278,6 +352,10 @@ func (b *Builder) printType(dst *build.Writer, expr ast.Expr, b2 bool) {
 				dst.Import("github.com/shopspring/decimal", "")
 			} else if build.Int64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Uint64 == build.BaseType((expr.(*ast.Ident).Name)) {
 				dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
+			} else if build.Duration == build.BaseType((expr.(*ast.Ident)).Name) {
+				dst.Import("time", "")
+			} else if build.Json == build.BaseType((expr.(*ast.Ident)).Name) {
+				dst.Import("encoding/json", "")
 			}
 			dst.Code(_types[build.BaseType((expr.(*ast.Ident)).Name)])
 		}
@@ -373,6 +451,10 @@ func (b *Builder) converter(field *build.DBField, name string) string {
 	if "json" == field.Dbs[0].Converter {
 		return "db.NewJson(&" + name + "." + fName + ")"
 	}
+	// json 类型的字段由 hbufDbJson 读写，驱动返回字符串时也能读取
+	if 0 == len(field.Dbs[0].Converter) && build.Json == build.GetBaseType(field.Field.Type) {
+		return "hbufDbJson{&" + name + "." + fName + "}"
+	}
 	return "&" + name + "." + fName
 }
 
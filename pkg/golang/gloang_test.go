@@ -0,0 +1,78 @@
+package golang
+
+import (
+	"hbuf/pkg/build"
+	"hbuf/pkg/build/buildtest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDuplicateOut 代码按 go 包名放置，不同目录下的同名文件生成到同一个包时报错，不同的包可以同名
+func TestDuplicateOut(t *testing.T) {
+	dir := t.TempDir()
+	buildtest.WriteFiles(t, dir, map[string]string{
+		"same/a/page.hbuf":  "package go = \"x\"\n\ndata A {\n    int32 a = 0\n}\n",
+		"same/b/page.hbuf":  "package go = \"x\"\n\ndata B {\n    int32 b = 0\n}\n",
+		"other/a/page.hbuf": "package go = \"x\"\n\ndata A {\n    int32 a = 0\n}\n",
+		"other/b/page.hbuf": "package go = \"y\"\n\ndata B {\n    int32 b = 0\n}\n",
+	})
+	build.AddBuildType("go", Build)
+	err := build.Build(filepath.Join(dir, "out"), filepath.Join(dir, "same", "*.hbuf"), "go", "")
+	if nil == err || !strings.Contains(err.Error(), "Duplicate output: a/page.hbuf and b/page.hbuf") {
+		t.Errorf("want duplicate output error, got %v", err)
+	}
+	out := filepath.Join(dir, "out")
+	err = build.Build(out, filepath.Join(dir, "other", "*.hbuf"), "go", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buildtest.ReadFile(t, out, "x", "page.data.go")
+	buildtest.ReadFile(t, out, "y", "page.data.go")
+}
+
+// TestFeatureData 关闭 data 时只生成枚举，不生成引用 data 类型的服务和数据库代码
+func TestFeatureData(t *testing.T) {
+	dir := t.TempDir()
+	buildtest.WriteFiles(t, dir, map[string]string{
+		"a.hbuf": "" +
+			"package go = \"x\"\n" +
+			"\n" +
+			"enum Status {\n" +
+			"    Enable = 1\n" +
+			"}\n" +
+			"[db:get=\"self\"]\n" +
+			"data Info = 1 {\n" +
+			"    [db:key=\"true\"]\n" +
+			"    int32 id = 0\n" +
+			"}\n" +
+			"server Api = 1 {\n" +
+			"    Info getInfo(Info req) = 0\n" +
+			"}\n",
+	})
+	build.AddBuildType("go", Build)
+	out := filepath.Join(dir, "out")
+	err := build.BuildConfig(&build.Config{
+		Inputs: []string{filepath.Join(dir, "a.hbuf")},
+		Targets: []*build.Target{{
+			Type:     "go",
+			Out:      out,
+			Features: map[string]bool{build.FeatureData: false},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Join(out, "x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, " "); "a.enum.go" != got {
+		t.Errorf("want a.enum.go, got %s", got)
+	}
+}
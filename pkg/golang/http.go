@@ -0,0 +1,329 @@
+package golang
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// hasHttp 返回服务中是否有带 http 注解的方法，包括继承的方法
+func hasHttp(typ *ast.ServerType) bool {
+	ret := false
+	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		_, ok := build.GetTag(method.Tags, "http")
+		ret = ret || ok
+		return nil
+	})
+	return ret
+}
+
+// isFileHttp 返回文件中是否有服务带 http 注解
+func isFileHttp(file *ast.File) bool {
+	for _, s := range file.Specs {
+		if spec, ok := s.(*ast.TypeSpec); ok {
+			if server, ok := spec.Type.(*ast.ServerType); ok && hasHttp(server) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printHttpRoutes 输出由 http 注解生成的 REST 路由，调用时使用 names 中的方法
+func (b *Builder) printHttpRoutes(dst *build.Writer, typ *ast.ServerType) error {
+	dst.Import("net/http", "")
+	dst.Tab(2).Code("routes: []*HttpRoute{\n")
+	err := build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		route, err := build.GetHttp(method)
+		if nil != err || nil == route {
+			return err
+		}
+		name := build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name)
+		dst.Tab(3).Code("{\n")
+		dst.Tab(4).Code("Method: \"" + route.Method + "\",\n")
+		dst.Tab(4).Code("Path:   \"" + route.Path + "\",\n")
+		dst.Tab(4).Code("Name:   \"/" + build.StringToUnderlineName(typ.Name.Name) + "/" + name + "\",\n")
+		dst.Tab(4).Code("ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {\n")
+		dst.Tab(5).Code("var req ")
+		b.printType(dst, method.Param, true)
+		dst.Code("\n")
+		dst.Tab(5).Code("return &req, hbufHttpBind(r, params, \"" + route.Body + "\", &req")
+		_ = build.EnumField(route.Data, func(field *ast.Field, data *ast.DataType) error {
+			if isHttpQuoted(field.Type) {
+				dst.Code(", \"" + build.StringToUnderlineName(field.Name.Name) + "\"")
+			}
+			return nil
+		})
+		dst.Code(")\n")
+		dst.Tab(4).Code("},\n")
+		dst.Tab(4).Code("Invoke: names[\"" + name + "\"],\n")
+		dst.Tab(3).Code("},\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	dst.Tab(2).Code("},\n")
+	return nil
+}
+
+// isHttpQuoted 返回字段在 JSON 中是否为字符串，路径和查询参数中的值需要加上引号
+func isHttpQuoted(typ ast.Expr) bool {
+	if build.IsArray(typ) || build.IsMap(typ) {
+		return false
+	}
+	switch build.GetBaseType(typ) {
+	case build.String, build.Uuid, build.Bytes:
+		return true
+	}
+	return false
+}
+
+// printHttpCode 生成同一个包内 REST 路由共用的代码
+func printHttpCode(dst *build.Writer) {
+	dst.Import("context", "")
+	dst.Import("encoding/json", "")
+	dst.Import("errors", "")
+	dst.Import("io", "")
+	dst.Import("net/http", "")
+	dst.Import("net/url", "")
+	dst.Import("sort", "")
+	dst.Import("strings", "")
+	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
+	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/rpc", "")
+	dst.Code(_httpCode + "\n")
+}
+
+const _httpCode = `// HttpRoute 由方法的 http 注解生成的 REST 路由
+type HttpRoute struct {
+	Method string
+	Path   string
+	Name   string
+	ToData func(r *http.Request, params map[string]string) (hbuf.Data, error)
+	Invoke *rpc.ServerInvoke
+}
+
+// HttpRouter 含有 REST 路由的服务路由
+type HttpRouter interface {
+	GetHttpRoutes() []*HttpRoute
+}
+
+// HttpMaxBody HttpHandler 默认的请求体最大字节数
+const HttpMaxBody = 4 << 20
+
+// HttpHandler 按 REST 路由处理请求，调用时经过 rpc.Server 的过滤器，返回与 rpc.ServerJson 相同的 rpc.Result
+type HttpHandler struct {
+	server *rpc.Server
+	routes []*HttpRoute
+	// MaxBody 请求体的最大字节数，超出时返回 413，为 0 时使用 HttpMaxBody，小于 0 时不限制
+	MaxBody int64
+}
+
+func NewHttpHandler(server *rpc.Server, routers ...HttpRouter) *HttpHandler {
+	h := &HttpHandler{
+		server: server,
+	}
+	for _, router := range routers {
+		h.Add(router)
+	}
+	return h
+}
+
+// Add 添加路由，同一个路径匹配多个路由时，固定的路径段优先于路径参数，如 /users/me 优先于 /users/{id}
+func (h *HttpHandler) Add(router HttpRouter) {
+	h.routes = append(h.routes, router.GetHttpRoutes()...)
+	sort.SliceStable(h.routes, func(i, j int) bool {
+		return hbufHttpLess(h.routes[i].Path, h.routes[j].Path)
+	})
+}
+
+func (h *HttpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	maxBody := h.MaxBody
+	if 0 == maxBody {
+		maxBody = HttpMaxBody
+	}
+	if 0 < maxBody && nil != r.Body {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+	}
+	ctx := rpc.NewContext(r.Context())
+	defer rpc.CloseContext(ctx)
+	for key := range r.Header {
+		rpc.SetHeader(ctx, key, r.Header.Get(key))
+	}
+	err := h.invoke(ctx, w, r)
+	if err != nil {
+		switch res := err.(type) {
+		case *hbufHttpError:
+			hbufHttpWrite(w, res.Code, res)
+		case *rpc.Result:
+			hbufHttpWrite(w, http.StatusOK, res)
+		default:
+			hbufHttpWrite(w, http.StatusInternalServerError, &rpc.Result{Code: http.StatusInternalServerError, Msg: http.StatusText(http.StatusInternalServerError)})
+		}
+	}
+}
+
+// hbufHttpError 路由错误，Code 同时作为 HTTP 状态码
+type hbufHttpError struct {
+	rpc.Result
+}
+
+func hbufHttpWrite(w http.ResponseWriter, status int, res error) {
+	marshal, err := json.Marshal(res)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(marshal)
+}
+
+func (h *HttpHandler) invoke(ctx context.Context, w http.ResponseWriter, r *http.Request) error {
+	var route *HttpRoute
+	var params map[string]string
+	found := false
+	for _, item := range h.routes {
+		if values, ok := hbufHttpMatch(item.Path, r.URL.EscapedPath()); ok {
+			found = true
+			if item.Method == r.Method {
+				route, params = item, values
+				break
+			}
+		}
+	}
+	if nil == route && found {
+		return &hbufHttpError{rpc.Result{Code: http.StatusMethodNotAllowed, Msg: "method not allowed"}}
+	} else if nil == route {
+		return &hbufHttpError{rpc.Result{Code: http.StatusNotFound, Msg: "not found"}}
+	}
+
+	data, err := route.ToData(r, params)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return &hbufHttpError{rpc.Result{Code: http.StatusRequestEntityTooLarge, Msg: err.Error()}}
+	} else if err != nil {
+		return &hbufHttpError{rpc.Result{Code: http.StatusBadRequest, Msg: err.Error()}}
+	}
+	rpc.SetMethod(ctx, route.Name)
+	route.Invoke.SetInfo(ctx)
+
+	rpc.SetContextOnClone(ctx, func(ctx context.Context) (context.Context, error) {
+		ctx, _, err := h.server.GetFilter().OnNext(ctx, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return ctx, nil
+	})
+	_, data, err = h.server.GetFilter().OnNext(ctx, data, func(ctx context.Context, data hbuf.Data) (context.Context, hbuf.Data, error) {
+		data, err := route.Invoke.Invoke(ctx, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ctx, data, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	result := rpc.Result{Code: 0, Msg: "Ok"}
+	if nil != route.Invoke.FormData {
+		result.Data, err = route.Invoke.FormData(data)
+		if err != nil {
+			return err
+		}
+	}
+	buffer, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(buffer)
+	return err
+}
+
+// hbufHttpLess 返回路径 a 是否应在 b 之前匹配，第一个不同的路径段中固定的路径段优先于路径参数，
+// 段数不同的路径不会匹配同一个请求，按段数排序只为保持顺序稳定
+func hbufHttpLess(a string, b string) bool {
+	as := strings.Split(strings.Trim(a, "/"), "/")
+	bs := strings.Split(strings.Trim(b, "/"), "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		ap := strings.HasPrefix(as[i], "{")
+		bp := strings.HasPrefix(bs[i], "{")
+		if ap != bp {
+			return bp
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// hbufHttpMatch 匹配路径，返回路径参数，{name} 匹配一段路径
+func hbufHttpMatch(pattern string, path string) (map[string]string, bool) {
+	patterns := strings.Split(strings.Trim(pattern, "/"), "/")
+	paths := strings.Split(strings.Trim(path, "/"), "/")
+	if len(patterns) != len(paths) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, item := range patterns {
+		if strings.HasPrefix(item, "{") && strings.HasSuffix(item, "}") {
+			value, err := url.PathUnescape(paths[i])
+			if err != nil {
+				return nil, false
+			}
+			params[item[1:len(item)-1]] = value
+		} else if item != paths[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// hbufHttpBind 把请求体、查询参数和路径参数依次写入 data，后写入的值覆盖先写入的值，
+// body 为 "*" 时请求体为整个 data，为字段名称时请求体为该字段，quoted 为 JSON 中是字符串的字段
+func hbufHttpBind(r *http.Request, params map[string]string, body string, data any, quoted ...string) error {
+	values := map[string]json.RawMessage{}
+	if 0 < len(body) && nil != r.Body {
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		if "*" != body && 0 < len(buf) {
+			values[body] = buf
+		} else if 0 < len(buf) {
+			err = json.Unmarshal(buf, data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	for key, list := range r.URL.Query() {
+		values[key] = hbufHttpValue(list[0], hbufHttpContains(quoted, key))
+	}
+	for key, value := range params {
+		values[key] = hbufHttpValue(value, hbufHttpContains(quoted, key))
+	}
+	buf, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(buf, data)
+}
+
+// hbufHttpValue 把路径或查询参数转为 JSON，字符串字段加上引号，其他字段的值本身应是 JSON
+func hbufHttpValue(value string, quoted bool) json.RawMessage {
+	if !quoted && json.Valid([]byte(value)) {
+		return json.RawMessage(value)
+	}
+	buf, _ := json.Marshal(value)
+	return buf
+}
+
+func hbufHttpContains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+`
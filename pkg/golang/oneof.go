@@ -0,0 +1,115 @@
+package golang
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// oneOfName 返回 oneof 接口的名称，如 EventPayload
+func oneOfName(typ *ast.DataType, oneOf *ast.OneOf) string {
+	return build.StringToHumpName(typ.Name.Name) + build.StringToHumpName(oneOf.Name.Name)
+}
+
+// printOneOfCode 生成 oneof 的接口和成员的包装类型，JSON 写成 {"成员名": 值}
+func (b *Builder) printOneOfCode(dst *build.Writer, typ *ast.DataType) {
+	if 0 == len(typ.OneOfs) {
+		return
+	}
+	for _, oneOf := range typ.OneOfs {
+		name := oneOfName(typ, oneOf)
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Code("// " + name + " " + oneOf.Doc.Text())
+		}
+		dst.Code("type " + name + " interface {\n")
+		dst.Tab(1).Code("is" + name + "()\n")
+		dst.Code("}\n\n")
+
+		for _, field := range oneOf.Fields {
+			member := build.StringToHumpName(field.Name.Name)
+			if nil != field.Doc && 0 < len(field.Doc.Text()) {
+				dst.Code("// " + name + member + " " + field.Doc.Text())
+			}
+			dst.Code("type " + name + member + " struct {\n")
+			dst.Tab(1).Code(member + " ")
+			b.printType(dst, field.Type, true)
+			dst.Code(" `json:\"" + build.StringToUnderlineName(field.Name.Name) + "\"`\n")
+			dst.Code("}\n\n")
+			dst.Code("func (*" + name + member + ") is" + name + "() {}\n\n")
+		}
+
+		dst.Code("func unmarshal" + name + "(data json.RawMessage) (" + name + ", error) {\n")
+		dst.Tab(1).Code("if 0 == len(data) || \"null\" == string(data) {\n")
+		dst.Tab(2).Code("return nil, nil\n")
+		dst.Tab(1).Code("}\n")
+		dst.Tab(1).Code("var temp map[string]json.RawMessage\n")
+		dst.Tab(1).Code("err := json.Unmarshal(data, &temp)\n")
+		dst.Tab(1).Code("if err != nil {\n")
+		dst.Tab(2).Code("return nil, err\n")
+		dst.Tab(1).Code("}\n")
+		for _, field := range oneOf.Fields {
+			member := build.StringToHumpName(field.Name.Name)
+			dst.Tab(1).Code("if val, ok := temp[\"" + build.StringToUnderlineName(field.Name.Name) + "\"]; ok {\n")
+			dst.Tab(2).Code("ret := &" + name + member + "{}\n")
+			dst.Tab(2).Code("return ret, json.Unmarshal(val, &ret." + member + ")\n")
+			dst.Tab(1).Code("}\n")
+		}
+		dst.Tab(1).Code("return nil, nil\n")
+		dst.Code("}\n\n")
+	}
+
+	// 接口类型的字段不能直接解码，先读取为 json.RawMessage
+	dataName := build.StringToHumpName(typ.Name.Name)
+	dst.Code("func (g *" + dataName + ") UnmarshalJSON(data []byte) error {\n")
+	dst.Tab(1).Code("type alias " + dataName + "\n")
+	dst.Tab(1).Code("temp := struct {\n")
+	dst.Tab(2).Code("*alias\n")
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(2).Code(build.StringToHumpName(oneOf.Name.Name) + " json.RawMessage `json:\"" + build.StringToUnderlineName(oneOf.Name.Name) + ",omitempty\"`\n")
+	}
+	dst.Tab(1).Code("}{alias: (*alias)(g)}\n")
+	dst.Tab(1).Code("err := json.Unmarshal(data, &temp)\n")
+	dst.Tab(1).Code("if err != nil {\n")
+	dst.Tab(2).Code("return err\n")
+	dst.Tab(1).Code("}\n")
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToHumpName(oneOf.Name.Name)
+		dst.Tab(1).Code("g." + name + ", err = unmarshal" + oneOfName(typ, oneOf) + "(temp." + name + ")\n")
+		dst.Tab(1).Code("if err != nil {\n")
+		dst.Tab(2).Code("return err\n")
+		dst.Tab(1).Code("}\n")
+	}
+	dst.Tab(1).Code("return nil\n")
+	dst.Code("}\n\n")
+}
+
+// printOneOfWriter 生成 oneof 的二进制编码，只写入已设置的成员
+func (b *Builder) printOneOfWriter(dst *build.Writer, typ *ast.DataType) {
+	for _, oneOf := range typ.OneOfs {
+		name := oneOfName(typ, oneOf)
+		dst.Code("\tswitch v := g." + build.StringToHumpName(oneOf.Name.Name) + ".(type) {\n")
+		for _, field := range oneOf.Fields {
+			member := build.StringToHumpName(field.Name.Name)
+			dst.Code("\tcase *" + name + member + ":\n")
+			dst.Code("\t\t")
+			b.printWriter(dst, field.Type)
+			dst.Code("(e, " + field.Id.Value + ", v." + member + ")\n")
+		}
+		dst.Code("\t}\n")
+	}
+}
+
+// printOneOfReader 生成 oneof 成员的二进制解码分支
+func (b *Builder) printOneOfReader(dst *build.Writer, typ *ast.DataType) {
+	for _, oneOf := range typ.OneOfs {
+		name := oneOfName(typ, oneOf)
+		for _, field := range oneOf.Fields {
+			member := build.StringToHumpName(field.Name.Name)
+			dst.Code("\t\tcase " + field.Id.Value + ":\n")
+			dst.Code("\t\t\tv := &" + name + member + "{}\n")
+			dst.Code("\t\t\tv." + member + ", err = ")
+			b.printReader(dst, field.Type)
+			dst.Code("(typ, val)\n")
+			dst.Code("\t\t\tg." + build.StringToHumpName(oneOf.Name.Name) + " = v\n")
+		}
+	}
+}
@@ -1,6 +1,5 @@
 package golang
 
-import "C"
 import (
 	"hbuf/pkg/ast"
 	"hbuf/pkg/build"
@@ -13,9 +12,15 @@ func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) error
 	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/rpc", "")
 	dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/manage", "")
 	b.printServer(dst, typ)
-	b.printClient(dst, typ)
-	b.printServerRouter(dst, typ)
-	err := b.printServerDefault(dst, typ)
+	err := b.printClient(dst, typ)
+	if err != nil {
+		return err
+	}
+	err = b.printServerRouter(dst, typ)
+	if err != nil {
+		return err
+	}
+	err = b.printServerDefault(dst, typ)
 	if err != nil {
 		return err
 	}
@@ -43,32 +48,71 @@ func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
 			dst.Tab(1).Code("//" + build.StringToHumpName(method.Name.Name) + " " + method.Doc.Text())
 		}
 
-		dst.Tab(1).Code("" + build.StringToHumpName(method.Name.Name))
-		dst.Code("(ctx context.Context, ")
-		dst.Code(build.StringToFirstLower(method.ParamName.Name))
-		if method.Param.Type().(*ast.Ident).Name == "stream" {
-			dst.Import("io", "")
-			dst.Code(" io.Reader")
-		} else {
-			dst.Code(" *")
-			b.printType(dst, method.Param, true)
-		}
+		dst.Tab(1)
+		b.printServerMethod(dst, method)
+		dst.Code("\n")
+	}
+	dst.Code("}\n\n")
+}
 
-		dst.Code(") ")
+// isStream 返回参数或结果是否为 stream
+func isStream(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "stream" == ident.Name
+}
 
-		resultType := method.Result.Type().(*ast.Ident).Name
-		if resultType == "void" {
-			dst.Code("error\n")
-		} else if resultType == "stream" {
-			dst.Code("(io.WriteCloser, error)\n")
-		} else {
-			dst.Code("(*")
-			b.printType(dst, method.Result.Type(), true)
-			dst.Code(", error)\n")
-		}
+// isVoid 返回结果是否为 void
+func isVoid(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "void" == ident.Name
+}
 
+// hasStream 返回方法的参数或结果是否为 stream 或 stream<Item>
+func hasStream(method *ast.FuncType) bool {
+	return isStream(method.Param) || isStream(method.Result) || nil != build.GetStreamItem(method.Param) || nil != build.GetStreamItem(method.Result)
+}
+
+// printServerMethod 输出服务端方法的名称、参数和结果，stream 参数为 io.Reader，stream<Item> 参数为 StreamReader，
+// stream 和 stream<Item> 结果由路由传入 io.WriteCloser 或 StreamWriter 供服务写入，方法只返回 error
+func (b *Builder) printServerMethod(dst *build.Writer, method *ast.FuncType) {
+	dst.Code(build.StringToHumpName(method.Name.Name))
+	dst.Code("(ctx context.Context, ")
+	dst.Code(build.StringToFirstLower(method.ParamName.Name) + " ")
+	b.printParamType(dst, method.Param)
+	if isStream(method.Result) {
+		dst.Import("io", "")
+		dst.Code(", writer io.WriteCloser) error")
+	} else if item := build.GetStreamItem(method.Result); nil != item {
+		dst.Code(", writer ")
+		b.printStreamType(dst, "StreamWriter", item)
+		dst.Code(") error")
+	} else if isVoid(method.Result) {
+		dst.Code(") error")
+	} else {
+		dst.Code(") (*")
+		b.printType(dst, method.Result.Type(), true)
+		dst.Code(", error)")
+	}
+}
+
+// printParamType 输出服务端和客户端方法的参数类型
+func (b *Builder) printParamType(dst *build.Writer, typ *ast.VarType) {
+	if isStream(typ) {
+		dst.Import("io", "")
+		dst.Code("io.Reader")
+	} else if item := build.GetStreamItem(typ); nil != item {
+		b.printStreamType(dst, "StreamReader", item)
+	} else {
+		dst.Code("*")
+		b.printType(dst, typ, true)
 	}
-	dst.Code("}\n\n")
+}
+
+// printStreamType 输出 stream<Item> 的读写接口，如 StreamReader[*Item]
+func (b *Builder) printStreamType(dst *build.Writer, name string, item *ast.VarType) {
+	dst.Code(name + "[*")
+	b.printType(dst, item, true)
+	dst.Code("]")
 }
 
 func (b *Builder) printServerDefault(dst *build.Writer, typ *ast.ServerType) error {
@@ -89,40 +133,19 @@ func (b *Builder) printServerDefault(dst *build.Writer, typ *ast.ServerType) err
 		if nil != method.Doc && 0 < len(method.Doc.Text()) {
 			dst.Code("// " + build.StringToHumpName(method.Name.Name) + " " + method.Doc.Text())
 		}
-		isSub := method.Result.Type().(*ast.Ident).Name == "void"
+		isSub := isVoid(method.Result) || isStream(method.Result) || nil != build.GetStreamItem(method.Result)
 
 		dst.Code("func (s *Default" + serverName + ") ")
-		dst.Code(build.StringToHumpName(method.Name.Name))
-		dst.Code("(ctx context.Context, ")
-		dst.Code(build.StringToFirstLower(method.ParamName.Name))
-		if method.Param.Type().(*ast.Ident).Name == "stream" {
-			dst.Import("io", "")
-			dst.Code(" io.Reader")
-		} else {
-			dst.Code(" *")
-			b.printType(dst, method.Param, true)
-		}
-
-		dst.Code(") ")
-
-		resultType := method.Result.Type().(*ast.Ident).Name
-		if resultType == "void" {
-			dst.Code("error {\n")
-		} else if resultType == "stream" {
-			dst.Code("(io.WriteCloser, error) {\n")
-		} else {
-			dst.Code("(*")
-			b.printType(dst, method.Result.Type(), true)
-			dst.Code(", error) {\n")
-		}
+		b.printServerMethod(dst, method)
+		dst.Code(" {\n")
 
 		bind, err := build.GetBinding(method.Tags, dst.File, b.GetDataType)
 		if nil != err {
 			return err
 		}
-		if bind == nil {
+		if bind == nil || hasStream(method) {
 			if isSub {
-				dst.Tab(1).Code("return ")
+				dst.Tab(1).Code("return")
 			} else {
 				dst.Tab(1).Code("return nil,")
 			}
@@ -176,10 +199,19 @@ func (b *Builder) printBinding(dst *build.Writer, method *ast.FuncType, bind *bu
 	return nil
 }
 
-func (b *Builder) printClient(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printClient(dst *build.Writer, typ *ast.ServerType) error {
 	serverName := build.StringToHumpName(typ.Name.Name)
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
+	// 有 stream 方法时客户端需要同时实现 StreamClient，在编译时检查
+	client := "rpc.Client"
+	if hasServerStream(typ) {
+		client = "StreamClient"
+	}
 	dst.Code("type " + serverName + "Client struct {\n")
-	dst.Tab(1).Code("client rpc.Client\n")
+	dst.Tab(1).Code("client " + client + "\n")
 	dst.Code("}\n\n")
 
 	dst.Code("func (p *" + serverName + "Client) Init(ctx context.Context) {\n")
@@ -190,58 +222,57 @@ func (b *Builder) printClient(dst *build.Writer, typ *ast.ServerType) {
 	dst.Code("}\n\n")
 
 	dst.Code("func (p *" + serverName + "Client) GetId() uint32 {\n")
-	dst.Tab(1).Code("return 1\n")
+	dst.Tab(1).Code("return " + serverId + "\n")
 	dst.Code("}\n\n")
 
-	dst.Code("func New" + serverName + "Client(client rpc.Client) *" + serverName + "Client {\n")
+	dst.Code("func New" + serverName + "Client(client " + client + ") *" + serverName + "Client {\n")
 	dst.Tab(1).Code("return &" + serverName + "Client{\n")
 	dst.Tab(2).Code("client: client,\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("}\n\n")
 	name := build.StringToUnderlineName(typ.Name.Name)
-	err := build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	return build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
 		if nil != method.Doc && 0 < len(method.Doc.Text()) {
 			dst.Code("// " + build.StringToHumpName(method.Name.Name) + " " + method.Doc.Text())
 		}
-		dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
-
-		resultType := method.Result.Type().(*ast.Ident).Name
-		paramType := method.Param.Type().(*ast.Ident).Name
-
 		dst.Code("func (r *" + serverName + "Client) ")
 		dst.Code(build.StringToHumpName(method.Name.Name))
 		dst.Code("(ctx context.Context, ")
-		dst.Code(build.StringToFirstLower(method.ParamName.Name))
-		if paramType == "stream" {
-			dst.Import("io", "")
-			dst.Code(" io.Reader")
-		} else {
-			dst.Code(" *")
-			b.printType(dst, method.Param, true)
-		}
+		dst.Code(build.StringToFirstLower(method.ParamName.Name) + " ")
+		b.printParamType(dst, method.Param)
 		dst.Code(") ")
 
-		if resultType == "void" {
+		if isVoid(method.Result) {
 			dst.Code("error {\n")
-		} else if resultType == "stream" {
-			dst.Code("(io.WriteCloser, error) {\n")
+		} else if isStream(method.Result) {
+			dst.Import("io", "")
+			dst.Code("(io.ReadCloser, error) {\n")
+		} else if item := build.GetStreamItem(method.Result); nil != item {
+			dst.Code("(")
+			b.printStreamType(dst, "StreamReader", item)
+			dst.Code(", error) {\n")
 		} else {
 			dst.Code("(*")
 			b.printType(dst, method.Result.Type(), true)
 			dst.Code(", error) {\n")
 		}
 
-		if paramType == "stream" {
-			dst.Tab(1).Code("return nil,nil\n")
+		if hasStream(method) {
+			b.printClientStream(dst, invokeId, method, name+"/"+build.StringToUnderlineName(typ.Name.Name)+"/"+build.StringToUnderlineName(method.Name.Name))
 		} else {
 			dst.Import("encoding/json", "")
-			if resultType == "void" {
+			dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
+			if isVoid(method.Result) {
 				dst.Tab(1).Code("_")
 			} else {
 				dst.Tab(1).Code("ret")
 			}
 			dst.Code(", err := r.client.Invoke(ctx, req, \"" + name + "/" + build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\", &rpc.ClientInvoke{\n")
-			if resultType != "void" {
+			if !isVoid(method.Result) {
 				dst.Tab(2).Code("ToData: func(buf []byte) (hbuf.Data, error) {\n")
 				dst.Tab(3).Code("var req ")
 				b.printType(dst, method.Result.Type(), true)
@@ -252,8 +283,17 @@ func (b *Builder) printClient(dst *build.Writer, typ *ast.ServerType) {
 			dst.Tab(2).Code("FormData: func(data hbuf.Data) ([]byte, error) {\n")
 			dst.Tab(3).Code("return json.Marshal(&data)\n")
 			dst.Tab(2).Code("},\n")
-			dst.Tab(1).Code("}, 1, &rpc.ClientInvoke{})\n")
-			if resultType == "void" {
+			dst.Tab(1).Code("}, " + invokeId + ", &rpc.ClientInvoke{\n")
+			if !isVoid(method.Result) {
+				dst.Tab(2).Code("ToData: func(buf []byte) (hbuf.Data, error) {\n")
+				b.printDecoderInvoke(dst, method.Result.Type(), 3)
+				dst.Tab(2).Code("},\n")
+			}
+			dst.Tab(2).Code("FormData: func(data hbuf.Data) ([]byte, error) {\n")
+			b.printEncoderInvoke(dst, method.Param, 3)
+			dst.Tab(2).Code("},\n")
+			dst.Tab(1).Code("})\n")
+			if isVoid(method.Result) {
 				dst.Tab(1).Code("if err != nil {\n")
 				dst.Tab(2).Code("return err\n")
 				dst.Tab(1).Code("}\n")
@@ -271,9 +311,6 @@ func (b *Builder) printClient(dst *build.Writer, typ *ast.ServerType) {
 		dst.Code("\n")
 		return nil
 	})
-	if err != nil {
-		return
-	}
 }
 
 type Tag map[string][]string
@@ -288,7 +325,7 @@ func (b *Builder) getTag(tags []*ast.Tag) *Tag {
 		for _, item := range val.KV {
 			list := make([]string, 0)
 			for _, value := range item.Values {
-				list = append(list, value.Value[1:len(value.Value)-1])
+				list = append(list, build.TagString(value))
 			}
 			au[item.Name.Name] = list
 		}
@@ -296,11 +333,25 @@ func (b *Builder) getTag(tags []*ast.Tag) *Tag {
 	return &au
 }
 
-func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) error {
 	serverName := build.StringToHumpName(typ.Name.Name)
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
+	isHttp := hasHttp(typ)
+	isStreams := hasServerStream(typ)
 	dst.Code("type " + serverName + "Router struct {\n")
 	dst.Tab(1).Code("server " + serverName + "\n")
-	dst.Tab(1).Code("names  map[string]*rpc.ServerInvoke\n")
+	dst.Tab(1).Code("names map[string]*rpc.ServerInvoke\n")
+	dst.Tab(1).Code("ids map[int64]*rpc.ServerInvoke\n")
+	if isHttp {
+		dst.Tab(1).Code("routes []*HttpRoute\n")
+	}
+	if isStreams {
+		dst.Tab(1).Code("streams map[string]*StreamInvoke\n")
+		dst.Tab(1).Code("streamIds map[int64]*StreamInvoke\n")
+	}
 	dst.Code("}\n\n")
 
 	dst.Code("func (p *" + serverName + "Router) GetName() string {\n")
@@ -308,7 +359,7 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Code("}\n\n")
 
 	dst.Code("func (p *" + serverName + "Router) GetId() uint32 {\n")
-	dst.Tab(1).Code("return 1\n")
+	dst.Tab(1).Code("return " + serverId + "\n")
 	dst.Code("}\n\n")
 
 	dst.Code("func (p *" + serverName + "Router) GetServer() rpc.Init {\n")
@@ -319,75 +370,209 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Tab(1).Code("return p.names\n")
 	dst.Code("}\n\n")
 
+	dst.Code("// GetInvokeIds 按 服务Id<<32 | 方法Id 查找调用，供二进制传输使用\n")
+	dst.Code("func (p *" + serverName + "Router) GetInvokeIds() map[int64]*rpc.ServerInvoke {\n")
+	dst.Tab(1).Code("return p.ids\n")
+	dst.Code("}\n\n")
+
+	if isHttp {
+		dst.Code("// GetHttpRoutes 返回由 http 注解生成的 REST 路由，供 HttpHandler 使用\n")
+		dst.Code("func (p *" + serverName + "Router) GetHttpRoutes() []*HttpRoute {\n")
+		dst.Tab(1).Code("return p.routes\n")
+		dst.Code("}\n\n")
+	}
+
+	if isStreams {
+		dst.Code("// GetStreams 返回 stream 方法的调用，供 StreamServer 使用\n")
+		dst.Code("func (p *" + serverName + "Router) GetStreams() map[string]*StreamInvoke {\n")
+		dst.Tab(1).Code("return p.streams\n")
+		dst.Code("}\n\n")
+
+		dst.Code("// GetStreamIds 按 服务Id<<32 | 方法Id 查找 stream 方法的调用\n")
+		dst.Code("func (p *" + serverName + "Router) GetStreamIds() map[int64]*StreamInvoke {\n")
+		dst.Tab(1).Code("return p.streamIds\n")
+		dst.Code("}\n\n")
+	}
+
 	dst.Code("func New" + serverName + "Router(server " + serverName + ") *" + serverName + "Router {\n")
-	dst.Tab(1).Code("return &" + serverName + "Router{\n")
-	dst.Tab(2).Code("server: server,\n")
-	dst.Tab(2).Code("names: map[string]*rpc.ServerInvoke{\n")
-	err := build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	// 只有 stream 方法时 names 和 ids 为空
+	isPlain := false
+	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		isPlain = isPlain || !hasStream(method)
+		return nil
+	})
+	dst.Tab(1).Code("names := map[string]*rpc.ServerInvoke{")
+	if isPlain {
+		dst.Code("\n")
+	}
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		if hasStream(method) {
+			return nil
+		}
 		dst.Import("github.com/wskfjtheqian/hbuf_golang/pkg/hbuf", "")
 
-		isMethod := method.Result.Type().(*ast.Ident).Name == "void"
-		isStream := method.Param.Type().(*ast.Ident).Name == "stream"
+		isMethod := isVoid(method.Result)
 
-		dst.Tab(3).Code("\"" + build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": {\n")
-		dst.Tab(4).Code("ToData: func(buf []byte) (hbuf.Data, error) {\n")
-		if isStream {
-			dst.Tab(5).Code("return nil, nil\n")
-		} else {
-			dst.Tab(5).Code("var req ")
-			b.printType(dst, method.Param, true)
-			dst.Code("\n")
-			dst.Import("encoding/json", "")
-			dst.Tab(5).Code("return &req, json.Unmarshal(buf, &req)\n")
-		}
+		dst.Tab(2).Code("\"" + build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": {\n")
+		dst.Tab(3).Code("ToData: func(buf []byte) (hbuf.Data, error) {\n")
+		dst.Tab(4).Code("var req ")
+		b.printType(dst, method.Param, true)
+		dst.Code("\n")
+		dst.Import("encoding/json", "")
+		dst.Tab(4).Code("return &req, json.Unmarshal(buf, &req)\n")
 
-		dst.Tab(4).Code("},\n")
+		dst.Tab(3).Code("},\n")
 		if !isMethod {
-			dst.Tab(4).Code("FormData: func(data hbuf.Data) ([]byte, error) {\n")
-			dst.Tab(5).Code("return json.Marshal(&data)\n")
-			dst.Tab(4).Code("},\n")
+			dst.Tab(3).Code("FormData: func(data hbuf.Data) ([]byte, error) {\n")
+			dst.Tab(4).Code("return json.Marshal(&data)\n")
+			dst.Tab(3).Code("},\n")
 		}
-		dst.Tab(4).Code("SetInfo: func(ctx context.Context) {\n")
-
-		au := b.getTag(method.Tags)
-		if nil != au {
-			keys := build.GetKeysByMap(*au)
-			sort.Strings(keys)
-			for _, key := range keys {
-				values := (*au)[key]
-				if len(values) > 0 {
-					dst.Tab(5).Code("rpc.SetTag(ctx, \"").Code(key)
-					for _, val := range values {
-						dst.Code("\", \"").Code(val)
-					}
-					dst.Code("\")\n")
-				}
-			}
-		}
-		dst.Tab(4).Code("},\n")
-		dst.Tab(4).Code("Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {\n")
-		dst.Tab(5).Code("return ")
+		b.printSetInfo(dst, method)
+		dst.Tab(3).Code("Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {\n")
+		dst.Tab(4).Code("return ")
 		if isMethod {
 			dst.Code("nil, ")
 		}
-		if isStream {
-			dst.Code("nil, nil\n")
-		} else {
-			dst.Code("server." + build.StringToHumpName(method.Name.Name) + "(ctx, data.(*")
-			b.printType(dst, method.Param, true)
-			dst.Code("))\n")
+		dst.Code("server." + build.StringToHumpName(method.Name.Name) + "(ctx, data.(*")
+		b.printType(dst, method.Param, true)
+		dst.Code("))\n")
+		dst.Tab(3).Code("},\n")
+		dst.Tab(2).Code("},\n")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if isPlain {
+		dst.Tab(1)
+	}
+	dst.Code("}\n")
+
+	if isStreams {
+		dst.Tab(1).Code("streams := map[string]*StreamInvoke{\n")
+		err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			dst.Tab(2).Code("\"" + build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": {\n")
+			b.printSetInfo(dst, method)
+			b.printRouterStream(dst, method)
+			dst.Tab(2).Code("},\n")
+			return nil
+		})
+		if err != nil {
+			return err
 		}
+		dst.Tab(1).Code("}\n")
+	}
 
+	dst.Tab(1).Code("return &" + serverName + "Router{\n")
+	if !isPlain {
+		dst.Tab(2).Code("server:  server,\n")
+		dst.Tab(2).Code("names:   names,\n")
+		dst.Tab(2).Code("ids:     map[int64]*rpc.ServerInvoke{},\n")
+	} else {
+		dst.Tab(2).Code("server: server,\n")
+		dst.Tab(2).Code("names:  names,\n")
+		dst.Tab(2).Code("ids: map[int64]*rpc.ServerInvoke{\n")
+	}
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		if hasStream(method) {
+			return nil
+		}
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
+		name := build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name)
+		dst.Tab(3).Code(invokeId + ": {\n")
+		dst.Tab(4).Code("ToData: func(buf []byte) (hbuf.Data, error) {\n")
+		b.printDecoderInvoke(dst, method.Param.Type(), 5)
 		dst.Tab(4).Code("},\n")
+		if !isVoid(method.Result) {
+			dst.Tab(4).Code("FormData: func(data hbuf.Data) ([]byte, error) {\n")
+			b.printEncoderInvoke(dst, method.Result.Type(), 5)
+			dst.Tab(4).Code("},\n")
+		}
+		dst.Tab(4).Code("SetInfo: names[\"" + name + "\"].SetInfo,\n")
+		dst.Tab(4).Code("Invoke:  names[\"" + name + "\"].Invoke,\n")
 		dst.Tab(3).Code("},\n")
 		return nil
 	})
 	if err != nil {
-		return
+		return err
+	}
+	if isPlain {
+		dst.Tab(2).Code("},\n")
+	}
+	if isHttp {
+		err = b.printHttpRoutes(dst, typ)
+		if err != nil {
+			return err
+		}
+	}
+	if isStreams {
+		dst.Tab(2).Code("streams: streams,\n")
+		dst.Tab(2).Code("streamIds: map[int64]*StreamInvoke{\n")
+		err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			invokeId, err := build.GetInvokeId(server, method)
+			if err != nil {
+				return err
+			}
+			dst.Tab(3).Code(invokeId + ": streams[\"" + build.StringToUnderlineName(typ.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\"],\n")
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		dst.Tab(2).Code("},\n")
 	}
-	dst.Tab(2).Code("},\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("}\n\n")
+	return nil
+}
+
+// printSetInfo 输出路由调用前把方法的 tag 注解写入上下文的代码
+func (b *Builder) printSetInfo(dst *build.Writer, method *ast.FuncType) {
+	dst.Tab(3).Code("SetInfo: func(ctx context.Context) {\n")
+	au := b.getTag(method.Tags)
+	if nil != au {
+		keys := build.GetKeysByMap(*au)
+		sort.Strings(keys)
+		for _, key := range keys {
+			values := (*au)[key]
+			if len(values) > 0 {
+				dst.Tab(4).Code("rpc.SetTag(ctx, \"").Code(key)
+				for _, val := range values {
+					dst.Code("\", \"").Code(val)
+				}
+				dst.Code("\")\n")
+			}
+		}
+	}
+	dst.Tab(3).Code("},\n")
+}
+
+// printDecoderInvoke 输出用二进制解码请求或结果的代码
+func (b *Builder) printDecoderInvoke(dst *build.Writer, typ ast.Expr, tab int) {
+	dst.Import("bytes", "")
+	dst.Tab(tab).Code("var ret ")
+	b.printType(dst, typ, true)
+	dst.Code("\n")
+	dst.Tab(tab).Code("return &ret, ret.Decoder(bytes.NewReader(buf))\n")
+}
+
+// printEncoderInvoke 输出用二进制编码请求或结果的代码
+func (b *Builder) printEncoderInvoke(dst *build.Writer, typ ast.Expr, tab int) {
+	dst.Import("bytes", "")
+	dst.Tab(tab).Code("buf := &bytes.Buffer{}\n")
+	dst.Tab(tab).Code("err := data.(*")
+	b.printType(dst, typ, true)
+	dst.Code(").Encoder(buf)\n")
+	dst.Tab(tab).Code("return buf.Bytes(), err\n")
 }
 
 func (b *Builder) printGetServerRouter(dst *build.Writer, typ *ast.ServerType) {
@@ -43,7 +43,7 @@ func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
 		}
 	}
 	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
+		if f, ok := b.pkg.Files[spec.File]; ok {
 			if obj := f.Scope.Lookup(name); nil != obj {
 				switch obj.Decl.(type) {
 				case *ast.TypeSpec:
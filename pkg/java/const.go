@@ -0,0 +1,37 @@
+package java
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+func (b *Builder) printConstCode(dst *build.Writer, spec *ast.ConstSpec) {
+	if nil != spec.Doc && 0 < len(spec.Doc.Text()) {
+		dst.Tab(1).Code("///" + spec.Doc.Text())
+	}
+	typ := build.BaseType(spec.Type.Name)
+	if build.Uint64 == typ {
+		dst.Import("java.math.BigInteger", "")
+	}
+	value := literalValue(typ, build.ConstValue(spec))
+	dst.Tab(1).Code(_types[typ] + " " + build.StringToAllUpper(spec.Name.Name) + " = " + value + ";\n\n")
+}
+
+// literalValue 将 build.LiteralValue 的结果转为 Java 的字面量，uint64 与字段相同使用 BigInteger
+func literalValue(typ build.BaseType, value string) string {
+	switch typ {
+	case build.Int64, build.Uint32:
+		return value + "L"
+	case build.Uint64:
+		return "new BigInteger(\"" + value + "\")"
+	case build.Uint8:
+		return "(char) " + value
+	case build.Float:
+		return value + "f"
+	case build.Double:
+		return value + "d"
+	case build.Decimal:
+		return "new BigDecimal(\"" + value + "\")"
+	}
+	return value
+}
@@ -0,0 +1,29 @@
+package java
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestConst uint64 常量与字段相同使用 BigInteger
+func TestConst(t *testing.T) {
+	src := "" +
+		"package java = \"com.demo\"\n" +
+		"\n" +
+		"const uint64 MaxId = 18446744073709551615\n" +
+		"const int64  MinId = -1\n" +
+		"\n" +
+		"data Info = 1 {\n" +
+		"    uint64 id = 0\n" +
+		"}\n"
+	out := buildtest.Generate(t, "java", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "AEnum.java"),
+		"import java.math.BigInteger;\n",
+		"BigInteger MAX_ID = new BigInteger(\"18446744073709551615\");\n",
+		"long MIN_ID = -1L;\n",
+	)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "AData.java"),
+		"import java.math.BigInteger;\n",
+		"BigInteger getId();\n",
+	)
+}
@@ -59,7 +59,12 @@ func (b *Builder) printDataEntity(dst *build.Writer, typ *ast.DataType) {
 	err := build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
 		dst.Tab(2).Code("")
 		b.printType(dst, field.Type, false)
-		dst.Code(" " + build.StringToFirstLower(field.Name.Name) + ";\n\n")
+		dst.Code(" " + build.StringToFirstLower(field.Name.Name))
+		if nil != field.Default {
+			dst.Code(" = ")
+			b.printFieldDefault(dst, field)
+		}
+		dst.Code(";\n\n")
 
 		dst.Tab(2).Code("@Override\n")
 		dst.Tab(2).Code("public ")
@@ -173,3 +178,14 @@ func (b *Builder) printExtend(dst *build.Writer, extends []*ast.Extends, start b
 		dst.Code(build.StringToHumpName(v.Name.Name))
 	}
 }
+
+// printFieldDefault 输出字段的默认值，默认值已在检查时统一写法
+func (b *Builder) printFieldDefault(dst *build.Writer, field *ast.Field) {
+	ident := field.Type.Type().(*ast.Ident)
+	if nil != ident.Obj {
+		b.printType(dst, ident, true)
+		dst.Code("." + build.StringToAllUpper(field.Default.Value))
+		return
+	}
+	dst.Code(literalValue(build.BaseType(ident.Name), field.Default.Value))
+}
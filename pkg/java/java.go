@@ -1,6 +1,7 @@
 package java
 
 import (
+	"errors"
 	"go/printer"
 	"hbuf/pkg/ast"
 	"hbuf/pkg/build"
@@ -14,12 +15,14 @@ var _types = map[build.BaseType]string{
 	build.Int8: "byte", build.Int16: "short", build.Int32: "int", build.Int64: "long", build.Uint8: "char",
 	build.Uint16: "int", build.Uint32: "long", build.Uint64: "BigInteger", build.Bool: "boolean", build.Float: "float",
 	build.Double: "double", build.String: "String", build.Date: "Date", build.Decimal: "BigDecimal",
+	build.Bytes: "byte[]", build.Duration: "Duration", build.Uuid: "UUID", build.Json: "Object",
 }
 
 var _nullTypes = map[build.BaseType]string{
 	build.Int8: "Byte", build.Int16: "Short", build.Int32: "Integer", build.Int64: "Long", build.Uint8: "Character",
 	build.Uint16: "Integer", build.Uint32: "Long", build.Uint64: "BigInteger", build.Bool: "Boolean", build.Float: "Float",
 	build.Double: "Double", build.String: "String", build.Date: "Date", build.Decimal: "BigDecimal",
+	build.Bytes: "byte[]", build.Duration: "Duration", build.Uuid: "UUID", build.Json: "Object",
 }
 
 type JavaWriter struct {
@@ -72,7 +75,11 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 		return nil
 	}
 
-	dir, name := filepath.Split(param.GetOut())
+	// 代码都生成到输出目录下，不同目录下的同名文件会互相覆盖
+	if other := build.DuplicateOut(b.pkg, file, javaPackage); nil != other {
+		return errors.New("Duplicate output: " + file.Name + " and " + other.Name + " generate the same java file")
+	}
+	dir, name := param.GetOutDir(), filepath.Base(param.GetOut())
 	name = build.StringToHumpName(name[:len(name)-len(".hbuf")])
 
 	err = os.MkdirAll(dir, os.ModePerm)
@@ -80,7 +87,7 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 		return err
 	}
 
-	if 0 < dst.data.GetCode().Len() {
+	if param.Feature(build.FeatureData) && 0 < dst.data.GetCode().Len() {
 		err := writerFile(dst.data, dst.Packages, filepath.Join(dir, name+"Data.java"))
 		if err != nil {
 			return err
@@ -92,7 +99,7 @@ func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
 			return err
 		}
 	}
-	if 0 < dst.server.GetCode().Len() {
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
 		err = writerFile(dst.server, dst.Packages, filepath.Join(dir, name+"Server.java"))
 		if err != nil {
 			return err
@@ -116,7 +123,7 @@ func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
 		}
 	}
 	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
+		if f, ok := b.pkg.Files[spec.File]; ok {
 			if obj := f.Scope.Lookup(name); nil != obj {
 				switch obj.Decl.(type) {
 				case *ast.TypeSpec:
@@ -134,6 +141,14 @@ func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
 	return nil
 }
 
+// javaPackage 有 java 包名的文件都生成到输出目录下，返回输出目录的名称
+func javaPackage(file *ast.File) string {
+	if _, ok := file.Packages["java"]; ok {
+		return "."
+	}
+	return ""
+}
+
 func writerFile(data *build.Writer, packages string, out string) error {
 	fc, err := os.Create(out)
 	if err != nil {
@@ -191,8 +206,13 @@ func (b *Builder) Node(dst *JavaWriter, fset *token.FileSet, node interface{}) e
 	for _, s := range file.Specs {
 		switch s.(type) {
 		case *ast.ImportSpec:
+		case *ast.ConstSpec:
+			b.printConstCode(dst.enum, s.(*ast.ConstSpec))
 		case *ast.TypeSpec:
-			b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
+			err := b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
+			if err != nil {
+				return build.ErrorToFileError(err, fset)
+			}
 		}
 	}
 	if 0 < dst.data.GetCode().Len() {
@@ -207,15 +227,16 @@ func (b *Builder) Node(dst *JavaWriter, fset *token.FileSet, node interface{}) e
 	return nil
 }
 
-func (b *Builder) printTypeSpec(dst *JavaWriter, expr ast.Expr) {
+func (b *Builder) printTypeSpec(dst *JavaWriter, expr ast.Expr) error {
 	switch expr.(type) {
 	case *ast.DataType:
 		b.printDataCode(dst.data, expr.(*ast.DataType))
 	case *ast.ServerType:
-		b.printServerCode(dst.server, expr.(*ast.ServerType))
+		return b.printServerCode(dst.server, expr.(*ast.ServerType))
 	case *ast.EnumType:
 		b.printEnumCode(dst.enum, expr.(*ast.EnumType))
 	}
+	return nil
 }
 
 func (b *Builder) printType(dst *build.Writer, expr ast.Expr, notEmpty bool) {
@@ -230,7 +251,11 @@ func (b *Builder) printType(dst *build.Writer, expr ast.Expr, notEmpty bool) {
 				dst.Import("java.math.BigDecimal", "")
 			} else if build.Date == build.BaseType((expr.(*ast.Ident).Name)) {
 				dst.Import("java.util.Date", "")
-			} else if build.Int64 == build.BaseType((expr.(*ast.Ident).Name)) {
+			} else if build.Duration == build.BaseType((expr.(*ast.Ident).Name)) {
+				dst.Import("java.time.Duration", "")
+			} else if build.Uuid == build.BaseType((expr.(*ast.Ident).Name)) {
+				dst.Import("java.util.UUID", "")
+			} else if build.Uint64 == build.BaseType((expr.(*ast.Ident).Name)) {
 				dst.Import("java.math.BigInteger", "")
 			}
 			if notEmpty {
@@ -5,15 +5,18 @@ import (
 	"hbuf/pkg/build"
 )
 
-func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) error {
 	dst.Import("java.util.concurrent.CompletableFuture", "")
 	dst.Import("com.hbuf.java.Data", "")
 	dst.Import("com.hbuf.java.Server", "")
 
 	b.printServer(dst, typ)
-	b.printServerClient(dst, typ)
+	err := b.printServerClient(dst, typ)
+	if err != nil {
+		return err
+	}
 	b.printServerRouter(dst, typ)
-
+	return nil
 }
 
 func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
@@ -44,7 +47,11 @@ func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
 	dst.Tab(1).Code("}\n\n")
 }
 
-func (b *Builder) printServerClient(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerClient(dst *build.Writer, typ *ast.ServerType) error {
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
 	dst.Tab(1).Code("class " + build.StringToHumpName(typ.Name.Name) + "Client extends Server.ClientRouter implements " + build.StringToHumpName(typ.Name.Name))
 
 	dst.Code("{\n")
@@ -59,11 +66,14 @@ func (b *Builder) printServerClient(dst *build.Writer, typ *ast.ServerType) {
 	dst.Tab(2).Code("}\n\n")
 	dst.Tab(2).Code("@Override\n")
 	dst.Tab(2).Code("public long getId() {\n")
-	//TODO dst.Tab(3).Code("return " + typ.Id.Value + ";\n")
-	dst.Tab(3).Code("return 0;\n")
+	dst.Tab(3).Code("return " + serverId + ";\n")
 	dst.Tab(2).Code("}\n\n")
 
-	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
 		dst.Tab(2).Code("@Override\n")
 		dst.Tab(2).Code("public CompletableFuture<")
 		b.printType(dst, method.Result.Type(), false)
@@ -76,8 +86,7 @@ func (b *Builder) printServerClient(dst *build.Writer, typ *ast.ServerType) {
 		dst.Tab(3).Code("return invoke(\"")
 		dst.Code(build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name))
 		dst.Code("\", ")
-		//TODO dst.Code(server.Id.Value + " << 32 | " + method.Id.Value)
-		dst.Code("0 << 32 | " + method.Id.Value)
+		dst.Code(invokeId + "L")
 		dst.Code(", ")
 		dst.Code(build.StringToFirstLower(method.ParamName.Name))
 		dst.Code(", (data) -> Data.formJson.invoke(new String(data), ")
@@ -89,7 +98,11 @@ func (b *Builder) printServerClient(dst *build.Writer, typ *ast.ServerType) {
 		dst.Tab(2).Code("}\n\n")
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 	dst.Tab(1).Code("}\n\n")
+	return nil
 }
 
 func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
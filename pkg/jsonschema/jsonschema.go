@@ -0,0 +1,93 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+	"hbuf/pkg/openapi"
+	"hbuf/pkg/token"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Build 为文件中的每个数据生成 文件名.数据名.schema.json，引用的数据和枚举放在 $defs 中
+func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
+	for _, s := range file.Specs {
+		spec, ok := s.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		data, ok := spec.Type.(*ast.DataType)
+		if !ok {
+			continue
+		}
+		out := filepath.Join(param.GetOutDir(), filepath.FromSlash(Id(file, data)))
+		schema, err := Schema(param.GetPkg(), data)
+		if err != nil {
+			return build.ErrorToFileError(err, fset)
+		}
+
+		err = os.MkdirAll(filepath.Dir(out), os.ModePerm)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(schema)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(out, buf.Bytes(), 0666)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Id 返回数据的 Schema 相对输出目录的路径，同时作为 $id，如 common/page.Page.schema.json，
+// 加上文件的路径和名称，不同文件中的同名数据不会互相覆盖
+func Id(file *ast.File, data *ast.DataType) string {
+	name := build.OutName(file)
+	name = name[:len(name)-len(path.Ext(name))]
+	return name + "." + build.StringToHumpName(data.Name.Name) + ".schema.json"
+}
+
+// Schema 返回数据的 JSON Schema，继承的字段合并到数据中
+func Schema(pkg *ast.Package, data *ast.DataType) (*openapi.Object, error) {
+	schemas := openapi.NewSchemas(pkg, "#/$defs/")
+	schemas.Root = data
+	_, err := schemas.Data(data)
+	if err != nil {
+		return nil, err
+	}
+
+	name := build.StringToHumpName(data.Name.Name)
+	ret := openapi.NewObject()
+	ret.Set("$schema", "https://json-schema.org/draft/2020-12/schema")
+	if file, ok := data.Name.Obj.Data.(*ast.File); ok {
+		ret.Set("$id", Id(file, data))
+	}
+	ret.Set("title", name)
+	defs := openapi.NewObject()
+	for i, key := range schemas.Defs.Keys() {
+		value, _ := schemas.Defs.Get(key)
+		if 0 == i {
+			root := value.(*openapi.Object)
+			for _, k := range root.Keys() {
+				v, _ := root.Get(k)
+				ret.Set(k, v)
+			}
+		} else {
+			defs.Set(key, value)
+		}
+	}
+	if 0 < defs.Len() {
+		ret.Set("$defs", defs)
+	}
+	return ret, nil
+}
@@ -0,0 +1,101 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"hbuf/pkg/build/buildtest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	src := "" +
+		"enum Verify {\n" +
+		"    [format:reg=\"^\\\\w+$\";max=\"8\"]\n" +
+		"    Name = 1\n" +
+		"}\n" +
+		"\n" +
+		"enum Status {\n" +
+		"    Enable  = 0\n" +
+		"    Disable = 1\n" +
+		"}\n" +
+		"\n" +
+		"data Base {\n" +
+		"    int64 id = 0\n" +
+		"}\n" +
+		"\n" +
+		"data Node : Base = 1 {\n" +
+		"    [verify:format=Verify.Name]\n" +
+		"    string? name = 0\n" +
+		"    Status<string> states = 1\n" +
+		"    Node?[] children = 2\n" +
+		"}\n"
+	out := buildtest.Generate(t, "jsonschema", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	if _, err := os.Stat(filepath.Join(out, "a.Base.schema.json")); err != nil {
+		t.Fatal(err)
+	}
+	buf := buildtest.ReadFile(t, out, "a.Node.schema.json")
+	var schema struct {
+		Id         string `json:"$id"`
+		Title      string
+		Required   []string
+		Properties map[string]map[string]any
+		Defs       map[string]map[string]any `json:"$defs"`
+	}
+	err := json.Unmarshal([]byte(buf), &schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if "a.Node.schema.json" != schema.Id || "Node" != schema.Title || 1 != len(schema.Required) || "name" != schema.Required[0] {
+		t.Errorf("node: %s", buf)
+	}
+	if "string" != schema.Properties["id"]["type"] {
+		t.Errorf("id: %v", schema.Properties["id"])
+	}
+	if `^\w+$` != schema.Properties["name"]["pattern"] || 8.0 != schema.Properties["name"]["maxLength"] {
+		t.Errorf("name: %v", schema.Properties["name"])
+	}
+	states := schema.Properties["states"]["additionalProperties"].(map[string]any)
+	if "#/$defs/Status" != states["$ref"] {
+		t.Errorf("states: %v", schema.Properties["states"])
+	}
+	if enum, ok := schema.Defs["Status"]["enum"].([]any); !ok || 2 != len(enum) {
+		t.Errorf("status: %v", schema.Defs["Status"])
+	}
+	items := schema.Properties["children"]["items"].(map[string]any)["anyOf"].([]any)
+	if "#" != items[0].(map[string]any)["$ref"] {
+		t.Errorf("children: %v", schema.Properties["children"])
+	}
+	if _, ok := schema.Defs["Node"]; ok {
+		t.Errorf("defs: %v", schema.Defs)
+	}
+}
+
+// TestBuildSameName 不同文件中的同名数据生成到不同的文件，$id 也不同
+func TestBuildSameName(t *testing.T) {
+	files := map[string]string{
+		"a.hbuf":           "import \"b.hbuf\" as b\nimport \"common/page.hbuf\" as c\n\ndata Page = 1 {\n    b.Page next = 0\n    c.Page last = 1\n}\n",
+		"b.hbuf":           "data Page = 2 {\n    int32 size = 0\n}\n",
+		"common/page.hbuf": "data Page = 3 {\n    string cursor = 0\n}\n",
+	}
+	out := buildtest.Generate(t, "jsonschema", Build, "a.hbuf", files)
+	ids := map[string]string{
+		"a.Page.schema.json":           "next",
+		"b.Page.schema.json":           "size",
+		"common/page.Page.schema.json": "cursor",
+	}
+	for id, field := range ids {
+		var schema struct {
+			Id         string `json:"$id"`
+			Properties map[string]any
+		}
+		err := json.Unmarshal([]byte(buildtest.ReadFile(t, out, filepath.FromSlash(id))), &schema)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := schema.Properties[field]; id != schema.Id || !ok {
+			t.Errorf("%s: got $id %s, properties %v", id, schema.Id, schema.Properties)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Object 按写入顺序输出键的 JSON 对象，生成的文档与 hbuf 文件中的顺序一致
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+func NewObject() *Object {
+	return &Object{values: map[string]any{}}
+}
+
+// Set 设置键的值，已有的键保持原来的位置
+func (o *Object) Set(key string, value any) *Object {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+	return o
+}
+
+func (o *Object) Get(key string) (any, bool) {
+	value, ok := o.values[key]
+	return value, ok
+}
+
+// Keys 返回按写入顺序排列的键
+func (o *Object) Keys() []string {
+	return o.keys
+}
+
+func (o *Object) Len() int {
+	return len(o.keys)
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if 0 < i {
+			buf.WriteByte(',')
+		}
+		err := encodeJson(&buf, key, "")
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(':')
+		err = encodeJson(&buf, o.values[key], "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o *Object) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range o.keys {
+		var keyNode, valueNode yaml.Node
+		keyNode.SetString(key)
+		err := valueNode.Encode(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &keyNode, &valueNode)
+	}
+	return node, nil
+}
+
+// encodeJson 写入 value 的 JSON，不转义正则和说明中的 <、>、&
+func encodeJson(buf *bytes.Buffer, value any, indent string) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", indent)
+	err := encoder.Encode(value)
+	if err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
@@ -0,0 +1,334 @@
+package openapi
+
+import (
+	"bytes"
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+	"hbuf/pkg/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Build 生成 OpenAPI 3.1 文档，每个 hbuf 文件生成 名称.openapi.json 和 名称.openapi.yaml，
+// 功能 json 和 yaml 可以关闭其中一个
+func Build(file *ast.File, fset *token.FileSet, param *build.Param) error {
+	b := &Builder{
+		schemas: NewSchemas(param.GetPkg(), "#/components/schemas/"),
+		tags:    []*Object{},
+		paths:   NewObject(),
+	}
+	err := b.Node(file)
+	if err != nil {
+		return build.ErrorToFileError(err, fset)
+	}
+	if 0 == b.schemas.Defs.Len() && 0 == b.paths.Len() {
+		return nil
+	}
+
+	dir, name := filepath.Split(param.GetOut())
+	name = name[:len(name)-len(".hbuf")]
+	doc := b.Document(name)
+
+	err = os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if param.Feature(build.FeatureJson) {
+		var buf bytes.Buffer
+		err = encodeJson(&buf, doc, "  ")
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		err = os.WriteFile(filepath.Join(dir, name+".openapi.json"), buf.Bytes(), 0666)
+		if err != nil {
+			return err
+		}
+	}
+	if param.Feature(build.FeatureYaml) {
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		err = encoder.Encode(doc)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(dir, name+".openapi.yaml"), buf.Bytes(), 0666)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Builder struct {
+	schemas *Schemas
+	tags    []*Object
+	paths   *Object
+}
+
+// Document 返回完整的文档
+func (b *Builder) Document(title string) *Object {
+	doc := NewObject()
+	doc.Set("openapi", "3.1.0")
+	doc.Set("info", NewObject().Set("title", title).Set("version", "1.0.0"))
+	if 0 < len(b.tags) {
+		doc.Set("tags", b.tags)
+	}
+	doc.Set("paths", b.paths)
+	doc.Set("components", NewObject().Set("schemas", b.schemas.Defs))
+	return doc
+}
+
+// Node 加入文件中的数据、枚举和服务，引用的其他文件中的类型一起加入
+func (b *Builder) Node(file *ast.File) error {
+	for _, s := range file.Specs {
+		spec, ok := s.(*ast.TypeSpec)
+		if !ok {
+			continue
+		}
+		switch t := spec.Type.(type) {
+		case *ast.DataType:
+			_, err := b.schemas.Data(t)
+			if err != nil {
+				return err
+			}
+		case *ast.EnumType:
+			b.schemas.Enum(t)
+		case *ast.ServerType:
+			err := b.server(t)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Builder) server(server *ast.ServerType) error {
+	name := build.StringToHumpName(server.Name.Name)
+	tag := NewObject().Set("name", name)
+	if text := Description(server.Doc, server.Tags); 0 < len(text) {
+		tag.Set("description", text)
+	}
+	b.tags = append(b.tags, tag)
+
+	return build.EnumMethod(server, func(method *ast.FuncType, s *ast.ServerType) error {
+		op := NewObject()
+		op.Set("operationId", name+"_"+build.StringToHumpName(method.Name.Name))
+		op.Set("tags", []string{name})
+		if text := Description(method.Doc, method.Tags); 0 < len(text) {
+			op.Set("summary", text)
+		}
+
+		route, err := build.GetHttp(method)
+		if err != nil {
+			return err
+		}
+		path := "/" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name)
+		verb := "post"
+		if nil != route {
+			path, verb = route.Path, strings.ToLower(route.Method)
+			err = b.httpParams(op, route)
+		} else if hasStream(method) {
+			err = b.streamBody(op, method.Param)
+		} else {
+			err = b.requestBody(op, method.Param)
+		}
+		if err != nil {
+			return err
+		}
+
+		if hasStream(method) {
+			err = b.streamResponses(op, method.Result)
+		} else {
+			err = b.responses(op, method.Result)
+		}
+		if err != nil {
+			return err
+		}
+		item, ok := b.paths.Get(path)
+		if !ok {
+			item = NewObject()
+			b.paths.Set(path, item)
+		}
+		item.(*Object).Set(verb, op)
+		return nil
+	})
+}
+
+// httpParams 按 http 注解写入路径参数、查询参数和请求体
+func (b *Builder) httpParams(op *Object, route *build.Http) error {
+	path := map[string]struct{}{}
+	build.HttpPath(route.Path, func(name string) string {
+		path[name] = struct{}{}
+		return ""
+	})
+
+	var params []*Object
+	err := build.EnumField(route.Data, func(field *ast.Field, data *ast.DataType) error {
+		name := build.StringToUnderlineName(field.Name.Name)
+		param := NewObject().Set("name", name)
+		if _, ok := path[name]; ok {
+			param.Set("in", "path").Set("required", true)
+		} else if "*" == route.Body || name == route.Body {
+			return nil
+		} else {
+			param.Set("in", "query")
+		}
+		if text := Description(field.Doc, field.Tags); 0 < len(text) {
+			param.Set("description", text)
+		}
+		schema, err := b.schemas.Type(field.Type)
+		if err != nil {
+			return err
+		}
+		// 查询参数中字符串原样写入，其他值为 JSON
+		if isSimple(field.Type) {
+			param.Set("schema", schema)
+		} else {
+			param.Set("content", NewObject().Set("application/json", NewObject().Set("schema", schema)))
+		}
+		params = append(params, param)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if 0 < len(params) {
+		op.Set("parameters", params)
+	}
+
+	if "*" == route.Body {
+		schema, err := b.schemas.Data(route.Data)
+		if err != nil {
+			return err
+		}
+		op.Set("requestBody", jsonBody(schema))
+	} else if 0 < len(route.Body) {
+		var schema *Object
+		err = build.EnumField(route.Data, func(field *ast.Field, data *ast.DataType) error {
+			if build.StringToUnderlineName(field.Name.Name) == route.Body {
+				schema, err = b.schemas.Type(field.Type)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		op.Set("requestBody", jsonBody(schema))
+	}
+	return nil
+}
+
+// isSimple 返回字段能否直接写在查询参数中，数据、数组、Map 和 json 需要写成 JSON
+func isSimple(typ ast.Expr) bool {
+	if build.IsArray(typ) || build.IsMap(typ) {
+		return false
+	}
+	if build.IsEnum(typ) {
+		return true
+	}
+	if t, ok := typ.(*ast.VarType); ok {
+		if ident, ok := t.Type().(*ast.Ident); ok && nil != ident.Obj {
+			return false
+		}
+	}
+	return build.Json != build.GetBaseType(typ)
+}
+
+func (b *Builder) requestBody(op *Object, param *ast.VarType) error {
+	schema, err := b.schemas.Type(param)
+	if err != nil {
+		return err
+	}
+	op.Set("requestBody", jsonBody(schema))
+	return nil
+}
+
+// responses 返回值放在 {"code":0,"msg":"Ok","data":...} 的 data 中
+func (b *Builder) responses(op *Object, result *ast.VarType) error {
+	data := NewObject().Set("type", "null")
+	if "void" != build.GetBaseType(result) {
+		var err error
+		data, err = b.schemas.Type(result)
+		if err != nil {
+			return err
+		}
+	}
+	schema := NewObject().
+		Set("type", "object").
+		Set("properties", NewObject().
+			Set("code", NewObject().Set("type", "integer")).
+			Set("msg", NewObject().Set("type", "string")).
+			Set("data", data)).
+		Set("required", []string{"code", "msg"})
+	ok := NewObject().Set("description", "OK").Set("content", NewObject().Set("application/json", NewObject().Set("schema", schema)))
+	op.Set("responses", NewObject().Set("200", ok))
+	return nil
+}
+
+func jsonBody(schema *Object) *Object {
+	return NewObject().Set("content", NewObject().Set("application/json", NewObject().Set("schema", schema)))
+}
+
+// hasStream 返回方法的参数或结果是否为 stream 或 stream<Item>
+func hasStream(method *ast.FuncType) bool {
+	return "stream" == build.GetBaseType(method.Param) || "stream" == build.GetBaseType(method.Result) ||
+		nil != build.GetStreamItem(method.Param) || nil != build.GetStreamItem(method.Result)
+}
+
+// _frameText 说明 stream 方法的请求和返回，与生成的 StreamServer 相同
+const _frameText = "A sequence of frames, each a 1-byte kind (0 end, 1 data, 2 null, 3 error) and a 4-byte little-endian length followed by the payload. " +
+	"Messages are JSON when called by path, and binary when called with the Hbuf-Invoke-Id header. "
+
+// streamBody stream 方法的请求体是一串帧，每条消息的 Schema 放在 x-hbuf-message 中
+func (b *Builder) streamBody(op *Object, param *ast.VarType) error {
+	body, err := b.frames(param, "")
+	if err != nil {
+		return err
+	}
+	op.Set("requestBody", body)
+	return nil
+}
+
+// streamResponses stream 方法的返回也是一串帧，出错时以错误帧结束，内容为 {"code":...,"msg":...}
+func (b *Builder) streamResponses(op *Object, result *ast.VarType) error {
+	ok, err := b.frames(result, " On failure an error frame with {\"code\",\"msg\"} is sent instead of the end or data frame.")
+	if err != nil {
+		return err
+	}
+	op.Set("responses", NewObject().Set("200", ok))
+	return nil
+}
+
+func (b *Builder) frames(typ *ast.VarType, suffix string) (*Object, error) {
+	var text string
+	var message *Object
+	var err error
+	switch {
+	case "stream" == build.GetBaseType(typ):
+		text = "Data frames carry the bytes, at most 32 KB each, followed by an end frame."
+	case "void" == build.GetBaseType(typ):
+		text = "An end frame."
+	case nil != build.GetStreamItem(typ):
+		text = "One data frame per message, a null frame for null, followed by an end frame."
+		message, err = b.schemas.Type(typ)
+	default:
+		text = "One data frame with the message."
+		message, err = b.schemas.Type(typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+	media := NewObject().Set("schema", NewObject().Set("type", "string").Set("contentMediaType", "application/octet-stream"))
+	if nil != message {
+		media.Set("x-hbuf-message", message)
+	}
+	return NewObject().
+		Set("description", _frameText+text+suffix).
+		Set("content", NewObject().Set("application/octet-stream", media)), nil
+}
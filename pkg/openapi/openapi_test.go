@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"encoding/json"
+	"hbuf/pkg/build/buildtest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	src := "" +
+		"enum Verify {\n" +
+		"    [format:reg=\"^a<b$\";min=\"2\";max=\"8\"]\n" +
+		"    Name = 1\n" +
+		"    [format:min=\"1\";max=\"120\";null=true]\n" +
+		"    Age = 2\n" +
+		"}\n" +
+		"\n" +
+		"// 用户\n" +
+		"data User {\n" +
+		"    [verify:format=Verify.Name]\n" +
+		"    [lang:zh=\"名称\";en=\"Name\"]\n" +
+		"    string? name = 0\n" +
+		"    [verify:format=Verify.Age]\n" +
+		"    int32? age = 1\n" +
+		"    User? parent = 2\n" +
+		"    oneof contact {\n" +
+		"        string email = 3\n" +
+		"        int64 phone = 4\n" +
+		"    }\n" +
+		"}\n" +
+		"\n" +
+		"server UserServer = 1 {\n" +
+		"    [http:method=\"GET\";path=\"/users/{age}\"]\n" +
+		"    User Get(User req) = 0\n" +
+		"    void Set(User req) = 1\n" +
+		"}\n"
+	out := buildtest.Generate(t, "openapi", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	if _, err := os.Stat(filepath.Join(out, "a.openapi.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	buf := buildtest.ReadFile(t, out, "a.openapi.json")
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage
+		Components struct {
+			Schemas map[string]struct {
+				Description string
+				Required    []string
+				Properties  map[string]map[string]any
+			}
+		}
+	}
+	err := json.Unmarshal([]byte(buf), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := doc.Components.Schemas["User"]
+	if "用户" != user.Description {
+		t.Errorf("description: %q", user.Description)
+	}
+	name := user.Properties["name"]
+	if "string" != name["type"] || "^a<b$" != name["pattern"] || 2.0 != name["minLength"] || 8.0 != name["maxLength"] || "名称 / Name" != name["description"] {
+		t.Errorf("name: %v", name)
+	}
+	if 1 != len(user.Required) || "name" != user.Required[0] {
+		t.Errorf("required: %v", user.Required)
+	}
+	age := user.Properties["age"]
+	if types, ok := age["type"].([]any); !ok || 2 != len(types) || 1.0 != age["minimum"] || 120.0 != age["maximum"] {
+		t.Errorf("age: %v", age)
+	}
+	if _, ok := user.Properties["contact"]["oneOf"]; !ok {
+		t.Errorf("contact: %v", user.Properties["contact"])
+	}
+	if _, ok := doc.Paths["/users/{age}"]["get"]; !ok {
+		t.Errorf("http path: %v", doc.Paths)
+	}
+	if _, ok := doc.Paths["/user_server/user_server/set"]["post"]; !ok {
+		t.Errorf("rpc path: %v", doc.Paths)
+	}
+}
+
+// TestStream stream 方法的请求和返回是一串帧，每条消息的 Schema 在 x-hbuf-message 中
+func TestStream(t *testing.T) {
+	src := "" +
+		"data Info = 1 {\n" +
+		"    string name = 0\n" +
+		"}\n" +
+		"\n" +
+		"server FileServer = 1 {\n" +
+		"    stream<Info> List(Info req) = 0\n" +
+		"    void Upload(stream req) = 1\n" +
+		"}\n"
+	out := buildtest.Generate(t, "openapi", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	buf := buildtest.ReadFile(t, out, "a.openapi.json")
+	type media struct {
+		Message map[string]any `json:"x-hbuf-message"`
+	}
+	type body struct {
+		Description string
+		Content     map[string]*media
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			RequestBody body
+			Responses   map[string]body
+		}
+	}
+	err := json.Unmarshal([]byte(buf), &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list := doc.Paths["/file_server/file_server/list"]["post"]
+	req := list.RequestBody.Content["application/octet-stream"]
+	if nil == req || "#/components/schemas/Info" != req.Message["$ref"] {
+		t.Errorf("list request: %+v", list.RequestBody)
+	}
+	resp := list.Responses["200"].Content["application/octet-stream"]
+	if nil == resp || "#/components/schemas/Info" != resp.Message["$ref"] {
+		t.Errorf("list response: %+v", list.Responses)
+	}
+	if _, ok := list.Responses["200"].Content["application/json"]; ok {
+		t.Errorf("list response: %+v", list.Responses)
+	}
+
+	upload := doc.Paths["/file_server/file_server/upload"]["post"]
+	req = upload.RequestBody.Content["application/octet-stream"]
+	if nil == req || nil != req.Message {
+		t.Errorf("upload request: %+v", upload.RequestBody)
+	}
+}
@@ -0,0 +1,386 @@
+package openapi
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schemas 把 hbuf 类型转为 JSON Schema，数据和枚举放在 Defs 中，使用 Ref 加名称引用，
+// 与 hbuf 的 JSON 格式一致：int64、uint64、decimal 为字符串，date 为毫秒，duration 为纳秒，枚举为整数
+type Schemas struct {
+	Ref   string   // 引用的前缀，如 "#/components/schemas/"
+	Defs  *Object  // 名称到 Schema
+	Root  ast.Expr // 文档本身的类型，引用它时使用 "#"
+	pkg   *ast.Package
+	names map[ast.Expr]string
+}
+
+func NewSchemas(pkg *ast.Package, ref string) *Schemas {
+	return &Schemas{
+		Ref:   ref,
+		Defs:  NewObject(),
+		pkg:   pkg,
+		names: map[ast.Expr]string{},
+	}
+}
+
+// Type 返回字段类型的 Schema，可为空的类型可以为 null，stream<Item> 返回其中一条消息的 Schema
+func (s *Schemas) Type(typ ast.Expr) (*Object, error) {
+	var ret *Object
+	switch t := typ.(type) {
+	case *ast.ArrayType:
+		items, err := s.Type(t.VType)
+		if err != nil {
+			return nil, err
+		}
+		ret = NewObject().Set("type", "array").Set("items", items)
+	case *ast.MapType:
+		value, err := s.Type(t.VType)
+		if err != nil {
+			return nil, err
+		}
+		ret = NewObject().Set("type", "object").Set("additionalProperties", value)
+	case *ast.VarType:
+		if item := build.GetStreamItem(t); nil != item {
+			return s.Type(item)
+		}
+		var err error
+		ret, err = s.varType(t)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return NewObject(), nil
+	}
+	if build.IsNil(typ) {
+		return Nullable(ret), nil
+	}
+	return ret, nil
+}
+
+func (s *Schemas) varType(typ *ast.VarType) (*Object, error) {
+	ident, ok := typ.Type().(*ast.Ident)
+	if !ok {
+		return NewObject(), nil
+	}
+	if nil != ident.Obj {
+		switch t := ident.Obj.Decl.(*ast.TypeSpec).Type.(type) {
+		case *ast.DataType:
+			return s.Data(t)
+		case *ast.EnumType:
+			return s.Enum(t), nil
+		}
+	}
+	return BaseType(build.BaseType(ident.Name)), nil
+}
+
+// BaseType 返回基础类型的 Schema
+func BaseType(typ build.BaseType) *Object {
+	ret := NewObject()
+	switch typ {
+	case build.Int8, build.Int16, build.Int32, build.Uint8, build.Uint16:
+		ret.Set("type", "integer").Set("format", "int32")
+	case build.Uint32:
+		ret.Set("type", "integer").Set("format", "int64")
+	case build.Int64, build.Uint64:
+		ret.Set("type", "string").Set("format", string(typ))
+	case build.Float, build.Double:
+		ret.Set("type", "number").Set("format", string(typ))
+	case build.Bool:
+		ret.Set("type", "boolean")
+	case build.String:
+		ret.Set("type", "string")
+	case build.Decimal:
+		ret.Set("type", "string").Set("format", "decimal")
+	case build.Date:
+		ret.Set("type", "integer").Set("format", "int64").Set("description", "Unix milliseconds")
+	case build.Duration:
+		ret.Set("type", "integer").Set("format", "int64").Set("description", "Nanoseconds")
+	case build.Bytes:
+		ret.Set("type", "string").Set("contentEncoding", "base64")
+	case build.Uuid:
+		ret.Set("type", "string").Set("format", "uuid")
+	}
+	return ret
+}
+
+// Nullable 返回可以为 null 的 Schema
+func Nullable(schema *Object) *Object {
+	if typ, ok := schema.Get("type"); ok {
+		if name, ok := typ.(string); ok {
+			schema.Set("type", []string{name, "null"})
+			return schema
+		}
+	}
+	if 0 == schema.Len() {
+		return schema
+	}
+	return NewObject().Set("anyOf", []*Object{schema, NewObject().Set("type", "null")})
+}
+
+// name 返回类型在 Defs 中的名称，不同文件中的同名类型加上序号
+func (s *Schemas) name(typ ast.Expr, ident *ast.Ident) (string, bool) {
+	if name, ok := s.names[typ]; ok {
+		return name, true
+	}
+	name := build.StringToHumpName(ident.Name)
+	for i := 2; ; i++ {
+		if _, ok := s.Defs.Get(name); !ok {
+			break
+		}
+		name = build.StringToHumpName(ident.Name) + strconv.Itoa(i)
+	}
+	s.names[typ] = name
+	return name, false
+}
+
+func (s *Schemas) ref(typ ast.Expr, name string) *Object {
+	if typ == s.Root {
+		return NewObject().Set("$ref", "#")
+	}
+	return NewObject().Set("$ref", s.Ref+name)
+}
+
+// Enum 加入枚举的 Schema，返回对它的引用
+func (s *Schemas) Enum(enum *ast.EnumType) *Object {
+	name, ok := s.name(enum, enum.Name)
+	ref := s.ref(enum, name)
+	if ok {
+		return ref
+	}
+
+	schema := NewObject()
+	s.Defs.Set(name, schema)
+	if text := Description(enum.Doc, enum.Tags); 0 < len(text) {
+		schema.Set("description", text)
+	}
+	schema.Set("type", "integer")
+	values := make([]int64, 0, len(enum.Items))
+	names := make([]string, 0, len(enum.Items))
+	texts := make([]string, 0, len(enum.Items))
+	hasText := false
+	for _, item := range enum.Items {
+		value, _ := strconv.ParseInt(item.Id.Value, 0, 64)
+		values = append(values, value)
+		names = append(names, item.Name.Name)
+		text := Description(item.Doc, item.Tags)
+		hasText = hasText || 0 < len(text)
+		texts = append(texts, text)
+	}
+	schema.Set("enum", values)
+	schema.Set("x-enum-varnames", names)
+	if hasText {
+		schema.Set("x-enum-descriptions", texts)
+	}
+	return ref
+}
+
+// Data 加入数据的 Schema，返回对它的引用，继承的字段合并到数据中
+func (s *Schemas) Data(data *ast.DataType) (*Object, error) {
+	name, ok := s.name(data, data.Name)
+	ref := s.ref(data, name)
+	if ok {
+		return ref, nil
+	}
+
+	// 先加入 Defs，数据引用自己时直接返回引用
+	schema := NewObject()
+	s.Defs.Set(name, schema)
+	if text := Description(data.Doc, data.Tags); 0 < len(text) {
+		schema.Set("description", text)
+	}
+	schema.Set("type", "object")
+
+	file, _ := data.Name.Obj.Data.(*ast.File)
+	properties := NewObject()
+	var required []string
+	err := build.EnumField(data, func(field *ast.Field, data *ast.DataType) error {
+		property, err := s.Type(field.Type)
+		if err != nil {
+			return err
+		}
+		need, err := s.format(property, field, file)
+		if err != nil {
+			return err
+		}
+		if need {
+			required = append(required, build.StringToUnderlineName(field.Name.Name))
+		}
+		if text := Description(field.Doc, field.Tags); 0 < len(text) {
+			property = withDescription(property, text)
+		}
+		properties.Set(build.StringToUnderlineName(field.Name.Name), property)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, oneOf := range data.OneOfs {
+		members := make([]*Object, 0, len(oneOf.Fields))
+		for _, field := range oneOf.Fields {
+			member, err := s.Type(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			if text := Description(field.Doc, field.Tags); 0 < len(text) {
+				member = withDescription(member, text)
+			}
+			key := build.StringToUnderlineName(field.Name.Name)
+			members = append(members, NewObject().
+				Set("type", "object").
+				Set("properties", NewObject().Set(key, member)).
+				Set("required", []string{key}).
+				Set("additionalProperties", false))
+		}
+		property := NewObject().Set("oneOf", members)
+		if text := Description(oneOf.Doc, nil); 0 < len(text) {
+			property.Set("description", text)
+		}
+		properties.Set(build.StringToUnderlineName(oneOf.Name.Name), property)
+	}
+	schema.Set("properties", properties)
+	if 0 < len(required) {
+		schema.Set("required", required)
+	}
+	return ref, nil
+}
+
+// format 把字段 verify 注解引用的 format 写入 Schema，返回字段是否必填，
+// 与生成的 Verify 方法一致：第一个 format 的 null 为 false 时可为空的字段不能为空，数组和 Map 不检查
+func (s *Schemas) format(schema *Object, field *ast.Field, file *ast.File) (bool, error) {
+	if nil == file {
+		return false, nil
+	}
+	verify, err := build.GetVerify(field.Tags, file, s.getType)
+	if err != nil || nil == verify || build.IsArray(field.Type) || build.IsMap(field.Type) {
+		return false, err
+	}
+	required := false
+	for i, val := range verify.GetFormat() {
+		f := build.GetFormat(val.Item.Tags)
+		if nil == f {
+			continue
+		}
+		if build.IsNil(field.Type) && 0 == i && !f.Null {
+			required = true
+			if typ, ok := schema.Get("type"); ok {
+				schema.Set("type", typ.([]string)[0])
+			} else if list, ok := schema.Get("anyOf"); ok {
+				*schema = *list.([]*Object)[0]
+			}
+			if build.String == build.GetBaseType(field.Type) && 0 == len(f.Min) {
+				schema.Set("minLength", 1)
+			}
+		}
+		switch build.GetBaseType(field.Type) {
+		case build.Int8, build.Int16, build.Int32, build.Uint8, build.Uint16, build.Uint32, build.Float, build.Double:
+			if 0 < len(f.Min) {
+				schema.Set("minimum", number(f.Min))
+			}
+			if 0 < len(f.Max) {
+				schema.Set("maximum", number(f.Max))
+			}
+		case build.Date:
+			if 0 < len(f.Min) {
+				parse, err := time.Parse("2006-01-02T15:04:05Z", f.Min)
+				if err != nil {
+					return false, err
+				}
+				schema.Set("minimum", parse.UnixMilli())
+			}
+			if 0 < len(f.Max) {
+				parse, err := time.Parse("2006-01-02T15:04:05Z", f.Max)
+				if err != nil {
+					return false, err
+				}
+				schema.Set("maximum", parse.UnixMilli())
+			}
+		case build.String:
+			if 0 < len(f.Min) {
+				schema.Set("minLength", number(f.Min))
+			}
+			if 0 < len(f.Max) {
+				schema.Set("maxLength", number(f.Max))
+			}
+			if 0 < len(f.Reg) {
+				// 注解中的正则按字符串字面量写入各语言代码，这里去掉转义
+				reg, err := strconv.Unquote("\"" + f.Reg + "\"")
+				if err != nil {
+					reg = f.Reg
+				}
+				schema.Set("pattern", reg)
+			}
+		}
+	}
+	return required, nil
+}
+
+// number 把 format 中的数字转为整数或小数，YAML 中才不会写成字符串
+func number(value string) any {
+	if val, err := strconv.ParseInt(value, 0, 64); nil == err {
+		return val
+	}
+	if val, err := strconv.ParseFloat(value, 64); nil == err {
+		return val
+	}
+	return value
+}
+
+// getType 在文件和引入的文件中查找 verify 注解引用的枚举
+func (s *Schemas) getType(file *ast.File, name string) *ast.Object {
+	if obj := file.Scope.Lookup(name); nil != obj {
+		if _, ok := obj.Decl.(*ast.TypeSpec); ok {
+			return obj
+		}
+	}
+	for _, spec := range file.Imports {
+		if f, ok := s.pkg.Files[spec.File]; ok {
+			if obj := f.Scope.Lookup(name); nil != obj {
+				if _, ok := obj.Decl.(*ast.TypeSpec); ok {
+					return obj
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// withDescription 给 Schema 加上说明，引用不能有其他键，放在 allOf 中
+func withDescription(schema *Object, text string) *Object {
+	if _, ok := schema.Get("$ref"); ok {
+		return NewObject().Set("description", text).Set("allOf", []*Object{schema})
+	}
+	schema.Set("description", text)
+	return schema
+}
+
+// Description 返回注释和 lang 注解中的文字，用 " / " 分隔
+func Description(doc *ast.CommentGroup, tags []*ast.Tag) string {
+	var list []string
+	if nil != doc {
+		if text := strings.TrimSpace(doc.Text()); 0 < len(text) {
+			list = append(list, text)
+		}
+	}
+	if val, ok := build.GetTag(tags, "lang"); ok {
+		for _, item := range val.KV {
+			text := build.TagString(item.Values[0])
+			if 0 < len(text) && !contains(list, text) {
+				list = append(list, text)
+			}
+		}
+	}
+	return strings.Join(list, " / ")
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -10,8 +10,10 @@ import (
 	"io/fs"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 )
 
 func readSource(filename string, src interface{}) ([]byte, error) {
@@ -87,14 +89,20 @@ func ParseFile(fset *token.FileSet, filename string, src interface{}, mode Mode)
 	return
 }
 
-func ParseDir(fset *token.FileSet, pkg *ast.Package, path string, reg *regexp.Regexp) error {
+// ParseDir 解析 path 目录及子目录下文件名匹配 reg 的文件和它们引入的文件，
+// 引入的文件先在引入它的文件所在目录查找，再依次在 includes 目录中查找
+func ParseDir(fset *token.FileSet, pkg *ast.Package, path string, reg *regexp.Regexp, includes ...string) error {
+	return parseDir(fset, pkg, path, path, reg, includes)
+}
+
+func parseDir(fset *token.FileSet, pkg *ast.Package, root string, path string, reg *regexp.Regexp, includes []string) error {
 	dir, err := ioutil.ReadDir(path)
 	if err != nil {
 		return err
 	}
 	for _, item := range dir {
 		if item.IsDir() {
-			err := ParseDir(fset, pkg, filepath.Join(path, item.Name()), reg)
+			err := parseDir(fset, pkg, root, filepath.Join(path, item.Name()), reg, includes)
 			if err != nil {
 				return err
 			}
@@ -102,7 +110,12 @@ func ParseDir(fset *token.FileSet, pkg *ast.Package, path string, reg *regexp.Re
 		if !reg.MatchString(item.Name()) {
 			continue
 		}
-		err := parseDirFile(fset, pkg, path, item.Name())
+		filePath := filepath.Join(path, item.Name())
+		key, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		_, err = parseDirFile(fset, pkg, filepath.ToSlash(key), filePath, includes)
 		if err != nil {
 			return err
 		}
@@ -110,45 +123,76 @@ func ParseDir(fset *token.FileSet, pkg *ast.Package, path string, reg *regexp.Re
 	return nil
 }
 
-func parseDirFile(fset *token.FileSet, pkg *ast.Package, path string, name string) error {
-	filePath := filepath.Join(path, name)
-	var temp = false
-	fset.Iterate(func(file *token.File) bool {
-		if file.Name() == filePath {
-			temp = true
-			return false
+// parseDirFile 解析文件并递归解析它引入的文件，返回文件在 pkg.Files 中的键。
+// 键是文件相对输入目录或引入目录的路径，如 common/page.hbuf，同一个文件无论从哪里引入都只解析一次，
+// 不同的文件得到相同的键时返回 Duplicate import 错误
+func parseDirFile(fset *token.FileSet, pkg *ast.Package, key string, filePath string, includes []string) (string, error) {
+	abs, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", err
+	}
+	for name, f := range pkg.Files {
+		if other, err := filepath.Abs(f.Path); err == nil && other == abs {
+			return name, nil
 		}
-		return true
-	})
-	if temp {
-		return nil
+	}
+	if f, ok := pkg.Files[key]; ok {
+		return "", errors.New("Duplicate import: " + key + " (" + f.Path + " and " + filePath + ")")
 	}
 
 	f, err := ParseFile(fset, filePath, nil, AllErrors|ParseComments)
 	if err != nil {
-		return err
+		return "", err
 	}
-	pkg.Files[filePath] = f
+	f.Name = key
+	pkg.Files[key] = f
 	for _, spec := range f.Imports {
-		imp := spec.Path.Value
-		imp = imp[1 : len(imp)-1]
-		if !("/" == imp || 0 != len(filepath.VolumeName(imp))) {
-			imp = filepath.Join(path, imp)
-			spec.Path.Value = imp
+		imp, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			imp = spec.Path.Value
 		}
-		path := filepath.Dir(imp)
-		name := imp[len(path)+1:]
-		err := parseDirFile(fset, pkg, path, name)
+		impPath, impKey := findImport(filepath.Dir(filePath), key, imp, includes)
+		if 0 == len(impPath) {
+			return "", scanner.Error{
+				Pos: fset.Position(spec.Pos()),
+				Msg: "Not import: " + imp,
+			}
+		}
+		spec.File, err = parseDirFile(fset, pkg, impKey, impPath, includes)
 		if err != nil {
 			switch err.(type) {
 			case *fs.PathError:
-				return scanner.Error{
+				return "", scanner.Error{
 					Pos: fset.Position(spec.Pos()),
-					Msg: "Not import: " + spec.Path.Value,
+					Msg: "Not import: " + imp,
 				}
+			case scanner.Error, scanner.ErrorList:
+				return "", err
+			}
+			return "", scanner.Error{
+				Pos: fset.Position(spec.Pos()),
+				Msg: err.Error(),
 			}
-			return err
 		}
 	}
-	return nil
+	return key, nil
+}
+
+// findImport 返回引入文件的路径和它在 pkg.Files 中的键，绝对路径直接使用，
+// 否则先在 dir 中查找，键为相对引入它的文件 key 的路径，再依次在 includes 中查找，键为 imp，都不存在时返回空
+func findImport(dir string, key string, imp string, includes []string) (string, string) {
+	if filepath.IsAbs(imp) {
+		return imp, filepath.ToSlash(imp)
+	}
+	file := filepath.Join(dir, imp)
+	if info, err := os.Stat(file); err == nil && !info.IsDir() {
+		return file, path.Join(path.Dir(key), filepath.ToSlash(imp))
+	}
+	for _, include := range includes {
+		file := filepath.Join(include, imp)
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			return file, path.Clean(filepath.ToSlash(imp))
+		}
+	}
+	return "", ""
 }
@@ -497,7 +497,21 @@ func (p *parser) parseTypeName() *ast.Ident {
 	return ident
 }
 
-func (p *parser) parseArrayType(elt *ast.VarType) *ast.ArrayType {
+// parseQualifiedType 解析类型名，可以带引入时 as 的包名，如 common.Page
+func (p *parser) parseQualifiedType() ast.Expr {
+	return p.parseQualifiedTypeOf(p.parseTypeName())
+}
+
+// parseQualifiedTypeOf 由已经读出的第一个名称继续解析类型名
+func (p *parser) parseQualifiedTypeOf(ident *ast.Ident) ast.Expr {
+	if p.tok != token.PERIOD {
+		return ident
+	}
+	p.next()
+	return &ast.SelectorExpr{X: ident, Sel: p.parseTypeName()}
+}
+
+func (p *parser) parseArrayType(elt ast.Type) *ast.ArrayType {
 	if p.trace {
 		defer un(trace(p, "ArrayType"))
 	}
@@ -522,6 +536,14 @@ func (p *parser) parseId() *ast.BasicLit {
 	return id
 }
 
+// parseTypeId 解析 data 和 server 的可选 Id，可写在 "{" 之前或 "}" 之后
+func (p *parser) parseTypeId() (token.Pos, *ast.BasicLit) {
+	if p.tok != token.ASSIGN {
+		return token.NoPos, nil
+	}
+	return p.pos, p.parseId()
+}
+
 func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 	if p.trace {
 		defer un(trace(p, "FieldDecl"))
@@ -532,9 +554,20 @@ func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 	if p.tok != token.IDENT {
 		p.errorExpected(p.pos, "not find Type")
 	}
-	name := p.parseIdent()
+	return p.parseFieldRest(scope, doc, tags, typ, p.parseIdent())
+}
+
+// parseFieldRest 解析字段名之后的 Id、默认值和 Tag，类型和名称已经读出
+func (p *parser) parseFieldRest(scope *ast.Scope, doc *ast.CommentGroup, tags []*ast.Tag, typ ast.Type, name *ast.Ident) *ast.Field {
 	var id = p.parseId()
 
+	// 默认值
+	var def *ast.BasicLit
+	if p.tok == token.ASSIGN {
+		p.next()
+		def = p.parseTagValue()
+	}
+
 	// Tag
 	var tag *ast.BasicLit
 	if p.tok == token.STRING {
@@ -550,6 +583,7 @@ func (p *parser) parseFieldDecl(scope *ast.Scope) *ast.Field {
 		Name:    name,
 		Type:    typ,
 		Id:      id,
+		Default: def,
 		Tag:     tag,
 		Comment: p.lineComment,
 	}
@@ -578,6 +612,11 @@ func (p *parser) parseExtend() *ast.Extends {
 		p.error(p.pos, "Extend not found name")
 	}
 	ret.Name = p.parseIdent()
+	if p.tok == token.PERIOD {
+		p.next()
+		ret.X = ret.Name
+		ret.Name = p.parseIdent()
+	}
 	ret.Id = p.parseId()
 	return ret
 }
@@ -602,18 +641,18 @@ func (p *parser) parseMethodSpec() *ast.FuncType {
 
 	doc := p.leadComment
 	tags := p.parseTags()
-	result := p.parseVarType()
+	result := p.parseMethodType()
 	name := p.parseIdent()
 	p.expect(token.LPAREN)
-	param := p.parseVarType()
+	param := p.parseMethodType()
 	paramName := p.parseIdent()
 	p.expect(token.RPAREN)
 	id := p.parseId()
 
 	var typ = &ast.FuncType{
-		Result:    result.(*ast.VarType),
+		Result:    result,
 		Name:      name,
-		Param:     param.(*ast.VarType),
+		Param:     param,
 		ParamName: paramName,
 		Doc:       doc,
 		Comment:   doc,
@@ -623,7 +662,31 @@ func (p *parser) parseMethodSpec() *ast.FuncType {
 	return typ
 }
 
-func (p *parser) parseMapType(value *ast.VarType) *ast.MapType {
+// parseMethodType 解析方法的参数或返回类型，stream<Item> 和 Map 的写法相同，这里转换为消息流
+func (p *parser) parseMethodType() *ast.VarType {
+	typ := p.parseVarType()
+	if m, ok := typ.(*ast.MapType); ok && !m.Empty {
+		if v, ok := m.VType.(*ast.VarType); ok && !v.Empty {
+			if ident, ok := v.TypeExpr.(*ast.Ident); ok && "stream" == ident.Name {
+				item, ok := m.Key.(*ast.VarType)
+				if !ok {
+					p.errorExpected(m.Key.Pos(), "data type")
+					item = &ast.VarType{TypeExpr: &ast.BadExpr{From: m.Key.Pos(), To: m.Key.End()}}
+				}
+				return &ast.VarType{
+					TypeExpr: &ast.StreamType{Stream: ident.NamePos, LSS: m.LSS, Item: item, GTR: m.GTR},
+				}
+			}
+		}
+	}
+	if v, ok := typ.(*ast.VarType); ok {
+		return v
+	}
+	p.errorExpected(typ.Pos(), "data type")
+	return &ast.VarType{TypeExpr: &ast.BadExpr{From: typ.Pos(), To: typ.End()}}
+}
+
+func (p *parser) parseMapType(value ast.Type) *ast.MapType {
 	if p.trace {
 		defer un(trace(p, "MapType"))
 	}
@@ -643,29 +706,39 @@ func (p *parser) tryIdentOrType() ast.Type {
 	if p.tok != token.IDENT {
 		defer un(trace(p, "TypeName"))
 	}
+	return p.parseTypeSuffix(p.parseQualifiedType())
+}
+
+// parseTypeSuffix 解析类型名之后的 ?、[] 和 <键类型>
+func (p *parser) parseTypeSuffix(x ast.Expr) ast.Type {
 	v := &ast.VarType{
-		TypeExpr: p.parseTypeName(),
+		TypeExpr: x,
 	}
 	if p.tok == token.Question {
 		v.Empty = true
 		p.next()
 	}
-	if p.tok == token.LBRACK {
-		a := p.parseArrayType(v)
-		if p.tok == token.Question {
-			a.Empty = true
-			p.next()
-		}
-		return a
-	} else if p.tok == token.LSS {
-		m := p.parseMapType(v)
-		if p.tok == token.Question {
-			m.Empty = true
-			p.next()
+	// 数组和 Map 可以嵌套，从左到右依次包裹前面的类型，如 int32[]<string> 为值是数组的 Map
+	var typ ast.Type = v
+	for {
+		switch p.tok {
+		case token.LBRACK:
+			a := p.parseArrayType(typ)
+			if p.tok == token.Question {
+				a.Empty = true
+				p.next()
+			}
+			typ = a
+		case token.LSS:
+			m := p.parseMapType(typ)
+			if p.tok == token.Question {
+				m.Empty = true
+				p.next()
+			}
+			typ = m
+		default:
+			return typ
 		}
-		return m
-	} else {
-		return v
 	}
 }
 
@@ -728,12 +801,19 @@ func (p *parser) parseImportSpec(doc *ast.CommentGroup, _ token.Token, _ int) as
 	} else {
 		p.expect(token.STRING) // use expect() error handling
 	}
+	// as 只在引入路径之后作为关键字，如 import "common.hbuf" as common
+	var name *ast.Ident
+	if p.tok == token.IDENT && "as" == p.lit {
+		p.next()
+		name = p.parseIdent()
+	}
 	p.expectSemi() // call before accessing p.linecomment
 
 	// collect imports
 	spec := &ast.ImportSpec{
 		Doc:     doc,
 		Path:    &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: path},
+		Name:    name,
 		Comment: p.lineComment,
 	}
 	p.imports = append(p.imports, spec)
@@ -750,26 +830,55 @@ func (p *parser) parseDataSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spec
 	p.expect(token.DATA)
 	name := p.parseIdent()
 	extends := p.parseExtends()
+	assign, id := p.parseTypeId()
 
 	lbrace := p.expect(token.LBRACE)
 	scope := ast.NewScope(nil) // struct scope
 	var list []*ast.Field
+	var reserved []*ast.Reserved
+	var oneOfs []*ast.OneOf
 	for p.tok != token.RBRACE && p.tok != token.EOF {
+		// reserved 和 oneof 只在成员开始处由后面的记号区分，仍可以作为字段的类型或名称
+		if p.tok == token.IDENT && ("reserved" == p.lit || "oneof" == p.lit) {
+			doc := p.leadComment
+			ident := p.parseIdent()
+			switch {
+			case "reserved" == ident.Name && (p.tok == token.INT || p.tok == token.STRING):
+				reserved = append(reserved, p.parseReserved(ident.NamePos))
+			case "oneof" == ident.Name && p.tok == token.IDENT:
+				name := p.parseIdent()
+				if p.tok == token.LBRACE {
+					oneOfs = append(oneOfs, p.parseOneOf(scope, doc, ident.NamePos, name))
+				} else {
+					list = append(list, p.parseFieldRest(scope, doc, nil, &ast.VarType{TypeExpr: ident}, name))
+				}
+			default:
+				typ := p.parseTypeSuffix(p.parseQualifiedTypeOf(ident))
+				if p.tok != token.IDENT {
+					p.errorExpected(p.pos, "not find Type")
+				}
+				list = append(list, p.parseFieldRest(scope, doc, nil, typ, p.parseIdent()))
+			}
+			continue
+		}
 		list = append(list, p.parseFieldDecl(scope))
 	}
 	rbrace := p.expect(token.RBRACE)
 
 	spec := &ast.TypeSpec{Doc: doc, Name: name}
 	p.declare(spec, nil, p.topScope, ast.Data, name)
-	if p.tok == token.ASSIGN {
-		spec.Assign = p.pos
-		p.next()
+	if nil == id {
+		assign, id = p.parseTypeId()
 	}
+	spec.Assign = assign
 	spec.Type = &ast.DataType{
-		Tags:    tags,
-		Data:    pos,
-		Name:    name,
-		Extends: extends,
+		Tags:     tags,
+		Data:     pos,
+		Name:     name,
+		Extends:  extends,
+		Id:       id,
+		Reserved: reserved,
+		OneOfs:   oneOfs,
 		Fields: &ast.FieldList{
 			Opening: lbrace,
 			List:    list,
@@ -793,7 +902,19 @@ func (p *parser) parseEnumSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spec
 	lbrace := p.expect(token.LBRACE)
 	scope := ast.NewScope(nil) // struct scope
 	var list []*ast.EnumItem
+	var reserved []*ast.Reserved
 	for p.tok != token.RBRACE && p.tok != token.EOF {
+		// reserved 后面是 Id 或名称时为保留，否则是名为 reserved 的枚举项
+		if p.tok == token.IDENT && "reserved" == p.lit {
+			doc := p.leadComment
+			ident := p.parseIdent()
+			if p.tok == token.INT || p.tok == token.STRING {
+				reserved = append(reserved, p.parseReserved(ident.NamePos))
+			} else {
+				list = append(list, p.parseEnumItemRest(doc, nil, ident))
+			}
+			continue
+		}
 		list = append(list, p.parseEnumItem(scope))
 	}
 	rbrace := p.expect(token.RBRACE)
@@ -805,12 +926,13 @@ func (p *parser) parseEnumSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spec
 		p.next()
 	}
 	spec.Type = &ast.EnumType{
-		Name:    name,
-		Opening: lbrace,
-		Closing: rbrace,
-		Items:   list,
-		Tags:    tags,
-		Doc:     doc,
+		Name:     name,
+		Opening:  lbrace,
+		Closing:  rbrace,
+		Items:    list,
+		Reserved: reserved,
+		Tags:     tags,
+		Doc:      doc,
 	}
 	p.expectSemi()
 	spec.Comment = p.lineComment
@@ -826,8 +948,11 @@ func (p *parser) parseEnumItem(scope *ast.Scope) *ast.EnumItem {
 	if p.tok != token.IDENT {
 		p.errorExpected(p.pos, "not find Type")
 	}
-	name := p.parseIdent()
+	return p.parseEnumItemRest(doc, tags, p.parseIdent())
+}
 
+// parseEnumItemRest 解析枚举项名称之后的 Id
+func (p *parser) parseEnumItemRest(doc *ast.CommentGroup, tags []*ast.Tag, name *ast.Ident) *ast.EnumItem {
 	var id *ast.BasicLit
 	p.next()
 	if p.tok != token.INT {
@@ -846,6 +971,97 @@ func (p *parser) parseEnumItem(scope *ast.Scope) *ast.EnumItem {
 	}
 }
 
+// parseConstSpec 解析 const int32 MaxPage = 100
+func (p *parser) parseConstSpec(doc *ast.CommentGroup) ast.Spec {
+	if p.trace {
+		defer un(trace(p, "ConstSpec"))
+	}
+
+	pos := p.pos
+	p.next()
+	typ := p.parseIdent()
+	name := p.parseIdent()
+	p.expect(token.ASSIGN)
+
+	var value *ast.BasicLit
+	switch p.tok {
+	case token.INT, token.FLOAT, token.STRING, token.IDENT:
+		value = &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}
+		p.next()
+	default:
+		p.errorExpected(p.pos, "const value")
+		value = &ast.BasicLit{ValuePos: p.pos, Kind: token.STRING, Value: `""`}
+	}
+	p.expectSemi() // call before accessing p.linecomment
+
+	spec := &ast.ConstSpec{
+		Doc:     doc,
+		Const:   pos,
+		Type:    typ,
+		Name:    name,
+		Value:   value,
+		Comment: p.lineComment,
+	}
+	p.declare(spec, nil, p.topScope, ast.Const, name)
+	return spec
+}
+
+// parseOneOf 解析 oneof 块，成员和数据的字段在同一个作用域中
+func (p *parser) parseOneOf(scope *ast.Scope, doc *ast.CommentGroup, pos token.Pos, name *ast.Ident) *ast.OneOf {
+	if p.trace {
+		defer un(trace(p, "OneOf"))
+	}
+
+	oneOf := &ast.OneOf{Doc: doc, OneOf: pos, Name: name}
+	p.declare(oneOf, nil, scope, ast.Var, oneOf.Name)
+	oneOf.Opening = p.expect(token.LBRACE)
+	for p.tok != token.RBRACE && p.tok != token.EOF {
+		oneOf.Fields = append(oneOf.Fields, p.parseFieldDecl(scope))
+	}
+	oneOf.Closing = p.expect(token.RBRACE)
+	p.expectSemi() // call before accessing p.linecomment
+	oneOf.Comment = p.lineComment
+	return oneOf
+}
+
+// parseReserved 解析 data 和 enum 中的 reserved 3, 5 to 9, "old_name"
+func (p *parser) parseReserved(pos token.Pos) *ast.Reserved {
+	if p.trace {
+		defer un(trace(p, "Reserved"))
+	}
+
+	reserved := &ast.Reserved{Reserved: pos}
+	for {
+		if p.tok == token.INT {
+			item := &ast.ReservedRange{From: &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}}
+			p.next()
+			if p.tok == token.IDENT && p.lit == "to" {
+				p.next()
+				if p.tok != token.INT {
+					p.errorExpected(p.pos, "not find int")
+				}
+				item.To = &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit}
+				p.next()
+			}
+			reserved.Ranges = append(reserved.Ranges, item)
+		} else if p.tok == token.STRING {
+			reserved.Names = append(reserved.Names, &ast.BasicLit{ValuePos: p.pos, Kind: p.tok, Value: p.lit})
+			p.next()
+		} else {
+			p.errorExpected(p.pos, "not find id or name")
+			break
+		}
+		if p.tok != token.COMMA {
+			break
+		}
+		p.next()
+	}
+
+	p.expectSemi() // call before accessing p.linecomment
+	reserved.Comment = p.lineComment
+	return reserved
+}
+
 func (p *parser) parseServerSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spec {
 	if p.trace {
 		defer un(trace(p, "ServerType"))
@@ -855,6 +1071,7 @@ func (p *parser) parseServerSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spe
 	p.expect(token.SERVER)
 	name := p.parseIdent()
 	extends := p.parseExtends()
+	assign, id := p.parseTypeId()
 
 	lbrace := p.expect(token.LBRACE)
 	var list []*ast.FuncType
@@ -866,15 +1083,16 @@ func (p *parser) parseServerSpec(doc *ast.CommentGroup, tags []*ast.Tag) ast.Spe
 
 	spec := &ast.TypeSpec{Doc: doc, Name: name}
 	p.declare(spec, nil, p.topScope, ast.Server, name)
-	if p.tok == token.ASSIGN {
-		spec.Assign = p.pos
-		p.next()
+	if nil == id {
+		assign, id = p.parseTypeId()
 	}
+	spec.Assign = assign
 	spec.Type = &ast.ServerType{
 		Tags:    tags,
 		Server:  pos,
 		Name:    name,
 		Extends: extends,
+		Id:      id,
 		Opening: lbrace,
 		Methods: list,
 		Closing: rbrace,
@@ -898,8 +1116,14 @@ func (p *parser) parseDecl(sync map[token.Token]bool) ast.Spec {
 		return p.parseServerSpec(doc, tags)
 	case token.ENUM:
 		return p.parseEnumSpec(doc, tags)
-
 	default:
+		// const 只在声明开始处作为关键字
+		if p.tok == token.IDENT && "const" == p.lit {
+			if 0 < len(tags) {
+				p.error(tags[0].Pos(), "const cannot have tags")
+			}
+			return p.parseConstSpec(doc)
+		}
 		pos := p.pos
 		p.errorExpected(pos, "declaration")
 		p.advance(sync)
@@ -1027,15 +1251,43 @@ func (p *parser) parseTag() *ast.Tag {
 			p.next()
 		}
 	}
-	p.expect(token.RBRACK)
+	closing := p.expect(token.RBRACK)
 	p.expectSemi()
 	return &ast.Tag{
 		Name:    name,
 		KV:      kvs,
 		Opening: pos,
+		Closing: closing,
 	}
 }
 
+// parseTagValue 解析标签值和字段默认值，可以是字符串、true/false、整数、小数或 Name.item 形式的引用
+func (p *parser) parseTagValue() *ast.BasicLit {
+	pos := p.pos
+	switch p.tok {
+	case token.STRING, token.INT, token.FLOAT:
+		value := &ast.BasicLit{ValuePos: pos, Kind: p.tok, Value: p.lit}
+		p.next()
+		return value
+	case token.IDENT:
+		value := p.lit
+		p.next()
+		for p.tok == token.PERIOD {
+			p.next()
+			if p.tok != token.IDENT {
+				p.expect(token.IDENT)
+				break
+			}
+			value += "." + p.lit
+			p.next()
+		}
+		return &ast.BasicLit{ValuePos: pos, Kind: token.IDENT, Value: value}
+	}
+	p.errorExpected(pos, "tag value")
+	p.next()
+	return &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: `""`}
+}
+
 func (p *parser) parseKeyValue() *ast.KeyValue {
 	name := p.parseIdent()
 	if token.ASSIGN != p.tok {
@@ -1046,15 +1298,7 @@ func (p *parser) parseKeyValue() *ast.KeyValue {
 
 	values := make([]*ast.BasicLit, 0)
 	for {
-		pos := p.pos
-		var value string
-		if p.tok == token.STRING {
-			value = p.lit
-			p.next()
-		} else {
-			p.expect(token.STRING)
-		}
-		values = append(values, &ast.BasicLit{ValuePos: pos, Kind: token.STRING, Value: value})
+		values = append(values, p.parseTagValue())
 		if token.COMMA != p.tok {
 			break
 		}
@@ -377,10 +377,34 @@ func (s *Scanner) scanNumber() (token.Token, string) {
 		digsep |= s.digits(base, &invalid)
 	}
 
+	// fractional part
+	if s.ch == '.' {
+		tok = token.FLOAT
+		if prefix == 'o' || prefix == 'b' || prefix == 'x' {
+			s.error(s.offset, "invalid radix point in "+litname(prefix))
+		}
+		s.next()
+		digsep |= s.digits(10, &invalid)
+	}
+
 	if digsep&1 == 0 {
 		s.error(s.offset, litname(prefix)+" has no digits")
 	}
 
+	// exponent
+	if lower(s.ch) == 'e' && (prefix == 0 || prefix == '0') {
+		s.next()
+		tok = token.FLOAT
+		if s.ch == '+' || s.ch == '-' {
+			s.next()
+		}
+		ds := s.digits(10, &invalid)
+		digsep |= ds
+		if ds&1 == 0 {
+			s.error(s.offset, "exponent has no digits")
+		}
+	}
+
 	lit := string(s.src[offs:s.offset])
 	if tok == token.INT && invalid >= 0 {
 		s.errorf(invalid, "invalid digit %q in %s", lit[invalid-offs], litname(prefix))
@@ -711,6 +735,12 @@ scanAgain:
 	case isDecimal(ch) || ch == '.' && isDecimal(rune(s.peek())):
 		insertSemi = true
 		tok, lit = s.scanNumber()
+	case ch == '-' && isDecimal(rune(s.peek())):
+		// 负数，用于常量和字段默认值
+		insertSemi = true
+		s.next()
+		tok, lit = s.scanNumber()
+		lit = "-" + lit
 	default:
 		s.next() // always make progress
 		switch ch {
@@ -738,6 +768,8 @@ scanAgain:
 			tok = token.COMMA
 		case ':':
 			tok = token.COLON
+		case '.':
+			tok = token.PERIOD
 		case ';':
 			tok = token.SEMICOLON
 			lit = ";"
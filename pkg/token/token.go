@@ -16,6 +16,7 @@ const (
 	literal_beg
 	IDENT  // main
 	INT    // 12345
+	FLOAT  // 123.45
 	STRING // "abc"
 	literal_end
 
@@ -33,6 +34,7 @@ const (
 	RBRACE    // }
 	SEMICOLON // ;
 	COLON     // :
+	PERIOD    // .
 	operator_end
 
 	keyword_beg
@@ -52,6 +54,7 @@ var tokens = [...]string{
 
 	IDENT:  "IDENT",
 	INT:    "INT",
+	FLOAT:  "FLOAT",
 	STRING: "STRING",
 
 	LSS:      "<",
@@ -69,6 +72,7 @@ var tokens = [...]string{
 	RBRACE:    "}",
 	SEMICOLON: ";",
 	COLON:     ":",
+	PERIOD:    ".",
 	IMPORT:    "import",
 	PACKAGE:   "package",
 
@@ -0,0 +1,23 @@
+package ts
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+func (b *Builder) printConstCode(dst *build.Writer, spec *ast.ConstSpec) {
+	if nil != spec.Doc && 0 < len(spec.Doc.Text()) {
+		dst.Code("///" + spec.Doc.Text())
+	}
+	typ := build.BaseType(spec.Type.Name)
+	value := build.ConstValue(spec)
+	switch typ {
+	case build.Int64:
+		dst.Import("long", "Long")
+		value = "Long.fromString(\"" + value + "\")"
+	case build.Uint64:
+		dst.Import("long", "Long")
+		value = "Long.fromString(\"" + value + "\", true)"
+	}
+	dst.Code("export const " + build.StringToHumpName(spec.Name.Name) + ": " + _types[typ] + " = " + value + ";\n\n")
+}
@@ -3,12 +3,15 @@ package ts
 import (
 	"hbuf/pkg/ast"
 	"hbuf/pkg/build"
+	"strconv"
 )
 
 func (b *Builder) printDataCode(dst *build.Writer, typ *ast.DataType) {
 	dst.Import("hbuf_ts", "* as h")
+	dst.Import(importPath(dst.File, "hbuf_encoder"), "* as c")
 
 	b.printData(dst, typ)
+	b.printOneOfCode(dst, typ)
 }
 
 func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
@@ -28,13 +31,23 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 		dst.Code(build.StringToFirstLower(field.Name.Name) + ": ")
 		b.printType(dst, field.Type, false, false)
 		dst.Code(" = ")
-		b.printDefault(dst, field.Type, false)
+		if nil != field.Default {
+			b.printFieldDefault(dst, field)
+		} else {
+			b.printDefault(dst, field.Type, false)
+		}
 		dst.Code(";\n\n")
 		return nil
 	})
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Tab(1).Code("///" + oneOf.Doc.Text())
+		}
+		dst.Tab(1).Code(build.StringToFirstLower(oneOf.Name.Name) + ": " + oneOfName(typ, oneOf) + " | null = null;\n\n")
+	}
 
 	dst.Tab(1).Code("public static fromJson(json: Record<string, any>): " + build.StringToHumpName(typ.Name.Name) + "{\n")
 	dst.Tab(2).Code("const ret = new " + build.StringToHumpName(typ.Name.Name) + "()\n")
@@ -42,13 +55,23 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 	err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
 		dst.Tab(2).Code("ret." + build.StringToFirstLower(field.Name.Name) + " = ")
 		jsonName := build.StringToUnderlineName(field.Name.Name)
-		b.printFormMap(dst, "(temp = json[\""+jsonName+"\"])", "temp", field.Type, data, false, false)
+		if nil != field.Default {
+			dst.Code("\"" + jsonName + "\" in json ? (")
+			b.printFormMap(dst, "(temp = json[\""+jsonName+"\"])", "temp", field.Type, data, false, false)
+			dst.Code(") : ")
+			b.printFieldDefault(dst, field)
+		} else {
+			b.printFormMap(dst, "(temp = json[\""+jsonName+"\"])", "temp", field.Type, data, false, false)
+		}
 		dst.Code("\n")
 		return nil
 	})
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(2).Code("ret." + build.StringToFirstLower(oneOf.Name.Name) + " = " + oneOfName(typ, oneOf) + ".fromJson(json[\"" + build.StringToUnderlineName(oneOf.Name.Name) + "\"])\n")
+	}
 
 	dst.Tab(2).Code("return ret\n")
 	dst.Tab(1).Code("}\n\n")
@@ -60,22 +83,44 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 		dst.Tab(3).Code("\"" + build.StringToUnderlineName(field.Name.Name))
 		dst.Code("\": ")
 		b.printToJson(dst, "this.", build.StringToFirstLower(field.Name.Name), field.Type, data, false, false)
+		// 有默认值的可空字段写成 null，缺少该字段时对方会读成默认值
+		if nil != field.Default && field.Type.IsEmpty() {
+			dst.Code(" ?? null")
+		}
 		dst.Code(",\n")
 		return nil
 	})
 	if err != nil {
 		return
 	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(3).Code("\"" + build.StringToUnderlineName(oneOf.Name.Name) + "\": " + oneOfName(typ, oneOf) + ".toJson(this." + build.StringToFirstLower(oneOf.Name.Name) + "),\n")
+	}
 	dst.Tab(2).Code("};\n")
 	dst.Tab(1).Code("}\n\n")
 
 	dst.Tab(1).Code("public static fromData(data: BinaryData): " + build.StringToHumpName(typ.Name.Name) + " {\n")
 	dst.Tab(2).Code("const ret = new " + build.StringToHumpName(typ.Name.Name) + "()\n")
+	// 二进制中可空字段为 null 时不写入，不能使用默认值
+	err = build.EnumField(typ, func(field *ast.Field, data *ast.DataType) error {
+		if nil != field.Default && field.Type.IsEmpty() {
+			dst.Tab(2).Code("ret." + build.StringToFirstLower(field.Name.Name) + " = null\n")
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	dst.Tab(2).Code("c.hbufWalk(c.hbufBytes(data), (typ, id, val) => {\n")
+	b.printFromData(dst, typ, 3)
+	dst.Tab(2).Code("})\n")
 	dst.Tab(2).Code("return ret\n")
 	dst.Tab(1).Code("}\n\n")
 
 	dst.Tab(1).Code("public toData(): BinaryData {\n")
-	dst.Tab(2).Code("return new ArrayBuffer(0)\n")
+	dst.Tab(2).Code("const writer = new c.HbufWriter()\n")
+	b.printToData(dst, typ, 2)
+	dst.Tab(2).Code("return writer.toData()\n")
 	dst.Tab(1).Code("}\n\n")
 
 	dst.Tab(1).Code("public clone(): ").Code(build.StringToHumpName(typ.Name.Name)).Code(" {\n")
@@ -87,11 +132,38 @@ func (b *Builder) printData(dst *build.Writer, typ *ast.DataType) {
 		dst.Code("\n")
 		return nil
 	})
+	for _, oneOf := range typ.OneOfs {
+		name := build.StringToFirstLower(oneOf.Name.Name)
+		dst.Tab(2).Code("ret." + name + " = " + oneOfName(typ, oneOf) + ".clone(this." + name + ")\n")
+	}
 	dst.Tab(2).Code("return ret\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("}\n\n")
 }
 
+// printFieldDefault 输出字段的默认值，默认值已在检查时统一写法
+func (b *Builder) printFieldDefault(dst *build.Writer, field *ast.Field) {
+	ident := field.Type.Type().(*ast.Ident)
+	if nil != ident.Obj {
+		dst.Code(b.getPackage(dst, ident, "") + "." + ident.Name + "." + build.StringToAllUpper(field.Default.Value))
+		return
+	}
+	switch build.BaseType(ident.Name) {
+	case build.Int64, build.Uint64:
+		dst.Import("long", "Long")
+		if v, err := strconv.ParseUint(field.Default.Value, 10, 64); err == nil {
+			dst.Code("Long.fromString(\"" + strconv.FormatInt(int64(v), 10) + "\")")
+		} else {
+			dst.Code("Long.fromString(\"" + field.Default.Value + "\")")
+		}
+	case build.Decimal:
+		dst.Import("decimal.js", "* as d")
+		dst.Code("new d.Decimal(\"" + field.Default.Value + "\")")
+	default:
+		dst.Code(field.Default.Value)
+	}
+}
+
 func (b *Builder) printCopy(dst *build.Writer, self, name string, expr ast.Expr, data *ast.DataType, empty bool, isRecordKey bool) {
 	switch expr.(type) {
 	case *ast.Ident:
@@ -140,6 +212,12 @@ func (b *Builder) printCopy(dst *build.Writer, self, name string, expr ast.Expr,
 						dst.Code("new Date(").Code(self).Code(name).Code("!.getTime())")
 					}
 				}
+			case build.Bytes:
+				if empty {
+					dst.Code(self).Code(name).Code(" == null ? null : new Uint8Array(").Code(self).Code(name).Code(")")
+				} else {
+					dst.Code("new Uint8Array(").Code(self).Code(name).Code(")")
+				}
 			default:
 				dst.Code(self).Code(name)
 			}
@@ -290,6 +368,34 @@ func (b *Builder) printFormMap(dst *build.Writer, name string, v string, expr as
 						dst.Code("null == " + name + " ? new d.Decimal(0) : new d.Decimal(" + v + ") ")
 					}
 				}
+			case build.Uuid:
+				if empty {
+					dst.Code("null == " + name + " ? null : " + v + ".toString()")
+				} else {
+					dst.Code("null == " + name + " ? \"\" : " + v + ".toString()")
+				}
+			case build.Bytes:
+				if empty {
+					dst.Code("null == " + name + " ? null : c.hbufFromBase64(" + v + ".toString())")
+				} else {
+					dst.Code("null == " + name + " ? new Uint8Array(0) : c.hbufFromBase64(" + v + ".toString())")
+				}
+			case build.Duration:
+				if isRecordKey {
+					if empty {
+						dst.Code("null == " + name + " ? null : " + v + ".toString()")
+					} else {
+						dst.Code("null == " + name + " ? \"\" : " + v + ".toString()")
+					}
+				} else {
+					if empty {
+						dst.Code("null == " + name + " ? null : Number(" + v + ").valueOf() / 1e6")
+					} else {
+						dst.Code("null == " + name + " ? 0 : (Number(" + v + ").valueOf() / 1e6 || 0)")
+					}
+				}
+			case build.Json:
+				dst.Code("null == " + name + " ? null : " + v)
 			default:
 				dst.Code("map[\"" + name + "\"]")
 			}
@@ -341,6 +447,7 @@ func (b *Builder) printToJson(dst *build.Writer, key string, name string, expr a
 	switch expr.(type) {
 	case *ast.Ident:
 		t := expr.(*ast.Ident)
+		name = key + name
 		if nil != t.Obj {
 			if ast.Enum == t.Obj.Kind {
 				if isRecordKey {
@@ -393,6 +500,20 @@ func (b *Builder) printToJson(dst *build.Writer, key string, name string, expr a
 				} else {
 					dst.Code(name + ".toString()")
 				}
+			case build.Bytes:
+				if empty {
+					dst.Code("null == " + name + " ? null : c.hbufToBase64(" + name + ")")
+				} else {
+					dst.Code("c.hbufToBase64(" + name + ")")
+				}
+			case build.Duration:
+				if isRecordKey {
+					dst.Code(name)
+				} else if empty {
+					dst.Code("null == " + name + " ? null : Math.round(" + name + " * 1e6)")
+				} else {
+					dst.Code("Math.round(" + name + " * 1e6)")
+				}
 			default:
 				dst.Code(name)
 			}
@@ -427,8 +548,7 @@ func (b *Builder) printToJson(dst *build.Writer, key string, name string, expr a
 		}
 	case *ast.VarType:
 		t := expr.(*ast.VarType)
-		dst.Code(key)
-		b.printToJson(dst, "", name, t.Type(), data, t.Empty, isRecordKey)
+		b.printToJson(dst, key, name, t.Type(), data, t.Empty, isRecordKey)
 	}
 }
 
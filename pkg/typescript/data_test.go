@@ -0,0 +1,25 @@
+package ts
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestDataDefault 显式的 null 保持为 null，只有缺少字段时使用默认值
+func TestDataDefault(t *testing.T) {
+	src := "" +
+		"data Option = 1 {\n" +
+		"    bool   enable = 0 = true\n" +
+		"    int32  size   = 1 = -1\n" +
+		"    int32? limit  = 2 = 10\n" +
+		"}\n"
+	out := buildtest.Generate(t, "ts", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	code := buildtest.ReadFile(t, out, "a.data.ts")
+	buildtest.Contains(t, code,
+		"ret.enable = \"enable\" in json ? (null == (temp = json[\"enable\"]) ? false",
+		"ret.size = \"size\" in json ? (null == (temp = json[\"size\"]) ? 0",
+		"ret.limit = \"limit\" in json ? (null == (temp = json[\"limit\"]) ? null",
+		"\"limit\": this.limit ?? null,\n",
+		"const ret = new Option()\n\t\tret.limit = null\n\t\tc.hbufWalk(",
+	)
+}
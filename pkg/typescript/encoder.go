@@ -0,0 +1,798 @@
+package ts
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// printToData 生成二进制编码，父类的字段写在对应 Extends id 下
+func (b *Builder) printToData(dst *build.Writer, typ *ast.DataType, tab int) {
+	for _, extend := range typ.Extends {
+		data := extend.Name.Obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType)
+		dst.Tab(tab).Code("writer.writeExtend(" + extend.Id.Value + ", (writer) => {\n")
+		b.printToData(dst, data, tab+1)
+		dst.Tab(tab).Code("})\n")
+	}
+	for _, field := range typ.Fields.List {
+		dst.Tab(tab).Code("")
+		b.printWriter(dst, field.Type)
+		dst.Code("(writer, " + field.Id.Value + ", this." + build.StringToFirstLower(field.Name.Name) + ")\n")
+	}
+	for _, oneOf := range typ.OneOfs {
+		dst.Tab(tab).Code(oneOfName(typ, oneOf) + ".toData(writer, this." + build.StringToFirstLower(oneOf.Name.Name) + ")\n")
+	}
+}
+
+// printFromData 生成二进制解码
+func (b *Builder) printFromData(dst *build.Writer, typ *ast.DataType, tab int) {
+	if 0 < len(typ.Extends) {
+		dst.Tab(tab).Code("if (c.hbufTypeExtend == typ) {\n")
+		dst.Tab(tab + 1).Code("switch (id) {\n")
+		for _, extend := range typ.Extends {
+			data := extend.Name.Obj.Decl.(*ast.TypeSpec).Type.(*ast.DataType)
+			dst.Tab(tab + 2).Code("case " + extend.Id.Value + ":\n")
+			dst.Tab(tab + 3).Code("c.hbufWalk(val, (typ, id, val) => {\n")
+			b.printFromData(dst, data, tab+4)
+			dst.Tab(tab + 3).Code("})\n")
+			dst.Tab(tab + 3).Code("break\n")
+		}
+		dst.Tab(tab + 1).Code("}\n")
+		dst.Tab(tab + 1).Code("return\n")
+		dst.Tab(tab).Code("}\n")
+	}
+	dst.Tab(tab).Code("switch (id) {\n")
+	for _, field := range typ.Fields.List {
+		dst.Tab(tab + 1).Code("case " + field.Id.Value + ":\n")
+		dst.Tab(tab + 2).Code("ret." + build.StringToFirstLower(field.Name.Name) + " = ")
+		b.printReader(dst, field.Type, false)
+		dst.Code("(typ, val)\n")
+		dst.Tab(tab + 2).Code("break\n")
+	}
+	for _, oneOf := range typ.OneOfs {
+		for _, field := range oneOf.Fields {
+			dst.Tab(tab + 1).Code("case " + field.Id.Value + ":\n")
+			dst.Tab(tab + 2).Code("ret." + build.StringToFirstLower(oneOf.Name.Name) + " = { kind: \"" + build.StringToFirstLower(field.Name.Name) + "\", value: ")
+			b.printReader(dst, field.Type, false)
+			dst.Code("(typ, val) }\n")
+			dst.Tab(tab + 2).Code("break\n")
+		}
+	}
+	dst.Tab(tab).Code("}\n")
+}
+
+// printWriter 输出字段类型对应的写入函数
+func (b *Builder) printWriter(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if ast.Enum == t.Obj.Kind {
+				dst.Code("c.hbufWriteEnum")
+			} else {
+				dst.Code("c.hbufWriteData")
+			}
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32:
+			dst.Code("c.hbufWriteInt")
+		case build.Uint8, build.Uint16, build.Uint32:
+			dst.Code("c.hbufWriteUint")
+		case build.Int64:
+			dst.Code("c.hbufWriteInt64")
+		case build.Uint64:
+			dst.Code("c.hbufWriteUint64")
+		case build.Bool:
+			dst.Code("c.hbufWriteBool")
+		case build.Float:
+			dst.Code("c.hbufWriteFloat")
+		case build.Double:
+			dst.Code("c.hbufWriteDouble")
+		case build.String:
+			dst.Code("c.hbufWriteString")
+		case build.Date:
+			dst.Code("c.hbufWriteDate")
+		case build.Decimal:
+			dst.Code("c.hbufWriteDecimal")
+		case build.Bytes:
+			dst.Code("c.hbufWriteBytes")
+		case build.Duration:
+			dst.Code("c.hbufWriteDuration")
+		case build.Uuid:
+			dst.Code("c.hbufWriteUuid")
+		case build.Json:
+			dst.Code("c.hbufWriteJson")
+		}
+	case *ast.ArrayType:
+		dst.Code("c.hbufWriteList(")
+		b.printWriter(dst, expr.(*ast.ArrayType).VType)
+		dst.Code(")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("c.hbufWriteMap(")
+		b.printKeyWriter(dst, ma.Key)
+		dst.Code(", ")
+		b.printWriter(dst, ma.VType)
+		dst.Code(")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty {
+			dst.Code("c.hbufWriteNullable(")
+			b.printWriter(dst, t.Type())
+			dst.Code(")")
+		} else {
+			b.printWriter(dst, t.Type())
+		}
+	}
+}
+
+// printKeyWriter 输出 Record 键对应的写入函数，Record 的键总是以字符串读出
+func (b *Builder) printKeyWriter(dst *build.Writer, expr ast.Expr) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			dst.Code("c.hbufWriteIntKey")
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32, build.Date:
+			dst.Code("c.hbufWriteIntKey")
+		case build.Uint8, build.Uint16, build.Uint32:
+			dst.Code("c.hbufWriteUintKey")
+		case build.Int64, build.Duration:
+			dst.Code("c.hbufWriteInt64Key")
+		case build.Uint64:
+			dst.Code("c.hbufWriteUint64Key")
+		case build.Bool:
+			dst.Code("c.hbufWriteBoolKey")
+		case build.Float:
+			dst.Code("c.hbufWriteFloatKey")
+		case build.Double:
+			dst.Code("c.hbufWriteDoubleKey")
+		case build.Uuid:
+			dst.Code("c.hbufWriteUuid")
+		default:
+			dst.Code("c.hbufWriteString")
+		}
+	case *ast.VarType:
+		b.printKeyWriter(dst, expr.(*ast.VarType).Type())
+	}
+}
+
+// printReader 输出字段类型对应的读取函数
+func (b *Builder) printReader(dst *build.Writer, expr ast.Expr, isRecordKey bool) {
+	switch expr.(type) {
+	case *ast.Ident:
+		t := expr.(*ast.Ident)
+		if nil != t.Obj {
+			if isRecordKey {
+				dst.Code("c.hbufReadInt")
+			} else if ast.Enum == t.Obj.Kind {
+				dst.Code("c.hbufReadEnum(")
+				b.printType(dst, t, true, false)
+				dst.Code(".valueOf)")
+			} else {
+				dst.Code("c.hbufReadData(")
+				b.printType(dst, t, true, false)
+				dst.Code(".fromData)")
+			}
+			return
+		}
+		switch build.BaseType(t.Name) {
+		case build.Int8, build.Int16, build.Int32:
+			dst.Code("c.hbufReadInt")
+		case build.Uint8, build.Uint16, build.Uint32:
+			dst.Code("c.hbufReadUint")
+		case build.Int64:
+			if isRecordKey {
+				dst.Code("c.hbufReadInt64Key")
+			} else {
+				dst.Code("c.hbufReadInt64")
+			}
+		case build.Uint64:
+			if isRecordKey {
+				dst.Code("c.hbufReadUint64Key")
+			} else {
+				dst.Code("c.hbufReadUint64")
+			}
+		case build.Bool:
+			dst.Code("c.hbufReadBool")
+		case build.Float, build.Double:
+			dst.Code("c.hbufReadDouble")
+		case build.String:
+			dst.Code("c.hbufReadString")
+		case build.Date:
+			if isRecordKey {
+				dst.Code("c.hbufReadInt")
+			} else {
+				dst.Code("c.hbufReadDate")
+			}
+		case build.Decimal:
+			if isRecordKey {
+				dst.Code("c.hbufReadString")
+			} else {
+				dst.Code("c.hbufReadDecimal")
+			}
+		case build.Bytes:
+			dst.Code("c.hbufReadBytes")
+		case build.Duration:
+			if isRecordKey {
+				dst.Code("c.hbufReadInt64Key")
+			} else {
+				dst.Code("c.hbufReadDuration")
+			}
+		case build.Uuid:
+			dst.Code("c.hbufReadUuid")
+		case build.Json:
+			dst.Code("c.hbufReadJson")
+		}
+	case *ast.ArrayType:
+		dst.Code("c.hbufReadList(")
+		b.printReader(dst, expr.(*ast.ArrayType).VType, false)
+		dst.Code(")")
+	case *ast.MapType:
+		ma := expr.(*ast.MapType)
+		dst.Code("c.hbufReadMap(")
+		b.printReader(dst, ma.Key, true)
+		dst.Code(", ")
+		b.printReader(dst, ma.VType, false)
+		dst.Code(")")
+	case *ast.VarType:
+		t := expr.(*ast.VarType)
+		if t.Empty && !isRecordKey {
+			dst.Code("c.hbufReadNullable(")
+			b.printReader(dst, t.Type(), false)
+			dst.Code(")")
+		} else {
+			b.printReader(dst, t.Type(), isRecordKey)
+		}
+	}
+}
+
+// printCodecCode 生成同一个目录内所有编码方法共用的函数
+func printCodecCode(dst *build.Writer) {
+	dst.Import("decimal.js", "* as d")
+	dst.Import("long", "Long")
+	dst.Code(_codecCode)
+}
+
+const _codecCode = `export const hbufTypeInt = 0
+export const hbufTypeUint = 1
+export const hbufTypeFloat = 2
+export const hbufTypeBytes = 3
+export const hbufTypeList = 4
+export const hbufTypeMap = 5
+export const hbufTypeData = 6
+export const hbufTypeExtend = 7
+
+const hbufMaxList = 1 << 24
+
+export type HbufRead<T> = (typ: number, val: Uint8Array) => T
+
+export type HbufWrite<T> = (writer: HbufWriter, id: number, v: T) => void
+
+export class HbufWriter {
+	private bytes: number[] = []
+
+	write(typ: number, id: number, val: number[], payload?: ArrayLike<number>): void {
+		const ids = hbufUintBytes(BigInt(id))
+		this.bytes.push((ids.length - 1) << 6 | typ << 3 | (val.length - 1))
+		this.append(ids)
+		this.append(val)
+		if (payload) {
+			this.append(payload)
+		}
+	}
+
+	sub(typ: number, id: number, call: (writer: HbufWriter) => void): void {
+		const sub = new HbufWriter()
+		call(sub)
+		this.write(typ, id, hbufUintBytes(BigInt(sub.bytes.length)), sub.bytes)
+	}
+
+	writeExtend(id: number, call: (writer: HbufWriter) => void): void {
+		this.sub(hbufTypeExtend, id, call)
+	}
+
+	toData(): ArrayBuffer {
+		return new Uint8Array(this.bytes).buffer
+	}
+
+	append(bytes: ArrayLike<number>): void {
+		for (let i = 0; i < bytes.length; i++) {
+			this.bytes.push(bytes[i])
+		}
+	}
+}
+
+export function hbufBytes(data: BinaryData): Uint8Array {
+	if (data instanceof Uint8Array) {
+		return data
+	}
+	if (ArrayBuffer.isView(data)) {
+		return new Uint8Array(data.buffer, data.byteOffset, data.byteLength)
+	}
+	return new Uint8Array(data as ArrayBuffer)
+}
+
+function hbufIntBytes(v: bigint): number[] {
+	const ret: number[] = []
+	for (; ;) {
+		ret.push(Number(BigInt.asUintN(8, v)))
+		if (BigInt(-128) <= v && v < BigInt(128)) {
+			return ret
+		}
+		v >>= BigInt(8)
+	}
+}
+
+function hbufUintBytes(v: bigint): number[] {
+	const ret: number[] = []
+	v = BigInt.asUintN(64, v)
+	for (; ;) {
+		ret.push(Number(BigInt.asUintN(8, v)))
+		v >>= BigInt(8)
+		if (BigInt(0) == v) {
+			return ret
+		}
+	}
+}
+
+function hbufToUint(val: Uint8Array): bigint {
+	let v = BigInt(0)
+	for (let i = val.length - 1; i >= 0; i--) {
+		v = v << BigInt(8) | BigInt(val[i])
+	}
+	return v
+}
+
+function hbufToInt(val: Uint8Array): bigint {
+	return BigInt.asIntN(8 * val.length, hbufToUint(val))
+}
+
+function hbufTypeError(typ: number): Error {
+	return new Error("hbuf: unexpected wire type " + typ)
+}
+
+export function hbufWriteInt(writer: HbufWriter, id: number, v: number): void {
+	writer.write(hbufTypeInt, id, hbufIntBytes(BigInt(Math.trunc(v))))
+}
+
+export function hbufWriteUint(writer: HbufWriter, id: number, v: number): void {
+	writer.write(hbufTypeUint, id, hbufUintBytes(BigInt(Math.trunc(v))))
+}
+
+export function hbufWriteInt64(writer: HbufWriter, id: number, v: Long): void {
+	writer.write(hbufTypeInt, id, hbufIntBytes(BigInt.asIntN(64, BigInt(v.toString()))))
+}
+
+export function hbufWriteUint64(writer: HbufWriter, id: number, v: Long): void {
+	writer.write(hbufTypeUint, id, hbufUintBytes(BigInt(v.toString())))
+}
+
+export function hbufWriteBool(writer: HbufWriter, id: number, v: boolean): void {
+	writer.write(hbufTypeUint, id, [v ? 1 : 0])
+}
+
+export function hbufWriteFloat(writer: HbufWriter, id: number, v: number): void {
+	const val = new DataView(new ArrayBuffer(4))
+	val.setFloat32(0, v, true)
+	writer.write(hbufTypeFloat, id, Array.from(new Uint8Array(val.buffer)))
+}
+
+export function hbufWriteDouble(writer: HbufWriter, id: number, v: number): void {
+	const val = new DataView(new ArrayBuffer(8))
+	val.setFloat64(0, v, true)
+	writer.write(hbufTypeFloat, id, Array.from(new Uint8Array(val.buffer)))
+}
+
+export function hbufWriteString(writer: HbufWriter, id: number, v: string): void {
+	const bytes = new TextEncoder().encode(v)
+	writer.write(hbufTypeBytes, id, hbufUintBytes(BigInt(bytes.length)), bytes)
+}
+
+export function hbufWriteDate(writer: HbufWriter, id: number, v: Date): void {
+	writer.write(hbufTypeInt, id, hbufIntBytes(BigInt(v.getTime())))
+}
+
+export function hbufWriteDecimal(writer: HbufWriter, id: number, v: d.Decimal): void {
+	hbufWriteString(writer, id, v.toString())
+}
+
+export function hbufWriteBytes(writer: HbufWriter, id: number, v: Uint8Array): void {
+	writer.write(hbufTypeBytes, id, hbufUintBytes(BigInt(v.length)), v)
+}
+
+export function hbufWriteDuration(writer: HbufWriter, id: number, v: number): void {
+	writer.write(hbufTypeInt, id, hbufIntBytes(BigInt(Math.round(v * 1e6))))
+}
+
+export function hbufWriteUuid(writer: HbufWriter, id: number, v: string): void {
+	const hex = v.replace(/-/g, "")
+	if (0 != v.length && (36 != v.length || !/^[0-9a-fA-F]{32}$/.test(hex))) {
+		throw new Error("hbuf: invalid uuid " + v)
+	}
+	const bytes = new Uint8Array(hex.length / 2)
+	for (let i = 0; i < bytes.length; i++) {
+		bytes[i] = parseInt(hex.substring(i * 2, i * 2 + 2), 16)
+	}
+	hbufWriteBytes(writer, id, bytes)
+}
+
+export function hbufWriteJson(writer: HbufWriter, id: number, v: any): void {
+	hbufWriteString(writer, id, null == v ? "" : JSON.stringify(v))
+}
+
+export function hbufWriteData(writer: HbufWriter, id: number, v: { toData(): BinaryData }): void {
+	const bytes = hbufBytes(v.toData())
+	writer.write(hbufTypeData, id, hbufUintBytes(BigInt(bytes.length)), bytes)
+}
+
+export function hbufWriteEnum(writer: HbufWriter, id: number, v: { value: number }): void {
+	hbufWriteInt(writer, id, v.value)
+}
+
+export function hbufWriteIntKey(writer: HbufWriter, id: number, v: string): void {
+	hbufWriteInt(writer, id, Number(v))
+}
+
+export function hbufWriteUintKey(writer: HbufWriter, id: number, v: string): void {
+	hbufWriteUint(writer, id, Number(v))
+}
+
+export function hbufWriteInt64Key(writer: HbufWriter, id: number, v: string): void {
+	writer.write(hbufTypeInt, id, hbufIntBytes(BigInt.asIntN(64, BigInt(v))))
+}
+
+export function hbufWriteUint64Key(writer: HbufWriter, id: number, v: string): void {
+	writer.write(hbufTypeUint, id, hbufUintBytes(BigInt(v)))
+}
+
+export function hbufWriteBoolKey(writer: HbufWriter, id: number, v: string): void {
+	hbufWriteBool(writer, id, "true" == v)
+}
+
+export function hbufWriteFloatKey(writer: HbufWriter, id: number, v: string): void {
+	hbufWriteFloat(writer, id, Number(v))
+}
+
+export function hbufWriteDoubleKey(writer: HbufWriter, id: number, v: string): void {
+	hbufWriteDouble(writer, id, Number(v))
+}
+
+export function hbufWriteNullable<T>(write: HbufWrite<T>): HbufWrite<T | null | undefined> {
+	return (writer, id, v) => {
+		if (null != v) {
+			write(writer, id, v)
+		}
+	}
+}
+
+export function hbufWriteList<T>(write: HbufWrite<T>): HbufWrite<T[] | null | undefined> {
+	return (writer, id, v) => {
+		if (null == v) {
+			return
+		}
+		// 读取时长度不能超过 hbufMaxList，超过的在写入时就报错
+		if (hbufMaxList < v.length) {
+			throw new Error("hbuf: list is too long: " + v.length)
+		}
+		writer.sub(hbufTypeList, id, (writer) => {
+			for (let i = 0; i < v.length; i++) {
+				write(writer, i, v[i])
+			}
+			// 末尾为空的元素没有写入，写入长度以便读取时补空
+			if (0 < v.length && null == v[v.length - 1]) {
+				hbufWriteUint(writer, hbufMaxList, v.length)
+			}
+		})
+	}
+}
+
+export function hbufWriteMap<V>(key: HbufWrite<string>, value: HbufWrite<V>): HbufWrite<Record<string, V> | Record<number, V> | null | undefined> {
+	return (writer, id, v) => {
+		if (null == v) {
+			return
+		}
+		const map = v as Record<string, V>
+		writer.sub(hbufTypeMap, id, (writer) => {
+			// 按编码后的键排序，与 Go 编码得到相同的字节
+			const list: { key: Uint8Array, item: V }[] = []
+			for (const k in map) {
+				const sub = new HbufWriter()
+				key(sub, 0, k)
+				list.push({key: new Uint8Array(sub.toData()), item: map[k]})
+			}
+			list.sort((a, b) => hbufCompare(a.key, b.key))
+			for (const entry of list) {
+				writer.append(entry.key)
+				value(writer, 1, entry.item)
+			}
+		})
+	}
+}
+
+function hbufCompare(a: Uint8Array, b: Uint8Array): number {
+	for (let i = 0; i < a.length && i < b.length; i++) {
+		if (a[i] != b[i]) {
+			return a[i] - b[i]
+		}
+	}
+	return a.length - b.length
+}
+
+export function hbufWalk(data: Uint8Array, call: (typ: number, id: number, val: Uint8Array) => void): void {
+	let offset = 0
+	while (offset < data.length) {
+		const head = data[offset]
+		const idLen = (head >> 6) + 1
+		const typ = head >> 3 & 7
+		const valLen = (head & 7) + 1
+		if (data.length < offset + 1 + idLen + valLen) {
+			throw new Error("hbuf: unexpected end of data")
+		}
+		const id = Number(hbufToUint(data.subarray(offset + 1, offset + 1 + idLen)))
+		let val = data.subarray(offset + 1 + idLen, offset + 1 + idLen + valLen)
+		offset += 1 + idLen + valLen
+		if (hbufTypeBytes <= typ) {
+			const size = hbufToUint(val)
+			if (BigInt(data.length - offset) < size) {
+				throw new Error("hbuf: unexpected end of data")
+			}
+			val = data.subarray(offset, offset + Number(size))
+			offset += Number(size)
+		}
+		call(typ, id, val)
+	}
+}
+
+function hbufReadBigInt(typ: number, val: Uint8Array): bigint {
+	switch (typ) {
+		case hbufTypeInt:
+			return hbufToInt(val)
+		case hbufTypeUint:
+			return hbufToUint(val)
+	}
+	throw hbufTypeError(typ)
+}
+
+export function hbufReadInt(typ: number, val: Uint8Array): number {
+	return Number(hbufReadBigInt(typ, val))
+}
+
+export function hbufReadUint(typ: number, val: Uint8Array): number {
+	return Number(hbufReadBigInt(typ, val))
+}
+
+export function hbufReadInt64(typ: number, val: Uint8Array): Long {
+	return Long.fromString(BigInt.asIntN(64, hbufReadBigInt(typ, val)).toString())
+}
+
+export function hbufReadUint64(typ: number, val: Uint8Array): Long {
+	return Long.fromString(BigInt.asUintN(64, hbufReadBigInt(typ, val)).toString(), true)
+}
+
+export function hbufReadInt64Key(typ: number, val: Uint8Array): string {
+	return BigInt.asIntN(64, hbufReadBigInt(typ, val)).toString()
+}
+
+export function hbufReadUint64Key(typ: number, val: Uint8Array): string {
+	return BigInt.asUintN(64, hbufReadBigInt(typ, val)).toString()
+}
+
+export function hbufReadBool(typ: number, val: Uint8Array): boolean {
+	return BigInt(0) != hbufReadBigInt(typ, val)
+}
+
+export function hbufReadDouble(typ: number, val: Uint8Array): number {
+	if (hbufTypeFloat == typ) {
+		const view = new DataView(val.buffer, val.byteOffset, val.byteLength)
+		switch (val.length) {
+			case 4:
+				return view.getFloat32(0, true)
+			case 8:
+				return view.getFloat64(0, true)
+		}
+	}
+	throw hbufTypeError(typ)
+}
+
+export function hbufReadString(typ: number, val: Uint8Array): string {
+	if (hbufTypeBytes != typ) {
+		throw hbufTypeError(typ)
+	}
+	return new TextDecoder().decode(val)
+}
+
+export function hbufReadDate(typ: number, val: Uint8Array): Date {
+	return new Date(Number(hbufReadBigInt(typ, val)))
+}
+
+export function hbufReadDecimal(typ: number, val: Uint8Array): d.Decimal {
+	return new d.Decimal(hbufReadString(typ, val))
+}
+
+export function hbufReadBytes(typ: number, val: Uint8Array): Uint8Array {
+	if (hbufTypeBytes != typ) {
+		throw hbufTypeError(typ)
+	}
+	return val.slice()
+}
+
+export function hbufReadDuration(typ: number, val: Uint8Array): number {
+	return Number(hbufReadBigInt(typ, val)) / 1e6
+}
+
+export function hbufReadUuid(typ: number, val: Uint8Array): string {
+	const bytes = hbufReadBytes(typ, val)
+	if (0 == bytes.length) {
+		return ""
+	}
+	if (16 != bytes.length) {
+		throw new Error("hbuf: invalid uuid length " + bytes.length)
+	}
+	const hex = Array.from(bytes, (e) => e.toString(16).padStart(2, "0")).join("")
+	return hex.substring(0, 8) + "-" + hex.substring(8, 12) + "-" + hex.substring(12, 16) + "-" + hex.substring(16, 20) + "-" + hex.substring(20)
+}
+
+export function hbufReadJson(typ: number, val: Uint8Array): any {
+	const text = hbufReadString(typ, val)
+	return 0 == text.length ? null : JSON.parse(text)
+}
+
+export function hbufToBase64(v: Uint8Array): string {
+	let text = ""
+	for (let i = 0; i < v.length; i++) {
+		text += String.fromCharCode(v[i])
+	}
+	return btoa(text)
+}
+
+export function hbufFromBase64(v: string): Uint8Array {
+	const text = atob(v)
+	const ret = new Uint8Array(text.length)
+	for (let i = 0; i < text.length; i++) {
+		ret[i] = text.charCodeAt(i)
+	}
+	return ret
+}
+
+export function hbufReadEnum<T>(valueOf: (value: number) => T): HbufRead<T> {
+	return (typ, val) => valueOf(hbufReadInt(typ, val))
+}
+
+export function hbufReadData<T>(fromData: (data: BinaryData) => T): HbufRead<T> {
+	return (typ, val) => {
+		if (hbufTypeData != typ) {
+			throw hbufTypeError(typ)
+		}
+		return fromData(val.slice().buffer)
+	}
+}
+
+export function hbufReadNullable<T>(read: HbufRead<T>): HbufRead<T | null> {
+	return read
+}
+
+export function hbufReadList<T>(read: HbufRead<T>): HbufRead<T[]> {
+	return (typ, val) => {
+		if (hbufTypeList != typ) {
+			throw hbufTypeError(typ)
+		}
+		const list: T[] = []
+		hbufWalk(val, (typ, id, val) => {
+			if (hbufMaxList == id) {
+				const size = hbufReadUint(typ, val)
+				if (size < list.length || hbufMaxList < size) {
+					throw new Error("hbuf: invalid list length " + size)
+				}
+				while (list.length < size) {
+					list.push(null as T)
+				}
+				return
+			}
+			if (id < list.length || hbufMaxList <= id) {
+				throw new Error("hbuf: invalid list index " + id)
+			}
+			const item = read(typ, val)
+			while (list.length < id) {
+				list.push(null as T)
+			}
+			list.push(item)
+		})
+		return list
+	}
+}
+
+export function hbufReadMap<K extends string | number, V>(key: HbufRead<K>, value: HbufRead<V>): HbufRead<Record<K, V>> {
+	return (typ, val) => {
+		if (hbufTypeMap != typ) {
+			throw hbufTypeError(typ)
+		}
+		const map = {} as Record<K, V>
+		let k: K | undefined
+		hbufWalk(val, (typ, id, val) => {
+			if (0 == id) {
+				if (undefined !== k) {
+					map[k] = null as V
+				}
+				k = key(typ, val)
+				return
+			}
+			if (undefined === k) {
+				throw new Error("hbuf: map value without key")
+			}
+			map[k] = value(typ, val)
+			k = undefined
+		})
+		if (undefined !== k) {
+			map[k] = null as V
+		}
+		return map
+	}
+}
+
+// HbufHttpRequest 由方法的 http 注解生成的 REST 请求，路径参数已替换为请求数据中的值
+export class HbufHttpRequest {
+	method: string
+	path: string
+	query: Record<string, string>
+	body: any
+
+	constructor(method: string, path: string, query: Record<string, string>, body: any) {
+		this.method = method
+		this.path = path
+		this.query = query
+		this.body = body
+	}
+
+	// fromJson 从 toJson 的结果中依次取出路径参数和请求体，其余不为 null 的字段放在查询参数中，body 为 "*" 时请求体为整个数据
+	public static fromJson(method: string, path: string, json: Record<string, any>, body: string): HbufHttpRequest {
+		const values: Record<string, any> = {...json}
+		const segments = path.split("/").map((item) => {
+			if (item.startsWith("{") && item.endsWith("}")) {
+				const key = item.substring(1, item.length - 1)
+				const value = values[key]
+				delete values[key]
+				return encodeURIComponent(hbufHttpValue(value))
+			}
+			return item
+		})
+
+		let data: any = undefined
+		if ("*" === body) {
+			data = json
+		} else if (0 < body.length) {
+			data = values[body]
+			delete values[body]
+		}
+		const query: Record<string, string> = {}
+		if ("*" !== body) {
+			for (const key in values) {
+				if (null != values[key]) {
+					query[key] = hbufHttpValue(values[key])
+				}
+			}
+		}
+		return new HbufHttpRequest(method, segments.join("/"), query, data)
+	}
+
+	// url 请求的地址，base 为服务的地址
+	public url(base: string): string {
+		const search = new URLSearchParams(this.query).toString()
+		return base + this.path + (0 < search.length ? "?" + search : "")
+	}
+}
+
+// hbufHttpValue 路径和查询参数中的值，字符串原样使用，其他值为 JSON
+export function hbufHttpValue(value: any): string {
+	if (null == value) {
+		return ""
+	}
+	if ("string" === typeof value) {
+		return value
+	}
+	return JSON.stringify(value)
+}
+`
@@ -0,0 +1,38 @@
+package ts
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"testing"
+)
+
+// TestEncoderWire 二进制编码与 Go 逐字节相同，Go 编码的样例在 test/go/parser/testdata/encoder.json 中
+func TestEncoderWire(t *testing.T) {
+	src := "" +
+		"data Item = 1 {\n" +
+		"    int64 id = 0\n" +
+		"    Item?[] list = 1\n" +
+		"    int32<string> map = 2\n" +
+		"}\n"
+	out := buildtest.Generate(t, "ts", Build, "a.hbuf", map[string]string{"a.hbuf": src})
+	code := buildtest.ReadFile(t, out, "hbuf_encoder.ts")
+	buildtest.Constants(t, code, "hbufType", buildtest.WireTypes)
+	buildtest.Contains(t, code,
+		// 头部：第 7-6 位 ID 长度 - 1，第 5-3 位类型，第 2-0 位值长度 - 1，之后是 ID 和值
+		"this.bytes.push((ids.length - 1) << 6 | typ << 3 | (val.length - 1))\n\t\tthis.append(ids)\n\t\tthis.append(val)\n",
+		// 整数按小端写入最少的字节，有符号数到能表示符号的字节为止
+		"ret.push(Number(BigInt.asUintN(8, v)))\n\t\tif (BigInt(-128) <= v && v < BigInt(128)) {\n\t\t\treturn ret\n\t\t}\n\t\tv >>= BigInt(8)\n",
+		"v = BigInt.asUintN(64, v)\n\tfor (; ;) {\n\t\tret.push(Number(BigInt.asUintN(8, v)))\n\t\tv >>= BigInt(8)\n",
+		// 列表末尾为空时以 ID 1 << 24 写入长度
+		"const hbufMaxList = 1 << 24\n",
+		"hbufWriteUint(writer, hbufMaxList, v.length)\n",
+		// Map 的键 ID 为 0，值 ID 为 1，按编码后的键排序
+		"key(sub, 0, k)\n",
+		"list.sort((a, b) => hbufCompare(a.key, b.key))\n",
+		"writer.append(entry.key)\n\t\t\t\tvalue(writer, 1, entry.item)\n",
+	)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "a.data.ts"),
+		"c.hbufWriteInt64(writer, 0, this.id)\n",
+		"c.hbufWriteList(c.hbufWriteNullable(c.hbufWriteData))(writer, 1, this.list)\n",
+		"c.hbufWriteMap(c.hbufWriteString, c.hbufWriteInt)(writer, 2, this.map)\n",
+	)
+}
@@ -0,0 +1,82 @@
+package ts
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// oneOfName 返回 oneof 类型的名称，如 EventPayload
+func oneOfName(typ *ast.DataType, oneOf *ast.OneOf) string {
+	return build.StringToHumpName(typ.Name.Name) + build.StringToHumpName(oneOf.Name.Name)
+}
+
+// printOneOfCode 生成 oneof 的联合类型和同名的转换函数，kind 为成员名称，JSON 写成 {"成员名": 值}
+func (b *Builder) printOneOfCode(dst *build.Writer, typ *ast.DataType) {
+	for _, oneOf := range typ.OneOfs {
+		name := oneOfName(typ, oneOf)
+		if nil != oneOf.Doc && 0 < len(oneOf.Doc.Text()) {
+			dst.Code("///" + oneOf.Doc.Text())
+		}
+		dst.Code("export type " + name + " =\n")
+		for _, field := range oneOf.Fields {
+			dst.Tab(1).Code("| { kind: \"" + build.StringToFirstLower(field.Name.Name) + "\", value: ")
+			b.printType(dst, field.Type, false, false)
+			dst.Code(" }\n")
+		}
+		dst.Code("\n")
+
+		dst.Code("export const " + name + " = {\n")
+		dst.Tab(1).Code("fromJson(json: any): " + name + " | null {\n")
+		dst.Tab(2).Code("if (null == json || \"object\" != typeof json) {\n")
+		dst.Tab(3).Code("return null\n")
+		dst.Tab(2).Code("}\n")
+		dst.Tab(2).Code("let temp: any\n")
+		for _, field := range oneOf.Fields {
+			jsonName := build.StringToUnderlineName(field.Name.Name)
+			dst.Tab(2).Code("if (\"" + jsonName + "\" in json) {\n")
+			dst.Tab(3).Code("return { kind: \"" + build.StringToFirstLower(field.Name.Name) + "\", value: ")
+			b.printFormMap(dst, "(temp = json[\""+jsonName+"\"])", "temp", field.Type, typ, false, false)
+			dst.Code(" }\n")
+			dst.Tab(2).Code("}\n")
+		}
+		dst.Tab(2).Code("return null\n")
+		dst.Tab(1).Code("},\n\n")
+
+		dst.Tab(1).Code("toJson(value: " + name + " | null): Record<string, any> | null {\n")
+		dst.Tab(2).Code("switch (value?.kind) {\n")
+		for _, field := range oneOf.Fields {
+			dst.Tab(3).Code("case \"" + build.StringToFirstLower(field.Name.Name) + "\":\n")
+			dst.Tab(4).Code("return { \"" + build.StringToUnderlineName(field.Name.Name) + "\": ")
+			b.printToJson(dst, "value.", "value", field.Type, typ, false, false)
+			dst.Code(" }\n")
+		}
+		dst.Tab(2).Code("}\n")
+		dst.Tab(2).Code("return null\n")
+		dst.Tab(1).Code("},\n\n")
+
+		dst.Tab(1).Code("toData(writer: c.HbufWriter, value: " + name + " | null): void {\n")
+		dst.Tab(2).Code("switch (value?.kind) {\n")
+		for _, field := range oneOf.Fields {
+			dst.Tab(3).Code("case \"" + build.StringToFirstLower(field.Name.Name) + "\":\n")
+			dst.Tab(4).Code("")
+			b.printWriter(dst, field.Type)
+			dst.Code("(writer, " + field.Id.Value + ", value.value)\n")
+			dst.Tab(4).Code("break\n")
+		}
+		dst.Tab(2).Code("}\n")
+		dst.Tab(1).Code("},\n\n")
+
+		dst.Tab(1).Code("clone(value: " + name + " | null): " + name + " | null {\n")
+		dst.Tab(2).Code("switch (value?.kind) {\n")
+		for _, field := range oneOf.Fields {
+			dst.Tab(3).Code("case \"" + build.StringToFirstLower(field.Name.Name) + "\":\n")
+			dst.Tab(4).Code("return { kind: value.kind, value: ")
+			b.printCopy(dst, "value.", "value", field.Type, typ, false, false)
+			dst.Code(" }\n")
+		}
+		dst.Tab(2).Code("}\n")
+		dst.Tab(2).Code("return null\n")
+		dst.Tab(1).Code("},\n")
+		dst.Code("}\n\n")
+	}
+}
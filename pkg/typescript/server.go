@@ -5,13 +5,15 @@ import (
 	"hbuf/pkg/build"
 )
 
-func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerCode(dst *build.Writer, typ *ast.ServerType) error {
 	dst.Import("hbuf_ts", "* as h")
 
 	b.printServer(dst, typ)
-	b.printServerImp(dst, typ)
-	b.printServerRouter(dst, typ)
-
+	err := b.printServerImp(dst, typ)
+	if err != nil {
+		return err
+	}
+	return b.printServerRouter(dst, typ)
 }
 
 func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
@@ -28,26 +30,23 @@ func (b *Builder) printServer(dst *build.Writer, typ *ast.ServerType) {
 		if nil != method.Doc && 0 < len(method.Doc.Text()) {
 			dst.Tab(1).Code("//" + method.Doc.Text())
 		}
-		isMethod := method.Result.Type().(*ast.Ident).Name == "void"
-
 		dst.Tab(1).Code("" + build.StringToFirstLower(method.Name.Name))
 		dst.Code("(")
 		dst.Code(build.StringToFirstLower(method.ParamName.Name) + ": ")
-		b.printType(dst, method.Param, false, false)
+		b.printMethodParam(dst, method)
 
 		dst.Code(", ctx?: h.Context): ")
-		dst.Code("Promise<")
-		if isMethod {
-			dst.Code("void")
-		} else {
-			b.printType(dst, method.Result.Type(), false, false)
-		}
-		dst.Code(">\n\n")
+		b.printMethodResult(dst, method)
+		dst.Code("\n\n")
 	}
 	dst.Code("}\n\n")
 }
 
-func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
+func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) error {
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
 	dst.Code("export class " + build.StringToHumpName(typ.Name.Name) + "Client extends h.ServerClient implements ")
 	dst.Code(b.getPackage(dst, typ.Name, ""))
 	dst.Code(".")
@@ -55,23 +54,47 @@ func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
 
 	dst.Code("{\n")
 
-	dst.Tab(1).Code("constructor(client: h.Client){\n")
-	dst.Tab(2).Code("super(client)\n")
-	dst.Tab(1).Code("}\n")
+	if hasServerStream(typ) {
+		dst.Import(importPath(dst.File, "hbuf_stream"), "* as s")
+		dst.Tab(1).Code("private readonly _stream: s.StreamClient\n\n")
+		dst.Tab(1).Code("constructor(client: s.StreamClient){\n")
+		dst.Tab(2).Code("super(client)\n")
+		dst.Tab(2).Code("this._stream = client\n")
+		dst.Tab(1).Code("}\n")
+	} else {
+		dst.Tab(1).Code("constructor(client: h.Client){\n")
+		dst.Tab(2).Code("super(client)\n")
+		dst.Tab(1).Code("}\n")
+	}
 
 	dst.Tab(1).Code("get name(): string {\n")
 	dst.Tab(2).Code("return \"" + build.StringToUnderlineName(typ.Name.Name) + "\"\n")
 	dst.Tab(1).Code("}\n\n")
 
 	dst.Tab(1).Code("get id(): number {\n")
-	dst.Tab(2).Code("return 0\t\n")
+	dst.Tab(2).Code("return " + serverId + "\n")
 	dst.Tab(1).Code("}\n\n")
 
-	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		invokeId, err := build.GetInvokeId(server, method)
+		if err != nil {
+			return err
+		}
 		if nil != method.Doc && 0 < len(method.Doc.Text()) {
 			dst.Tab(1).Code("//" + method.Doc.Text())
 		}
-		isMethod := method.Result.Type().(*ast.Ident).Name == "void"
+		if hasStream(method) {
+			dst.Tab(1).Code(build.StringToFirstLower(method.Name.Name) + "(")
+			dst.Code(build.StringToFirstLower(method.ParamName.Name) + ": ")
+			b.printMethodParam(dst, method)
+			dst.Code(", ctx?: h.Context): ")
+			b.printMethodResult(dst, method)
+			dst.Code(" {\n")
+			b.printClientStream(dst, invokeId, method, build.StringToUnderlineName(typ.Name.Name)+"/"+build.StringToUnderlineName(server.Name.Name)+"/"+build.StringToUnderlineName(method.Name.Name))
+			dst.Tab(1).Code("}\n\n")
+			return nil
+		}
+		isMethod := isVoid(method.Result)
 
 		dst.Tab(1).Code("" + build.StringToFirstLower(method.Name.Name))
 		dst.Code("(")
@@ -97,7 +120,7 @@ func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
 		dst.Code(">(\"")
 		dst.Code(build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name))
 		dst.Code("\", ")
-		dst.Code("0 << 32 | " + method.Id.Value)
+		dst.Code(invokeId)
 		dst.Code(", ")
 		dst.Code(build.StringToFirstLower(method.ParamName.Name))
 		dst.Code(", ")
@@ -111,14 +134,87 @@ func (b *Builder) printServerImp(dst *build.Writer, typ *ast.ServerType) {
 		}
 
 		dst.Tab(1).Code("}\n\n")
+		b.printClientHttp(dst, method)
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 	dst.Code("}\n\n")
+	return nil
 }
 
-func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
+// printClientHttp 输出带 http 注解的方法对应的 REST 请求，如 getUserHttp(req)，由调用方发送
+func (b *Builder) printClientHttp(dst *build.Writer, method *ast.FuncType) {
+	route, err := build.GetHttp(method)
+	if nil != err || nil == route {
+		return
+	}
+	dst.Import(importPath(dst.File, "hbuf_encoder"), "* as c")
+	dst.Tab(1).Code("// " + route.Method + " " + route.Path + "\n")
+	dst.Tab(1).Code(build.StringToFirstLower(method.Name.Name) + "Http(")
+	dst.Code(build.StringToFirstLower(method.ParamName.Name) + ": ")
+	b.printType(dst, method.Param, false, false)
+	dst.Code("): c.HbufHttpRequest {\n")
+	dst.Tab(2).Code("return c.HbufHttpRequest.fromJson(\"" + route.Method + "\", \"" + route.Path + "\", ")
+	dst.Code(build.StringToFirstLower(method.ParamName.Name) + ".toJson(), \"" + route.Body + "\")\n")
+	dst.Tab(1).Code("}\n\n")
+}
+
+// isVoid 返回结果是否为 void
+func isVoid(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "void" == ident.Name
+}
 
-	dst.Code("export class " + build.StringToHumpName(typ.Name.Name) + "Router implements h.ServerRouter {\n")
+// printMethodParam 输出方法的参数类型，stream 为 AsyncIterable<Uint8Array>，stream<Item> 为 AsyncIterable<Item>
+func (b *Builder) printMethodParam(dst *build.Writer, method *ast.FuncType) {
+	if isStream(method.Param) {
+		dst.Code("AsyncIterable<Uint8Array>")
+		return
+	}
+	if item := build.GetStreamItem(method.Param); nil != item {
+		dst.Code("AsyncIterable<")
+		b.printType(dst, item, false, false)
+		dst.Code(">")
+		return
+	}
+	b.printType(dst, method.Param, false, false)
+}
+
+// printMethodResult 输出方法的返回类型，stream 为 AsyncIterable<Uint8Array>，stream<Item> 为 AsyncIterable<Item>
+func (b *Builder) printMethodResult(dst *build.Writer, method *ast.FuncType) {
+	if isStream(method.Result) {
+		dst.Code("AsyncIterable<Uint8Array>")
+		return
+	}
+	if item := build.GetStreamItem(method.Result); nil != item {
+		dst.Code("AsyncIterable<")
+		b.printType(dst, item, false, false)
+		dst.Code(">")
+		return
+	}
+	dst.Code("Promise<")
+	if isVoid(method.Result) {
+		dst.Code("void")
+	} else {
+		b.printType(dst, method.Result.Type(), false, false)
+	}
+	dst.Code(">")
+}
+
+func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) error {
+	serverId, err := build.GetServerId(typ)
+	if err != nil {
+		return err
+	}
+	isStreams := hasServerStream(typ)
+	dst.Code("export class " + build.StringToHumpName(typ.Name.Name) + "Router implements h.ServerRouter")
+	if isStreams {
+		dst.Import(importPath(dst.File, "hbuf_stream"), "* as s")
+		dst.Code(", s.StreamRouter")
+	}
+	dst.Code(" {\n")
 	dst.Tab(1).Code("readonly server: " + build.StringToHumpName(typ.Name.Name) + "\n")
 	dst.Code("\n")
 	dst.Tab(1).Code("invoke: Record<string, h.ServerInvoke>\n")
@@ -127,18 +223,35 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 	dst.Tab(2).Code("return this.invoke\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("\n")
+	if isStreams {
+		dst.Tab(1).Code("streams: Record<string, s.StreamInvoke>\n")
+		dst.Code("\n")
+		dst.Tab(1).Code("streamIds: Record<number, s.StreamInvoke>\n")
+		dst.Code("\n")
+		dst.Tab(1).Code("getStreams(): Record<string, s.StreamInvoke> {\n")
+		dst.Tab(2).Code("return this.streams\n")
+		dst.Tab(1).Code("}\n")
+		dst.Code("\n")
+		dst.Tab(1).Code("getStreamIds(): Record<number, s.StreamInvoke> {\n")
+		dst.Tab(2).Code("return this.streamIds\n")
+		dst.Tab(1).Code("}\n")
+		dst.Code("\n")
+	}
 	dst.Tab(1).Code("getName(): string {\n")
 	dst.Tab(2).Code("return \"" + build.StringToUnderlineName(typ.Name.Name) + "\"\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("\n")
 	dst.Tab(1).Code("getId(): number {\n")
-	dst.Tab(2).Code("return 0\n")
+	dst.Tab(2).Code("return " + serverId + "\n")
 	dst.Tab(1).Code("}\n")
 	dst.Code("\n")
 	dst.Tab(1).Code("constructor(server: " + build.StringToHumpName(typ.Name.Name) + ") {\n")
 	dst.Tab(2).Code("this.server = server\n")
 	dst.Tab(2).Code("this.invoke = {\n")
-	err := build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+	err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		if hasStream(method) {
+			return nil
+		}
 		dst.Tab(3).Code("\"" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": {\n")
 		dst.Tab(4).Code("formData(data: BinaryData | Record<string, any>): h.Data {\n")
 		dst.Tab(5).Code("return ")
@@ -157,10 +270,40 @@ func (b *Builder) printServerRouter(dst *build.Writer, typ *ast.ServerType) {
 		return nil
 	})
 	if err != nil {
-		return
+		return err
 	}
-
 	dst.Tab(2).Code("}\n")
+
+	if isStreams {
+		dst.Tab(2).Code("this.streams = {\n")
+		_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			dst.Tab(3).Code("\"" + build.StringToUnderlineName(server.Name.Name) + "/" + build.StringToUnderlineName(method.Name.Name) + "\": ")
+			b.printRouterStream(dst, method)
+			return nil
+		})
+		dst.Tab(2).Code("}\n")
+		dst.Tab(2).Code("this.streamIds = {\n")
+		err = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+			if !hasStream(method) {
+				return nil
+			}
+			invokeId, err := build.GetInvokeId(server, method)
+			if err != nil {
+				return err
+			}
+			dst.Tab(3).Code(invokeId + ": ")
+			b.printRouterStream(dst, method)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		dst.Tab(2).Code("}\n")
+	}
 	dst.Tab(1).Code("}\n")
 	dst.Code("}\n")
+	return nil
 }
@@ -0,0 +1,68 @@
+package ts
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"strings"
+	"testing"
+)
+
+const _streamSrc = "" +
+	"data Req = 1 {\n" +
+	"    string name = 0\n" +
+	"}\n" +
+	"server File = 1 {\n" +
+	"    Req Get(Req req) = 0\n" +
+	"    Req Upload(stream req) = 1\n" +
+	"    stream Download(Req req) = 2\n" +
+	"    Req Sum(stream<Req> req) = 3\n" +
+	"    stream<Req> List(Req req) = 4\n" +
+	"    stream<Req> Chat(stream<Req> req) = 5\n" +
+	"}\n"
+
+// TestServerStream stream 和 stream<Item> 方法不进入普通调用的路由，客户端和路由按帧读写
+func TestServerStream(t *testing.T) {
+	out := buildtest.Generate(t, "ts", Build, "a.hbuf", map[string]string{"a.hbuf": _streamSrc})
+	code := buildtest.ReadFile(t, out, "a.server.ts")
+	buildtest.Contains(t, code,
+		"upload(req: AsyncIterable<Uint8Array>, ctx?: h.Context): Promise<$1.Req>\n",
+		"download(req: $1.Req, ctx?: h.Context): AsyncIterable<Uint8Array>\n",
+		"constructor(client: s.StreamClient){\n",
+		"s.hbufCall(this._stream, \"file/file/upload\", 4294967297, (binary) => s.hbufSendStream(req), ctx)\n",
+		"return s.hbufRecvStream(call.frames)\n",
+		"implements h.ServerRouter, s.StreamRouter {\n",
+		"\"file/upload\": {\n",
+		"4294967298: {\n",
+		"return s.hbufReplyStream(async () => server.download(await s.hbufRecv(request, binary, $1.Req.fromJson, $1.Req.fromData), ctx))\n",
+		"return s.hbufRecvMessages(call.frames, call.binary, $1.Req.fromJson, $1.Req.fromData)\n",
+		"(binary) => s.hbufSendMessages(binary, req), ctx)\n",
+		"return s.hbufReplyMessages(binary, async () => server.list(await s.hbufRecv(request, binary, $1.Req.fromJson, $1.Req.fromData), ctx))\n",
+		"return s.hbufReply(binary, async () => server.sum(s.hbufRecvMessages(request, binary, $1.Req.fromJson, $1.Req.fromData), ctx))\n",
+		"return s.hbufReplyMessages(binary, async () => server.chat(s.hbufRecvMessages(request, binary, $1.Req.fromJson, $1.Req.fromData), ctx))\n",
+	)
+	invoke := code[strings.Index(code, "this.invoke = {"):strings.Index(code, "this.streams = {")]
+	if !strings.Contains(invoke, "\"file/get\"") || strings.Contains(invoke, "\"file/upload\"") {
+		t.Errorf("stream methods in invoke:\n%s", invoke)
+	}
+}
+
+// TestStreamFrame 帧的类型和帧头与 Go 生成的 StreamServer 相同
+func TestStreamFrame(t *testing.T) {
+	out := buildtest.Generate(t, "ts", Build, "a.hbuf", map[string]string{"a.hbuf": _streamSrc})
+	code := buildtest.ReadFile(t, out, "hbuf_stream.ts")
+	buildtest.Constants(t, code, "hbufFrame", buildtest.FrameKinds)
+	buildtest.Contains(t, code,
+		// 写入：1 字节类型，之后是 4 字节小端长度
+		"const ret = new Uint8Array(5 + size)\n\tret[0] = kind\n\tnew DataView(ret.buffer).setUint32(1, size, true)\n",
+		"ret.set(data, 5)\n",
+		// 读取：从第 2 个字节读出小端长度，帧的内容从第 6 个字节开始
+		"const size = new DataView(buf.buffer, buf.byteOffset + offset + 1, 4).getUint32(0, true)\n",
+		"yield {kind: buf[offset], data: buf.subarray(offset + 5, offset + need)}\n",
+		// 空消息发送空消息帧，读取消息流时跳过
+		"return hbufFrame(hbufFrameNull)\n",
+		"} else if (hbufFrameNull != frame.kind) {\n",
+		// 错误帧的内容为 {"code", "msg"}
+		"hbufFrame(hbufFrameError, new TextEncoder().encode(JSON.stringify({\"code\": res.code, \"msg\": res.msg})))",
+		// stream 结束和 void 结果都发送结束帧
+		"yield hbufFrame(hbufFrameEnd)\n",
+	)
+}
@@ -0,0 +1,457 @@
+package ts
+
+import (
+	"hbuf/pkg/ast"
+	"hbuf/pkg/build"
+)
+
+// isStream 返回参数或结果是否为 stream
+func isStream(typ *ast.VarType) bool {
+	ident, ok := typ.Type().(*ast.Ident)
+	return ok && "stream" == ident.Name
+}
+
+// hasStream 返回方法的参数或结果是否为 stream 或 stream<Item>
+func hasStream(method *ast.FuncType) bool {
+	return isStream(method.Param) || isStream(method.Result) || nil != build.GetStreamItem(method.Param) || nil != build.GetStreamItem(method.Result)
+}
+
+// hasServerStream 返回服务中是否有 stream 方法，包括继承的方法
+func hasServerStream(typ *ast.ServerType) bool {
+	ret := false
+	_ = build.EnumMethod(typ, func(method *ast.FuncType, server *ast.ServerType) error {
+		ret = ret || hasStream(method)
+		return nil
+	})
+	return ret
+}
+
+// isFileStream 返回文件中是否有服务含有 stream 方法
+func isFileStream(file *ast.File) bool {
+	for _, s := range file.Specs {
+		if spec, ok := s.(*ast.TypeSpec); ok {
+			if server, ok := spec.Type.(*ast.ServerType); ok && hasServerStream(server) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printClientStream 输出 stream 方法的调用，请求边读边发，结果边收边读
+func (b *Builder) printClientStream(dst *build.Writer, invokeId string, method *ast.FuncType, name string) {
+	dst.Import(importPath(dst.File, "hbuf_stream"), "* as s")
+	param := build.StringToFirstLower(method.ParamName.Name)
+	dst.Tab(2).Code("const call = s.hbufCall(this._stream, \"" + name + "\", " + invokeId + ", (binary) => ")
+	if isStream(method.Param) {
+		dst.Code("s.hbufSendStream(" + param + ")")
+	} else if nil != build.GetStreamItem(method.Param) {
+		dst.Code("s.hbufSendMessages(binary, " + param + ")")
+	} else {
+		dst.Code("s.hbufSend(binary, " + param + ")")
+	}
+	dst.Code(", ctx)\n")
+
+	if isVoid(method.Result) {
+		dst.Tab(2).Code("return s.hbufCallEnd(call)\n")
+	} else if isStream(method.Result) {
+		dst.Tab(2).Code("return s.hbufRecvStream(call.frames)\n")
+	} else if item := build.GetStreamItem(method.Result); nil != item {
+		dst.Tab(2).Code("return s.hbufRecvMessages(call.frames, call.binary, ")
+		b.printType(dst, item.Type(), false, false)
+		dst.Code(".fromJson, ")
+		b.printType(dst, item.Type(), false, false)
+		dst.Code(".fromData)\n")
+	} else {
+		dst.Tab(2).Code("return s.hbufCallReply(call, ")
+		b.printType(dst, method.Result.Type(), false, false)
+		dst.Code(".fromJson, ")
+		b.printType(dst, method.Result.Type(), false, false)
+		dst.Code(".fromData)\n")
+	}
+}
+
+// printRouterStream 输出路由中 stream 方法的调用，请求和结果都按帧边读边写
+func (b *Builder) printRouterStream(dst *build.Writer, method *ast.FuncType) {
+	dst.Code("{\n")
+	dst.Tab(4).Code("invoke(binary: boolean, request: AsyncIterable<s.HbufFrame>, ctx?: h.Context): AsyncIterable<Uint8Array> {\n")
+	if isVoid(method.Result) {
+		dst.Tab(5).Code("return s.hbufReplyEnd(async () => ")
+	} else if isStream(method.Result) {
+		dst.Tab(5).Code("return s.hbufReplyStream(async () => ")
+	} else if nil != build.GetStreamItem(method.Result) {
+		dst.Tab(5).Code("return s.hbufReplyMessages(binary, async () => ")
+	} else {
+		dst.Tab(5).Code("return s.hbufReply(binary, async () => ")
+	}
+	dst.Code("server." + build.StringToFirstLower(method.Name.Name) + "(")
+	if isStream(method.Param) {
+		dst.Code("s.hbufRecvStream(request)")
+	} else if item := build.GetStreamItem(method.Param); nil != item {
+		dst.Code("s.hbufRecvMessages(request, binary, ")
+		b.printType(dst, item.Type(), false, false)
+		dst.Code(".fromJson, ")
+		b.printType(dst, item.Type(), false, false)
+		dst.Code(".fromData)")
+	} else {
+		dst.Code("await s.hbufRecv(request, binary, ")
+		b.printType(dst, method.Param.Type(), false, false)
+		dst.Code(".fromJson, ")
+		b.printType(dst, method.Param.Type(), false, false)
+		dst.Code(".fromData)")
+	}
+	dst.Code(", ctx))\n")
+	dst.Tab(4).Code("}\n")
+	dst.Tab(3).Code("},\n")
+}
+
+// printStreamCode 生成 stream 方法共用的帧读写、客户端调用和路由分发代码
+func printStreamCode(dst *build.Writer) {
+	dst.Import("hbuf_ts", "* as h")
+	dst.Import(importPath(dst.File, "hbuf_encoder"), "* as c")
+	dst.Code(_streamCode)
+}
+
+const _streamCode = `// stream 方法的请求和结果都是一串帧，帧头为 1 字节类型和 4 字节小端长度，之后是帧的内容，与 Go 生成的代码相同
+export const hbufFrameEnd = 0 // 流结束
+export const hbufFrameData = 1 // 一条消息，或 stream 中的一段字节
+export const hbufFrameNull = 2 // 为 null 的消息
+export const hbufFrameError = 3 // 服务返回的错误，内容为 {"code": ..., "msg": ...} 的 JSON
+
+// hbufStreamChunk 为 stream 每帧最多发送的字节数，hbufMaxFrame 为一帧最大的长度
+export const hbufStreamChunk = 32 << 10
+export const hbufMaxFrame = 64 << 20
+
+// HbufFrame 一帧的类型和内容
+export interface HbufFrame {
+	kind: number
+	data: Uint8Array
+}
+
+// HbufStreamError 错误帧中服务返回的错误
+export class HbufStreamError extends Error {
+	code: number
+	msg: string
+
+	constructor(code: number, msg: string) {
+		super(msg)
+		this.code = code
+		this.msg = msg
+	}
+}
+
+export function hbufFrame(kind: number, data?: Uint8Array): Uint8Array {
+	const size = undefined === data ? 0 : data.length
+	const ret = new Uint8Array(5 + size)
+	ret[0] = kind
+	new DataView(ret.buffer).setUint32(1, size, true)
+	if (undefined !== data) {
+		ret.set(data, 5)
+	}
+	return ret
+}
+
+function hbufConcat(chunks: Uint8Array[], length: number): Uint8Array {
+	if (1 == chunks.length) {
+		return chunks[0]
+	}
+	const ret = new Uint8Array(length)
+	let offset = 0
+	for (const chunk of chunks) {
+		ret.set(chunk, offset)
+		offset += chunk.length
+	}
+	return ret
+}
+
+// hbufReadFrames 把收到的字节拆成帧，一帧可以分在多段字节中，字节结束时还有没读完的帧则抛出错误
+export async function* hbufReadFrames(input: AsyncIterable<Uint8Array>): AsyncGenerator<HbufFrame> {
+	let pending: Uint8Array[] = []
+	let length = 0
+	let need = 5
+	for await (const chunk of input) {
+		pending.push(chunk)
+		length += chunk.length
+		if (length < need) {
+			continue
+		}
+		const buf = hbufConcat(pending, length)
+		let offset = 0
+		while (true) {
+			need = 5
+			if (buf.length - offset < need) {
+				break
+			}
+			const size = new DataView(buf.buffer, buf.byteOffset + offset + 1, 4).getUint32(0, true)
+			if (hbufMaxFrame < size) {
+				throw new Error("hbuf: frame too large")
+			}
+			need = 5 + size
+			if (buf.length - offset < need) {
+				break
+			}
+			yield {kind: buf[offset], data: buf.subarray(offset + 5, offset + need)}
+			offset += need
+		}
+		pending = [buf.subarray(offset)]
+		length = buf.length - offset
+	}
+	if (0 < length) {
+		throw new Error("hbuf: unexpected end of stream")
+	}
+}
+
+// hbufFrameException 数据帧以外的帧，错误帧返回其中的错误
+function hbufFrameException(frame: HbufFrame): Error {
+	if (hbufFrameError == frame.kind) {
+		const json = JSON.parse(new TextDecoder().decode(frame.data))
+		return new HbufStreamError(json["code"] ?? 0, json["msg"] ?? "")
+	}
+	return new Error("hbuf: unexpected frame " + frame.kind)
+}
+
+// hbufErrorFrame 错误帧，不是 HbufStreamError 的错误只返回状态码 500
+export function hbufErrorFrame(error: any): Uint8Array {
+	const res = error instanceof HbufStreamError ? error : new HbufStreamError(500, "Internal Server Error")
+	return hbufFrame(hbufFrameError, new TextEncoder().encode(JSON.stringify({"code": res.code, "msg": res.msg})))
+}
+
+// hbufChunks 把 data 中的字节分段作为数据帧
+async function* hbufChunks(data: AsyncIterable<Uint8Array>): AsyncGenerator<Uint8Array> {
+	for await (const chunk of data) {
+		for (let i = 0; i < chunk.length; i += hbufStreamChunk) {
+			yield hbufFrame(hbufFrameData, chunk.subarray(i, i + hbufStreamChunk))
+		}
+	}
+}
+
+// hbufSendStream 分段发送 data 中的全部字节，之后发送结束帧
+export async function* hbufSendStream(data: AsyncIterable<Uint8Array>): AsyncGenerator<Uint8Array> {
+	yield* hbufChunks(data)
+	yield hbufFrame(hbufFrameEnd)
+}
+
+// hbufRecvStream 依次读出数据帧中的字节，读到结束帧时结束，读到错误帧时抛出其中的错误
+export async function* hbufRecvStream(frames: AsyncIterable<HbufFrame>): AsyncGenerator<Uint8Array> {
+	for await (const frame of frames) {
+		if (hbufFrameData == frame.kind) {
+			yield frame.data
+		} else if (hbufFrameEnd == frame.kind) {
+			return
+		} else {
+			throw hbufFrameException(frame)
+		}
+	}
+	throw new Error("hbuf: unexpected end of stream")
+}
+
+type HbufStreamMessage = {
+	toJson(): Record<string, any>
+	toData(): BinaryData
+}
+
+// hbufEncode 编码一条消息，binary 为 true 时使用二进制编码，否则使用 JSON，null 编码为空消息帧
+export function hbufEncode(binary: boolean, v: HbufStreamMessage | null | undefined): Uint8Array {
+	if (null == v) {
+		return hbufFrame(hbufFrameNull)
+	}
+	if (binary) {
+		return hbufFrame(hbufFrameData, c.hbufBytes(v.toData()))
+	}
+	return hbufFrame(hbufFrameData, new TextEncoder().encode(JSON.stringify(v.toJson())))
+}
+
+export function hbufDecode<T>(binary: boolean, buf: Uint8Array, fromJson: (json: Record<string, any>) => T, fromData: (data: BinaryData) => T): T {
+	if (binary) {
+		return fromData(buf)
+	}
+	return fromJson(JSON.parse(new TextDecoder().decode(buf)))
+}
+
+// hbufSend 发送一条消息
+export async function* hbufSend(binary: boolean, v: HbufStreamMessage | null | undefined): AsyncGenerator<Uint8Array> {
+	yield hbufEncode(binary, v)
+}
+
+// hbufRecv 读出一条消息，读到错误帧时抛出其中的错误
+export async function hbufRecv<T>(frames: AsyncIterable<HbufFrame>, binary: boolean, fromJson: (json: Record<string, any>) => T, fromData: (data: BinaryData) => T): Promise<T> {
+	for await (const frame of frames) {
+		if (hbufFrameData == frame.kind) {
+			return hbufDecode(binary, frame.data, fromJson, fromData)
+		}
+		if (hbufFrameNull == frame.kind) {
+			throw new Error("hbuf: unexpected null message")
+		}
+		throw hbufFrameException(frame)
+	}
+	throw new Error("hbuf: unexpected end of stream")
+}
+
+// hbufSendMessages 依次发送 items 中的全部消息，之后发送结束帧
+export async function* hbufSendMessages(binary: boolean, items: AsyncIterable<HbufStreamMessage | null | undefined>): AsyncGenerator<Uint8Array> {
+	for await (const item of items) {
+		yield hbufEncode(binary, item)
+	}
+	yield hbufFrame(hbufFrameEnd)
+}
+
+// hbufRecvMessages 逐帧读出 stream<T> 中的消息，读到结束帧时结束，读到错误帧时抛出其中的错误，为 null 的消息会被忽略
+export async function* hbufRecvMessages<T>(frames: AsyncIterable<HbufFrame>, binary: boolean, fromJson: (json: Record<string, any>) => T, fromData: (data: BinaryData) => T): AsyncGenerator<T> {
+	for await (const frame of frames) {
+		if (hbufFrameData == frame.kind) {
+			yield hbufDecode(binary, frame.data, fromJson, fromData)
+		} else if (hbufFrameEnd == frame.kind) {
+			return
+		} else if (hbufFrameNull != frame.kind) {
+			throw hbufFrameException(frame)
+		}
+	}
+	throw new Error("hbuf: unexpected end of stream")
+}
+
+// StreamClient 传输 stream 方法的客户端，普通方法仍由 h.Client 调用，含有 stream 方法的服务的客户端需要传入它
+export interface StreamClient extends h.Client {
+	// binary 为 true 时按 id 调用并使用二进制编码，否则按 name 调用并使用 JSON 编码
+	readonly binary: boolean
+
+	// stream 打开一次调用，边发送 request 中的请求帧边返回收到的结果帧，停止读取时结束调用
+	stream(name: string, id: number, request: AsyncIterable<Uint8Array>, ctx?: h.Context): AsyncIterable<Uint8Array>
+}
+
+// HbufStreamCall 客户端的一次 stream 调用，frames 为收到的结果帧
+export class HbufStreamCall {
+	readonly binary: boolean
+	readonly frames: AsyncIterable<HbufFrame>
+
+	constructor(binary: boolean, frames: AsyncIterable<HbufFrame>) {
+		this.binary = binary
+		this.frames = frames
+	}
+}
+
+export function hbufCall(client: StreamClient, name: string, id: number, send: (binary: boolean) => AsyncIterable<Uint8Array>, ctx?: h.Context): HbufStreamCall {
+	const binary = client.binary
+	return new HbufStreamCall(binary, hbufReadFrames(client.stream(name, id, send(binary), ctx)))
+}
+
+// hbufCallReply 读出调用的结果
+export function hbufCallReply<T>(call: HbufStreamCall, fromJson: (json: Record<string, any>) => T, fromData: (data: BinaryData) => T): Promise<T> {
+	return hbufRecv(call.frames, call.binary, fromJson, fromData)
+}
+
+// hbufCallEnd 等到没有结果的调用结束
+export async function hbufCallEnd(call: HbufStreamCall): Promise<void> {
+	for await (const frame of call.frames) {
+		if (hbufFrameEnd == frame.kind) {
+			return
+		}
+		throw hbufFrameException(frame)
+	}
+	throw new Error("hbuf: unexpected end of stream")
+}
+
+// hbufReply 发送路由中服务返回的结果或错误
+export async function* hbufReply(binary: boolean, call: () => Promise<HbufStreamMessage | null | undefined>): AsyncGenerator<Uint8Array> {
+	let ret: Uint8Array
+	try {
+		ret = hbufEncode(binary, await call())
+	} catch (e) {
+		ret = hbufErrorFrame(e)
+	}
+	yield ret
+}
+
+// hbufReplyEnd 结束路由中没有结果的调用，成功时发送结束帧，否则发送错误帧
+export async function* hbufReplyEnd(call: () => Promise<void>): AsyncGenerator<Uint8Array> {
+	let ret: Uint8Array
+	try {
+		await call()
+		ret = hbufFrame(hbufFrameEnd)
+	} catch (e) {
+		ret = hbufErrorFrame(e)
+	}
+	yield ret
+}
+
+// hbufReplyStream 分段发送路由中服务返回的字节，之后发送结束帧，出错时发送错误帧
+export async function* hbufReplyStream(call: () => Promise<AsyncIterable<Uint8Array>>): AsyncGenerator<Uint8Array> {
+	try {
+		for await (const frame of hbufChunks(await call())) {
+			yield frame
+		}
+	} catch (e) {
+		yield hbufErrorFrame(e)
+		return
+	}
+	yield hbufFrame(hbufFrameEnd)
+}
+
+// hbufReplyMessages 逐条发送路由中服务返回的消息，之后发送结束帧，出错时发送错误帧
+export async function* hbufReplyMessages(binary: boolean, call: () => Promise<AsyncIterable<HbufStreamMessage | null | undefined>>): AsyncGenerator<Uint8Array> {
+	try {
+		for await (const item of await call()) {
+			yield hbufEncode(binary, item)
+		}
+	} catch (e) {
+		yield hbufErrorFrame(e)
+		return
+	}
+	yield hbufFrame(hbufFrameEnd)
+}
+
+// StreamInvoke 路由中 stream 方法的调用，从 request 读出请求帧，返回结果帧
+export interface StreamInvoke {
+	invoke(binary: boolean, request: AsyncIterable<HbufFrame>, ctx?: h.Context): AsyncIterable<Uint8Array>
+}
+
+// StreamRouter 含有 stream 方法的服务路由
+export interface StreamRouter {
+	getName(): string
+
+	getStreams(): Record<string, StreamInvoke>
+
+	getStreamIds(): Record<number, StreamInvoke>
+}
+
+// StreamServer 按名称或 Id 分发 stream 方法的调用，名称与 Go 的 StreamServer 相同，为 "/服务名/" 加上路由中的名称
+export class StreamServer {
+	private names: Record<string, StreamInvoke> = {}
+	private ids: Record<number, StreamInvoke> = {}
+
+	constructor(...routers: StreamRouter[]) {
+		for (const router of routers) {
+			this.add(router)
+		}
+	}
+
+	add(router: StreamRouter): void {
+		const streams = router.getStreams()
+		for (const key in streams) {
+			this.names["/" + router.getName() + "/" + key] = streams[key]
+		}
+		Object.assign(this.ids, router.getStreamIds())
+	}
+
+	// invoke 按名称调用并使用 JSON 编码
+	invoke(name: string, request: AsyncIterable<Uint8Array>, ctx?: h.Context): AsyncIterable<Uint8Array> {
+		const value = this.names[name]
+		if (undefined === value) {
+			return hbufNotFound()
+		}
+		return value.invoke(false, hbufReadFrames(request), ctx)
+	}
+
+	// invokeId 按 服务Id<<32 | 方法Id 调用并使用二进制编码
+	invokeId(id: number, request: AsyncIterable<Uint8Array>, ctx?: h.Context): AsyncIterable<Uint8Array> {
+		const value = this.ids[id]
+		if (undefined === value) {
+			return hbufNotFound()
+		}
+		return value.invoke(true, hbufReadFrames(request), ctx)
+	}
+}
+
+async function* hbufNotFound(): AsyncGenerator<Uint8Array> {
+	yield hbufErrorFrame(new HbufStreamError(404, "not found"))
+}
+`
@@ -9,18 +9,21 @@ import (
 	"path/filepath"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 var _types = map[build.BaseType]string{
 	build.Int8: "number", build.Int16: "number", build.Int32: "number", build.Int64: "Long", build.Uint8: "number",
 	build.Uint16: "number", build.Uint32: "number", build.Uint64: "Long", build.Bool: "boolean", build.Float: "number",
 	build.Double: "number", build.String: "string", build.Date: "Date", build.Decimal: "d.Decimal",
+	build.Bytes: "Uint8Array", build.Duration: "number", build.Uuid: "string", build.Json: "any",
 }
 
 var _typesValue = map[build.BaseType]string{
 	build.Int8: "0", build.Int16: "0", build.Int32: "0", build.Int64: "Long.ZERO", build.Uint8: "0",
 	build.Uint16: "0", build.Uint32: "0", build.Uint64: "Long.ZERO", build.Bool: "false", build.Float: "0.0",
 	build.Double: "0.0", build.String: "\"\"", build.Date: "new Date()", build.Decimal: "new d.Decimal(0)",
+	build.Bytes: "new Uint8Array(0)", build.Duration: "0", build.Uuid: "\"\"", build.Json: "null",
 }
 
 type DartWriter struct {
@@ -84,7 +87,26 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 		return err
 	}
 
-	if 0 < dst.data.GetCode().Len() {
+	// 二进制编码和 REST 请求的公共代码，服务端和客户端也会用到
+	isData := param.Feature(build.FeatureData) && 0 < dst.data.GetCode().Len()
+	if isData || param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
+		codec := build.NewWriter()
+		printCodecCode(codec)
+		err = writerFile(codec, filepath.Join(param.GetOutDir(), "hbuf_encoder.ts"))
+		if err != nil {
+			return err
+		}
+	}
+	// stream 方法的公共代码，只有服务含有 stream 方法时生成
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() && isFileStream(file) {
+		stream := build.NewWriter()
+		printStreamCode(stream)
+		err = writerFile(stream, filepath.Join(param.GetOutDir(), "hbuf_stream.ts"))
+		if err != nil {
+			return err
+		}
+	}
+	if isData {
 		err := writerFile(dst.data, filepath.Join(dir, name+".data.ts"))
 		if err != nil {
 			return err
@@ -96,27 +118,27 @@ func Build(file *ast.File, fSet *token.FileSet, param *build.Param) error {
 			return err
 		}
 	}
-	if 0 < dst.server.GetCode().Len() {
+	if param.Feature(build.FeatureServer) && 0 < dst.server.GetCode().Len() {
 		err = writerFile(dst.server, filepath.Join(dir, name+".server.ts"))
 		if err != nil {
 			return err
 		}
 	}
 	printLanguge(dst.ui.GetLangs(), dst.lang)
-	if 0 < dst.lang.GetCode().Len() {
+	if param.Feature(build.FeatureUi) && 0 < dst.lang.GetCode().Len() {
 		err = writerFile(dst.lang, filepath.Join(dir, name+".lang.ts"))
 		if err != nil {
 			return err
 		}
 	}
 
-	if 0 < dst.ui.GetCode().Len() {
+	if param.Feature(build.FeatureUi) && 0 < dst.ui.GetCode().Len() {
 		err = writerFile(dst.ui, filepath.Join(dir, name+".ui.tsx"))
 		if err != nil {
 			return err
 		}
 	}
-	if 0 < dst.verify.GetCode().Len() {
+	if param.Feature(build.FeatureVerify) && 0 < dst.verify.GetCode().Len() {
 		err = writerFile(dst.verify, filepath.Join(dir, name+".verify.ts"))
 		if err != nil {
 			return err
@@ -139,7 +161,7 @@ func (b *Builder) GetDataType(file *ast.File, name string) *ast.Object {
 		}
 	}
 	for _, spec := range file.Imports {
-		if f, ok := b.pkg.Files[spec.Path.Value]; ok {
+		if f, ok := b.pkg.Files[spec.File]; ok {
 			if obj := f.Scope.Lookup(name); nil != obj {
 				switch obj.Decl.(type) {
 				case *ast.TypeSpec:
@@ -205,10 +227,12 @@ func (b *Builder) Node(dst *DartWriter, fset *token.FileSet, node interface{}) e
 	for _, s := range file.Specs {
 		switch s.(type) {
 		case *ast.ImportSpec:
+		case *ast.ConstSpec:
+			b.printConstCode(dst.enum, s.(*ast.ConstSpec))
 		case *ast.TypeSpec:
 			err := b.printTypeSpec(dst, (s.(*ast.TypeSpec)).Type)
 			if err != nil {
-				return err
+				return build.ErrorToFileError(err, fset)
 			}
 		}
 	}
@@ -225,7 +249,7 @@ func (b *Builder) printTypeSpec(dst *DartWriter, expr ast.Expr) error {
 			return err
 		}
 	case *ast.ServerType:
-		b.printServerCode(dst.server, expr.(*ast.ServerType))
+		return b.printServerCode(dst.server, expr.(*ast.ServerType))
 
 	case *ast.EnumType:
 		b.printEnumCode(dst.enum, expr.(*ast.EnumType))
@@ -251,7 +275,7 @@ func (b *Builder) printType(dst *build.Writer, expr ast.Expr, notEmpty bool, isR
 			if isRecordKey {
 				if build.Decimal == build.BaseType((expr.(*ast.Ident).Name)) {
 					dst.Code("string")
-				} else if build.Int64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Uint64 == build.BaseType((expr.(*ast.Ident).Name)) {
+				} else if build.Int64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Uint64 == build.BaseType((expr.(*ast.Ident).Name)) || build.Duration == build.BaseType((expr.(*ast.Ident).Name)) {
 					dst.Code("string")
 				} else if build.Date == build.BaseType((expr.(*ast.Ident).Name)) {
 					dst.Code("number")
@@ -304,7 +328,7 @@ func (b *Builder) getPackage(dst *build.Writer, expr ast.Expr, s string) string
 		return ""
 	}
 
-	_, name := filepath.Split(file.(*ast.File).Path)
+	name := build.OutName(file.(*ast.File))
 	name = name[:len(name)-len(".hbuf")]
 	if 0 < len(s) {
 		name = name + "." + s
@@ -319,10 +343,19 @@ func (b *Builder) getPackage(dst *build.Writer, expr ast.Expr, s string) string
 		}
 	}
 
-	p := dst.Import("./"+name, "* as $"+strconv.Itoa(len(dst.GetImports())))
+	p := dst.Import(importPath(dst.File, name), "* as $"+strconv.Itoa(len(dst.GetImports())))
 	return p[5:]
 }
 
+// importPath 返回 file 生成的代码引入输出目录中 to 模块的路径，同一目录下的模块以 ./ 开头
+func importPath(file *ast.File, to string) string {
+	p := build.ImportPath(file, to)
+	if strings.HasPrefix(p, "../") {
+		return p
+	}
+	return "./" + p
+}
+
 func (b *Builder) printDefault(dst *build.Writer, expr ast.Expr, notEmpty bool) string {
 	switch expr.(type) {
 	case *ast.Ident:
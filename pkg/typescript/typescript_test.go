@@ -0,0 +1,35 @@
+package ts
+
+import (
+	"hbuf/pkg/build/buildtest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestImportPath 子目录中的文件生成到相同的子目录，引入时使用相对路径，公共代码只生成在输出目录下
+func TestImportPath(t *testing.T) {
+	files := map[string]string{
+		"common/page.hbuf": "" +
+			"data Page = 1 {\n" +
+			"    int32 page = 0\n" +
+			"}\n",
+		"a.hbuf": "" +
+			"import \"common/page.hbuf\"\n" +
+			"\n" +
+			"data Result = 2 {\n" +
+			"    Page page = 0\n" +
+			"}\n",
+	}
+	out := buildtest.Generate(t, "ts", Build, "a.hbuf", files)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "a.data.ts"),
+		" from \"./common/page.data\"\n",
+		"import * as c from \"./hbuf_encoder\"\n",
+	)
+	buildtest.Contains(t, buildtest.ReadFile(t, out, "common", "page.data.ts"),
+		"import * as c from \"../hbuf_encoder\"\n",
+	)
+	if _, err := os.Stat(filepath.Join(out, "common", "hbuf_encoder.ts")); err == nil {
+		t.Errorf("hbuf_encoder.ts generated in sub directory")
+	}
+}
@@ -75,83 +75,76 @@ func (b *Builder) getUI(tags []*ast.Tag) *ui {
 	if nil != val.KV {
 		for _, item := range val.KV {
 			if "onlyRead" == item.Name.Name {
-				form.onlyRead = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.onlyRead, _ = build.TagBool(item.Values[0])
 			} else if "form" == item.Name.Name {
-				form.form = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.form = build.TagString(item.Values[0])
 			} else if "table" == item.Name.Name {
-				form.table = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.table = build.TagString(item.Values[0])
 			} else if "digit" == item.Name.Name {
-				atoi, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.digit = atoi
 			} else if "index" == item.Name.Name {
-				atoi, err := strconv.Atoi(item.Values[0].Value[1 : len(item.Values[0].Value)-1])
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.index = &atoi
 			} else if "format" == item.Name.Name {
-				form.format = item.Values[0].Value[1 : len(item.Values[0].Value)-1]
+				form.format = build.TagString(item.Values[0])
 			} else if "width" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.width = atoi
 			} else if "height" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.height = atoi
 			} else if "maxLine" == item.Name.Name {
-				atoi, err := strconv.ParseInt(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10, 64)
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
 					println(err.Error())
 					return nil
 				}
-				form.maxLine = int(atoi)
+				form.maxLine = atoi
 			} else if "maxCount" == item.Name.Name {
-				atoi, err := strconv.ParseInt(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10, 64)
+				atoi, err := build.TagInt(item.Values[0])
 				if err != nil {
 					println(err.Error())
 					return nil
 				}
-				form.maxCount = int(atoi)
+				form.maxCount = atoi
 			} else if "clip" == item.Name.Name {
-				form.clip = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.clip, _ = build.TagBool(item.Values[0])
 			} else if "toNull" == item.Name.Name {
-				form.toNull = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.toNull, _ = build.TagBool(item.Values[0])
 			} else if "unlink" == item.Name.Name {
-				form.unlink = "true" == item.Values[0].Value[1:len(item.Values[0].Value)-1]
+				form.unlink, _ = build.TagBool(item.Values[0])
 			} else if "extensions" == item.Name.Name {
 				for _, value := range item.Values {
-					form.extensions = append(form.extensions, value.Value[1:len(value.Value)-1])
+					form.extensions = append(form.extensions, build.TagString(value))
 				}
 			} else if "min" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.min = &atoi
 			} else if "max" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.max = &atoi
 			} else if "step" == item.Name.Name {
-				atoi, err := strconv.ParseFloat(item.Values[0].Value[1:len(item.Values[0].Value)-1], 10)
+				atoi, err := build.TagFloat(item.Values[0])
 				if err != nil {
-					//TODO 添加错误处理
 					return nil
 				}
 				form.step = &atoi
@@ -2,8 +2,30 @@ package parser
 
 import (
 	"encoding/json"
+	"github.com/shopspring/decimal"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
 )
 
+type Base struct {
+	Id hbuf.Int64 `json:"id,omitempty"` //
+}
+
+func (g *Base) ToData() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func (g *Base) FormData(data []byte) error {
+	return json.Unmarshal(data, g)
+}
+
+func (g *Base) GetId() hbuf.Int64 {
+	return g.Id
+}
+
+func (g *Base) SetId(val hbuf.Int64) {
+	g.Id = val
+}
+
 type Test struct {
 	Id int32 `json:"id,omitempty"` //
 }
@@ -23,3 +45,207 @@ func (g *Test) GetId() int32 {
 func (g *Test) SetId(val int32) {
 	g.Id = val
 }
+
+type Info struct {
+	Base
+	Min    int8              `json:"min,omitempty"`    //
+	Max    hbuf.Uint64       `json:"max,omitempty"`    //
+	Ok     bool              `json:"ok,omitempty"`     //
+	Rate   float32           `json:"rate,omitempty"`   //
+	Score  float64           `json:"score,omitempty"`  //
+	Name   *string           `json:"name,omitempty"`   //
+	Time   hbuf.Time         `json:"time,omitempty"`   //
+	Money  *decimal.Decimal  `json:"money,omitempty"`  //
+	Status Status            `json:"status,omitempty"` //
+	Parent *Base             `json:"parent,omitempty"` //
+	Tags   []string          `json:"tags,omitempty"`   //
+	Items  []*Base           `json:"items,omitempty"`  //
+	States map[string]Status `json:"states,omitempty"` //
+	Bases  map[int32]Base    `json:"bases,omitempty"`  //
+	Groups [][]*Base         `json:"groups,omitempty"` //
+}
+
+func (g *Info) ToData() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func (g *Info) FormData(data []byte) error {
+	return json.Unmarshal(data, g)
+}
+
+func (g *Info) GetMin() int8 {
+	return g.Min
+}
+
+func (g *Info) SetMin(val int8) {
+	g.Min = val
+}
+
+func (g *Info) GetMax() hbuf.Uint64 {
+	return g.Max
+}
+
+func (g *Info) SetMax(val hbuf.Uint64) {
+	g.Max = val
+}
+
+func (g *Info) GetOk() bool {
+	return g.Ok
+}
+
+func (g *Info) SetOk(val bool) {
+	g.Ok = val
+}
+
+func (g *Info) GetRate() float32 {
+	return g.Rate
+}
+
+func (g *Info) SetRate(val float32) {
+	g.Rate = val
+}
+
+func (g *Info) GetScore() float64 {
+	return g.Score
+}
+
+func (g *Info) SetScore(val float64) {
+	g.Score = val
+}
+
+func (g *Info) GetName() string {
+	if nil == g.Name {
+		return ""
+	}
+	return *g.Name
+}
+
+func (g *Info) SetName(val string) {
+	g.Name = &val
+}
+
+func (g *Info) GetTime() hbuf.Time {
+	return g.Time
+}
+
+func (g *Info) SetTime(val hbuf.Time) {
+	g.Time = val
+}
+
+func (g *Info) GetMoney() decimal.Decimal {
+	if nil == g.Money {
+		return decimal.Zero
+	}
+	return *g.Money
+}
+
+func (g *Info) SetMoney(val decimal.Decimal) {
+	g.Money = &val
+}
+
+func (g *Info) GetStatus() Status {
+	return g.Status
+}
+
+func (g *Info) SetStatus(val Status) {
+	g.Status = val
+}
+
+func (g *Info) GetParent() Base {
+	if nil == g.Parent {
+		return Base{}
+	}
+	return *g.Parent
+}
+
+func (g *Info) SetParent(val Base) {
+	g.Parent = &val
+}
+
+func (g *Info) GetTags() []string {
+	return g.Tags
+}
+
+func (g *Info) SetTags(val []string) {
+	g.Tags = val
+}
+
+func (g *Info) GetItems() []*Base {
+	return g.Items
+}
+
+func (g *Info) SetItems(val []*Base) {
+	g.Items = val
+}
+
+func (g *Info) GetStates() map[string]Status {
+	return g.States
+}
+
+func (g *Info) SetStates(val map[string]Status) {
+	g.States = val
+}
+
+func (g *Info) GetBases() map[int32]Base {
+	return g.Bases
+}
+
+func (g *Info) SetBases(val map[int32]Base) {
+	g.Bases = val
+}
+
+func (g *Info) GetGroups() [][]*Base {
+	return g.Groups
+}
+
+func (g *Info) SetGroups(val [][]*Base) {
+	g.Groups = val
+}
+
+// Option 有默认值的字段
+type Option struct {
+	Enable bool   `json:"enable"` //
+	Size   int32  `json:"size"`   //
+	Limit  *int32 `json:"limit"`  //
+}
+
+func (g *Option) ToData() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func (g *Option) FormData(data []byte) error {
+	return json.Unmarshal(data, g)
+}
+
+func (g *Option) GetEnable() bool {
+	if nil == g {
+		return true
+	}
+	return g.Enable
+}
+
+func (g *Option) SetEnable(val bool) {
+	g.Enable = val
+}
+
+func (g *Option) GetSize() int32 {
+	if nil == g {
+		return -1
+	}
+	return g.Size
+}
+
+func (g *Option) SetSize(val int32) {
+	g.Size = val
+}
+
+func (g *Option) GetLimit() int32 {
+	if nil == g || nil == g.Limit {
+		return 10
+	}
+	return *g.Limit
+}
+
+func (g *Option) SetLimit(val int32) {
+	g.Limit = &val
+}
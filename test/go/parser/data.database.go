@@ -16,7 +16,7 @@ func (val *Test) DbName() string {
 }
 
 func (g Test) DbGet(ctx context.Context) (*Test, error) {
-	tableName := db.GET(ctx).Table("test")
+	tableName := hbufDbTable(ctx, "test")
 	s := db.NewSql()
 	s.T("SELECT id FROM ").T(tableName).T(" WHERE delete_time IS  NULL")
 	s.T("AND id = ").V(&g.Id)
@@ -1,189 +1,128 @@
 package parser
 
 import (
+	"bytes"
+	"github.com/shopspring/decimal"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
 	"io"
 )
 
-func (t *GetInfoReq) Encoder(w io.Writer) error {
-	var err error
-	err = hbuf.WriterInt64(w, 1, int64(t.UserId))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBytes(w, 0, []byte(t.Name))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 2, int64(t.Age))
-	if err != nil {
-		return err
-	}
-	return nil
+func (g *Base) Encoder(w io.Writer) error {
+	e := &hbufEncoder{w: w}
+	hbufWriteInt[hbuf.Int64](e, 0, g.Id)
+	return e.err
 }
 
-func (t *GetInfoReq) Decoder(r io.Reader) error {
-	return nil
+func (g *Base) Decoder(r io.Reader) error {
+	return hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {
+		switch id {
+		case 0:
+			g.Id, err = hbufReadInt[hbuf.Int64](typ, val)
+		}
+		return
+	})
 }
 
-func (t *InfoReq) Encoder(w io.Writer) error {
-	var err error
-	err = hbuf.WriterInt64(w, 1, int64(t.UserId))
-	if err != nil {
-		return err
-	}
-	return nil
+func (g *Test) Encoder(w io.Writer) error {
+	e := &hbufEncoder{w: w}
+	hbufWriteInt[int32](e, 0, g.Id)
+	return e.err
 }
 
-func (t *InfoReq) Decoder(r io.Reader) error {
-	return nil
+func (g *Test) Decoder(r io.Reader) error {
+	return hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {
+		switch id {
+		case 0:
+			g.Id, err = hbufReadInt[int32](typ, val)
+		}
+		return
+	})
 }
 
-func (t *InfoSet) Encoder(w io.Writer) error {
-	var err error
-	err = hbuf.WriterInt64(w, 1, int64(t.UserId))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBytes(w, 0, []byte(t.Name))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 2, int64(t.Age))
-	if err != nil {
-		return err
-	}
-	return nil
+func (g *Info) Encoder(w io.Writer) error {
+	e := &hbufEncoder{w: w}
+	hbufWriteExtend(e, 1, g.Base.Encoder)
+	hbufWriteInt[int8](e, 0, g.Min)
+	hbufWriteUint[hbuf.Uint64](e, 1, g.Max)
+	hbufWriteBool(e, 2, g.Ok)
+	hbufWriteFloat(e, 3, g.Rate)
+	hbufWriteDouble(e, 4, g.Score)
+	hbufWriteNullable(hbufWriteString)(e, 5, g.Name)
+	hbufWriteTime(e, 6, g.Time)
+	hbufWriteNullable(hbufWriteText[decimal.Decimal])(e, 7, g.Money)
+	hbufWriteInt[Status](e, 8, g.Status)
+	hbufWriteNullable(hbufWriteData[Base])(e, 9, g.Parent)
+	hbufWriteList(hbufWriteString, false)(e, 10, g.Tags)
+	hbufWriteList(hbufWriteNullable(hbufWriteData[Base]), true)(e, 11, g.Items)
+	hbufWriteMap(hbufWriteString, hbufWriteInt[Status], true)(e, 12, g.States)
+	hbufWriteMap(hbufWriteInt[int32], hbufWriteData[Base], false)(e, 13, g.Bases)
+	hbufWriteList(hbufWriteList(hbufWriteNullable(hbufWriteData[Base]), true), false)(e, 14, g.Groups)
+	return e.err
 }
 
-func (t *InfoSet) Decoder(r io.Reader) error {
-	return nil
+func (g *Info) Decoder(r io.Reader) error {
+	return hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {
+		if hbufTypeExtend == typ {
+			switch id {
+			case 1:
+				err = g.Base.Decoder(bytes.NewReader(val))
+			}
+			return
+		}
+		switch id {
+		case 0:
+			g.Min, err = hbufReadInt[int8](typ, val)
+		case 1:
+			g.Max, err = hbufReadUint[hbuf.Uint64](typ, val)
+		case 2:
+			g.Ok, err = hbufReadBool(typ, val)
+		case 3:
+			g.Rate, err = hbufReadFloat(typ, val)
+		case 4:
+			g.Score, err = hbufReadDouble(typ, val)
+		case 5:
+			g.Name, err = hbufReadNullable(hbufReadString)(typ, val)
+		case 6:
+			g.Time, err = hbufReadTime(typ, val)
+		case 7:
+			g.Money, err = hbufReadNullable(hbufReadText[decimal.Decimal])(typ, val)
+		case 8:
+			g.Status, err = hbufReadInt[Status](typ, val)
+		case 9:
+			g.Parent, err = hbufReadNullable(hbufReadData[Base])(typ, val)
+		case 10:
+			g.Tags, err = hbufReadList(hbufReadString)(typ, val)
+		case 11:
+			g.Items, err = hbufReadList(hbufReadNullable(hbufReadData[Base]))(typ, val)
+		case 12:
+			g.States, err = hbufReadMap(hbufReadString, hbufReadInt[Status])(typ, val)
+		case 13:
+			g.Bases, err = hbufReadMap(hbufReadInt[int32], hbufReadData[Base])(typ, val)
+		case 14:
+			g.Groups, err = hbufReadList(hbufReadList(hbufReadNullable(hbufReadData[Base])))(typ, val)
+		}
+		return
+	})
 }
 
-func (t *GetInfoResp) Encoder(w io.Writer) error {
-	var err error
-	err = hbuf.WriterInt64(w, 0, int64(t.V1))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 50, int64(t.B1))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 1, int64(t.V2))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 51, int64(t.B2))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 2, int64(t.V3))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 52, int64(t.B3))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 3, int64(t.V4))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterInt64(w, 53, int64(t.B4))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 4, uint64(t.V5))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 54, uint64(t.B5))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 5, uint64(t.V6))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 55, uint64(t.B6))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 6, uint64(t.V7))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 56, uint64(t.B7))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 7, uint64(t.V8))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterUint64(w, 57, uint64(t.B8))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBool(w, 8, t.V9)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBool(w, 58, t.B9)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterFloat(w, 9, t.V10)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterFloat(w, 59, t.B10)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterDouble(w, 10, t.V11)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterDouble(w, 60, t.B11)
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBytes(w, 11, []byte(t.V12))
-	if err != nil {
-		return err
-	}
-	err = hbuf.WriterBytes(w, 61, []byte(t.B12))
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	if err != nil {
-		return err
-	}
-	return nil
+func (g *Option) Encoder(w io.Writer) error {
+	e := &hbufEncoder{w: w}
+	hbufWriteBool(e, 0, g.Enable)
+	hbufWriteInt[int32](e, 1, g.Size)
+	hbufWriteNullable(hbufWriteInt[int32])(e, 2, g.Limit)
+	return e.err
 }
 
-func (t *GetInfoResp) Decoder(r io.Reader) error {
-	return nil
+func (g *Option) Decoder(r io.Reader) error {
+	return hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {
+		switch id {
+		case 0:
+			g.Enable, err = hbufReadBool(typ, val)
+		case 1:
+			g.Size, err = hbufReadInt[int32](typ, val)
+		case 2:
+			g.Limit, err = hbufReadNullable(hbufReadInt[int32])(typ, val)
+		}
+		return
+	})
 }
@@ -1,13 +1,10 @@
 package parser
 
-// Status 12
 type Status int
 
-// StatusEnable 启用
 const StatusEnable Status = 0
 
-// StatusDisabled 禁用
-const StatusDisabled Status = 1
+const StatusDisable Status = 1
 
 func (e Status) Pointer() *Status {
 	pointer := e
@@ -15,8 +12,8 @@ func (e Status) Pointer() *Status {
 }
 
 var statusMap = map[Status]string{
-	StatusEnable:   "Enable",
-	StatusDisabled: "Disabled",
+	StatusEnable:  "Enable",
+	StatusDisable: "Disable",
 }
 
 func (e Status) ToName() string {
@@ -24,8 +21,8 @@ func (e Status) ToName() string {
 }
 
 var statusValues = map[string]Status{
-	"Enable":   StatusEnable,
-	"Disabled": StatusDisabled,
+	"Enable":  StatusEnable,
+	"Disable": StatusDisable,
 }
 
 func StatusValues() map[string]Status {
@@ -1,125 +1,199 @@
 package parser
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"github.com/wskfjtheqian/hbuf_golang/pkg/erro"
 	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
 	"github.com/wskfjtheqian/hbuf_golang/pkg/manage"
 	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
 )
 
-// UserServer 12
-type UserServer interface {
+type InfoServer interface {
 	Init(ctx context.Context)
 
-	//GetInfo 12
-	GetInfo(ctx context.Context, req *GetInfoReq) (*GetInfoResp, error)
+	GetInfo(ctx context.Context, req *Base) (*Info, error)
+
+	SetInfo(ctx context.Context, req *Info) error
 }
 
-type UserServerClient struct {
+type InfoServerClient struct {
 	client rpc.Client
 }
 
-func (p *UserServerClient) Init(ctx context.Context) {
+func (p *InfoServerClient) Init(ctx context.Context) {
 }
 
-func (p *UserServerClient) GetName() string {
-	return "user_server"
+func (p *InfoServerClient) GetName() string {
+	return "info_server"
 }
 
-func (p *UserServerClient) GetId() uint32 {
+func (p *InfoServerClient) GetId() uint32 {
 	return 1
 }
 
-func NewUserServerClient(client rpc.Client) *UserServerClient {
-	return &UserServerClient{
+func NewInfoServerClient(client rpc.Client) *InfoServerClient {
+	return &InfoServerClient{
 		client: client,
 	}
 }
 
-// GetInfo 12
-func (r *UserServerClient) GetInfo(ctx context.Context, req *GetInfoReq) (*GetInfoResp, error) {
-	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/get_info", &rpc.ClientInvoke{
+func (r *InfoServerClient) GetInfo(ctx context.Context, req *Base) (*Info, error) {
+	ret, err := r.client.Invoke(ctx, req, "info_server/info_server/get_info", &rpc.ClientInvoke{
 		ToData: func(buf []byte) (hbuf.Data, error) {
-			var req GetInfoResp
+			var req Info
 			return &req, json.Unmarshal(buf, &req)
 		},
 		FormData: func(data hbuf.Data) ([]byte, error) {
 			return json.Marshal(&data)
 		},
-	}, 1, &rpc.ClientInvoke{})
+	}, 4294967296, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret Info
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*Base).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
-	return ret.(*GetInfoResp), nil
+	return ret.(*Info), nil
 }
 
-type UserServerRouter struct {
-	server UserServer
+func (r *InfoServerClient) SetInfo(ctx context.Context, req *Info) error {
+	_, err := r.client.Invoke(ctx, req, "info_server/info_server/set_info", &rpc.ClientInvoke{
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 4294967297, &rpc.ClientInvoke{
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*Info).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type InfoServerRouter struct {
+	server InfoServer
 	names  map[string]*rpc.ServerInvoke
+	ids    map[int64]*rpc.ServerInvoke
 }
 
-func (p *UserServerRouter) GetName() string {
-	return "user_server"
+func (p *InfoServerRouter) GetName() string {
+	return "info_server"
 }
 
-func (p *UserServerRouter) GetId() uint32 {
+func (p *InfoServerRouter) GetId() uint32 {
 	return 1
 }
 
-func (p *UserServerRouter) GetServer() rpc.Init {
+func (p *InfoServerRouter) GetServer() rpc.Init {
 	return p.server
 }
 
-func (p *UserServerRouter) GetInvoke() map[string]*rpc.ServerInvoke {
+func (p *InfoServerRouter) GetInvoke() map[string]*rpc.ServerInvoke {
 	return p.names
 }
 
-func NewUserServerRouter(server UserServer) *UserServerRouter {
-	return &UserServerRouter{
+// GetInvokeIds 按 服务Id<<32 | 方法Id 查找调用，供二进制传输使用
+func (p *InfoServerRouter) GetInvokeIds() map[int64]*rpc.ServerInvoke {
+	return p.ids
+}
+
+func NewInfoServerRouter(server InfoServer) *InfoServerRouter {
+	names := map[string]*rpc.ServerInvoke{
+		"info_server/get_info": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req Base
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.GetInfo(ctx, data.(*Base))
+			},
+		},
+		"info_server/set_info": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req Info
+				return &req, json.Unmarshal(buf, &req)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return nil, server.SetInfo(ctx, data.(*Info))
+			},
+		},
+	}
+	return &InfoServerRouter{
 		server: server,
-		names: map[string]*rpc.ServerInvoke{
-			"user_server/get_info": {
+		names:  names,
+		ids: map[int64]*rpc.ServerInvoke{
+			4294967296: {
 				ToData: func(buf []byte) (hbuf.Data, error) {
-					var req GetInfoReq
-					return &req, json.Unmarshal(buf, &req)
+					var ret Base
+					return &ret, ret.Decoder(bytes.NewReader(buf))
 				},
 				FormData: func(data hbuf.Data) ([]byte, error) {
-					return json.Marshal(&data)
-				},
-				SetInfo: func(ctx context.Context) {
+					buf := &bytes.Buffer{}
+					err := data.(*Info).Encoder(buf)
+					return buf.Bytes(), err
 				},
-				Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
-					return server.GetInfo(ctx, data.(*GetInfoReq))
+				SetInfo: names["info_server/get_info"].SetInfo,
+				Invoke:  names["info_server/get_info"].Invoke,
+			},
+			4294967297: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret Info
+					return &ret, ret.Decoder(bytes.NewReader(buf))
 				},
+				SetInfo: names["info_server/set_info"].SetInfo,
+				Invoke:  names["info_server/set_info"].Invoke,
 			},
 		},
 	}
 }
 
-// UserServer 12
-type DefaultUserServer struct {
+type DefaultInfoServer struct {
 }
 
-func (s *DefaultUserServer) Init(ctx context.Context) {
+func (s *DefaultInfoServer) Init(ctx context.Context) {
 }
 
-// GetInfo 12
-func (s *DefaultUserServer) GetInfo(ctx context.Context, req *GetInfoReq) (*GetInfoResp, error) {
-	return nil, erro.Wrap(errors.New("not find server user_server"))
+func (s *DefaultInfoServer) GetInfo(ctx context.Context, req *Base) (*Info, error) {
+	return nil, erro.NewError("not find server info_server")
 }
 
-var NotFoundUserServer = &DefaultUserServer{}
+func (s *DefaultInfoServer) SetInfo(ctx context.Context, req *Info) error {
+	return erro.NewError("not find server info_server")
+}
+
+var Default_InfoServer = &DefaultInfoServer{}
 
-func GetUserServer(ctx context.Context) UserServer {
-	router := manage.GET(ctx).Get(&UserServerRouter{})
+func GetInfoServer(ctx context.Context) InfoServer {
+	router := manage.GET(ctx).Get(&InfoServerRouter{})
 	if nil == router {
-		return NotFoundUserServer
+		return Default_InfoServer
 	}
-	if val, ok := router.(UserServer); ok {
+	if val, ok := router.(InfoServer); ok {
 		return val
 	}
-	return NotFoundUserServer
+	return Default_InfoServer
+}
+
+func GetInfoServerName() string {
+	return "info_server"
 }
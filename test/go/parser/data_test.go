@@ -0,0 +1,30 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestDefaultJson 有默认值的字段为零值或 null 时也要写入，Dart 和 TS 只在缺少字段时使用默认值
+func TestDefaultJson(t *testing.T) {
+	buf, err := json.Marshal(&Option{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if `{"enable":false,"size":0,"limit":null}` != string(buf) {
+		t.Fatalf("json: %s", buf)
+	}
+
+	var dst Option
+	if err = json.Unmarshal(buf, &dst); err != nil {
+		t.Fatal(err)
+	}
+	if dst.GetEnable() || 0 != dst.GetSize() || nil != dst.Limit {
+		t.Errorf("want zero values, got %+v", dst)
+	}
+
+	var empty *Option
+	if !empty.GetEnable() || -1 != empty.GetSize() || 10 != empty.GetLimit() {
+		t.Errorf("nil data must return defaults")
+	}
+}
@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+)
+
+func TestEncoder(t *testing.T) {
+	name := "hbuf"
+	money := decimal.RequireFromString("-12.50")
+	src := Info{
+		Base:   Base{Id: -300},
+		Min:    -1,
+		Max:    1 << 40,
+		Ok:     true,
+		Rate:   1.5,
+		Score:  -2.25,
+		Name:   &name,
+		Time:   hbuf.Time(time.UnixMilli(1700000000123)),
+		Money:  &money,
+		Status: Status(1),
+		Parent: &Base{Id: 7},
+		Tags:   []string{"a", "", "c"},
+		Items:  []*Base{nil, {Id: 1}, nil, {Id: 0}},
+		States: map[string]Status{"a": Status(1), "b": Status(0)},
+		Bases:  map[int32]Base{-1: {Id: 2}},
+		Groups: [][]*Base{{{Id: 3}}, {}},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := src.Encoder(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dst Info
+	if err := dst.Decoder(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !money.Equal(*dst.Money) {
+		t.Errorf("money: want %s, got %s", money, dst.Money)
+	}
+	src.Money, dst.Money = nil, nil
+	if !time.Time(src.Time).Equal(time.Time(dst.Time)) {
+		t.Errorf("time: want %v, got %v", time.Time(src.Time), time.Time(dst.Time))
+	}
+	src.Time, dst.Time = hbuf.Time{}, hbuf.Time{}
+	if !reflect.DeepEqual(src, dst) {
+		t.Errorf("want %+v, got %+v", src, dst)
+	}
+}
+
+func TestEncoderNull(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := (&Info{}).Encoder(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dst Info
+	if err := dst.Decoder(buf); err != nil {
+		t.Fatal(err)
+	}
+	if nil != dst.Name || nil != dst.Parent || nil != dst.Items || nil != dst.States {
+		t.Errorf("nullable fields must stay nil: %+v", dst)
+	}
+	if nil == dst.Tags || nil == dst.Bases {
+		t.Errorf("non-null fields must be present: %+v", dst)
+	}
+}
+
+func TestEncoderTrailingNull(t *testing.T) {
+	src := Info{
+		Items:  []*Base{{Id: 1}, nil, nil},
+		Groups: [][]*Base{{{Id: 2}, nil}, nil},
+	}
+	buf := &bytes.Buffer{}
+	if err := src.Encoder(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var dst Info
+	if err := dst.Decoder(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(src.Items, dst.Items) {
+		t.Errorf("items: want %v, got %v", src.Items, dst.Items)
+	}
+	if !reflect.DeepEqual(src.Groups, dst.Groups) {
+		t.Errorf("groups: want %v, got %v", src.Groups, dst.Groups)
+	}
+}
+
+func TestEncoderMapOrder(t *testing.T) {
+	src := Info{
+		States: map[string]Status{"a": 1, "b": 0, "c": 1, "d": 0, "e": 1},
+		Bases:  map[int32]Base{-1: {Id: 2}, 0: {Id: 3}, 1: {Id: 4}, 300: {Id: 5}},
+	}
+	want := &bytes.Buffer{}
+	if err := src.Encoder(want); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		buf := &bytes.Buffer{}
+		if err := src.Encoder(buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(want.Bytes(), buf.Bytes()) {
+			t.Fatalf("encoding is not deterministic: %x != %x", want.Bytes(), buf.Bytes())
+		}
+	}
+}
+
+func TestEncoderListTooLong(t *testing.T) {
+	e := &hbufEncoder{w: io.Discard}
+	hbufWriteList(hbufWriteBool, false)(e, 0, make([]bool, hbufMaxList+1))
+	if errHbufList != e.err {
+		t.Errorf("want %v, got %v", errHbufList, e.err)
+	}
+	if 0 != e.size {
+		t.Errorf("too long list must not be written, got %d bytes", e.size)
+	}
+}
+
+var update = flag.Bool("update", false, "update testdata/encoder.json")
+
+// golden 一条二进制编码的固定样例，Dart 和 TypeScript 由 json 构造数据后编码，结果必须与 hex 相同
+type golden struct {
+	Name string          `json:"name"`
+	Json json.RawMessage `json:"json"`
+	Hex  string          `json:"hex"`
+}
+
+// TestEncoderGolden 编码结果与 testdata/encoder.json 中逐字节相同，修改编码后用 -update 重新生成
+func TestEncoderGolden(t *testing.T) {
+	name := "hbuf"
+	money := decimal.RequireFromString("-12.50")
+	tests := []struct {
+		name string
+		data interface {
+			Encoder(w io.Writer) error
+		}
+	}{
+		{"base", &Base{Id: -300}},
+		{"info", &Info{
+			Base:   Base{Id: 1 << 20},
+			Min:    -1,
+			Max:    1 << 40,
+			Ok:     true,
+			Rate:   1.5,
+			Score:  -2.25,
+			Name:   &name,
+			Time:   hbuf.Time(time.UnixMilli(1700000000123)),
+			Money:  &money,
+			Status: Status(1),
+			Parent: &Base{Id: 7},
+			Tags:   []string{"a", "", "c"},
+			Items:  []*Base{nil, {Id: 1}, nil, {Id: 0}},
+			States: map[string]Status{"b": Status(0), "a": Status(1), "ab": Status(1)},
+			Bases:  map[int32]Base{300: {Id: 5}, -1: {Id: 2}, 0: {Id: 3}},
+			Groups: [][]*Base{{{Id: 3}}, {}},
+		}},
+		{"trailing", &Info{Items: []*Base{{Id: 1}, nil, nil}, Groups: [][]*Base{{{Id: 2}, nil}, nil}}},
+		{"option", &Option{Enable: true, Size: -1}},
+	}
+
+	var list []golden
+	for _, test := range tests {
+		buf := &bytes.Buffer{}
+		if err := test.data.Encoder(buf); err != nil {
+			t.Fatal(err)
+		}
+		text, err := json.Marshal(test.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, golden{Name: test.name, Json: text, Hex: hex.EncodeToString(buf.Bytes())})
+	}
+
+	path := filepath.Join("testdata", "encoder.json")
+	if *update {
+		buf, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(path, append(buf, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []golden
+	if err = json.Unmarshal(buf, &want); err != nil {
+		t.Fatal(err)
+	}
+	if len(want) != len(list) {
+		t.Fatalf("want %d cases, got %d", len(want), len(list))
+	}
+	for i, got := range list {
+		if want[i].Name != got.Name || want[i].Hex != got.Hex {
+			t.Errorf("%s: want %s, got %s", want[i].Name, want[i].Hex, got.Hex)
+		}
+	}
+	// 字段头部：第 7-6 位 ID 长度 - 1，第 5-3 位类型，第 2-0 位值长度 - 1，整数按小端写入最少的字节
+	if "0100d4fe" != want[0].Hex {
+		t.Errorf("base: want 0100d4fe, got %s", want[0].Hex)
+	}
+}
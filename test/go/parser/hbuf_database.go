@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/db"
+)
+
+// hbufDbTable 返回实际的表名，数据库连接提供 Table 方法（如添加表名前缀）时由它决定
+func hbufDbTable(ctx context.Context, name string) string {
+	if t, ok := db.GET(ctx).(interface{ Table(name string) string }); ok {
+		return t.Table(name)
+	}
+	return name
+}
+
+// hbufDbJson 读写 json 类型的字段，数据库的 NULL 对应 nil，驱动返回字符串或 []byte 时都原样保存
+type hbufDbJson struct {
+	data any
+}
+
+func (j hbufDbJson) Scan(value any) error {
+	var raw json.RawMessage
+	switch v := value.(type) {
+	case nil:
+	case []byte:
+		raw = append(json.RawMessage{}, v...)
+	case string:
+		raw = json.RawMessage(v)
+	default:
+		return fmt.Errorf("hbuf: cannot scan %T into json", value)
+	}
+	switch d := j.data.(type) {
+	case *json.RawMessage:
+		*d = raw
+	case **json.RawMessage:
+		*d = nil
+		if nil != raw {
+			*d = &raw
+		}
+	}
+	return nil
+}
+
+func (j hbufDbJson) Value() (driver.Value, error) {
+	var raw json.RawMessage
+	switch d := j.data.(type) {
+	case *json.RawMessage:
+		raw = *d
+	case **json.RawMessage:
+		if nil != *d {
+			raw = **d
+		}
+	}
+	if 0 == len(raw) {
+		return nil, nil
+	}
+	return string(raw), nil
+}
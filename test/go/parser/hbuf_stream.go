@@ -0,0 +1,678 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/erro"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// stream 方法的请求和结果都是一串帧，帧头为 1 字节类型和 4 字节小端长度，之后是帧的内容
+const (
+	hbufFrameEnd   byte = 0 // 流结束
+	hbufFrameData  byte = 1 // 一条消息，或 stream 中的一段字节
+	hbufFrameNull  byte = 2 // stream<T> 中为 nil 的消息
+	hbufFrameError byte = 3 // 服务返回的错误，内容为 rpc.Result 的 JSON
+)
+
+// hbufStreamChunk 为 stream 每帧最多发送的字节数，hbufMaxFrame 为一帧最大的长度
+const (
+	hbufStreamChunk = 32 << 10
+	hbufMaxFrame    = 64 << 20
+)
+
+var errHbufFrame = errors.New("hbuf: frame too large")
+
+func hbufWriteFrame(w io.Writer, kind byte, buf []byte) error {
+	var head [5]byte
+	head[0] = kind
+	binary.LittleEndian.PutUint32(head[1:], uint32(len(buf)))
+	_, err := w.Write(head[:])
+	if nil == err && 0 < len(buf) {
+		_, err = w.Write(buf)
+	}
+	return err
+}
+
+func hbufReadFrame(r io.Reader, head *[5]byte) (byte, uint32, error) {
+	_, err := io.ReadFull(r, head[:])
+	if io.EOF == err {
+		err = io.ErrUnexpectedEOF
+	}
+	return head[0], binary.LittleEndian.Uint32(head[1:]), err
+}
+
+func hbufReadPayload(r io.Reader, size uint32) ([]byte, error) {
+	if hbufMaxFrame < size {
+		return nil, errHbufFrame
+	}
+	buf := make([]byte, size)
+	_, err := io.ReadFull(r, buf)
+	if io.EOF == err {
+		err = io.ErrUnexpectedEOF
+	}
+	return buf, err
+}
+
+// hbufReadEnd 读出数据帧以外的帧，结束帧返回 io.EOF，错误帧返回其中的 rpc.Result
+func hbufReadEnd(r io.Reader, kind byte, size uint32) error {
+	switch kind {
+	case hbufFrameEnd:
+		return io.EOF
+	case hbufFrameError:
+		buf, err := hbufReadPayload(r, size)
+		if err != nil {
+			return err
+		}
+		var res rpc.Result
+		err = json.Unmarshal(buf, &res)
+		if err != nil {
+			return err
+		}
+		return erro.Wrap(&res)
+	}
+	return fmt.Errorf("hbuf: unexpected frame %d", kind)
+}
+
+// hbufEnd 结束路由中的调用，err 为 nil 时发送结束帧，否则发送错误帧，不是 rpc.Result 的错误只返回状态码 500
+func hbufEnd(w io.Writer, err error) error {
+	if nil == err {
+		return hbufWriteFrame(w, hbufFrameEnd, nil)
+	}
+	var res *rpc.Result
+	if !errors.As(err, &res) {
+		erro.PrintStack(err)
+		res = &rpc.Result{Code: http.StatusInternalServerError, Msg: http.StatusText(http.StatusInternalServerError)}
+	}
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return hbufWriteFrame(w, hbufFrameError, buf)
+}
+
+// hbufStreamWriter 把写入的内容分段作为数据帧发送，Close 后不能再写入
+type hbufStreamWriter struct {
+	w      io.Writer
+	closed bool
+}
+
+func (s *hbufStreamWriter) Write(p []byte) (int, error) {
+	if s.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n := 0
+	for n < len(p) {
+		size := len(p) - n
+		if hbufStreamChunk < size {
+			size = hbufStreamChunk
+		}
+		err := hbufWriteFrame(s.w, hbufFrameData, p[n:n+size])
+		if err != nil {
+			return n, err
+		}
+		n += size
+	}
+	return n, nil
+}
+
+func (s *hbufStreamWriter) Close() error {
+	s.closed = true
+	return nil
+}
+
+// hbufSendStream 分段发送 r 中的全部内容，之后发送结束帧
+func hbufSendStream(w io.Writer, r io.Reader) error {
+	_, err := io.Copy(&hbufStreamWriter{w: w}, r)
+	if err != nil {
+		return err
+	}
+	return hbufWriteFrame(w, hbufFrameEnd, nil)
+}
+
+// hbufStreamReader 依次读出数据帧中的字节，读到结束帧时返回 io.EOF，读到错误帧时返回其中的错误，
+// 结束或出错后关闭 closer
+type hbufStreamReader struct {
+	r      io.Reader
+	closer io.Closer
+	head   [5]byte
+	size   uint32
+	err    error
+}
+
+func (s *hbufStreamReader) Read(p []byte) (int, error) {
+	for nil == s.err && 0 == s.size {
+		var kind byte
+		kind, s.size, s.err = hbufReadFrame(s.r, &s.head)
+		if nil == s.err && hbufFrameData != kind {
+			s.err = hbufReadEnd(s.r, kind, s.size)
+			s.size = 0
+		}
+		if nil != s.err {
+			_ = s.Close()
+		}
+	}
+	if nil != s.err {
+		return 0, s.err
+	}
+	if s.size < uint32(len(p)) {
+		p = p[:s.size]
+	}
+	n, err := s.r.Read(p)
+	s.size -= uint32(n)
+	if nil != err {
+		if io.EOF == err {
+			err = io.ErrUnexpectedEOF
+		}
+		s.err = err
+		_ = s.Close()
+	}
+	return n, err
+}
+
+func (s *hbufStreamReader) Close() error {
+	if nil == s.closer {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// StreamReader 按顺序读取 stream<T> 中的消息，读完后返回 io.EOF
+type StreamReader[T any] interface {
+	Recv() (T, error)
+}
+
+// StreamWriter 按顺序写入 stream<T> 中的消息，写完后调用 Close
+type StreamWriter[T any] interface {
+	Send(v T) error
+	Close() error
+}
+
+type hbufMessage[T any] interface {
+	*T
+	Encoder(w io.Writer) error
+	Decoder(r io.Reader) error
+}
+
+// hbufSend 发送一条消息，binary 为 true 时使用二进制编码，否则使用 JSON，nil 发送为空消息帧
+func hbufSend[T any, P hbufMessage[T]](w io.Writer, binary bool, v P) error {
+	if nil == v {
+		return hbufWriteFrame(w, hbufFrameNull, nil)
+	}
+	var buf []byte
+	var err error
+	if binary {
+		b := &bytes.Buffer{}
+		err = v.Encoder(b)
+		buf = b.Bytes()
+	} else {
+		buf, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	return hbufWriteFrame(w, hbufFrameData, buf)
+}
+
+// hbufRecv 读出一条消息，读到结束帧时返回 io.EOF，读到错误帧时返回其中的错误
+func hbufRecv[T any, P hbufMessage[T]](r io.Reader, binary bool) (P, error) {
+	var head [5]byte
+	kind, size, err := hbufReadFrame(r, &head)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case hbufFrameNull:
+		return nil, nil
+	case hbufFrameData:
+		buf, err := hbufReadPayload(r, size)
+		if err != nil {
+			return nil, err
+		}
+		v := P(new(T))
+		if binary {
+			err = v.Decoder(bytes.NewReader(buf))
+		} else {
+			err = json.Unmarshal(buf, v)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, hbufReadEnd(r, kind, size)
+}
+
+// hbufReply 发送路由中服务返回的结果或错误
+func hbufReply[T any, P hbufMessage[T]](w io.Writer, binary bool, v P, err error) error {
+	if err != nil {
+		return hbufEnd(w, err)
+	}
+	return hbufSend(w, binary, v)
+}
+
+// hbufSendMessages 依次发送 r 中的全部消息，之后发送结束帧
+func hbufSendMessages[T any, P hbufMessage[T]](w io.Writer, binary bool, r StreamReader[P]) error {
+	for {
+		v, err := r.Recv()
+		if io.EOF == err {
+			return hbufWriteFrame(w, hbufFrameEnd, nil)
+		}
+		if err != nil {
+			return err
+		}
+		err = hbufSend(w, binary, v)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// hbufMessageReader 逐帧读出 stream<T> 中的消息，结束或出错后关闭 closer
+type hbufMessageReader[T any, P hbufMessage[T]] struct {
+	r      io.Reader
+	binary bool
+	closer io.Closer
+	err    error
+}
+
+func newHbufMessageReader[T any, P hbufMessage[T]](r io.Reader, binary bool, closer io.Closer) *hbufMessageReader[T, P] {
+	return &hbufMessageReader[T, P]{r: r, binary: binary, closer: closer}
+}
+
+func (s *hbufMessageReader[T, P]) Recv() (P, error) {
+	if nil != s.err {
+		return nil, s.err
+	}
+	v, err := hbufRecv[T, P](s.r, s.binary)
+	if err != nil {
+		s.err = err
+		if nil != s.closer {
+			_ = s.closer.Close()
+		}
+	}
+	return v, err
+}
+
+// hbufMessageWriter 逐帧发送 stream<T> 中的消息，Close 后不能再发送
+type hbufMessageWriter[T any, P hbufMessage[T]] struct {
+	w      io.Writer
+	binary bool
+	closed bool
+}
+
+func newHbufMessageWriter[T any, P hbufMessage[T]](w io.Writer, binary bool) *hbufMessageWriter[T, P] {
+	return &hbufMessageWriter[T, P]{w: w, binary: binary}
+}
+
+func (s *hbufMessageWriter[T, P]) Send(v P) error {
+	if s.closed {
+		return io.ErrClosedPipe
+	}
+	return hbufSend(s.w, s.binary, v)
+}
+
+func (s *hbufMessageWriter[T, P]) Close() error {
+	s.closed = true
+	return nil
+}
+
+// hbufStreamItems 依次读出 items 的消息流
+type hbufStreamItems[T any] struct {
+	items []T
+}
+
+// NewStreamReader 返回依次读出 items 的消息流，用于调用参数为 stream<T> 的方法
+func NewStreamReader[T any, P hbufMessage[T]](items ...P) StreamReader[P] {
+	return &hbufStreamItems[P]{items: items}
+}
+
+func (s *hbufStreamItems[T]) Recv() (T, error) {
+	if 0 == len(s.items) {
+		return *new(T), io.EOF
+	}
+	v := s.items[0]
+	s.items = s.items[1:]
+	return v, nil
+}
+
+// hbufStreamPipe 不带缓冲的消息管道，Send 等到消息被 Recv 取走才返回
+type hbufStreamPipe[T any] struct {
+	ch   chan T
+	done chan struct{}
+	once sync.Once
+}
+
+// NewStreamPipe 返回连在一起的消息流读写两端，写端 Close 后读端返回 io.EOF，
+// 用于边收边发地调用参数为 stream<T> 的方法
+func NewStreamPipe[T any]() (StreamReader[T], StreamWriter[T]) {
+	p := &hbufStreamPipe[T]{ch: make(chan T), done: make(chan struct{})}
+	return p, p
+}
+
+func (p *hbufStreamPipe[T]) Recv() (T, error) {
+	select {
+	case v := <-p.ch:
+		return v, nil
+	case <-p.done:
+		return *new(T), io.EOF
+	}
+}
+
+func (p *hbufStreamPipe[T]) Send(v T) error {
+	select {
+	case p.ch <- v:
+		return nil
+	case <-p.done:
+		return io.ErrClosedPipe
+	}
+}
+
+func (p *hbufStreamPipe[T]) Close() error {
+	p.once.Do(func() {
+		close(p.done)
+	})
+	return nil
+}
+
+// StreamConn 一次 stream 调用的连接，Write 发送请求帧，请求发完后调用 CloseWrite，Read 读出结果帧，
+// Close 结束调用并让未完成的读写返回错误
+type StreamConn interface {
+	io.ReadWriteCloser
+	CloseWrite() error
+}
+
+// StreamClient 传输 stream 方法的客户端，普通方法仍由 rpc.Client 调用，含有 stream 方法的服务的 New...Client 需要传入它
+type StreamClient interface {
+	rpc.Client
+
+	// Stream 打开一次调用，binary 为 true 时按 id 调用并使用二进制编码，否则按 name 调用并使用 JSON 编码
+	Stream(ctx context.Context, name string, id int64) (conn StreamConn, binary bool, err error)
+}
+
+// hbufStreamCall 客户端的一次 stream 调用，请求在另一个 goroutine 中发送，
+// 发送失败时关闭连接，之后读取结果时返回发送的错误
+type hbufStreamCall struct {
+	conn   StreamConn
+	binary bool
+	lock   sync.Mutex
+	err    error
+}
+
+func hbufCall(ctx context.Context, client StreamClient, name string, id int64, send func(w io.Writer, binary bool) error) (*hbufStreamCall, error) {
+	conn, binary, err := client.Stream(ctx, name, id)
+	if err != nil {
+		return nil, err
+	}
+	call := &hbufStreamCall{conn: conn, binary: binary}
+	go func() {
+		err := send(conn, binary)
+		if nil == err {
+			err = conn.CloseWrite()
+		}
+		if nil != err {
+			call.lock.Lock()
+			call.err = err
+			call.lock.Unlock()
+			_ = conn.Close()
+		}
+	}()
+	return call, nil
+}
+
+func (c *hbufStreamCall) Read(p []byte) (int, error) {
+	n, err := c.conn.Read(p)
+	if nil != err {
+		c.lock.Lock()
+		if nil != c.err {
+			err = c.err
+		}
+		c.lock.Unlock()
+	}
+	return n, err
+}
+
+func (c *hbufStreamCall) Close() error {
+	return c.conn.Close()
+}
+
+// hbufCallReply 读出调用的结果后结束调用
+func hbufCallReply[T any, P hbufMessage[T]](call *hbufStreamCall) (P, error) {
+	defer call.Close()
+	return hbufRecv[T, P](call, call.binary)
+}
+
+// hbufCallEnd 等到没有结果的调用结束
+func hbufCallEnd(call *hbufStreamCall) error {
+	defer call.Close()
+	var head [5]byte
+	kind, size, err := hbufReadFrame(call, &head)
+	if err != nil {
+		return err
+	}
+	err = hbufReadEnd(call, kind, size)
+	if io.EOF == err {
+		return nil
+	}
+	return err
+}
+
+// StreamInvoke 路由中 stream 方法的调用，从 r 读出请求帧，向 w 写入结果帧，只返回读写连接时的错误
+type StreamInvoke struct {
+	SetInfo func(ctx context.Context)
+	Invoke  func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error
+}
+
+// StreamRouter 含有 stream 方法的服务路由
+type StreamRouter interface {
+	GetName() string
+
+	GetStreams() map[string]*StreamInvoke
+
+	GetStreamIds() map[int64]*StreamInvoke
+}
+
+// StreamServer 按名称或 Id 分发 stream 方法的调用，调用时经过 rpc.Server 的过滤器，过滤器收到的数据为 nil
+type StreamServer struct {
+	server *rpc.Server
+	lock   sync.RWMutex
+	names  map[string]*StreamInvoke
+	ids    map[int64]*StreamInvoke
+}
+
+func NewStreamServer(server *rpc.Server, routers ...StreamRouter) *StreamServer {
+	s := &StreamServer{
+		server: server,
+		names:  map[string]*StreamInvoke{},
+		ids:    map[int64]*StreamInvoke{},
+	}
+	for _, router := range routers {
+		s.Add(router)
+	}
+	return s
+}
+
+func (s *StreamServer) Add(router StreamRouter) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for key, value := range router.GetStreams() {
+		s.names["/"+router.GetName()+"/"+key] = value
+	}
+	for key, value := range router.GetStreamIds() {
+		s.ids[key] = value
+	}
+}
+
+// Invoke 按名称调用并使用 JSON 编码，实现了 rpc.Invoke
+func (s *StreamServer) Invoke(ctx context.Context, name string, in io.Reader, out io.Writer) error {
+	s.lock.RLock()
+	value, ok := s.names[name]
+	s.lock.RUnlock()
+	if !ok {
+		return hbufEnd(out, &rpc.Result{Code: http.StatusNotFound, Msg: "not found"})
+	}
+	rpc.SetMethod(ctx, name)
+	return s.invoke(ctx, value, false, in, out)
+}
+
+// InvokeId 按 服务Id<<32 | 方法Id 调用并使用二进制编码
+func (s *StreamServer) InvokeId(ctx context.Context, id int64, in io.Reader, out io.Writer) error {
+	s.lock.RLock()
+	value, ok := s.ids[id]
+	s.lock.RUnlock()
+	if !ok {
+		return hbufEnd(out, &rpc.Result{Code: http.StatusNotFound, Msg: "not found"})
+	}
+	return s.invoke(ctx, value, true, in, out)
+}
+
+func (s *StreamServer) invoke(ctx context.Context, value *StreamInvoke, binary bool, in io.Reader, out io.Writer) error {
+	value.SetInfo(ctx)
+	called := false
+	_, _, err := s.server.GetFilter().OnNext(ctx, nil, func(ctx context.Context, data hbuf.Data) (context.Context, hbuf.Data, error) {
+		called = true
+		return ctx, nil, value.Invoke(ctx, binary, in, out)
+	})
+	if nil != err && !called {
+		return hbufEnd(out, err)
+	}
+	return err
+}
+
+// ServeHTTP 通过 HTTP 全双工传输 stream 方法，请求头有 Hbuf-Invoke-Id 时按 Id 调用并使用二进制编码，否则按路径调用并使用 JSON 编码，
+// 帧写入后立即发送
+func (s *StreamServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := rpc.NewContext(r.Context())
+	defer rpc.CloseContext(ctx)
+	for key := range r.Header {
+		rpc.SetHeader(ctx, key, r.Header.Get(key))
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.EnableFullDuplex()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	out := &hbufFlushWriter{w: w, rc: rc}
+
+	var err error
+	if value := r.Header.Get("Hbuf-Invoke-Id"); 0 < len(value) {
+		id, e := strconv.ParseInt(value, 10, 64)
+		if e != nil {
+			err = hbufEnd(out, &rpc.Result{Code: http.StatusBadRequest, Msg: e.Error()})
+		} else {
+			err = s.InvokeId(ctx, id, r.Body, out)
+		}
+	} else {
+		err = s.Invoke(ctx, r.URL.Path, r.Body, out)
+	}
+	if err != nil {
+		erro.PrintStack(err)
+	}
+}
+
+type hbufFlushWriter struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (f *hbufFlushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if nil == err {
+		err = f.rc.Flush()
+	}
+	return n, err
+}
+
+// StreamHttpClient 普通方法交给 rpc.Client 调用，stream 方法通过 HTTP 全双工传输到 StreamServer，
+// binary 为 true 时按 Id 调用并使用二进制编码
+type StreamHttpClient struct {
+	rpc.Client
+	base   string
+	binary bool
+	client *http.Client
+}
+
+func NewStreamHttpClient(client rpc.Client, base string, binary bool) *StreamHttpClient {
+	return &StreamHttpClient{
+		Client: client,
+		base:   strings.TrimSuffix(base, "/"),
+		binary: binary,
+		client: &http.Client{},
+	}
+}
+
+func (c *StreamHttpClient) Stream(ctx context.Context, name string, id int64) (StreamConn, bool, error) {
+	reader, writer := io.Pipe()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.base+"/"+name, reader)
+	if err != nil {
+		return nil, false, err
+	}
+	for key, values := range rpc.GetHeaders(ctx) {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	if c.binary {
+		request.Header.Set("Hbuf-Invoke-Id", strconv.FormatInt(id, 10))
+	}
+	request.Header.Set("Content-Type", "application/octet-stream")
+
+	conn := &hbufHttpConn{writer: writer, done: make(chan struct{})}
+	// 请求体边写边发，响应头要等服务端开始处理才返回，所以在另一个 goroutine 中等待
+	go func() {
+		defer close(conn.done)
+		response, err := c.client.Do(request)
+		if nil == err && http.StatusOK != response.StatusCode {
+			_ = response.Body.Close()
+			err = errors.New(response.Status)
+		}
+		if err != nil {
+			conn.err = err
+			_ = writer.CloseWithError(err)
+			return
+		}
+		conn.body = response.Body
+	}()
+	return conn, c.binary, nil
+}
+
+type hbufHttpConn struct {
+	writer *io.PipeWriter
+	done   chan struct{}
+	body   io.ReadCloser
+	err    error
+}
+
+func (c *hbufHttpConn) Read(p []byte) (int, error) {
+	<-c.done
+	if nil != c.err {
+		return 0, c.err
+	}
+	return c.body.Read(p)
+}
+
+func (c *hbufHttpConn) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+func (c *hbufHttpConn) CloseWrite() error {
+	return c.writer.Close()
+}
+
+func (c *hbufHttpConn) Close() error {
+	_ = c.writer.CloseWithError(io.ErrClosedPipe)
+	<-c.done
+	if nil != c.body {
+		return c.body.Close()
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"encoding/json"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+)
+
+type User struct {
+	Id     hbuf.Int64 `json:"id,omitempty"`     //
+	Name   string     `json:"name,omitempty"`   //
+	Status Status     `json:"status,omitempty"` //
+	Parent *Base      `json:"parent,omitempty"` //
+}
+
+func (g *User) ToData() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func (g *User) FormData(data []byte) error {
+	return json.Unmarshal(data, g)
+}
+
+func (g *User) GetId() hbuf.Int64 {
+	return g.Id
+}
+
+func (g *User) SetId(val hbuf.Int64) {
+	g.Id = val
+}
+
+func (g *User) GetName() string {
+	return g.Name
+}
+
+func (g *User) SetName(val string) {
+	g.Name = val
+}
+
+func (g *User) GetStatus() Status {
+	return g.Status
+}
+
+func (g *User) SetStatus(val Status) {
+	g.Status = val
+}
+
+func (g *User) GetParent() Base {
+	if nil == g.Parent {
+		return Base{}
+	}
+	return *g.Parent
+}
+
+func (g *User) SetParent(val Base) {
+	g.Parent = &val
+}
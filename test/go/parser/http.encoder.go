@@ -0,0 +1,31 @@
+package parser
+
+import (
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+	"io"
+)
+
+func (g *User) Encoder(w io.Writer) error {
+	e := &hbufEncoder{w: w}
+	hbufWriteInt[hbuf.Int64](e, 0, g.Id)
+	hbufWriteString(e, 1, g.Name)
+	hbufWriteInt[Status](e, 2, g.Status)
+	hbufWriteNullable(hbufWriteData[Base])(e, 3, g.Parent)
+	return e.err
+}
+
+func (g *User) Decoder(r io.Reader) error {
+	return hbufDecode(r, func(typ byte, id uint32, val []byte) (err error) {
+		switch id {
+		case 0:
+			g.Id, err = hbufReadInt[hbuf.Int64](typ, val)
+		case 1:
+			g.Name, err = hbufReadString(typ, val)
+		case 2:
+			g.Status, err = hbufReadInt[Status](typ, val)
+		case 3:
+			g.Parent, err = hbufReadNullable(hbufReadData[Base])(typ, val)
+		}
+		return
+	})
+}
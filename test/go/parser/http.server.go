@@ -0,0 +1,494 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/erro"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/manage"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+	"net/http"
+)
+
+type UserServer interface {
+	Init(ctx context.Context)
+
+	GetUser(ctx context.Context, req *User) (*User, error)
+
+	SetName(ctx context.Context, req *User) (*User, error)
+
+	AddUser(ctx context.Context, req *User) (*User, error)
+
+	DelUser(ctx context.Context, req *User) error
+
+	Find(ctx context.Context, req *User) (*User, error)
+
+	GetMe(ctx context.Context, req *User) (*User, error)
+}
+
+type UserServerClient struct {
+	client rpc.Client
+}
+
+func (p *UserServerClient) Init(ctx context.Context) {
+}
+
+func (p *UserServerClient) GetName() string {
+	return "user_server"
+}
+
+func (p *UserServerClient) GetId() uint32 {
+	return 4
+}
+
+func NewUserServerClient(client rpc.Client) *UserServerClient {
+	return &UserServerClient{
+		client: client,
+	}
+}
+
+func (r *UserServerClient) GetUser(ctx context.Context, req *User) (*User, error) {
+	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/get_user", &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var req User
+			return &req, json.Unmarshal(buf, &req)
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869184, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret User
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*User), nil
+}
+
+func (r *UserServerClient) SetName(ctx context.Context, req *User) (*User, error) {
+	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/set_name", &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var req User
+			return &req, json.Unmarshal(buf, &req)
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869185, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret User
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*User), nil
+}
+
+func (r *UserServerClient) AddUser(ctx context.Context, req *User) (*User, error) {
+	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/add_user", &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var req User
+			return &req, json.Unmarshal(buf, &req)
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869186, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret User
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*User), nil
+}
+
+func (r *UserServerClient) DelUser(ctx context.Context, req *User) error {
+	_, err := r.client.Invoke(ctx, req, "user_server/user_server/del_user", &rpc.ClientInvoke{
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869187, &rpc.ClientInvoke{
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *UserServerClient) Find(ctx context.Context, req *User) (*User, error) {
+	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/find", &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var req User
+			return &req, json.Unmarshal(buf, &req)
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869188, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret User
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*User), nil
+}
+
+func (r *UserServerClient) GetMe(ctx context.Context, req *User) (*User, error) {
+	ret, err := r.client.Invoke(ctx, req, "user_server/user_server/get_me", &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var req User
+			return &req, json.Unmarshal(buf, &req)
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			return json.Marshal(&data)
+		},
+	}, 17179869189, &rpc.ClientInvoke{
+		ToData: func(buf []byte) (hbuf.Data, error) {
+			var ret User
+			return &ret, ret.Decoder(bytes.NewReader(buf))
+		},
+		FormData: func(data hbuf.Data) ([]byte, error) {
+			buf := &bytes.Buffer{}
+			err := data.(*User).Encoder(buf)
+			return buf.Bytes(), err
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret.(*User), nil
+}
+
+type UserServerRouter struct {
+	server UserServer
+	names  map[string]*rpc.ServerInvoke
+	ids    map[int64]*rpc.ServerInvoke
+	routes []*HttpRoute
+}
+
+func (p *UserServerRouter) GetName() string {
+	return "user_server"
+}
+
+func (p *UserServerRouter) GetId() uint32 {
+	return 4
+}
+
+func (p *UserServerRouter) GetServer() rpc.Init {
+	return p.server
+}
+
+func (p *UserServerRouter) GetInvoke() map[string]*rpc.ServerInvoke {
+	return p.names
+}
+
+// GetInvokeIds 按 服务Id<<32 | 方法Id 查找调用，供二进制传输使用
+func (p *UserServerRouter) GetInvokeIds() map[int64]*rpc.ServerInvoke {
+	return p.ids
+}
+
+// GetHttpRoutes 返回由 http 注解生成的 REST 路由，供 HttpHandler 使用
+func (p *UserServerRouter) GetHttpRoutes() []*HttpRoute {
+	return p.routes
+}
+
+func NewUserServerRouter(server UserServer) *UserServerRouter {
+	names := map[string]*rpc.ServerInvoke{
+		"user_server/get_user": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.GetUser(ctx, data.(*User))
+			},
+		},
+		"user_server/set_name": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.SetName(ctx, data.(*User))
+			},
+		},
+		"user_server/add_user": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.AddUser(ctx, data.(*User))
+			},
+		},
+		"user_server/del_user": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return nil, server.DelUser(ctx, data.(*User))
+			},
+		},
+		"user_server/find": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.Find(ctx, data.(*User))
+			},
+		},
+		"user_server/get_me": {
+			ToData: func(buf []byte) (hbuf.Data, error) {
+				var req User
+				return &req, json.Unmarshal(buf, &req)
+			},
+			FormData: func(data hbuf.Data) ([]byte, error) {
+				return json.Marshal(&data)
+			},
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, data hbuf.Data) (hbuf.Data, error) {
+				return server.GetMe(ctx, data.(*User))
+			},
+		},
+	}
+	return &UserServerRouter{
+		server: server,
+		names:  names,
+		ids: map[int64]*rpc.ServerInvoke{
+			17179869184: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				FormData: func(data hbuf.Data) ([]byte, error) {
+					buf := &bytes.Buffer{}
+					err := data.(*User).Encoder(buf)
+					return buf.Bytes(), err
+				},
+				SetInfo: names["user_server/get_user"].SetInfo,
+				Invoke:  names["user_server/get_user"].Invoke,
+			},
+			17179869185: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				FormData: func(data hbuf.Data) ([]byte, error) {
+					buf := &bytes.Buffer{}
+					err := data.(*User).Encoder(buf)
+					return buf.Bytes(), err
+				},
+				SetInfo: names["user_server/set_name"].SetInfo,
+				Invoke:  names["user_server/set_name"].Invoke,
+			},
+			17179869186: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				FormData: func(data hbuf.Data) ([]byte, error) {
+					buf := &bytes.Buffer{}
+					err := data.(*User).Encoder(buf)
+					return buf.Bytes(), err
+				},
+				SetInfo: names["user_server/add_user"].SetInfo,
+				Invoke:  names["user_server/add_user"].Invoke,
+			},
+			17179869187: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				SetInfo: names["user_server/del_user"].SetInfo,
+				Invoke:  names["user_server/del_user"].Invoke,
+			},
+			17179869188: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				FormData: func(data hbuf.Data) ([]byte, error) {
+					buf := &bytes.Buffer{}
+					err := data.(*User).Encoder(buf)
+					return buf.Bytes(), err
+				},
+				SetInfo: names["user_server/find"].SetInfo,
+				Invoke:  names["user_server/find"].Invoke,
+			},
+			17179869189: {
+				ToData: func(buf []byte) (hbuf.Data, error) {
+					var ret User
+					return &ret, ret.Decoder(bytes.NewReader(buf))
+				},
+				FormData: func(data hbuf.Data) ([]byte, error) {
+					buf := &bytes.Buffer{}
+					err := data.(*User).Encoder(buf)
+					return buf.Bytes(), err
+				},
+				SetInfo: names["user_server/get_me"].SetInfo,
+				Invoke:  names["user_server/get_me"].Invoke,
+			},
+		},
+		routes: []*HttpRoute{
+			{
+				Method: "GET",
+				Path:   "/users/{id}",
+				Name:   "/user_server/user_server/get_user",
+				ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {
+					var req User
+					return &req, hbufHttpBind(r, params, "", &req, "name")
+				},
+				Invoke: names["user_server/get_user"],
+			},
+			{
+				Method: "PUT",
+				Path:   "/users/{id}/name",
+				Name:   "/user_server/user_server/set_name",
+				ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {
+					var req User
+					return &req, hbufHttpBind(r, params, "name", &req, "name")
+				},
+				Invoke: names["user_server/set_name"],
+			},
+			{
+				Method: "POST",
+				Path:   "/users",
+				Name:   "/user_server/user_server/add_user",
+				ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {
+					var req User
+					return &req, hbufHttpBind(r, params, "*", &req, "name")
+				},
+				Invoke: names["user_server/add_user"],
+			},
+			{
+				Method: "DELETE",
+				Path:   "/users/{id}",
+				Name:   "/user_server/user_server/del_user",
+				ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {
+					var req User
+					return &req, hbufHttpBind(r, params, "", &req, "name")
+				},
+				Invoke: names["user_server/del_user"],
+			},
+			{
+				Method: "GET",
+				Path:   "/users/me",
+				Name:   "/user_server/user_server/get_me",
+				ToData: func(r *http.Request, params map[string]string) (hbuf.Data, error) {
+					var req User
+					return &req, hbufHttpBind(r, params, "", &req, "name")
+				},
+				Invoke: names["user_server/get_me"],
+			},
+		},
+	}
+}
+
+type DefaultUserServer struct {
+}
+
+func (s *DefaultUserServer) Init(ctx context.Context) {
+}
+
+func (s *DefaultUserServer) GetUser(ctx context.Context, req *User) (*User, error) {
+	return nil, erro.NewError("not find server user_server")
+}
+
+func (s *DefaultUserServer) SetName(ctx context.Context, req *User) (*User, error) {
+	return nil, erro.NewError("not find server user_server")
+}
+
+func (s *DefaultUserServer) AddUser(ctx context.Context, req *User) (*User, error) {
+	return nil, erro.NewError("not find server user_server")
+}
+
+func (s *DefaultUserServer) DelUser(ctx context.Context, req *User) error {
+	return erro.NewError("not find server user_server")
+}
+
+func (s *DefaultUserServer) Find(ctx context.Context, req *User) (*User, error) {
+	return nil, erro.NewError("not find server user_server")
+}
+
+func (s *DefaultUserServer) GetMe(ctx context.Context, req *User) (*User, error) {
+	return nil, erro.NewError("not find server user_server")
+}
+
+var Default_UserServer = &DefaultUserServer{}
+
+func GetUserServer(ctx context.Context) UserServer {
+	router := manage.GET(ctx).Get(&UserServerRouter{})
+	if nil == router {
+		return Default_UserServer
+	}
+	if val, ok := router.(UserServer); ok {
+		return val
+	}
+	return Default_UserServer
+}
+
+func GetUserServerName() string {
+	return "user_server"
+}
@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+)
+
+type userServer struct {
+	DefaultUserServer
+	deleted []*User
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *User) (*User, error) {
+	return req, nil
+}
+
+func (s *userServer) GetMe(ctx context.Context, req *User) (*User, error) {
+	return &User{Name: "me"}, nil
+}
+
+func (s *userServer) SetName(ctx context.Context, req *User) (*User, error) {
+	return req, nil
+}
+
+func (s *userServer) AddUser(ctx context.Context, req *User) (*User, error) {
+	return req, nil
+}
+
+func (s *userServer) DelUser(ctx context.Context, req *User) error {
+	s.deleted = append(s.deleted, req)
+	return nil
+}
+
+// testHttp 发送 REST 请求，返回 rpc.Result 中的 code 和 data，路由错误的 code 同时是 HTTP 状态码
+func testHttp(t *testing.T, url string, method string, path string, body string) (int, *User) {
+	request, err := http.NewRequest(method, url+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	buf, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result rpc.Result
+	if err = json.Unmarshal(buf, &result); err != nil {
+		t.Fatalf("%s %s: %s", method, path, buf)
+	}
+	if status := response.StatusCode; (0 == result.Code && http.StatusOK != status) || (0 != result.Code && result.Code != status) {
+		t.Fatalf("%s %s: status %d, code %d", method, path, status, result.Code)
+	}
+	if 0 != result.Code || "null" == string(result.Data) {
+		return result.Code, nil
+	}
+	var user User
+	if err = result.GetData(&user); err != nil {
+		t.Fatal(err)
+	}
+	return result.Code, &user
+}
+
+func TestHttpRoutes(t *testing.T) {
+	server := &userServer{}
+	handler := NewHttpHandler(rpc.NewServer(), NewUserServerRouter(server))
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	code, user := testHttp(t, httpServer.URL, "GET", "/users/12?name=34&status=1&parent=%7B%22id%22%3A%225%22%7D", "")
+	if 0 != code || 12 != user.Id || "34" != user.Name || StatusDisable != user.Status || 5 != user.Parent.Id {
+		t.Fatalf("get user: %d %+v", code, user)
+	}
+
+	// 固定的路径段优先于路径参数，GetMe 定义在 GetUser 之后
+	code, user = testHttp(t, httpServer.URL, "GET", "/users/me", "")
+	if 0 != code || "me" != user.Name {
+		t.Fatalf("get me: %d %+v", code, user)
+	}
+
+	code, user = testHttp(t, httpServer.URL, "PUT", "/users/12/name?status=1", `"Tom"`)
+	if 0 != code || 12 != user.Id || "Tom" != user.Name || StatusDisable != user.Status {
+		t.Fatalf("set name: %d %+v", code, user)
+	}
+
+	code, user = testHttp(t, httpServer.URL, "POST", "/users", `{"id":"7","name":"Amy"}`)
+	if 0 != code || 7 != user.Id || "Amy" != user.Name {
+		t.Fatalf("add user: %d %+v", code, user)
+	}
+
+	code, _ = testHttp(t, httpServer.URL, "DELETE", "/users/a%2Fb", "")
+	if http.StatusBadRequest != code {
+		t.Fatalf("invalid id: want %d, got %d", http.StatusBadRequest, code)
+	}
+	code, _ = testHttp(t, httpServer.URL, "DELETE", "/users/9", "")
+	if 0 != code || 1 != len(server.deleted) || 9 != server.deleted[0].Id {
+		t.Fatalf("del user: %d %+v", code, server.deleted)
+	}
+
+	code, _ = testHttp(t, httpServer.URL, "POST", "/users/9", "")
+	if http.StatusMethodNotAllowed != code {
+		t.Fatalf("method: want %d, got %d", http.StatusMethodNotAllowed, code)
+	}
+	code, _ = testHttp(t, httpServer.URL, "POST", "/user_server/find", "{}")
+	if http.StatusNotFound != code {
+		t.Fatalf("find: want %d, got %d", http.StatusNotFound, code)
+	}
+}
+
+// TestHttpMaxBody 请求体超过 MaxBody 时返回 413，不读取多余的内容
+func TestHttpMaxBody(t *testing.T) {
+	handler := NewHttpHandler(rpc.NewServer(), NewUserServerRouter(&userServer{}))
+	handler.MaxBody = 32
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+
+	code, user := testHttp(t, httpServer.URL, "POST", "/users", `{"id":"7","name":"Amy"}`)
+	if 0 != code || 7 != user.Id {
+		t.Fatalf("add user: %d %+v", code, user)
+	}
+	code, _ = testHttp(t, httpServer.URL, "POST", "/users", `{"id":"7","name":"`+strings.Repeat("a", 64)+`"}`)
+	if http.StatusRequestEntityTooLarge != code {
+		t.Fatalf("max body: want %d, got %d", http.StatusRequestEntityTooLarge, code)
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+)
+
+type infoServer struct {
+	DefaultInfoServer
+}
+
+func (s *infoServer) GetInfo(ctx context.Context, req *Base) (*Info, error) {
+	return &Info{Base: Base{Id: req.Id}, Tags: []string{"ok"}}, nil
+}
+
+// idClient 通过路由的 Id 表直接调用，模拟二进制传输
+type idClient struct {
+	router interface {
+		GetInvokeIds() map[int64]*rpc.ServerInvoke
+	}
+}
+
+func (c *idClient) Invoke(ctx context.Context, param hbuf.Data, name string, nameInvoke *rpc.ClientInvoke, id int64, idInvoke *rpc.ClientInvoke) (hbuf.Data, error) {
+	invoke, ok := c.router.GetInvokeIds()[id]
+	if !ok {
+		return nil, errors.New("not find id")
+	}
+	buf, err := idInvoke.FormData(param)
+	if err != nil {
+		return nil, err
+	}
+	req, err := invoke.ToData(buf)
+	if err != nil {
+		return nil, err
+	}
+	ret, err := invoke.Invoke(ctx, req)
+	if err != nil || nil == invoke.FormData {
+		return nil, err
+	}
+	buf, err = invoke.FormData(ret)
+	if err != nil {
+		return nil, err
+	}
+	return idInvoke.ToData(buf)
+}
+
+func TestServerInvokeId(t *testing.T) {
+	router := NewInfoServerRouter(&infoServer{})
+	if 1 != router.GetId() {
+		t.Fatalf("server id: want 1, got %d", router.GetId())
+	}
+	if _, ok := router.GetInvokeIds()[1<<32|1]; !ok {
+		t.Fatal("set_info not registered by id")
+	}
+
+	client := NewInfoServerClient(&idClient{router: router})
+	info, err := client.GetInfo(context.TODO(), &Base{Id: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 42 != info.Id || 1 != len(info.Tags) || "ok" != info.Tags[0] {
+		t.Errorf("unexpected result: %+v", info)
+	}
+}
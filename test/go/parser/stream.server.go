@@ -0,0 +1,392 @@
+package parser
+
+import (
+	"context"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/erro"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/manage"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+	"io"
+)
+
+type FileServer interface {
+	Init(ctx context.Context)
+
+	Upload(ctx context.Context, req io.Reader) (*Base, error)
+
+	Download(ctx context.Context, req *Base, writer io.WriteCloser) error
+
+	Echo(ctx context.Context, req io.Reader, writer io.WriteCloser) error
+}
+
+type FileServerClient struct {
+	client StreamClient
+}
+
+func (p *FileServerClient) Init(ctx context.Context) {
+}
+
+func (p *FileServerClient) GetName() string {
+	return "file_server"
+}
+
+func (p *FileServerClient) GetId() uint32 {
+	return 2
+}
+
+func NewFileServerClient(client StreamClient) *FileServerClient {
+	return &FileServerClient{
+		client: client,
+	}
+}
+
+func (r *FileServerClient) Upload(ctx context.Context, req io.Reader) (*Base, error) {
+	call, err := hbufCall(ctx, r.client, "file_server/file_server/upload", 8589934592, func(w io.Writer, binary bool) error {
+		return hbufSendStream(w, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hbufCallReply[Base](call)
+}
+
+func (r *FileServerClient) Download(ctx context.Context, req *Base) (io.ReadCloser, error) {
+	call, err := hbufCall(ctx, r.client, "file_server/file_server/download", 8589934593, func(w io.Writer, binary bool) error {
+		return hbufSend(w, binary, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hbufStreamReader{r: call, closer: call}, nil
+}
+
+func (r *FileServerClient) Echo(ctx context.Context, req io.Reader) (io.ReadCloser, error) {
+	call, err := hbufCall(ctx, r.client, "file_server/file_server/echo", 8589934594, func(w io.Writer, binary bool) error {
+		return hbufSendStream(w, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &hbufStreamReader{r: call, closer: call}, nil
+}
+
+type FileServerRouter struct {
+	server    FileServer
+	names     map[string]*rpc.ServerInvoke
+	ids       map[int64]*rpc.ServerInvoke
+	streams   map[string]*StreamInvoke
+	streamIds map[int64]*StreamInvoke
+}
+
+func (p *FileServerRouter) GetName() string {
+	return "file_server"
+}
+
+func (p *FileServerRouter) GetId() uint32 {
+	return 2
+}
+
+func (p *FileServerRouter) GetServer() rpc.Init {
+	return p.server
+}
+
+func (p *FileServerRouter) GetInvoke() map[string]*rpc.ServerInvoke {
+	return p.names
+}
+
+// GetInvokeIds 按 服务Id<<32 | 方法Id 查找调用，供二进制传输使用
+func (p *FileServerRouter) GetInvokeIds() map[int64]*rpc.ServerInvoke {
+	return p.ids
+}
+
+// GetStreams 返回 stream 方法的调用，供 StreamServer 使用
+func (p *FileServerRouter) GetStreams() map[string]*StreamInvoke {
+	return p.streams
+}
+
+// GetStreamIds 按 服务Id<<32 | 方法Id 查找 stream 方法的调用
+func (p *FileServerRouter) GetStreamIds() map[int64]*StreamInvoke {
+	return p.streamIds
+}
+
+func NewFileServerRouter(server FileServer) *FileServerRouter {
+	names := map[string]*rpc.ServerInvoke{}
+	streams := map[string]*StreamInvoke{
+		"file_server/upload": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				ret, err := server.Upload(ctx, &hbufStreamReader{r: r})
+				return hbufReply(w, binary, ret, err)
+			},
+		},
+		"file_server/download": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				req, err := hbufRecv[Base](r, binary)
+				if err != nil {
+					return hbufEnd(w, err)
+				}
+				return hbufEnd(w, server.Download(ctx, req, &hbufStreamWriter{w: w}))
+			},
+		},
+		"file_server/echo": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				return hbufEnd(w, server.Echo(ctx, &hbufStreamReader{r: r}, &hbufStreamWriter{w: w}))
+			},
+		},
+	}
+	return &FileServerRouter{
+		server:  server,
+		names:   names,
+		ids:     map[int64]*rpc.ServerInvoke{},
+		streams: streams,
+		streamIds: map[int64]*StreamInvoke{
+			8589934592: streams["file_server/upload"],
+			8589934593: streams["file_server/download"],
+			8589934594: streams["file_server/echo"],
+		},
+	}
+}
+
+type DefaultFileServer struct {
+}
+
+func (s *DefaultFileServer) Init(ctx context.Context) {
+}
+
+func (s *DefaultFileServer) Upload(ctx context.Context, req io.Reader) (*Base, error) {
+	return nil, erro.NewError("not find server file_server")
+}
+
+func (s *DefaultFileServer) Download(ctx context.Context, req *Base, writer io.WriteCloser) error {
+	return erro.NewError("not find server file_server")
+}
+
+func (s *DefaultFileServer) Echo(ctx context.Context, req io.Reader, writer io.WriteCloser) error {
+	return erro.NewError("not find server file_server")
+}
+
+var Default_FileServer = &DefaultFileServer{}
+
+func GetFileServer(ctx context.Context) FileServer {
+	router := manage.GET(ctx).Get(&FileServerRouter{})
+	if nil == router {
+		return Default_FileServer
+	}
+	if val, ok := router.(FileServer); ok {
+		return val
+	}
+	return Default_FileServer
+}
+
+func GetFileServerName() string {
+	return "file_server"
+}
+
+type MessageServer interface {
+	Init(ctx context.Context)
+
+	Sum(ctx context.Context, req StreamReader[*Base]) (*Base, error)
+
+	List(ctx context.Context, req *Base, writer StreamWriter[*Info]) error
+
+	Chat(ctx context.Context, req StreamReader[*Base], writer StreamWriter[*Base]) error
+
+	Push(ctx context.Context, req StreamReader[*Base]) error
+}
+
+type MessageServerClient struct {
+	client StreamClient
+}
+
+func (p *MessageServerClient) Init(ctx context.Context) {
+}
+
+func (p *MessageServerClient) GetName() string {
+	return "message_server"
+}
+
+func (p *MessageServerClient) GetId() uint32 {
+	return 3
+}
+
+func NewMessageServerClient(client StreamClient) *MessageServerClient {
+	return &MessageServerClient{
+		client: client,
+	}
+}
+
+func (r *MessageServerClient) Sum(ctx context.Context, req StreamReader[*Base]) (*Base, error) {
+	call, err := hbufCall(ctx, r.client, "message_server/message_server/sum", 12884901888, func(w io.Writer, binary bool) error {
+		return hbufSendMessages(w, binary, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hbufCallReply[Base](call)
+}
+
+func (r *MessageServerClient) List(ctx context.Context, req *Base) (StreamReader[*Info], error) {
+	call, err := hbufCall(ctx, r.client, "message_server/message_server/list", 12884901889, func(w io.Writer, binary bool) error {
+		return hbufSend(w, binary, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newHbufMessageReader[Info](call, call.binary, call), nil
+}
+
+func (r *MessageServerClient) Chat(ctx context.Context, req StreamReader[*Base]) (StreamReader[*Base], error) {
+	call, err := hbufCall(ctx, r.client, "message_server/message_server/chat", 12884901890, func(w io.Writer, binary bool) error {
+		return hbufSendMessages(w, binary, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newHbufMessageReader[Base](call, call.binary, call), nil
+}
+
+func (r *MessageServerClient) Push(ctx context.Context, req StreamReader[*Base]) error {
+	call, err := hbufCall(ctx, r.client, "message_server/message_server/push", 12884901891, func(w io.Writer, binary bool) error {
+		return hbufSendMessages(w, binary, req)
+	})
+	if err != nil {
+		return err
+	}
+	return hbufCallEnd(call)
+}
+
+type MessageServerRouter struct {
+	server    MessageServer
+	names     map[string]*rpc.ServerInvoke
+	ids       map[int64]*rpc.ServerInvoke
+	streams   map[string]*StreamInvoke
+	streamIds map[int64]*StreamInvoke
+}
+
+func (p *MessageServerRouter) GetName() string {
+	return "message_server"
+}
+
+func (p *MessageServerRouter) GetId() uint32 {
+	return 3
+}
+
+func (p *MessageServerRouter) GetServer() rpc.Init {
+	return p.server
+}
+
+func (p *MessageServerRouter) GetInvoke() map[string]*rpc.ServerInvoke {
+	return p.names
+}
+
+// GetInvokeIds 按 服务Id<<32 | 方法Id 查找调用，供二进制传输使用
+func (p *MessageServerRouter) GetInvokeIds() map[int64]*rpc.ServerInvoke {
+	return p.ids
+}
+
+// GetStreams 返回 stream 方法的调用，供 StreamServer 使用
+func (p *MessageServerRouter) GetStreams() map[string]*StreamInvoke {
+	return p.streams
+}
+
+// GetStreamIds 按 服务Id<<32 | 方法Id 查找 stream 方法的调用
+func (p *MessageServerRouter) GetStreamIds() map[int64]*StreamInvoke {
+	return p.streamIds
+}
+
+func NewMessageServerRouter(server MessageServer) *MessageServerRouter {
+	names := map[string]*rpc.ServerInvoke{}
+	streams := map[string]*StreamInvoke{
+		"message_server/sum": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				req := newHbufMessageReader[Base](r, binary, nil)
+				ret, err := server.Sum(ctx, req)
+				return hbufReply(w, binary, ret, err)
+			},
+		},
+		"message_server/list": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				req, err := hbufRecv[Base](r, binary)
+				if err != nil {
+					return hbufEnd(w, err)
+				}
+				return hbufEnd(w, server.List(ctx, req, newHbufMessageWriter[Info](w, binary)))
+			},
+		},
+		"message_server/chat": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				req := newHbufMessageReader[Base](r, binary, nil)
+				return hbufEnd(w, server.Chat(ctx, req, newHbufMessageWriter[Base](w, binary)))
+			},
+		},
+		"message_server/push": {
+			SetInfo: func(ctx context.Context) {
+			},
+			Invoke: func(ctx context.Context, binary bool, r io.Reader, w io.Writer) error {
+				req := newHbufMessageReader[Base](r, binary, nil)
+				return hbufEnd(w, server.Push(ctx, req))
+			},
+		},
+	}
+	return &MessageServerRouter{
+		server:  server,
+		names:   names,
+		ids:     map[int64]*rpc.ServerInvoke{},
+		streams: streams,
+		streamIds: map[int64]*StreamInvoke{
+			12884901888: streams["message_server/sum"],
+			12884901889: streams["message_server/list"],
+			12884901890: streams["message_server/chat"],
+			12884901891: streams["message_server/push"],
+		},
+	}
+}
+
+type DefaultMessageServer struct {
+}
+
+func (s *DefaultMessageServer) Init(ctx context.Context) {
+}
+
+func (s *DefaultMessageServer) Sum(ctx context.Context, req StreamReader[*Base]) (*Base, error) {
+	return nil, erro.NewError("not find server message_server")
+}
+
+func (s *DefaultMessageServer) List(ctx context.Context, req *Base, writer StreamWriter[*Info]) error {
+	return erro.NewError("not find server message_server")
+}
+
+func (s *DefaultMessageServer) Chat(ctx context.Context, req StreamReader[*Base], writer StreamWriter[*Base]) error {
+	return erro.NewError("not find server message_server")
+}
+
+func (s *DefaultMessageServer) Push(ctx context.Context, req StreamReader[*Base]) error {
+	return erro.NewError("not find server message_server")
+}
+
+var Default_MessageServer = &DefaultMessageServer{}
+
+func GetMessageServer(ctx context.Context) MessageServer {
+	router := manage.GET(ctx).Get(&MessageServerRouter{})
+	if nil == router {
+		return Default_MessageServer
+	}
+	if val, ok := router.(MessageServer); ok {
+		return val
+	}
+	return Default_MessageServer
+}
+
+func GetMessageServerName() string {
+	return "message_server"
+}
@@ -0,0 +1,476 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/wskfjtheqian/hbuf_golang/pkg/hbuf"
+	"github.com/wskfjtheqian/hbuf_golang/pkg/rpc"
+)
+
+// pipeClient 通过 io.Pipe 在进程内连接 StreamServer，binary 为 true 时按 Id 调用
+type pipeClient struct {
+	server *StreamServer
+	binary bool
+}
+
+func (c *pipeClient) Invoke(ctx context.Context, param hbuf.Data, name string, nameInvoke *rpc.ClientInvoke, id int64, idInvoke *rpc.ClientInvoke) (hbuf.Data, error) {
+	return nil, errors.New("only stream methods")
+}
+
+func (c *pipeClient) Stream(ctx context.Context, name string, id int64) (StreamConn, bool, error) {
+	reqReader, reqWriter := io.Pipe()
+	resReader, resWriter := io.Pipe()
+	go func() {
+		var err error
+		if c.binary {
+			err = c.server.InvokeId(ctx, id, reqReader, resWriter)
+		} else {
+			err = c.server.Invoke(ctx, "/"+name, reqReader, resWriter)
+		}
+		_ = reqReader.CloseWithError(io.ErrClosedPipe)
+		_ = resWriter.CloseWithError(err)
+	}()
+	return &pipeConn{reader: resReader, writer: reqWriter}, c.binary, nil
+}
+
+type pipeConn struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+}
+
+func (c *pipeConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *pipeConn) Write(p []byte) (int, error) {
+	return c.writer.Write(p)
+}
+
+func (c *pipeConn) CloseWrite() error {
+	return c.writer.Close()
+}
+
+func (c *pipeConn) Close() error {
+	_ = c.writer.CloseWithError(io.ErrClosedPipe)
+	return c.reader.Close()
+}
+
+// randomReader 返回由 seed 生成的 size 字节，不占用内存
+func randomReader(seed int64, size int64) io.Reader {
+	return io.LimitReader(rand.New(rand.NewSource(seed)), size)
+}
+
+func randomSum(t *testing.T, seed int64, size int64) []byte {
+	h := sha256.New()
+	if _, err := io.Copy(h, randomReader(seed, size)); err != nil {
+		t.Fatal(err)
+	}
+	return h.Sum(nil)
+}
+
+// within 在 timeout 内运行 call，超时说明 stream 没有边收边发
+func within(t *testing.T, timeout time.Duration, call func() error) {
+	done := make(chan error, 1)
+	go func() {
+		done <- call()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(timeout):
+		t.Fatal("timeout, stream is buffered")
+	}
+}
+
+type fileServer struct {
+	DefaultFileServer
+	size int64
+	sum  []byte
+}
+
+func (s *fileServer) Upload(ctx context.Context, req io.Reader) (*Base, error) {
+	h := sha256.New()
+	n, err := io.Copy(h, req)
+	if err != nil {
+		return nil, err
+	}
+	s.size, s.sum = n, h.Sum(nil)
+	return &Base{Id: hbuf.Int64(n)}, nil
+}
+
+func (s *fileServer) Download(ctx context.Context, req *Base, writer io.WriteCloser) error {
+	if req.Id < 0 {
+		return &rpc.Result{Code: http.StatusBadRequest, Msg: "negative size"}
+	}
+	defer writer.Close()
+	_, err := io.Copy(writer, randomReader(2, int64(req.Id)))
+	return err
+}
+
+func (s *fileServer) Echo(ctx context.Context, req io.Reader, writer io.WriteCloser) error {
+	defer writer.Close()
+	_, err := io.Copy(writer, req)
+	return err
+}
+
+func testStream(t *testing.T, client *FileServerClient, server *fileServer, size int64) {
+	ret, err := client.Upload(context.TODO(), randomReader(1, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hbuf.Int64(size) != ret.Id || size != server.size || !bytes.Equal(randomSum(t, 1, size), server.sum) {
+		t.Fatalf("upload: got %d bytes", server.size)
+	}
+
+	reader, err := client.Download(context.TODO(), &Base{Id: hbuf.Int64(size)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(h, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = reader.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if size != n || !bytes.Equal(randomSum(t, 2, size), h.Sum(nil)) {
+		t.Fatalf("download: got %d bytes", n)
+	}
+
+	reader, err = client.Download(context.TODO(), &Base{Id: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res *rpc.Result
+	if _, err = io.ReadAll(reader); !errors.As(err, &res) || http.StatusBadRequest != res.Code {
+		t.Fatalf("download: want code 400, got %v", err)
+	}
+
+	// 每写入一段请求就读回这一段结果，stream 先收完全部请求才返回结果时会超时
+	in, out := io.Pipe()
+	reader, err = client.Echo(context.TODO(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	within(t, 10*time.Second, func() error {
+		data := make([]byte, 1000)
+		buf := make([]byte, len(data))
+		random := rand.New(rand.NewSource(3))
+		for i := 0; i < 100; i++ {
+			random.Read(data)
+			if _, err := out.Write(data); err != nil {
+				return err
+			}
+			if _, err := io.ReadFull(reader, buf); err != nil {
+				return err
+			}
+			if !bytes.Equal(data, buf) {
+				return errors.New("echo: unexpected data")
+			}
+		}
+		_ = out.Close()
+		if buf, err := io.ReadAll(reader); err != nil || 0 != len(buf) {
+			return errors.New("echo: unexpected end")
+		}
+		return reader.Close()
+	})
+}
+
+func TestStreamInvokeId(t *testing.T) {
+	server := &fileServer{}
+	client := NewFileServerClient(&pipeClient{server: NewStreamServer(rpc.NewServer(), NewFileServerRouter(server)), binary: true})
+	testStream(t, client, server, 16<<20)
+}
+
+func TestStreamInvokeJson(t *testing.T) {
+	server := &fileServer{}
+	client := NewFileServerClient(&pipeClient{server: NewStreamServer(rpc.NewServer(), NewFileServerRouter(server))})
+	testStream(t, client, server, 16<<20)
+}
+
+// TestStreamMemory 上传和下载 256 MB，分配的内存远小于数据大小
+func TestStreamMemory(t *testing.T) {
+	server := &fileServer{}
+	client := NewFileServerClient(&pipeClient{server: NewStreamServer(rpc.NewServer(), NewFileServerRouter(server)), binary: true})
+	const size = 256 << 20
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	ret, err := client.Upload(context.TODO(), randomReader(1, size))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader, err := client.Download(context.TODO(), &Base{Id: size})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := io.Copy(io.Discard, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime.ReadMemStats(&after)
+
+	if size != ret.Id || size != n {
+		t.Fatalf("upload %d bytes, download %d bytes", ret.Id, n)
+	}
+	if alloc := after.TotalAlloc - before.TotalAlloc; 16<<20 < alloc {
+		t.Errorf("allocated %d bytes for %d bytes stream", alloc, 2*size)
+	}
+}
+
+func TestStreamHttp(t *testing.T) {
+	server := &fileServer{}
+	ts := httptest.NewServer(NewStreamServer(rpc.NewServer(), NewFileServerRouter(server)))
+	defer ts.Close()
+	testStream(t, NewFileServerClient(NewStreamHttpClient(nil, ts.URL, true)), server, 4<<20)
+	testStream(t, NewFileServerClient(NewStreamHttpClient(nil, ts.URL, false)), server, 4<<20)
+}
+
+// TestStreamFilter stream 方法的调用经过 rpc.Server 的过滤器
+func TestStreamFilter(t *testing.T) {
+	s := rpc.NewServer()
+	s.PrefixFilter(func(ctx context.Context, data hbuf.Data, in *rpc.Filter, call rpc.FilterCall) (context.Context, hbuf.Data, error) {
+		return nil, nil, &rpc.Result{Code: http.StatusForbidden, Msg: "forbidden"}
+	})
+	server := &fileServer{}
+	client := NewFileServerClient(&pipeClient{server: NewStreamServer(s, NewFileServerRouter(server)), binary: true})
+	_, err := client.Upload(context.TODO(), bytes.NewReader(make([]byte, 1<<20)))
+	var res *rpc.Result
+	if !errors.As(err, &res) || http.StatusForbidden != res.Code {
+		t.Fatalf("want code 403, got %v", err)
+	}
+	if nil != server.sum {
+		t.Error("upload must not be invoked")
+	}
+}
+
+type messageServer struct {
+	DefaultMessageServer
+	pushed []hbuf.Int64
+}
+
+func (s *messageServer) Sum(ctx context.Context, req StreamReader[*Base]) (*Base, error) {
+	sum := &Base{}
+	for {
+		item, err := req.Recv()
+		if io.EOF == err {
+			return sum, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		sum.Id += item.Id
+	}
+}
+
+func (s *messageServer) List(ctx context.Context, req *Base, writer StreamWriter[*Info]) error {
+	defer writer.Close()
+	for i := hbuf.Int64(0); i < req.Id; i++ {
+		err := writer.Send(&Info{Base: Base{Id: i}, Tags: []string{"item"}})
+		if err != nil {
+			return err
+		}
+	}
+	if req.Id < 0 {
+		return &rpc.Result{Code: http.StatusBadRequest, Msg: "negative size"}
+	}
+	return nil
+}
+
+func (s *messageServer) Push(ctx context.Context, req StreamReader[*Base]) error {
+	s.pushed = nil
+	for {
+		item, err := req.Recv()
+		if io.EOF == err {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if nil == item {
+			s.pushed = append(s.pushed, -1)
+			continue
+		}
+		s.pushed = append(s.pushed, item.Id)
+	}
+}
+
+// Chat 每收到一条消息就返回 id 的两倍，客户端发一条收一条，请求和返回要能交替进行
+func (s *messageServer) Chat(ctx context.Context, req StreamReader[*Base], writer StreamWriter[*Base]) error {
+	defer writer.Close()
+	for {
+		item, err := req.Recv()
+		if io.EOF == err {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = writer.Send(&Base{Id: item.Id * 2})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func testChat(t *testing.T, client *MessageServerClient) {
+	req, writer := NewStreamPipe[*Base]()
+	reply, err := client.Chat(context.TODO(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	within(t, 10*time.Second, func() error {
+		for i := hbuf.Int64(0); i < 100; i++ {
+			err := writer.Send(&Base{Id: i})
+			if err != nil {
+				return err
+			}
+			item, err := reply.Recv()
+			if err != nil {
+				return err
+			}
+			if i*2 != item.Id {
+				return fmt.Errorf("chat: want %d, got %d", i*2, item.Id)
+			}
+		}
+		_ = writer.Close()
+		if _, err := reply.Recv(); io.EOF != err {
+			return fmt.Errorf("chat: want io.EOF, got %v", err)
+		}
+		return nil
+	})
+}
+
+func testMessage(t *testing.T, client *MessageServerClient, server *messageServer) {
+	sum, err := client.Sum(context.TODO(), NewStreamReader(&Base{Id: 1}, &Base{Id: 2}, &Base{Id: 3}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 6 != sum.Id {
+		t.Errorf("sum: want 6, got %d", sum.Id)
+	}
+
+	list, err := client.List(context.TODO(), &Base{Id: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := hbuf.Int64(0); ; i++ {
+		item, err := list.Recv()
+		if io.EOF == err {
+			if 1000 != i {
+				t.Errorf("list: want 1000 items, got %d", i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != item.Id || 1 != len(item.Tags) || "item" != item.Tags[0] {
+			t.Fatalf("list: unexpected item %d: %+v", i, item)
+		}
+	}
+
+	list, err = client.List(context.TODO(), &Base{Id: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res *rpc.Result
+	if _, err = list.Recv(); !errors.As(err, &res) || http.StatusBadRequest != res.Code {
+		t.Fatalf("list: want code 400, got %v", err)
+	}
+
+	err = client.Push(context.TODO(), NewStreamReader[Base]())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 0 != len(server.pushed) {
+		t.Errorf("push: want no items, got %v", server.pushed)
+	}
+	err = client.Push(context.TODO(), NewStreamReader(&Base{Id: 7}, nil, &Base{Id: 9}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if 3 != len(server.pushed) || 7 != server.pushed[0] || -1 != server.pushed[1] || 9 != server.pushed[2] {
+		t.Errorf("push: unexpected items %v", server.pushed)
+	}
+	testChat(t, client)
+}
+
+func TestMessageInvokeId(t *testing.T) {
+	server := &messageServer{}
+	testMessage(t, NewMessageServerClient(&pipeClient{server: NewStreamServer(rpc.NewServer(), NewMessageServerRouter(server)), binary: true}), server)
+}
+
+func TestMessageInvokeJson(t *testing.T) {
+	server := &messageServer{}
+	testMessage(t, NewMessageServerClient(&pipeClient{server: NewStreamServer(rpc.NewServer(), NewMessageServerRouter(server))}), server)
+}
+
+func TestMessageHttp(t *testing.T) {
+	server := &messageServer{}
+	ts := httptest.NewServer(NewStreamServer(rpc.NewServer(), NewMessageServerRouter(server)))
+	defer ts.Close()
+	testMessage(t, NewMessageServerClient(NewStreamHttpClient(nil, ts.URL, true)), server)
+	testMessage(t, NewMessageServerClient(NewStreamHttpClient(nil, ts.URL, false)), server)
+}
+
+// frame 按帧的格式拼出一帧：1 字节类型，4 字节小端长度，之后是内容
+func frame(kind byte, data []byte) []byte {
+	n := len(data)
+	return append([]byte{kind, byte(n), byte(n >> 8), byte(n >> 16), byte(n >> 24)}, data...)
+}
+
+func frameJson(t *testing.T, v any) []byte {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return frame(1, buf)
+}
+
+// TestStreamFrame 直接检查 StreamServer 收发的字节，帧类型为 0 结束、1 数据、2 空消息、3 错误，
+// Dart 和 TypeScript 生成的代码使用相同的帧
+func TestStreamFrame(t *testing.T) {
+	server := &messageServer{}
+	stream := NewStreamServer(rpc.NewServer(), NewMessageServerRouter(server))
+	list := [][]byte{
+		frameJson(t, &Info{Base: Base{Id: 0}, Tags: []string{"item"}}),
+		frameJson(t, &Info{Base: Base{Id: 1}, Tags: []string{"item"}}),
+		frame(0, nil),
+	}
+	tests := []struct {
+		name string
+		req  []byte
+		res  []byte
+	}{
+		{"/message_server/message_server/list", frame(1, []byte(`{"id":2}`)), bytes.Join(list, nil)},
+		{"/message_server/message_server/list", frame(1, []byte(`{"id":-1}`)), frame(3, []byte(`{"code":400,"msg":"negative size","data":null}`))},
+		{"/message_server/message_server/push", bytes.Join([][]byte{frame(1, []byte(`{"id":7}`)), frame(2, nil), frame(0, nil)}, nil), frame(0, nil)},
+		{"/message_server/message_server/none", nil, frame(3, []byte(`{"code":404,"msg":"not found","data":null}`))},
+	}
+	for _, test := range tests {
+		res := &bytes.Buffer{}
+		_ = stream.Invoke(context.TODO(), test.name, bytes.NewReader(test.req), res)
+		if !bytes.Equal(test.res, res.Bytes()) {
+			t.Errorf("%s: want %q, got %q", test.name, test.res, res.Bytes())
+		}
+	}
+	if 2 != len(server.pushed) || 7 != server.pushed[0] || -1 != server.pushed[1] {
+		t.Errorf("push: unexpected items %v", server.pushed)
+	}
+}